@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// reservedAnnotationPrefix is the annotation key prefix reserved by Notary.
+const reservedAnnotationPrefix = "io.cncf.notary"
+
+// maxSubjectManifestSize is the maximum size of a subject manifest read for
+// its annotations.
+const maxSubjectManifestSize = 4 * 1024 * 1024 // 4 MiB
+
+// annotatedRepository wraps a notationregistry.Repository and adds extra
+// annotations to the signature manifests it pushes.
+type annotatedRepository struct {
+	notationregistry.Repository
+	annotations map[string]string
+}
+
+// PushSignature pushes the signature with the extra annotations merged into
+// the signature manifest annotations. Annotations generated by notation take
+// precedence.
+func (r *annotatedRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	merged := make(map[string]string, len(annotations)+len(r.annotations))
+	for k, v := range r.annotations {
+		merged[k] = v
+	}
+	for k, v := range annotations {
+		merged[k] = v
+	}
+	return r.Repository.PushSignature(ctx, mediaType, blob, subject, merged)
+}
+
+// validateAnnotationKeys returns an error if any of the keys is empty or
+// reserved by Notary.
+func validateAnnotationKeys(keys []string) error {
+	for _, key := range keys {
+		if strings.TrimSpace(key) == "" {
+			return errors.New("annotation key cannot be empty")
+		}
+		if strings.HasPrefix(key, reservedAnnotationPrefix) {
+			return fmt.Errorf("annotation key %q has reserved prefix %q", key, reservedAnnotationPrefix)
+		}
+	}
+	return nil
+}
+
+// getSubjectAnnotations fetches the subject manifest referenced by ref and
+// returns the values of the given annotation keys. Keys absent from the
+// subject manifest are skipped with a warning.
+func getSubjectAnnotations(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference, keys []string) (map[string]string, error) {
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return nil, err
+	}
+	_, rc, err := remoteRepo.Manifests().FetchReference(ctx, ref.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch subject manifest %s: %w", ref, err)
+	}
+	defer rc.Close()
+	manifestJSON, err := io.ReadAll(io.LimitReader(rc, maxSubjectManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subject manifest %s: %w", ref, err)
+	}
+	if len(manifestJSON) > maxSubjectManifestSize {
+		return nil, fmt.Errorf("subject manifest %s is too large, exceeding %d bytes", ref, maxSubjectManifestSize)
+	}
+
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse subject manifest %s: %w", ref, err)
+	}
+	annotations := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, ok := manifest.Annotations[key]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Warning: annotation %q is not present in the subject manifest and will not be copied\n", key)
+			continue
+		}
+		annotations[key] = value
+	}
+	return annotations, nil
+}
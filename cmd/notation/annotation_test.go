@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockRepository struct {
+	pushedAnnotations map[string]string
+}
+
+func (r *mockRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return ocispec.Descriptor{}, nil
+}
+
+func (r *mockRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	return nil
+}
+
+func (r *mockRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	return nil, ocispec.Descriptor{}, nil
+}
+
+func (r *mockRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	r.pushedAnnotations = annotations
+	return ocispec.Descriptor{}, ocispec.Descriptor{}, nil
+}
+
+func TestAnnotatedRepository_PushSignature(t *testing.T) {
+	repo := &mockRepository{}
+	annotatedRepo := &annotatedRepository{
+		Repository: repo,
+		annotations: map[string]string{
+			"com.example.retention": "30d",
+			"com.example.owner":     "team-a",
+		},
+	}
+	_, _, err := annotatedRepo.PushSignature(context.Background(), "", nil, ocispec.Descriptor{}, map[string]string{
+		"io.cncf.notary.x509chain.thumbprint#S256": "[]",
+		"com.example.owner":                        "notation",
+	})
+	if err != nil {
+		t.Fatalf("PushSignature() error = %v", err)
+	}
+	want := map[string]string{
+		"com.example.retention":                    "30d",
+		"com.example.owner":                        "notation",
+		"io.cncf.notary.x509chain.thumbprint#S256": "[]",
+	}
+	if !reflect.DeepEqual(repo.pushedAnnotations, want) {
+		t.Fatalf("pushed annotations = %v, want %v", repo.pushedAnnotations, want)
+	}
+}
+
+func TestValidateAnnotationKeys(t *testing.T) {
+	if err := validateAnnotationKeys([]string{"com.example.retention", "org.opencontainers.image.source"}); err != nil {
+		t.Fatalf("validateAnnotationKeys() error = %v", err)
+	}
+	if err := validateAnnotationKeys([]string{""}); err == nil {
+		t.Fatal("validateAnnotationKeys() expected error for empty key, but got nil")
+	}
+	if err := validateAnnotationKeys([]string{"io.cncf.notary.x509chain.thumbprint#S256"}); err == nil {
+		t.Fatal("validateAnnotationKeys() expected error for reserved key, but got nil")
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// artifactSizeUnits maps the units accepted by --max-artifact-size to their
+// number of bytes, longest suffixes first.
+var artifactSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"KiB", 1 << 10},
+	{"MiB", 1 << 20},
+	{"GiB", 1 << 30},
+	{"TiB", 1 << 40},
+	{"KB", 1000},
+	{"MB", 1000 * 1000},
+	{"GB", 1000 * 1000 * 1000},
+	{"TB", 1000 * 1000 * 1000 * 1000},
+	{"B", 1},
+}
+
+// parseArtifactSize parses a size in bytes, optionally followed by a unit,
+// for example "500MiB" or "2GB".
+func parseArtifactSize(value string) (int64, error) {
+	number, multiplier := value, int64(1)
+	for _, unit := range artifactSizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			number, multiplier = strings.TrimSuffix(value, unit.suffix), unit.bytes
+			break
+		}
+	}
+	size, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || size <= 0 {
+		return 0, fmt.Errorf("--max-artifact-size must be a positive number of bytes, optionally followed by a unit such as \"MiB\" or \"GB\", got %q", value)
+	}
+	if size > (1<<63-1)/multiplier {
+		return 0, fmt.Errorf("--max-artifact-size %q is too large", value)
+	}
+	return size * multiplier, nil
+}
+
+// checkArtifactSize fails if the size of the artifact of manifestDesc,
+// identified by the digest reference ref, exceeds limit.
+func checkArtifactSize(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference, manifestDesc ocispec.Descriptor, limit int64) error {
+	size := manifestDesc.Size
+	if size <= limit {
+		contentSize, err := getReferencedContentSize(ctx, opts, ref, manifestDesc)
+		if err != nil {
+			return err
+		}
+		size += contentSize
+	}
+	if size > limit {
+		return fmt.Errorf("artifact %s is %d bytes, exceeding --max-artifact-size of %d bytes", ref, size, limit)
+	}
+	return nil
+}
+
+// getReferencedContentSize returns the total size of the content referenced
+// by the manifest of manifestDesc, that is the config and layers of an image
+// manifest, the blobs of an artifact manifest, or the manifests of an index.
+// Manifests referenced by an index are not traversed.
+func getReferencedContentSize(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference, manifestDesc ocispec.Descriptor) (int64, error) {
+	if manifestDesc.Size > maxSubjectManifestSize {
+		return 0, fmt.Errorf("subject manifest %s is too large, exceeding %d bytes", ref, maxSubjectManifestSize)
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return 0, err
+	}
+	rc, err := remoteRepo.Manifests().Fetch(ctx, manifestDesc)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch subject manifest %s: %w", ref, err)
+	}
+	defer rc.Close()
+	manifestJSON, err := io.ReadAll(io.LimitReader(rc, maxSubjectManifestSize+1))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read subject manifest %s: %w", ref, err)
+	}
+	if len(manifestJSON) > maxSubjectManifestSize {
+		return 0, fmt.Errorf("subject manifest %s is too large, exceeding %d bytes", ref, maxSubjectManifestSize)
+	}
+
+	var manifest struct {
+		Config    *ocispec.Descriptor  `json:"config"`
+		Layers    []ocispec.Descriptor `json:"layers"`
+		Blobs     []ocispec.Descriptor `json:"blobs"`
+		Manifests []ocispec.Descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return 0, fmt.Errorf("failed to parse subject manifest %s: %w", ref, err)
+	}
+	descs := append(append(append([]ocispec.Descriptor{}, manifest.Layers...), manifest.Blobs...), manifest.Manifests...)
+	if manifest.Config != nil {
+		descs = append(descs, *manifest.Config)
+	}
+	var size int64
+	for _, desc := range descs {
+		if desc.Size < 0 || size > 1<<63-1-desc.Size {
+			return 0, fmt.Errorf("subject manifest %s has invalid content sizes", ref)
+		}
+		size += desc.Size
+	}
+	return size, nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestParseArtifactSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int64
+		wantErr bool
+	}{
+		{value: "1024", want: 1024},
+		{value: "100B", want: 100},
+		{value: "2KB", want: 2000},
+		{value: "500MiB", want: 500 << 20},
+		{value: "2GB", want: 2000000000},
+		{value: "1TiB", want: 1 << 40},
+		{value: "", wantErr: true},
+		{value: "0", wantErr: true},
+		{value: "-1MiB", wantErr: true},
+		{value: "1.5GB", wantErr: true},
+		{value: "10XB", wantErr: true},
+		{value: "9223372036854775807KiB", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseArtifactSize(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseArtifactSize(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseArtifactSize(%q) = %d, want %d", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckArtifactSize(t *testing.T) {
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: "application/vnd.oci.empty.v1+json",
+			Digest:    digest.FromString("{}"),
+			Size:      2,
+		},
+		Layers: []ocispec.Descriptor{{
+			MediaType: ocispec.MediaTypeImageLayerGzip,
+			Digest:    digest.FromString("layer"),
+			Size:      3 << 30,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	var fetched int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/net-monitor/manifests/"+manifestDesc.Digest.String() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fetched++
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+		w.Write(manifest)
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := registry.Reference{Registry: uri.Host, Repository: "net-monitor", Reference: manifestDesc.Digest.String()}
+	opts := &SecureFlagOpts{PlainHTTP: true}
+	total := manifestDesc.Size + 2 + 3<<30
+
+	if err := checkArtifactSize(context.Background(), opts, ref, manifestDesc, total); err != nil {
+		t.Fatalf("checkArtifactSize() error = %v", err)
+	}
+	err = checkArtifactSize(context.Background(), opts, ref, manifestDesc, 1<<30)
+	if err == nil || !strings.Contains(err.Error(), strconv.FormatInt(total, 10)) {
+		t.Fatalf("checkArtifactSize() error = %v, want error reporting the size %d", err, total)
+	}
+
+	// the manifest is not fetched if it alone exceeds the limit
+	fetched = 0
+	if err := checkArtifactSize(context.Background(), opts, ref, manifestDesc, manifestDesc.Size-1); err == nil {
+		t.Fatal("checkArtifactSize() expected error for a manifest larger than the limit, but got nil")
+	}
+	if fetched != 0 {
+		t.Fatalf("manifest fetched %d times, want 0", fetched)
+	}
+}
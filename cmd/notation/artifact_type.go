@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+// signatureArtifactType returns the artifact type of the signature manifest
+// manifestJSON described by desc, which is the artifactType of an OCI
+// artifact manifest and the config media type of an OCI image manifest.
+// Referrers queries filtering on artifactType match against this value.
+func signatureArtifactType(desc ocispec.Descriptor, manifestJSON []byte) (string, error) {
+	var manifest struct {
+		ArtifactType string             `json:"artifactType"`
+		Config       ocispec.Descriptor `json:"config"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return "", fmt.Errorf("failed to parse signature manifest: %w", err)
+	}
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest:
+		return manifest.Config.MediaType, nil
+	case ocispec.MediaTypeArtifactManifest:
+		return manifest.ArtifactType, nil
+	default:
+		return "", fmt.Errorf("unsupported signature manifest media type %q", desc.MediaType)
+	}
+}
+
+// outputSignatureArtifactType fetches the signature manifest pushed through
+// recorder and prints its artifact type, failing if it is not the artifact
+// type of Notary Project signatures.
+func outputSignatureArtifactType(ctx context.Context, w io.Writer, opts *SecureFlagOpts, ref registry.Reference, recorder *signatureRecorder) error {
+	desc := recorder.manifestDesc
+	if desc.Size > maxSignatureManifestSize {
+		return fmt.Errorf("signature manifest %s is too large, exceeding %d bytes", desc.Digest, maxSignatureManifestSize)
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return err
+	}
+	manifestJSON, err := content.FetchAll(ctx, remoteRepo.Manifests(), desc)
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature manifest %s: %w", desc.Digest, err)
+	}
+	artifactType, err := signatureArtifactType(desc, manifestJSON)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Signature artifact type: %s (manifest media type %s, envelope media type %s)\n", artifactType, desc.MediaType, recorder.mediaType)
+	if artifactType != notationregistry.ArtifactTypeNotation {
+		return fmt.Errorf("signature manifest %s has artifact type %q instead of %q, so referrers queries filtering on %q do not return it", desc.Digest, artifactType, notationregistry.ArtifactTypeNotation, notationregistry.ArtifactTypeNotation)
+	}
+	return nil
+}
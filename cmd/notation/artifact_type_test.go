@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestSignatureArtifactType(t *testing.T) {
+	tests := []struct {
+		name         string
+		mediaType    string
+		manifestJSON string
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:         "image manifest",
+			mediaType:    ocispec.MediaTypeImageManifest,
+			manifestJSON: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"` + notationregistry.ArtifactTypeNotation + `"}}`,
+			want:         notationregistry.ArtifactTypeNotation,
+		},
+		{
+			name:         "artifact manifest",
+			mediaType:    ocispec.MediaTypeArtifactManifest,
+			manifestJSON: `{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","artifactType":"application/vnd.example.signature"}`,
+			want:         "application/vnd.example.signature",
+		},
+		{
+			name:         "unsupported manifest",
+			mediaType:    ocispec.MediaTypeImageIndex,
+			manifestJSON: `{}`,
+			wantErr:      true,
+		},
+		{
+			name:         "malformed manifest",
+			mediaType:    ocispec.MediaTypeImageManifest,
+			manifestJSON: `{`,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := signatureArtifactType(ocispec.Descriptor{MediaType: tt.mediaType}, []byte(tt.manifestJSON))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("signatureArtifactType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("signatureArtifactType() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+)
+
+// defaultBlobMediaType is the media type of the signed blobs if not set by
+// --media-type.
+const defaultBlobMediaType = "application/octet-stream"
+
+type blobSignOpts struct {
+	cmd.LoggingFlagOpts
+	cmd.SignerFlagOpts
+	expiry        time.Duration
+	pluginConfig  []string
+	userMetadata  []string
+	mediaType     string
+	signatureFile string
+	force         bool
+	blobPath      string
+}
+
+func blobCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "blob",
+		Short: "Sign files stored outside of registries",
+	}
+	command.AddCommand(blobSignCommand(nil))
+	return command
+}
+
+func blobSignCommand(opts *blobSignOpts) *cobra.Command {
+	if opts == nil {
+		opts = &blobSignOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "sign [flags] <file>",
+		Short: "Produce a detached signature for a file",
+		Long: `Produce a detached signature for a file
+
+The signature envelope signs the digest, size and media type of the file, and
+is written to a file that can be distributed alongside it, without a registry.
+
+Example - Sign a file using the default signing key, writing the signature to <file>.<format>.sig:
+  notation blob sign ./installer.msi
+
+Example - Sign a file with a given media type and user metadata, writing the signature to a given path:
+  notation blob sign --media-type application/spdx+json --user-metadata release=v1.0 --signature-file ./sbom.sig ./sbom.spdx.json
+
+Example - Sign a file using a COSE signature envelope that expires in 24 hours:
+  notation blob sign --signature-format cose --expiry 24h ./installer.msi
+
+Example - Sign a file again, overwriting its existing signature file:
+  notation blob sign --force ./installer.msi
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing file to sign")
+			}
+			opts.blobPath = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runBlobSign(cmd, opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SignerFlagOpts.ApplyFlagsToCommand(command)
+	cmd.SetPflagExpiry(command.Flags(), &opts.expiry)
+	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
+	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataSignUsage)
+	command.Flags().StringVar(&opts.mediaType, "media-type", defaultBlobMediaType, "media type of the file, recorded in the signed descriptor")
+	command.Flags().StringVar(&opts.signatureFile, "signature-file", "", "path to write the signature envelope to (default to \"<file>.<format>.sig\")")
+	command.Flags().BoolVar(&opts.force, "force", false, "overwrite the signature file if it already exists")
+	return command
+}
+
+func runBlobSign(command *cobra.Command, cmdOpts *blobSignOpts) error {
+	// set log level
+	ctx := cmdOpts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+
+	if cmdOpts.expiry < 0 {
+		return errors.New("expiry duration cannot be a negative value")
+	}
+	if cmdOpts.expiry%time.Second != 0 {
+		return errors.New("expiry duration supports minimum granularity of seconds")
+	}
+	if cmdOpts.mediaType == "" {
+		return errors.New("--media-type cannot be empty")
+	}
+	mediaType, err := envelope.GetEnvelopeMediaType(cmdOpts.SignatureFormat)
+	if err != nil {
+		return err
+	}
+	pluginConfig, err := cmd.ParseFlagMap(cmdOpts.pluginConfig, cmd.PflagPluginConfig.Name)
+	if err != nil {
+		return err
+	}
+	userMetadata, err := cmd.ParseFlagMap(cmdOpts.userMetadata, cmd.PflagUserMetadata.Name)
+	if err != nil {
+		return err
+	}
+	desc, err := blobDescriptor(cmdOpts.blobPath, cmdOpts.mediaType, userMetadata)
+	if err != nil {
+		return err
+	}
+	signatureFile := cmdOpts.signatureFile
+	if signatureFile == "" {
+		signatureFile = cmdOpts.blobPath + "." + cmdOpts.SignatureFormat + ".sig"
+	}
+	// fail before signing, the file is created exclusively when written
+	if !cmdOpts.force {
+		if _, err := os.Stat(signatureFile); err == nil {
+			return errSignatureFileExists(signatureFile)
+		}
+	}
+
+	signer, err := cmd.GetSigner(ctx, &cmdOpts.SignerFlagOpts)
+	if err != nil {
+		return err
+	}
+	sig, _, err := signer.Sign(ctx, desc, notation.SignOptions{
+		SignatureMediaType: mediaType,
+		ExpiryDuration:     cmdOpts.expiry,
+		PluginConfig:       pluginConfig,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := osutil.WriteFileWithPermission(signatureFile, sig, 0644, cmdOpts.force); err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return errSignatureFileExists(signatureFile)
+		}
+		return fmt.Errorf("failed to write signature file: %w", err)
+	}
+	fmt.Printf("Successfully signed %s\n", cmdOpts.blobPath)
+	fmt.Printf("Signature file written to %s\n", signatureFile)
+	return nil
+}
+
+// blobDescriptor returns the descriptor of the file at path with the given
+// media type, annotated with userMetadata, as signed by `notation blob sign`.
+func blobDescriptor(path, mediaType string, userMetadata map[string]string) (ocispec.Descriptor, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	defer file.Close()
+	fi, err := file.Stat()
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if !fi.Mode().IsRegular() {
+		return ocispec.Descriptor{}, fmt.Errorf("%s is not a regular file", path)
+	}
+	digester := digest.Canonical.Digester()
+	size, err := io.Copy(digester.Hash(), file)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digester.Digest(),
+		Size:      size,
+	}
+	for key, value := range userMetadata {
+		if strings.HasPrefix(key, reservedAnnotationPrefix) {
+			return ocispec.Descriptor{}, fmt.Errorf("metadata key %q has reserved prefix %q", key, reservedAnnotationPrefix)
+		}
+		if desc.Annotations == nil {
+			desc.Annotations = map[string]string{}
+		}
+		desc.Annotations[key] = value
+	}
+	return desc, nil
+}
+
+// errSignatureFileExists returns the error of an existing signature file at
+// path, not overwritten without --force.
+func errSignatureFileExists(path string) error {
+	return fmt.Errorf("signature file %s already exists, use --force to overwrite it", path)
+}
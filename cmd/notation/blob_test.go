@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestBlobSignCommand(t *testing.T) {
+	opts := &blobSignOpts{}
+	command := blobSignCommand(opts)
+	if err := command.ParseFlags([]string{
+		"./installer.msi",
+		"--key", "test",
+		"--signature-file", "./installer.sig",
+		"--user-metadata", "release=v1.0",
+		"--plugin-config", "region=us",
+		"--expiry", "24h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if opts.blobPath != "./installer.msi" || opts.Key != "test" || opts.signatureFile != "./installer.sig" || opts.mediaType != defaultBlobMediaType {
+		t.Fatalf("unexpected blob sign opts: %+v", opts)
+	}
+	if !reflect.DeepEqual(opts.userMetadata, []string{"release=v1.0"}) || !reflect.DeepEqual(opts.pluginConfig, []string{"region=us"}) {
+		t.Fatalf("unexpected blob sign opts: %+v", opts)
+	}
+
+	command = blobSignCommand(nil)
+	if err := command.Args(command, nil); err == nil {
+		t.Fatal("Parse Args expects error for a missing file, but got nil")
+	}
+}
+
+func TestBlobDescriptor(t *testing.T) {
+	content := []byte("installer")
+	path := filepath.Join(t.TempDir(), "installer.msi")
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := blobDescriptor(path, defaultBlobMediaType, map[string]string{"release": "v1.0"})
+	if err != nil {
+		t.Fatalf("blobDescriptor() error = %v", err)
+	}
+	want := ocispec.Descriptor{
+		MediaType:   defaultBlobMediaType,
+		Digest:      digest.FromBytes(content),
+		Size:        int64(len(content)),
+		Annotations: map[string]string{"release": "v1.0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("blobDescriptor() = %+v, want %+v", got, want)
+	}
+
+	if _, err := blobDescriptor(path, defaultBlobMediaType, map[string]string{"io.cncf.notary.expiry": "never"}); err == nil {
+		t.Fatal("blobDescriptor() expects error for a reserved metadata key, but got nil")
+	}
+	if _, err := blobDescriptor(filepath.Dir(path), defaultBlobMediaType, nil); err == nil {
+		t.Fatal("blobDescriptor() expects error for a directory, but got nil")
+	}
+	if _, err := blobDescriptor(filepath.Join(t.TempDir(), "missing"), defaultBlobMediaType, nil); err == nil {
+		t.Fatal("blobDescriptor() expects error for a missing file, but got nil")
+	}
+}
+
+func TestBlobSignCommand_Execute(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	pki := newChainTestPKI(t)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pki.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir.UserConfigDir, "localkeys", "test.key")
+	certPath := filepath.Join(dir.UserConfigDir, "localkeys", "test.crt")
+	if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var certPEM []byte
+	for _, cert := range [][]byte{pki.leaf.Raw, pki.intermediate.Raw, pki.root.Raw} {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})...)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	signingKeys := &config.SigningKeys{Keys: []config.KeySuite{{
+		Name:        "test",
+		X509KeyPair: &config.X509KeyPair{KeyPath: keyPath, CertificatePath: certPath},
+	}}}
+	if err := signingKeys.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("installer")
+	blobPath := filepath.Join(t.TempDir(), "installer.msi")
+	if err := os.WriteFile(blobPath, content, 0600); err != nil {
+		t.Fatal(err)
+	}
+	command := blobSignCommand(nil)
+	command.SetArgs([]string{"--key", "test", "--user-metadata", "release=v1.0", blobPath})
+	command.SetContext(context.Background())
+	command.SilenceUsage = true
+	if err := command.Execute(); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	sig, err := os.ReadFile(blobPath + ".jws.sig")
+	if err != nil {
+		t.Fatalf("signature file not written: %v", err)
+	}
+	sigEnv, err := signature.ParseEnvelope(jws.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	envContent, err := sigEnv.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if envContent.Payload.ContentType != envelope.MediaTypePayloadV1 {
+		t.Fatalf("payload content type = %s, want %s", envContent.Payload.ContentType, envelope.MediaTypePayloadV1)
+	}
+	var payload envelope.Payload
+	if err := json.Unmarshal(envContent.Payload.Content, &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := ocispec.Descriptor{
+		MediaType:   defaultBlobMediaType,
+		Digest:      digest.FromBytes(content),
+		Size:        int64(len(content)),
+		Annotations: map[string]string{"release": "v1.0"},
+	}
+	if !reflect.DeepEqual(payload.TargetArtifact, want) {
+		t.Fatalf("signed descriptor = %+v, want %+v", payload.TargetArtifact, want)
+	}
+}
+
+func TestBlobSignCommand_ExistingSignatureFile(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	saveTestSigningKeys(t, "test")
+
+	blobPath := filepath.Join(t.TempDir(), "installer.msi")
+	if err := os.WriteFile(blobPath, []byte("installer"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	signatureFile := blobPath + ".jws.sig"
+	existing := []byte("existing signature")
+	if err := os.WriteFile(signatureFile, existing, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	command := blobSignCommand(nil)
+	command.SetArgs([]string{"--key", "test", blobPath})
+	command.SetContext(context.Background())
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "already exists") || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Execute() error = %v, want error of an existing signature file", err)
+	}
+	if got, err := os.ReadFile(signatureFile); err != nil || !bytes.Equal(got, existing) {
+		t.Fatalf("signature file = %q, %v, want it unchanged", got, err)
+	}
+
+	command = blobSignCommand(nil)
+	command.SetArgs([]string{"--key", "test", "--force", blobPath})
+	command.SetContext(context.Background())
+	command.SilenceUsage = true
+	if err := command.Execute(); err != nil {
+		t.Fatalf("Execute() with --force error = %v", err)
+	}
+	sig, err := os.ReadFile(signatureFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigEnv, err := signature.ParseEnvelope(jws.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatalf("signature file not overwritten with --force: %v", err)
+	}
+	if _, err := sigEnv.Verify(); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+}
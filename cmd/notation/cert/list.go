@@ -2,7 +2,10 @@ package cert
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
 
 	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/log"
@@ -58,6 +61,12 @@ func listCerts(ctx context.Context, opts *certListOpts) error {
 	namedStore := opts.namedStore
 	storeType := opts.storeType
 	configFS := dir.ConfigFS()
+	if storeType != "" && !truststore.IsValidStoreType(storeType) {
+		return fmt.Errorf("unsupported store type: %s", storeType)
+	}
+	if namedStore != "" && !truststore.IsValidFileName(namedStore) {
+		return errors.New("named store name needs to follow [a-zA-Z0-9_.-]+ format")
+	}
 
 	// List all certificates under truststore/x509, display empty if there's
 	// no certificate yet
@@ -81,6 +90,13 @@ func listCerts(ctx context.Context, opts *certListOpts) error {
 		if err := truststore.CheckNonErrNotExistError(err); err != nil {
 			return err
 		}
+		exists, err := isDir(path)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("named store %s of type %s does not exist", namedStore, storeType)
+		}
 		if err := truststore.CheckNonErrNotExistError(truststore.ListCerts(path, 0)); err != nil {
 			logger.Debugln("Failed to complete list at path:", path)
 			return fmt.Errorf("failed to list all certificates stored in the named store %s of type %s, with error: %s", namedStore, storeType, err.Error())
@@ -101,19 +117,43 @@ func listCerts(ctx context.Context, opts *certListOpts) error {
 			return fmt.Errorf("failed to list all certificates stored of type %s, with error: %s", storeType, err.Error())
 		}
 	} else {
-		// List all certificates under named store namedStore, display empty if
-		// there's no such certificate
+		// List all certificates under named store namedStore of any type,
+		// display empty if there's no such certificate
+		found := false
 		for _, t := range notationgoTruststore.Types {
 			path, err := configFS.SysPath(dir.TrustStoreDir, "x509", string(t), namedStore)
 			if err := truststore.CheckNonErrNotExistError(err); err != nil {
 				return err
 			}
+			exists, err := isDir(path)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			found = true
 			if err := truststore.CheckNonErrNotExistError(truststore.ListCerts(path, 0)); err != nil {
 				logger.Debugln("Failed to complete list at path:", path)
 				return fmt.Errorf("failed to list all certificates stored in the named store %s, with error: %s", namedStore, err.Error())
 			}
 		}
+		if !found {
+			return fmt.Errorf("named store %s does not exist", namedStore)
+		}
 	}
 
 	return nil
 }
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return fi.IsDir(), nil
+}
@@ -1,8 +1,14 @@
 package cert
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
 )
 
 func TestCertListCommand(t *testing.T) {
@@ -21,3 +27,40 @@ func TestCertListCommand(t *testing.T) {
 		t.Fatalf("Expect cert list opts: %v, got: %v", expected, opts)
 	}
 }
+
+func TestListCerts_NamedStoreScoping(t *testing.T) {
+	configDir := t.TempDir()
+	defer func(old string) { dir.UserConfigDir = old }(dir.UserConfigDir)
+	dir.UserConfigDir = configDir
+	if err := os.MkdirAll(filepath.Join(configDir, dir.TrustStoreDir, "x509", "ca", "test"), 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    *certListOpts
+		wantErr string
+	}{
+		{name: "type and store", opts: &certListOpts{storeType: "ca", namedStore: "test"}},
+		{name: "store only", opts: &certListOpts{namedStore: "test"}},
+		{name: "type only", opts: &certListOpts{storeType: "signingAuthority"}},
+		{name: "unsupported type", opts: &certListOpts{storeType: "tsa"}, wantErr: "unsupported store type: tsa"},
+		{name: "invalid store name", opts: &certListOpts{namedStore: "a/b"}, wantErr: "named store name needs to follow"},
+		{name: "missing store of type", opts: &certListOpts{storeType: "signingAuthority", namedStore: "test"}, wantErr: "named store test of type signingAuthority does not exist"},
+		{name: "missing store", opts: &certListOpts{namedStore: "missing"}, wantErr: "named store missing does not exist"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := listCerts(context.Background(), tt.opts)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("listCerts() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("listCerts() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// formats of --print-cert-chain
+const (
+	certChainFormatSummary = "summary"
+	certChainFormatPEM     = "pem"
+)
+
+var supportedCertChainFormats = []string{certChainFormatSummary, certChainFormatPEM}
+
+// certificateRole returns the role of the i-th certificate of a certificate
+// chain of n certificates, ordered from the signing certificate to the root.
+func certificateRole(cert *x509.Certificate, i, n int) string {
+	switch {
+	case i == 0:
+		return "signing certificate"
+	case i == n-1 && bytes.Equal(cert.RawSubject, cert.RawIssuer):
+		return "root certificate"
+	default:
+		return "intermediate certificate"
+	}
+}
+
+// printCertChain prints the certificate chain certs of a signature in the
+// given format, from the signing certificate to the root. The summary lists
+// the role, subject, issuer, SHA-1 fingerprint and expiry of each
+// certificate, while the PEM format prints the certificates themselves.
+func printCertChain(w io.Writer, format string, certs []*x509.Certificate) error {
+	if len(certs) == 0 {
+		return errors.New("signature has no certificate chain")
+	}
+	if format == certChainFormatPEM {
+		data, err := encodeCertChainPEM(certs)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	fmt.Fprintf(w, "Certificate chain of the signature (%d certificates):\n", len(certs))
+	for i, cert := range certs {
+		h := sha1.Sum(cert.Raw)
+		fmt.Fprintf(w, "  [%d] %s\n", i, certificateRole(cert, i, len(certs)))
+		fmt.Fprintf(w, "      issued to: %s\n", cert.Subject)
+		fmt.Fprintf(w, "      issued by: %s\n", cert.Issuer)
+		fmt.Fprintf(w, "      SHA1 fingerprint: %s\n", hex.EncodeToString(h[:]))
+		if _, err := fmt.Fprintf(w, "      expiry: %s\n", cert.NotAfter.Format(time.ANSIC)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"fmt"
+	"strings"
+	"time"
+
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/internal/cmd"
+)
+
+// newCertChainCompletingSigner returns a signer for the local key in opts,
+// whose certificate chain is completed to a root certificate with the
+// certificates of the trust store in the format {type}:{name}. The signing
+// time is signingTime, if not zero, or backdated by tolerance, if not zero.
+func newCertChainCompletingSigner(ctx context.Context, opts *cmd.SignerFlagOpts, trustStore string, tolerance time.Duration, signingTime time.Time) (notation.Signer, error) {
+	privateKey, certs, err := loadLocalSigningKey(opts, "--cert-chain-from-store")
+	if err != nil {
+		return nil, err
+	}
+	storeType, name, _ := strings.Cut(trustStore, ":")
+	storeCerts, err := truststore.NewX509TrustStore(dir.ConfigFS()).GetCertificates(ctx, truststore.Type(storeType), name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trust store %s: %w", trustStore, err)
+	}
+	chain, err := completeCertChain(certs, storeCerts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to complete the certificate chain from trust store %s: %w", trustStore, err)
+	}
+	if added := len(chain) - len(certs); added > 0 {
+		log.GetLogger(ctx).Infof("Completed the certificate chain with %d certificates of trust store %s", added, trustStore)
+	}
+	if tolerance > 0 || !signingTime.IsZero() {
+		backdated, err := newBackdatedSignerFromKey(privateKey, chain, tolerance)
+		if err != nil {
+			return nil, err
+		}
+		backdated.signingTime = signingTime
+		return backdated, nil
+	}
+	return signer.New(privateKey, chain)
+}
+
+// completeCertChain returns the certificate chain certs, ordered from the
+// signing certificate, completed to a root certificate with the issuers
+// found in storeCerts. It fails if no issuer of the last certificate is
+// found, or if the completed chain is not a valid code signing chain.
+func completeCertChain(certs, storeCerts []*x509.Certificate) ([]*x509.Certificate, error) {
+	chain := append([]*x509.Certificate(nil), certs...)
+	for !isSelfSigned(chain[len(chain)-1]) {
+		// a chain longer than the store with the given certificates loops
+		if len(chain) > len(certs)+len(storeCerts) {
+			return nil, fmt.Errorf("certificate chain of %q does not end with a root certificate", certs[0].Subject)
+		}
+		last := chain[len(chain)-1]
+		issuer := findIssuer(last, storeCerts)
+		if issuer == nil {
+			return nil, fmt.Errorf("issuer %q of certificate %q is not found", last.Issuer, last.Subject)
+		}
+		chain = append(chain, issuer)
+	}
+	if err := corex509.ValidateCodeSigningCertChain(chain, nil); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// findIssuer returns the certificate of candidates issuing cert, or nil if
+// there is none.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if bytes.Equal(cert.RawIssuer, candidate.RawSubject) && cert.CheckSignatureFrom(candidate) == nil {
+			return candidate
+		}
+	}
+	return nil
+}
+
+// isSelfSigned reports whether cert is a self-signed certificate. The
+// signature is checked without requiring cert to be a CA, so that
+// self-signed signing certificates are roots of their own chain.
+func isSelfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignature(cert.SignatureAlgorithm, cert.RawTBSCertificate, cert.Signature) == nil
+}
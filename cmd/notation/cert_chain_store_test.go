@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/cmd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// chainTestPKI is a PKI of a root CA, an intermediate CA issued by the root
+// CA, and a code signing certificate issued by the intermediate CA.
+type chainTestPKI struct {
+	root         *x509.Certificate
+	intermediate *x509.Certificate
+	leafKey      *ecdsa.PrivateKey
+	leaf         *x509.Certificate
+}
+
+func newChainTestPKI(t *testing.T) *chainTestPKI {
+	t.Helper()
+	issue := func(template, parent *x509.Certificate, parentKey, key *ecdsa.PrivateKey) *x509.Certificate {
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	caTemplate := func(serial int64, name string) *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name, Organization: []string{"Notary"}, Province: []string{"WA"}, Country: []string{"US"}},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+	}
+	rootKey, intermediateKey, leafKey := newKey(), newKey(), newKey()
+	rootTemplate := caTemplate(1, "chain test root")
+	root := issue(rootTemplate, rootTemplate, rootKey, rootKey)
+	intermediate := issue(caTemplate(2, "chain test intermediate"), root, rootKey, intermediateKey)
+	leaf := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(3),
+		Subject:               pkix.Name{CommonName: "chain test", Organization: []string{"Notary"}, Province: []string{"WA"}, Country: []string{"US"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}, intermediate, intermediateKey, leafKey)
+	return &chainTestPKI{root: root, intermediate: intermediate, leafKey: leafKey, leaf: leaf}
+}
+
+func TestCompleteCertChain(t *testing.T) {
+	pki := newChainTestPKI(t)
+	other := newChainTestPKI(t)
+	tests := []struct {
+		name       string
+		certs      []*x509.Certificate
+		storeCerts []*x509.Certificate
+		wantLen    int
+		wantErr    string
+	}{
+		{name: "leaf only", certs: []*x509.Certificate{pki.leaf}, storeCerts: []*x509.Certificate{other.root, pki.root, pki.intermediate}, wantLen: 3},
+		{name: "leaf and intermediate", certs: []*x509.Certificate{pki.leaf, pki.intermediate}, storeCerts: []*x509.Certificate{pki.root}, wantLen: 3},
+		{name: "complete chain", certs: []*x509.Certificate{pki.leaf, pki.intermediate, pki.root}, storeCerts: []*x509.Certificate{other.root}, wantLen: 3},
+		{name: "missing intermediate", certs: []*x509.Certificate{pki.leaf}, storeCerts: []*x509.Certificate{pki.root}, wantErr: `issuer "CN=chain test intermediate`},
+		{name: "missing root", certs: []*x509.Certificate{pki.leaf}, storeCerts: []*x509.Certificate{pki.intermediate, other.root}, wantErr: `issuer "CN=chain test root`},
+		{name: "issuer with the same subject from another PKI", certs: []*x509.Certificate{pki.leaf}, storeCerts: []*x509.Certificate{other.intermediate, other.root}, wantErr: "is not found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := completeCertChain(tt.certs, tt.storeCerts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("completeCertChain() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("completeCertChain() error = %v", err)
+			}
+			if len(chain) != tt.wantLen {
+				t.Fatalf("completeCertChain() returned %d certificates, want %d", len(chain), tt.wantLen)
+			}
+			for i, want := range []*x509.Certificate{pki.leaf, pki.intermediate, pki.root} {
+				if !chain[i].Equal(want) {
+					t.Fatalf("certificate %d is %q, want %q", i, chain[i].Subject, want.Subject)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCertChainCompletingSigner(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	pki := newChainTestPKI(t)
+	writePEM := func(path, blockType string, der []byte) {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(pki.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir.UserConfigDir, "localkeys", "test.key")
+	certPath := filepath.Join(dir.UserConfigDir, "localkeys", "test.crt")
+	writePEM(keyPath, "PRIVATE KEY", keyDER)
+	writePEM(certPath, "CERTIFICATE", pki.leaf.Raw)
+	storeDir := filepath.Join(dir.UserConfigDir, dir.X509TrustStoreDir("ca", "acme-rockets"))
+	writePEM(filepath.Join(storeDir, "root.pem"), "CERTIFICATE", pki.root.Raw)
+	writePEM(filepath.Join(storeDir, "intermediate.pem"), "CERTIFICATE", pki.intermediate.Raw)
+	signingKeys := &config.SigningKeys{Keys: []config.KeySuite{{
+		Name:        "test",
+		X509KeyPair: &config.X509KeyPair{KeyPath: keyPath, CertificatePath: certPath},
+	}}}
+	if err := signingKeys.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	opts := &cmd.SignerFlagOpts{Key: "test"}
+	signer, err := newCertChainCompletingSigner(ctx, opts, "ca:acme-rockets", 0, time.Time{})
+	if err != nil {
+		t.Fatalf("newCertChainCompletingSigner() error = %v", err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	sig, _, err := signer.Sign(ctx, desc, notation.SignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	sigEnv, err := signature.ParseEnvelope(jws.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := sigEnv.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if n := len(content.SignerInfo.CertificateChain); n != 3 {
+		t.Fatalf("signature has %d certificates, want 3", n)
+	}
+
+	if _, err := newCertChainCompletingSigner(ctx, opts, "ca:missing", 0, time.Time{}); err == nil || !strings.Contains(err.Error(), "ca:missing") {
+		t.Fatalf("newCertChainCompletingSigner() error = %v, want error of missing trust store", err)
+	}
+	if _, err := newCertChainCompletingSigner(ctx, &cmd.SignerFlagOpts{KeyID: "id", PluginName: "plugin"}, "ca:acme-rockets", 0, time.Time{}); err == nil || !strings.Contains(err.Error(), "--cert-chain-from-store") {
+		t.Fatalf("newCertChainCompletingSigner() error = %v, want error of unsupported plugin key", err)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+)
+
+func TestPrintCertChain(t *testing.T) {
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	certs := []*x509.Certificate{pki.leaf, pki.ca}
+
+	var buf bytes.Buffer
+	if err := printCertChain(&buf, certChainFormatSummary, certs); err != nil {
+		t.Fatalf("printCertChain() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"Certificate chain of the signature (2 certificates):",
+		"[0] signing certificate",
+		"[1] root certificate",
+		"issued to: " + pki.leaf.Subject.String(),
+		"issued by: " + pki.ca.Subject.String(),
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("printCertChain() output = %q, want it to contain %q", got, want)
+		}
+	}
+
+	buf.Reset()
+	if err := printCertChain(&buf, certChainFormatPEM, certs); err != nil {
+		t.Fatalf("printCertChain() error = %v", err)
+	}
+	rest := buf.Bytes()
+	for i, cert := range certs {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil || !bytes.Equal(block.Bytes, cert.Raw) {
+			t.Fatalf("PEM block %d does not match certificate %d", i, i)
+		}
+	}
+
+	if err := printCertChain(&buf, certChainFormatSummary, nil); err == nil {
+		t.Fatal("printCertChain() expects error for an empty certificate chain")
+	}
+}
+
+func TestCertificateRole(t *testing.T) {
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	if got := certificateRole(pki.leaf, 0, 3); got != "signing certificate" {
+		t.Fatalf("certificateRole() = %s, want signing certificate", got)
+	}
+	if got := certificateRole(pki.ca, 1, 3); got != "intermediate certificate" {
+		t.Fatalf("certificateRole() = %s, want intermediate certificate", got)
+	}
+	if got := certificateRole(pki.ca, 2, 3); got != "root certificate" {
+		t.Fatalf("certificateRole() = %s, want root certificate", got)
+	}
+	if got := certificateRole(pki.leaf, 2, 3); got != "intermediate certificate" {
+		t.Fatalf("certificateRole() = %s, want intermediate certificate for a chain not ending with a root", got)
+	}
+}
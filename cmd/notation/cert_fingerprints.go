@@ -0,0 +1,87 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/notaryproject/notation-go"
+	"oras.land/oras-go/v2/registry"
+)
+
+// formats of --output-cert-fingerprints
+const (
+	certFingerprintsFormatText = "text"
+	certFingerprintsFormatJSON = "json"
+)
+
+var supportedCertFingerprintsFormats = []string{certFingerprintsFormatText, certFingerprintsFormatJSON}
+
+// certFingerprints are the SHA-256 fingerprints of the certificate chain of
+// the signature verified for an artifact, printed by
+// --output-cert-fingerprints.
+type certFingerprints struct {
+	SchemaVersion string            `json:"schemaVersion"`
+	Reference     string            `json:"reference"`
+	Certificates  []certFingerprint `json:"certificates"`
+}
+
+// certFingerprint is the SHA-256 fingerprint of a certificate of a
+// certificate chain.
+type certFingerprint struct {
+	Role    string `json:"role"`
+	Subject string `json:"subject"`
+	SHA256  string `json:"sha256"`
+}
+
+// newCertFingerprints returns the fingerprints of the certificate chain
+// certs of the signature verified for ref, ordered from the signing
+// certificate to the root.
+func newCertFingerprints(ref registry.Reference, certs []*x509.Certificate) *certFingerprints {
+	fingerprints := &certFingerprints{Reference: ref.String()}
+	for i, cert := range certs {
+		fingerprint := sha256.Sum256(cert.Raw)
+		fingerprints.Certificates = append(fingerprints.Certificates, certFingerprint{
+			Role:    certificateRole(cert, i, len(certs)),
+			Subject: cert.Subject.String(),
+			SHA256:  hex.EncodeToString(fingerprint[:]),
+		})
+	}
+	return fingerprints
+}
+
+// printCertFingerprints prints the fingerprints of the certificate chain of
+// the signature verified in outcome for ref in the given format. JSON
+// fingerprints are printed as a single line with the given version of the
+// schema of the JSON outputs, so that batch verifications print JSON Lines.
+// Nothing is printed if signature verification was skipped.
+func printCertFingerprints(w io.Writer, format, schemaVersion string, outcome *notation.VerificationOutcome, ref registry.Reference) error {
+	if outcome.EnvelopeContent == nil {
+		return nil
+	}
+	certs := outcome.EnvelopeContent.SignerInfo.CertificateChain
+	if len(certs) == 0 {
+		return errors.New("verified signature has no certificate chain")
+	}
+	fingerprints := newCertFingerprints(ref, certs)
+	if format == certFingerprintsFormatJSON {
+		fingerprints.SchemaVersion = schemaVersion
+		data, err := json.Marshal(fingerprints)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+	fmt.Fprintf(w, "Certificate fingerprints of the verified signature for %s:\n", fingerprints.Reference)
+	for i, cert := range fingerprints.Certificates {
+		if _, err := fmt.Fprintf(w, "  [%d] %s %s (%s)\n", i, cert.SHA256, cert.Subject, cert.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
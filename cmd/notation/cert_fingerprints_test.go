@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestPrintCertFingerprints(t *testing.T) {
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	outcome := &notation.VerificationOutcome{
+		EnvelopeContent: &signature.EnvelopeContent{
+			SignerInfo: signature.SignerInfo{CertificateChain: []*x509.Certificate{pki.leaf, pki.ca}},
+		},
+	}
+	ref := registry.Reference{Registry: "localhost:5000", Repository: "net-monitor", Reference: "sha256:" + strings.Repeat("0", 64)}
+	leafFingerprint := sha256.Sum256(pki.leaf.Raw)
+	caFingerprint := sha256.Sum256(pki.ca.Raw)
+
+	var buf bytes.Buffer
+	if err := printCertFingerprints(&buf, certFingerprintsFormatText, jsonSchemaVersion1, outcome, ref); err != nil {
+		t.Fatalf("printCertFingerprints() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"Certificate fingerprints of the verified signature for " + ref.String() + ":",
+		"[0] " + hex.EncodeToString(leafFingerprint[:]) + " " + pki.leaf.Subject.String() + " (signing certificate)",
+		"[1] " + hex.EncodeToString(caFingerprint[:]) + " " + pki.ca.Subject.String() + " (root certificate)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("printCertFingerprints() output = %q, want it to contain %q", got, want)
+		}
+	}
+
+	buf.Reset()
+	if err := printCertFingerprints(&buf, certFingerprintsFormatJSON, jsonSchemaVersion1, outcome, ref); err != nil {
+		t.Fatalf("printCertFingerprints() error = %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Fatalf("printCertFingerprints() printed %d lines of JSON, want 1", lines)
+	}
+	var fingerprints certFingerprints
+	if err := json.Unmarshal(buf.Bytes(), &fingerprints); err != nil {
+		t.Fatalf("failed to unmarshal fingerprints: %v", err)
+	}
+	want := certFingerprints{
+		SchemaVersion: jsonSchemaVersion1,
+		Reference:     ref.String(),
+		Certificates: []certFingerprint{
+			{Role: "signing certificate", Subject: pki.leaf.Subject.String(), SHA256: hex.EncodeToString(leafFingerprint[:])},
+			{Role: "root certificate", Subject: pki.ca.Subject.String(), SHA256: hex.EncodeToString(caFingerprint[:])},
+		},
+	}
+	if fingerprints.SchemaVersion != want.SchemaVersion || fingerprints.Reference != want.Reference || len(fingerprints.Certificates) != len(want.Certificates) {
+		t.Fatalf("fingerprints = %+v, want %+v", fingerprints, want)
+	}
+	for i := range want.Certificates {
+		if fingerprints.Certificates[i] != want.Certificates[i] {
+			t.Fatalf("fingerprints.Certificates[%d] = %+v, want %+v", i, fingerprints.Certificates[i], want.Certificates[i])
+		}
+	}
+
+	buf.Reset()
+	skipped := &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}
+	if err := printCertFingerprints(&buf, certFingerprintsFormatText, jsonSchemaVersion1, skipped, ref); err != nil || buf.Len() != 0 {
+		t.Fatalf("printCertFingerprints() = %q, %v, want nothing printed for skipped verification", buf.String(), err)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/x509util"
+	"github.com/notaryproject/notation/pkg/configutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// maxClockSkewTolerance is the largest accepted --clock-skew-tolerance. The
+// tolerance is meant to absorb small clock differences between signers and
+// verifiers, not to forge signing times.
+const maxClockSkewTolerance = time.Hour
+
+// validateClockSkewTolerance returns an error if tolerance is negative or
+// larger than maxClockSkewTolerance.
+func validateClockSkewTolerance(tolerance time.Duration) error {
+	if tolerance < 0 {
+		return fmt.Errorf("--clock-skew-tolerance cannot be a negative value, got %s", tolerance)
+	}
+	if tolerance > maxClockSkewTolerance {
+		return fmt.Errorf("--clock-skew-tolerance cannot be larger than %s, got %s", maxClockSkewTolerance, tolerance)
+	}
+	return nil
+}
+
+// backdatedSigner implements notation.Signer. It signs as the builtin signer
+// of notation-go does, except that the signing time is set tolerance before
+// the current time, or to signingTime if set. The builtin signer always signs
+// at the current time, so it cannot be wrapped.
+type backdatedSigner struct {
+	signer      signature.Signer
+	tolerance   time.Duration
+	signingTime time.Time
+}
+
+// newBackdatedSigner returns a backdatedSigner for the key in opts. Only local
+// keys are supported, as plugins set the signing time themselves.
+func newBackdatedSigner(opts *cmd.SignerFlagOpts, tolerance time.Duration) (*backdatedSigner, error) {
+	privateKey, certs, err := loadLocalSigningKey(opts, "--clock-skew-tolerance")
+	if err != nil {
+		return nil, err
+	}
+	return newBackdatedSignerFromKey(privateKey, certs, tolerance)
+}
+
+// loadLocalSigningKey returns the private key and the certificate chain of
+// the local key in opts. flag is the flag requiring a local key, reported as
+// unsupported with plugin keys.
+func loadLocalSigningKey(opts *cmd.SignerFlagOpts, flag string) (crypto.PrivateKey, []*x509.Certificate, error) {
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		return nil, nil, fmt.Errorf("%s is not supported with on-demand plugin keys", flag)
+	}
+	key, err := configutil.ResolveKey(opts.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.X509KeyPair == nil {
+		return nil, nil, fmt.Errorf("%s is not supported with plugin key %q, use a local key", flag, key.Name)
+	}
+	if opts.NoDefaultPluginConfig {
+		return nil, nil, fmt.Errorf("--%s is only supported for keys of signing plugins", cmd.PflagNoDefaultPluginConfig.Name)
+	}
+	privateKey, err := corex509.ReadPrivateKeyFile(key.X509KeyPair.KeyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	certs, err := corex509.ReadCertificateFile(key.X509KeyPair.CertificatePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("%q does not contain certificate", key.X509KeyPair.CertificatePath)
+	}
+	if opts.ReproducibleCertOrder {
+		certs, err = x509util.SortCertificateChain(certs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to sort certificate chain %q: %w", key.X509KeyPair.CertificatePath, err)
+		}
+	}
+	return privateKey, certs, nil
+}
+
+// newBackdatedSignerFromKey returns a backdatedSigner signing with key and the
+// certificate chain certs.
+func newBackdatedSignerFromKey(key crypto.PrivateKey, certs []*x509.Certificate, tolerance time.Duration) (*backdatedSigner, error) {
+	localSigner, err := signature.NewLocalSigner(certs, key)
+	if err != nil {
+		return nil, err
+	}
+	return &backdatedSigner{signer: localSigner, tolerance: tolerance}, nil
+}
+
+// Sign signs the artifact described by desc with a signing time backdated by
+// s.tolerance, or with the fixed s.signingTime. A backdated signature expires
+// opts.ExpiryDuration after the current time, as if it was not backdated,
+// while a signature with a fixed signing time expires opts.ExpiryDuration
+// after it.
+func (s *backdatedSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	logger := log.GetLogger(ctx)
+	signReq, err := newSignRequest(desc, opts, s.signer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.signingTime.IsZero() {
+		signReq.SigningTime = signReq.SigningTime.Add(-s.tolerance)
+		logger.Debugf("Signing time backdated by %v to %v", s.tolerance, signReq.SigningTime)
+	} else {
+		signReq.SigningTime = s.signingTime
+		if opts.ExpiryDuration != 0 {
+			signReq.Expiry = s.signingTime.Add(opts.ExpiryDuration)
+		}
+		logger.Debugf("Signing time set to %v", signReq.SigningTime)
+	}
+
+	sigEnv, err := signature.NewEnvelope(opts.SignatureMediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := sigEnv.Sign(signReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	envContent, err := sigEnv.Verify()
+	if err != nil {
+		return nil, nil, fmt.Errorf("generated signature failed verification: %v", err)
+	}
+	if err := envelope.ValidatePayloadContentType(&envContent.Payload); err != nil {
+		return nil, nil, err
+	}
+	return sig, &envContent.SignerInfo, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestValidateClockSkewTolerance(t *testing.T) {
+	tests := []struct {
+		tolerance time.Duration
+		wantErr   bool
+	}{
+		{0, false},
+		{30 * time.Second, false},
+		{maxClockSkewTolerance, false},
+		{-time.Second, true},
+		{maxClockSkewTolerance + time.Second, true},
+	}
+	for _, tt := range tests {
+		if err := validateClockSkewTolerance(tt.tolerance); (err != nil) != tt.wantErr {
+			t.Errorf("validateClockSkewTolerance(%s) error = %v, wantErr %v", tt.tolerance, err, tt.wantErr)
+		}
+	}
+}
+
+func TestBackdatedSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "clock skew test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tolerance := 5 * time.Minute
+	signer, err := newBackdatedSignerFromKey(key, []*x509.Certificate{cert}, tolerance)
+	if err != nil {
+		t.Fatalf("newBackdatedSignerFromKey() error = %v", err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	before := time.Now()
+	sig, _, err := signer.Sign(context.Background(), desc, notation.SignOptions{
+		SignatureMediaType: jws.MediaTypeEnvelope,
+		ExpiryDuration:     time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	sigEnv, err := signature.ParseEnvelope(jws.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatalf("ParseEnvelope() error = %v", err)
+	}
+	content, err := sigEnv.Verify()
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	signedAttrs := content.SignerInfo.SignedAttributes
+	if backdate := before.Sub(signedAttrs.SigningTime); backdate < tolerance-time.Second || backdate > tolerance+time.Minute {
+		t.Errorf("signing time %s is backdated by %s, want about %s", signedAttrs.SigningTime, backdate, tolerance)
+	}
+	after := time.Now()
+	// the expiry stays --expiry after the time of signing, so backdating does
+	// not shorten the validity of the signature
+	if signedAttrs.Expiry.Before(before.Add(time.Hour).Truncate(time.Second)) || signedAttrs.Expiry.After(after.Add(time.Hour)) {
+		t.Errorf("expiry %s is not %s after the time of signing, between %s and %s", signedAttrs.Expiry, time.Hour, before, after)
+	}
+	if got := signedAttrs.Expiry.Sub(signedAttrs.SigningTime); got != time.Hour+tolerance {
+		t.Errorf("expiry is %s after the backdated signing time, want %s", got, time.Hour+tolerance)
+	}
+}
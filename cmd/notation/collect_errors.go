@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// signatureReport is the failed checks of a signature, collected by
+// `notation verify --collect-all-errors`.
+type signatureReport struct {
+	signer string
+	errs   []error
+}
+
+// allErrorsVerifier wraps a notation.Verifier and, for signatures failing
+// verification, re-verifies them with diagnostic, a notation-go verifier with
+// the trust policy checks logged instead of enforced. Every failing check of
+// the signature is then recorded, instead of the first one that stopped the
+// verification. The signature is still rejected.
+type allErrorsVerifier struct {
+	verifierWrapper
+	diagnostic notation.Verifier
+	reports    []signatureReport
+}
+
+// Verify verifies the signature with the wrapped verifier and, if it fails,
+// records the failures of all checks of the signature.
+func (v *allErrorsVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err == nil {
+		return outcome, nil
+	}
+	diagOutcome, diagErr := v.diagnostic.Verify(ctx, desc, signature, opts)
+	v.reports = append(v.reports, collectSignatureErrors(diagOutcome, diagErr, err))
+	return outcome, err
+}
+
+// collectSignatureErrors returns the report of the failed checks in the
+// diagnostic outcome, followed by the diagnostic error and the error of the
+// actual verification err if they are not the failure of a check already
+// reported.
+func collectSignatureErrors(outcome *notation.VerificationOutcome, diagErr, err error) signatureReport {
+	report := signatureReport{signer: "(unknown)"}
+	reported := map[string]bool{}
+	if outcome != nil {
+		if outcome.EnvelopeContent != nil && len(outcome.EnvelopeContent.SignerInfo.CertificateChain) > 0 {
+			report.signer = outcome.EnvelopeContent.SignerInfo.CertificateChain[0].Subject.String()
+		}
+		for _, result := range outcome.VerificationResults {
+			if result.Error == nil {
+				continue
+			}
+			report.errs = append(report.errs, fmt.Errorf("%s: %w", result.Type, result.Error))
+			reported[result.Error.Error()] = true
+		}
+	}
+	for _, e := range []error{diagErr, err} {
+		if e != nil && !reported[e.Error()] {
+			report.errs = append(report.errs, e)
+			reported[e.Error()] = true
+		}
+	}
+	return report
+}
+
+// relaxTrustPolicy returns a copy of doc with the enforced checks of each
+// trust policy statement logged instead, so that verification runs every
+// check. The integrity check cannot be relaxed, and statements skipping
+// signature verification are left unchanged.
+func relaxTrustPolicy(doc *trustpolicy.Document) (*trustpolicy.Document, error) {
+	relaxed := &trustpolicy.Document{
+		Version:       doc.Version,
+		TrustPolicies: make([]trustpolicy.TrustPolicy, len(doc.TrustPolicies)),
+	}
+	for i, statement := range doc.TrustPolicies {
+		level, err := statement.SignatureVerification.GetVerificationLevel()
+		if err != nil {
+			return nil, fmt.Errorf("trust policy statement %q: %w", statement.Name, err)
+		}
+		if level.Name != trustpolicy.LevelSkip.Name {
+			override := map[trustpolicy.ValidationType]trustpolicy.ValidationAction{}
+			for validationType, action := range level.Enforcement {
+				if validationType == trustpolicy.TypeIntegrity {
+					continue
+				}
+				if action == trustpolicy.ActionEnforce {
+					action = trustpolicy.ActionLog
+				}
+				override[validationType] = action
+			}
+			statement.SignatureVerification = trustpolicy.SignatureVerification{
+				VerificationLevel: statement.SignatureVerification.VerificationLevel,
+				Override:          override,
+			}
+		}
+		relaxed.TrustPolicies[i] = statement
+	}
+	return relaxed, nil
+}
+
+// getDiagnosticVerifier returns a notation-go verifier with the trust policy
+// selected by opts relaxed by relaxTrustPolicy.
+func getDiagnosticVerifier(ctx context.Context, opts *verifyOpts) (notation.Verifier, error) {
+	doc, err := loadTrustPolicyDocument(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	relaxed, err := relaxTrustPolicy(doc)
+	if err != nil {
+		return nil, err
+	}
+	x509TrustStore := truststore.NewX509TrustStore(dir.ConfigFS())
+	return verifier.New(relaxed, x509TrustStore, plugin.NewCLIManager(dir.PluginFS()))
+}
+
+// printSignatureReports prints the failed checks of each signature.
+func printSignatureReports(w io.Writer, reports []signatureReport) {
+	for i, report := range reports {
+		fmt.Fprintf(w, "Error: signature %d of %d signed by %q failed with %d errors:\n", i+1, len(reports), report.signer, len(report.errs))
+		for _, err := range report.errs {
+			fmt.Fprintf(w, "  - %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestRelaxTrustPolicy(t *testing.T) {
+	doc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  "strict",
+				RegistryScopes:        []string{"localhost:5000/net-monitor"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: trustpolicy.LevelStrict.Name},
+				TrustStores:           []string{"ca:acme-rockets"},
+				TrustedIdentities:     []string{"*"},
+			},
+			{
+				Name:           "custom",
+				RegistryScopes: []string{"localhost:5000/other"},
+				SignatureVerification: trustpolicy.SignatureVerification{
+					VerificationLevel: trustpolicy.LevelPermissive.Name,
+					Override:          map[trustpolicy.ValidationType]trustpolicy.ValidationAction{trustpolicy.TypeRevocation: trustpolicy.ActionSkip},
+				},
+				TrustStores:       []string{"ca:acme-rockets"},
+				TrustedIdentities: []string{"*"},
+			},
+			{
+				Name:                  "skip",
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: trustpolicy.LevelSkip.Name},
+			},
+		},
+	}
+	relaxed, err := relaxTrustPolicy(doc)
+	if err != nil {
+		t.Fatalf("relaxTrustPolicy() error = %v", err)
+	}
+	if err := relaxed.Validate(); err != nil {
+		t.Fatalf("relaxed trust policy is invalid: %v", err)
+	}
+	if doc.TrustPolicies[0].SignatureVerification.Override != nil {
+		t.Fatal("relaxTrustPolicy() modified the original trust policy")
+	}
+
+	level, err := relaxed.TrustPolicies[0].SignatureVerification.GetVerificationLevel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for validationType, want := range map[trustpolicy.ValidationType]trustpolicy.ValidationAction{
+		trustpolicy.TypeIntegrity:          trustpolicy.ActionEnforce,
+		trustpolicy.TypeAuthenticity:       trustpolicy.ActionLog,
+		trustpolicy.TypeAuthenticTimestamp: trustpolicy.ActionLog,
+		trustpolicy.TypeExpiry:             trustpolicy.ActionLog,
+		trustpolicy.TypeRevocation:         trustpolicy.ActionLog,
+	} {
+		if got := level.Enforcement[validationType]; got != want {
+			t.Errorf("%s action of the relaxed strict statement = %s, want %s", validationType, got, want)
+		}
+	}
+	level, err = relaxed.TrustPolicies[1].SignatureVerification.GetVerificationLevel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := level.Enforcement[trustpolicy.TypeRevocation]; got != trustpolicy.ActionSkip {
+		t.Errorf("revocation action of the relaxed custom statement = %s, want %s", got, trustpolicy.ActionSkip)
+	}
+	if got := relaxed.TrustPolicies[2].SignatureVerification; got.VerificationLevel != trustpolicy.LevelSkip.Name || got.Override != nil {
+		t.Errorf("relaxed skip statement = %+v, want unchanged", got)
+	}
+}
+
+func TestCollectSignatureErrors(t *testing.T) {
+	authenticityErr := errors.New("signature is not produced by a trusted signer")
+	expiryErr := errors.New("digital signature has expired")
+	content := &signature.EnvelopeContent{}
+	content.SignerInfo.CertificateChain = []*x509.Certificate{newTestCertificate(t, "wabbit-networks.io")}
+	outcome := &notation.VerificationOutcome{
+		EnvelopeContent: content,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity},
+			{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionLog, Error: authenticityErr},
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionLog, Error: expiryErr},
+		},
+	}
+	identityErr := errors.New("signing certificate is not a trusted identity")
+
+	report := collectSignatureErrors(outcome, nil, errors.New(authenticityErr.Error()))
+	if report.signer != "CN=wabbit-networks.io" {
+		t.Fatalf("signer = %s, want CN=wabbit-networks.io", report.signer)
+	}
+	if len(report.errs) != 2 || !errors.Is(report.errs[0], authenticityErr) || !errors.Is(report.errs[1], expiryErr) {
+		t.Fatalf("errs = %v, want the authenticity and expiry errors", report.errs)
+	}
+
+	report = collectSignatureErrors(outcome, nil, identityErr)
+	if len(report.errs) != 3 || report.errs[2] != identityErr {
+		t.Fatalf("errs = %v, want the authenticity, expiry and identity errors", report.errs)
+	}
+
+	report = collectSignatureErrors(nil, identityErr, identityErr)
+	if report.signer != "(unknown)" || len(report.errs) != 1 {
+		t.Fatalf("report = %+v, want the single error of an unknown signer", report)
+	}
+}
+
+func TestAllErrorsVerifier(t *testing.T) {
+	ctx := context.Background()
+	sig, _ := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+	doc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{{
+			Name:                  "test",
+			RegistryScopes:        []string{"*"},
+			SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: trustpolicy.LevelStrict.Name},
+			TrustStores:           []string{"ca:untrusted"},
+			TrustedIdentities:     []string{"*"},
+		}},
+	}
+	relaxed, err := relaxTrustPolicy(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &mockTrustStore{}
+	strictVerifier, err := verifier.New(doc, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagnostic, err := verifier.New(relaxed, store, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &allErrorsVerifier{verifierWrapper: verifierWrapper{Verifier: strictVerifier}, diagnostic: diagnostic}
+	opts := notation.VerifyOptions{ArtifactReference: "localhost:5000/net-monitor@sha256:abc", SignatureMediaType: jws.MediaTypeEnvelope}
+	if _, err := v.Verify(ctx, ocispec.Descriptor{}, sig, opts); err == nil {
+		t.Fatal("Verify() expected error, but got nil")
+	}
+	if len(v.reports) != 1 {
+		t.Fatalf("got %d reports, want 1", len(v.reports))
+	}
+	if report := v.reports[0]; report.signer != "CN=signer,O=notation" || len(report.errs) == 0 || !strings.HasPrefix(report.errs[0].Error(), string(trustpolicy.TypeAuthenticity)) {
+		t.Fatalf("report = %+v, want authenticity error of CN=signer,O=notation", report)
+	}
+
+	var buf bytes.Buffer
+	printSignatureReports(&buf, v.reports)
+	if !strings.HasPrefix(buf.String(), `Error: signature 1 of 1 signed by "CN=signer,O=notation" failed with`) {
+		t.Fatalf("printSignatureReports() = %q", buf.String())
+	}
+}
@@ -4,12 +4,16 @@ import (
 	"os"
 
 	"github.com/spf13/pflag"
+	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
 const (
-	defaultUsernameEnv = "NOTATION_USERNAME"
-	defaultPasswordEnv = "NOTATION_PASSWORD"
-	defaultMediaType   = "application/vnd.docker.distribution.manifest.v2+json"
+	defaultUsernameEnv   = "NOTATION_USERNAME"
+	defaultPasswordEnv   = "NOTATION_PASSWORD"
+	registryUserAgentEnv = "NOTATION_REGISTRY_USER_AGENT"
+	defaultMediaType     = "application/vnd.docker.distribution.manifest.v2+json"
+
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
 )
 
 var (
@@ -39,12 +43,52 @@ var (
 	setFlagPlainHTTP = func(fs *pflag.FlagSet, p *bool) {
 		fs.BoolVar(p, flagPlainHTTP.Name, false, flagPlainHTTP.Usage)
 	}
+
+	flagMinTLSVersion = &pflag.Flag{
+		Name:  "min-tls-version",
+		Usage: "minimum TLS version of connections to the registry, connections to registries not supporting it fail, options: \"1.2\", \"1.3\" (default \"" + defaultMinTLSVersion + "\")",
+	}
+	setFlagMinTLSVersion = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, flagMinTLSVersion.Name, "", flagMinTLSVersion.Usage)
+	}
+
+	flagCipherSuites = &pflag.Flag{
+		Name:  "cipher-suites",
+		Usage: "comma separated names of the TLS 1.2 cipher suites allowed for connections to the registry, for example \"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384\". The cipher suites of TLS 1.3 are not configurable (default to the secure cipher suites of Go)",
+	}
+	setFlagCipherSuites = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, flagCipherSuites.Name, "", flagCipherSuites.Usage)
+	}
+
+	flagRegistryUserAgent = &pflag.Flag{
+		Name:  "registry-user-agent",
+		Usage: "User-Agent header of the registry requests of the command, to attribute the registry traffic for quota or audit, for example \"tenant-a/1.0\" (default to $" + registryUserAgentEnv + " if set, otherwise to the User-Agent of notation)",
+	}
+	setFlagRegistryUserAgent = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, flagRegistryUserAgent.Name, "", flagRegistryUserAgent.Usage)
+		*p = os.Getenv(registryUserAgentEnv)
+	}
 )
 
 type SecureFlagOpts struct {
-	Username  string
-	Password  string
-	PlainHTTP bool
+	Username      string
+	Password      string
+	PlainHTTP     bool
+	MinTLSVersion string
+	CipherSuites  string
+	// UserAgent is the User-Agent header of registry requests, or empty for
+	// the default User-Agent of notation. It is not set by ApplyFlags, but by
+	// --registry-user-agent or --signing-agent of the commands supporting them.
+	UserAgent string
+	// RegistryScopes are the additional auth scopes to request registry tokens
+	// for, in the format of the --registry-scope flag of notation sign. It is
+	// not set by ApplyFlags either.
+	RegistryScopes []string
+	// AuthCache caches the auth tokens of the registry clients created with
+	// the options, so that the references of a batch authenticate once per
+	// registry and scope instead of once per client. Nil gives each client
+	// its own cache. It is not set by ApplyFlags either.
+	AuthCache auth.Cache
 }
 
 // ApplyFlags set flags and their default values for the FlagSet
@@ -52,6 +96,20 @@ func (opts *SecureFlagOpts) ApplyFlags(fs *pflag.FlagSet) {
 	setflagUsername(fs, &opts.Username)
 	setFlagPassword(fs, &opts.Password)
 	setFlagPlainHTTP(fs, &opts.PlainHTTP)
+	setFlagMinTLSVersion(fs, &opts.MinTLSVersion)
+	setFlagCipherSuites(fs, &opts.CipherSuites)
 	opts.Username = os.Getenv(defaultUsernameEnv)
 	opts.Password = os.Getenv(defaultPasswordEnv)
 }
+
+// setFlagOCILayoutAlias makes fs accept --local-content as another name of
+// the --oci-layout flag, so that the flags are interchangeable, including in
+// the flag groups of --oci-layout.
+func setFlagOCILayoutAlias(fs *pflag.FlagSet) {
+	fs.SetNormalizeFunc(func(_ *pflag.FlagSet, name string) pflag.NormalizedName {
+		if name == "local-content" {
+			name = "oci-layout"
+		}
+		return pflag.NormalizedName(name)
+	})
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+// unsignedAttributeCRL is the unsigned attribute of a signature envelope
+// storing the DER encoded CRL of the signing certificate, embedded by
+// `notation sign --embed-crl`.
+const unsignedAttributeCRL = "io.cncf.notary.crl"
+
+// maxCRLSize is the largest CRL fetched for embedding, in bytes.
+const maxCRLSize = 4 << 20
+
+// crlFetchTimeout is the timeout of fetching the CRL of a distribution point.
+const crlFetchTimeout = 10 * time.Second
+
+// crlEmbeddingSigner wraps a notation.Signer and embeds the CRL of the
+// signing certificate into the unsigned attributes of the generated
+// signature envelope.
+type crlEmbeddingSigner struct {
+	signerWrapper
+	client *http.Client
+}
+
+// newCRLEmbeddingSigner returns a crlEmbeddingSigner wrapping s.
+func newCRLEmbeddingSigner(s notation.Signer) *crlEmbeddingSigner {
+	return &crlEmbeddingSigner{
+		signerWrapper: signerWrapper{Signer: s},
+		client:        &http.Client{Timeout: crlFetchTimeout},
+	}
+}
+
+// Sign signs the artifact with the wrapped signer, then fetches the CRL of
+// the signing certificate and embeds it into the signature envelope.
+func (s *crlEmbeddingSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	certs := signerInfo.CertificateChain
+	if len(certs) < 2 {
+		return nil, nil, errors.New("--embed-crl requires the certificate chain of the signature to contain the issuer of the signing certificate")
+	}
+	crl, err := fetchCRL(ctx, s.client, certs[0], certs[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch the CRL of the signing certificate: %w", err)
+	}
+	sig, err = embedCRL(opts.SignatureMediaType, sig, crl)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to embed the CRL into the signature: %w", err)
+	}
+	log.GetLogger(ctx).Infof("Embedded CRL of %d bytes into the signature", len(crl))
+	return sig, signerInfo, nil
+}
+
+// fetchCRL fetches the CRL of cert from the first HTTP distribution point of
+// cert serving a CRL issued by issuer that is not outdated, and returns it
+// DER encoded.
+func fetchCRL(ctx context.Context, client *http.Client, cert, issuer *x509.Certificate) ([]byte, error) {
+	var errs []error
+	for _, point := range cert.CRLDistributionPoints {
+		u, err := url.Parse(point)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			errs = append(errs, fmt.Errorf("unsupported distribution point %q", point))
+			continue
+		}
+		crl, err := fetchCRLFrom(ctx, client, point)
+		if err == nil {
+			_, err = parseCRL(crl, issuer, time.Now())
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", point, err))
+			continue
+		}
+		return crl, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("signing certificate with subject %q has no CRL distribution point", cert.Subject)
+	}
+	return nil, errors.Join(errs...)
+}
+
+// fetchCRLFrom downloads the CRL at point and returns it DER encoded.
+func fetchCRLFrom(ctx context.Context, client *http.Client, point string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, point, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCRLSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCRLSize {
+		return nil, fmt.Errorf("CRL is larger than %d bytes", maxCRLSize)
+	}
+	if block, _ := pem.Decode(data); block != nil && block.Type == "X509 CRL" {
+		data = block.Bytes
+	}
+	return data, nil
+}
+
+// parseCRL parses the DER encoded CRL der and checks that it is issued by
+// issuer and is not outdated at now.
+func parseCRL(der []byte, issuer *x509.Certificate, now time.Time) (*x509.RevocationList, error) {
+	crl, err := x509.ParseRevocationList(der)
+	if err != nil {
+		return nil, fmt.Errorf("malformed CRL: %w", err)
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("CRL is not issued by %q: %w", issuer.Subject, err)
+	}
+	if !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate) {
+		return nil, fmt.Errorf("CRL is outdated since %s", crl.NextUpdate.Format(time.RFC3339))
+	}
+	return crl, nil
+}
+
+// checkRevocationWithCRL checks that cert is not revoked by the DER encoded
+// CRL der issued by issuer, at now.
+func checkRevocationWithCRL(der []byte, cert, issuer *x509.Certificate, now time.Time) error {
+	crl, err := parseCRL(der, issuer, now)
+	if err != nil {
+		return err
+	}
+	for _, revoked := range crl.RevokedCertificates {
+		if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 && !revoked.RevocationTime.After(now) {
+			return fmt.Errorf("signing certificate with subject %q and serial number %s is revoked since %s", cert.Subject, formatSerialNumber(cert.SerialNumber), revoked.RevocationTime.Format(time.RFC3339))
+		}
+	}
+	return nil
+}
+
+// formatSerialNumber returns the serial number in hexadecimal.
+func formatSerialNumber(serial *big.Int) string {
+	return fmt.Sprintf("%x", serial)
+}
+
+// embedCRL returns the signature envelope sig of mediaType with the CRL
+// added to its unsigned attributes. The signed content of the envelope is
+// unchanged.
+func embedCRL(mediaType string, sig, crl []byte) ([]byte, error) {
+	return embedUnsignedAttribute(mediaType, sig, unsignedAttributeCRL, crl)
+}
+
+// embedUnsignedAttribute returns the signature envelope sig of mediaType with
+// the unsigned attribute key set to value, a byte string or a text string.
+// The signed content of the envelope is unchanged.
+func embedUnsignedAttribute(mediaType string, sig []byte, key string, value any) ([]byte, error) {
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(sig, &envelope); err != nil {
+			return nil, err
+		}
+		header := map[string]json.RawMessage{}
+		if raw, ok := envelope["header"]; ok {
+			if err := json.Unmarshal(raw, &header); err != nil {
+				return nil, err
+			}
+		}
+		valueJSON, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		header[key] = valueJSON
+		headerJSON, err := json.Marshal(header)
+		if err != nil {
+			return nil, err
+		}
+		envelope["header"] = headerJSON
+		return json.Marshal(envelope)
+	case cose.MediaTypeEnvelope:
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			return nil, err
+		}
+		msg.Headers.Unprotected[key] = value
+		// re-encode the unprotected header only
+		msg.Headers.RawUnprotected = nil
+		return msg.MarshalCBOR()
+	default:
+		return nil, fmt.Errorf("unsupported signature envelope media type %q", mediaType)
+	}
+}
+
+// extractEmbeddedCRL returns the CRL embedded in the signature envelope sig
+// of mediaType, or nil if there is none.
+func extractEmbeddedCRL(mediaType string, sig []byte) ([]byte, error) {
+	return extractUnsignedAttribute(mediaType, sig, unsignedAttributeCRL)
+}
+
+// extractUnsignedAttribute returns the byte string value of the unsigned
+// attribute key of the signature envelope sig of mediaType, or nil if there
+// is none.
+func extractUnsignedAttribute(mediaType string, sig []byte, key string) ([]byte, error) {
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		var envelope struct {
+			Header map[string]json.RawMessage `json:"header"`
+		}
+		if err := json.Unmarshal(sig, &envelope); err != nil {
+			return nil, err
+		}
+		raw, ok := envelope.Header[key]
+		if !ok {
+			return nil, nil
+		}
+		var value []byte
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("unsigned attribute %q is not a byte string", key)
+		}
+		return value, nil
+	case cose.MediaTypeEnvelope:
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			return nil, err
+		}
+		value, ok := msg.Headers.Unprotected[key]
+		if !ok {
+			return nil, nil
+		}
+		b, ok := value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("unsigned attribute %q is not a byte string", key)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("unsupported signature envelope media type %q", mediaType)
+	}
+}
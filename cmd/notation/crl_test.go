@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// crlTestPKI is a CA issuing a code signing certificate and its CRL.
+type crlTestPKI struct {
+	caKey   *ecdsa.PrivateKey
+	ca      *x509.Certificate
+	leafKey *ecdsa.PrivateKey
+	leaf    *x509.Certificate
+}
+
+func newCRLTestPKI(t *testing.T, crlURL string) *crlTestPKI {
+	t.Helper()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "crl test CA", Organization: []string{"Notary"}, Province: []string{"WA"}, Country: []string{"US"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(42),
+		Subject:               pkix.Name{CommonName: "crl test", Organization: []string{"Notary"}, Province: []string{"WA"}, Country: []string{"US"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+		CRLDistributionPoints: []string{crlURL},
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, ca, &leafKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &crlTestPKI{caKey: caKey, ca: ca, leafKey: leafKey, leaf: leaf}
+}
+
+// createCRL returns a DER encoded CRL of the CA revoking serials.
+func (p *crlTestPKI) createCRL(t *testing.T, serials ...*big.Int) []byte {
+	t.Helper()
+	var revoked []pkix.RevokedCertificate
+	for _, serial := range serials {
+		revoked = append(revoked, pkix.RevokedCertificate{SerialNumber: serial, RevocationTime: time.Now().Add(-time.Minute)})
+	}
+	crl, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:              big.NewInt(1),
+		ThisUpdate:          time.Now().Add(-time.Hour),
+		NextUpdate:          time.Now().Add(time.Hour),
+		RevokedCertificates: revoked,
+	}, p.ca, p.caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func TestCRLEmbeddingSigner(t *testing.T) {
+	var crl []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(crl)
+	}))
+	defer server.Close()
+	pki := newCRLTestPKI(t, server.URL+"/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			crl = pki.createCRL(t)
+			s := newCRLEmbeddingSigner(localSigner)
+			sig, _, err := s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			// the signature stays valid
+			sigEnv, err := signature.ParseEnvelope(mediaType, sig)
+			if err != nil {
+				t.Fatalf("ParseEnvelope() error = %v", err)
+			}
+			if _, err := sigEnv.Verify(); err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+
+			certs := []*x509.Certificate{pki.leaf, pki.ca}
+			if err := checkEmbeddedCRL(mediaType, sig, certs, time.Now()); err != nil {
+				t.Fatalf("checkEmbeddedCRL() error = %v", err)
+			}
+			if err := checkEmbeddedCRL(mediaType, sig, certs, time.Now().Add(2*time.Hour)); err == nil || !strings.Contains(err.Error(), "outdated") {
+				t.Fatalf("checkEmbeddedCRL() error = %v, want error of outdated CRL", err)
+			}
+
+			// revoked signing certificate
+			crl = pki.createCRL(t, pki.leaf.SerialNumber)
+			sig, _, err = s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			if err := checkEmbeddedCRL(mediaType, sig, certs, time.Now()); err == nil || !strings.Contains(err.Error(), "revoked") {
+				t.Fatalf("checkEmbeddedCRL() error = %v, want error of revoked certificate", err)
+			}
+		})
+	}
+}
+
+func TestCRLEmbeddingSigner_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	pki := newCRLTestPKI(t, server.URL+"/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newCRLEmbeddingSigner(localSigner)
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	if _, _, err := s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: jws.MediaTypeEnvelope}); err == nil {
+		t.Fatal("Sign() expects error for an unavailable CRL")
+	}
+}
+
+func TestCheckEmbeddedCRL_Missing(t *testing.T) {
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		sig, _, err := localSigner.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		if err := checkEmbeddedCRL(mediaType, sig, []*x509.Certificate{pki.leaf, pki.ca}, time.Now()); err == nil || !strings.Contains(err.Error(), "no embedded CRL") {
+			t.Fatalf("checkEmbeddedCRL() error = %v, want error of missing CRL", err)
+		}
+	}
+}
+
+func TestStapledRevocationVerifier(t *testing.T) {
+	var crl []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(crl)
+	}))
+	defer server.Close()
+	pki := newCRLTestPKI(t, server.URL+"/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	sign := func(s notation.Signer) []byte {
+		sig, _, err := s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		return sig
+	}
+	crl = pki.createCRL(t)
+	validSig := sign(newCRLEmbeddingSigner(localSigner))
+	crl = pki.createCRL(t, pki.leaf.SerialNumber)
+	revokedSig := sign(newCRLEmbeddingSigner(localSigner))
+	unstapledSig := sign(localSigner)
+
+	tests := []struct {
+		name        string
+		sig         []byte
+		level       *trustpolicy.VerificationLevel
+		wantErr     bool
+		wantResults int
+	}{
+		{name: "valid stapled CRL", sig: validSig, level: trustpolicy.LevelStrict},
+		{name: "no stapled CRL", sig: unstapledSig, level: trustpolicy.LevelStrict},
+		{name: "revoked with enforced revocation", sig: revokedSig, level: trustpolicy.LevelStrict, wantErr: true, wantResults: 1},
+		{name: "revoked with logged revocation", sig: revokedSig, level: trustpolicy.LevelPermissive, wantResults: 1},
+		{name: "revoked with skipped revocation", sig: revokedSig, level: &trustpolicy.VerificationLevel{
+			Name:        "custom",
+			Enforcement: map[trustpolicy.ValidationType]trustpolicy.ValidationAction{trustpolicy.TypeRevocation: trustpolicy.ActionSkip},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := &notation.VerificationOutcome{
+				EnvelopeContent:   &signature.EnvelopeContent{},
+				VerificationLevel: tt.level,
+			}
+			outcome.EnvelopeContent.SignerInfo.CertificateChain = []*x509.Certificate{pki.leaf, pki.ca}
+			v := &stapledRevocationVerifier{verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcome}}}
+			got, err := v.Verify(context.Background(), desc, tt.sig, notation.VerifyOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got.VerificationResults) != tt.wantResults {
+				t.Fatalf("got %d verification results, want %d", len(got.VerificationResults), tt.wantResults)
+			}
+			if tt.wantResults > 0 && !strings.Contains(got.VerificationResults[0].Error.Error(), "revoked") {
+				t.Fatalf("verification result error = %v, want error of revoked certificate", got.VerificationResults[0].Error)
+			}
+			if tt.wantErr && len(v.errs) != 1 {
+				t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+			}
+		})
+	}
+}
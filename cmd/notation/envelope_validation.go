@@ -0,0 +1,303 @@
+package main
+
+import (
+	b64 "encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+// protected headers of the Notary Project signature specification
+const (
+	headerSigningScheme        = "io.cncf.notary.signingScheme"
+	headerSigningTime          = "io.cncf.notary.signingTime"
+	headerAuthenticSigningTime = "io.cncf.notary.authenticSigningTime"
+	headerExpiry               = "io.cncf.notary.expiry"
+)
+
+// standard protected headers, which must not be listed as critical headers
+const (
+	headerAlgorithm   = "alg"
+	headerContentType = "cty"
+	headerCritical    = "crit"
+)
+
+// supportedEnvelopeAlgorithms are the signature algorithms of the Notary
+// Project signature specification.
+var supportedEnvelopeAlgorithms = []string{"PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// envelopeHeaders are the headers of a signature envelope relevant to its
+// conformance, read without interpreting the signature.
+type envelopeHeaders struct {
+	// algorithm is the name of the signature algorithm.
+	algorithm string
+
+	// contentType is the content type of the payload.
+	contentType string
+
+	// signingScheme is the value of the signing scheme header.
+	signingScheme string
+
+	// protected are the names of the protected headers.
+	protected []string
+
+	// critical are the names of the critical headers, in order. hasCritical
+	// is set if the critical header set is present.
+	critical    []string
+	hasCritical bool
+
+	// certChainHeader is the name of the unprotected header containing the
+	// certificate chain, and certChainLength the number of certificates it
+	// contains.
+	certChainHeader string
+	certChainLength int
+
+	// payload is the payload of the envelope.
+	payload []byte
+}
+
+// validateEnvelope checks the structural conformance of the signature
+// envelope sigBlob of mediaType to the Notary Project signature
+// specification, and its linkage to the artifact it is attached to. Trust is
+// not evaluated. It returns the nonconformances found, or nil if there is
+// none.
+func validateEnvelope(mediaType string, sigBlob []byte, artifact ocispec.Descriptor) []string {
+	var headers *envelopeHeaders
+	var err error
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		headers, err = parseJWSHeaders(sigBlob)
+	case cose.MediaTypeEnvelope:
+		headers, err = parseCOSEHeaders(sigBlob)
+	default:
+		return []string{fmt.Sprintf("unsupported signature envelope media type %q", mediaType)}
+	}
+	if err != nil {
+		return []string{err.Error()}
+	}
+	issues := checkEnvelopeHeaders(headers)
+
+	payload := &signature.Payload{ContentType: headers.contentType, Content: headers.payload}
+	if err := verifySignedTarget(payload, artifact, nil); err != nil {
+		issues = append(issues, fmt.Sprintf("payload does not target the artifact %s: %v", artifact.Digest, err))
+	}
+
+	sigEnv, err := signature.ParseEnvelope(mediaType, sigBlob)
+	if err == nil {
+		_, err = sigEnv.Verify()
+	}
+	if err != nil {
+		issues = append(issues, fmt.Sprintf("signature envelope failed validation: %v", err))
+	}
+	return issues
+}
+
+// checkEnvelopeHeaders returns the nonconformances of the headers.
+func checkEnvelopeHeaders(headers *envelopeHeaders) []string {
+	var issues []string
+	present := func(name string) bool {
+		return slices.Contains(headers.protected, name)
+	}
+	missing := func(name string) {
+		issues = append(issues, fmt.Sprintf("required protected header %q is missing", name))
+	}
+
+	// required protected headers
+	if !present(headerAlgorithm) {
+		missing(headerAlgorithm)
+	} else if !slices.Contains(supportedEnvelopeAlgorithms, headers.algorithm) {
+		issues = append(issues, fmt.Sprintf("signature algorithm %q is not supported, supported algorithms are %q", headers.algorithm, supportedEnvelopeAlgorithms))
+	}
+	if !present(headerContentType) {
+		missing(headerContentType)
+	} else if headers.contentType != envelope.MediaTypePayloadV1 {
+		issues = append(issues, fmt.Sprintf("payload content type %q is not %q", headers.contentType, envelope.MediaTypePayloadV1))
+	}
+	var requiredCritical []string
+	if !present(headerSigningScheme) {
+		missing(headerSigningScheme)
+	} else {
+		requiredCritical = append(requiredCritical, headerSigningScheme)
+		switch signature.SigningScheme(headers.signingScheme) {
+		case signature.SigningSchemeX509:
+			if !present(headerSigningTime) {
+				missing(headerSigningTime)
+			}
+		case signature.SigningSchemeX509SigningAuthority:
+			if !present(headerAuthenticSigningTime) {
+				missing(headerAuthenticSigningTime)
+			} else {
+				requiredCritical = append(requiredCritical, headerAuthenticSigningTime)
+			}
+		default:
+			issues = append(issues, fmt.Sprintf("signing scheme %q is not supported", headers.signingScheme))
+		}
+	}
+	for _, name := range []string{headerExpiry, verifier.HeaderVerificationPlugin, verifier.HeaderVerificationPluginMinVersion} {
+		if present(name) {
+			requiredCritical = append(requiredCritical, name)
+		}
+	}
+
+	// critical header set
+	if !headers.hasCritical {
+		missing(headerCritical)
+	} else {
+		for i, name := range headers.critical {
+			switch {
+			case slices.Contains(headers.critical[:i], name):
+				issues = append(issues, fmt.Sprintf("critical header %q is listed more than once", name))
+			case name == headerAlgorithm || name == headerContentType || name == headerCritical:
+				issues = append(issues, fmt.Sprintf("standard header %q must not be listed as critical header", name))
+			case !present(name):
+				issues = append(issues, fmt.Sprintf("critical header %q is not a protected header", name))
+			}
+		}
+		for _, name := range requiredCritical {
+			if !slices.Contains(headers.critical, name) {
+				issues = append(issues, fmt.Sprintf("protected header %q is not marked critical", name))
+			}
+		}
+	}
+
+	// certificate chain
+	if headers.certChainLength == 0 {
+		issues = append(issues, fmt.Sprintf("unprotected header %q has no certificate", headers.certChainHeader))
+	}
+	return issues
+}
+
+// parseJWSHeaders returns the headers of the JWS envelope sigBlob in JSON
+// serialization.
+func parseJWSHeaders(sigBlob []byte) (*envelopeHeaders, error) {
+	var env struct {
+		Payload   *string `json:"payload"`
+		Protected *string `json:"protected"`
+		Header    struct {
+			CertChain [][]byte `json:"x5c"`
+		} `json:"header"`
+		Signature *string `json:"signature"`
+	}
+	if err := json.Unmarshal(sigBlob, &env); err != nil {
+		return nil, fmt.Errorf("signature envelope is not a JWS in JSON serialization: %v", err)
+	}
+	for _, member := range []struct {
+		name  string
+		value *string
+	}{{"payload", env.Payload}, {"protected", env.Protected}, {"signature", env.Signature}} {
+		if member.value == nil {
+			return nil, fmt.Errorf("signature envelope has no %q member", member.name)
+		}
+	}
+	payload, err := b64.RawURLEncoding.DecodeString(*env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload is not base64url encoded: %v", err)
+	}
+	protectedJSON, err := b64.RawURLEncoding.DecodeString(*env.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("protected headers are not base64url encoded: %v", err)
+	}
+	var protected map[string]json.RawMessage
+	if err := json.Unmarshal(protectedJSON, &protected); err != nil {
+		return nil, fmt.Errorf("protected headers are not a JSON object: %v", err)
+	}
+
+	headers := &envelopeHeaders{
+		certChainHeader: "x5c",
+		certChainLength: len(env.Header.CertChain),
+		payload:         payload,
+	}
+	for name := range protected {
+		headers.protected = append(headers.protected, name)
+	}
+	for name, value := range map[string]*string{headerAlgorithm: &headers.algorithm, headerContentType: &headers.contentType, headerSigningScheme: &headers.signingScheme} {
+		if raw, ok := protected[name]; ok {
+			if err := json.Unmarshal(raw, value); err != nil {
+				return nil, fmt.Errorf("protected header %q is not a string", name)
+			}
+		}
+	}
+	if raw, ok := protected[headerCritical]; ok {
+		headers.hasCritical = true
+		if err := json.Unmarshal(raw, &headers.critical); err != nil {
+			return nil, fmt.Errorf("protected header %q is not an array of strings", headerCritical)
+		}
+	}
+	return headers, nil
+}
+
+// parseCOSEHeaders returns the headers of the COSE_Sign1 envelope sigBlob.
+func parseCOSEHeaders(sigBlob []byte) (*envelopeHeaders, error) {
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(sigBlob); err != nil {
+		return nil, fmt.Errorf("signature envelope is not a COSE_Sign1 message: %v", err)
+	}
+	headers := &envelopeHeaders{
+		certChainHeader: "x5chain",
+		payload:         msg.Payload,
+	}
+	protected := msg.Headers.Protected
+	for label := range protected {
+		headers.protected = append(headers.protected, coseHeaderName(label))
+	}
+	if _, ok := protected[gocose.HeaderLabelAlgorithm]; ok {
+		alg, err := protected.Algorithm()
+		if err != nil {
+			return nil, fmt.Errorf("protected header %q is invalid: %v", headerAlgorithm, err)
+		}
+		headers.algorithm = alg.String()
+	}
+	if value, ok := protected[gocose.HeaderLabelContentType]; ok {
+		contentType, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("protected header %q is not a text string", headerContentType)
+		}
+		headers.contentType = contentType
+	}
+	if value, ok := protected[headerSigningScheme]; ok {
+		signingScheme, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("protected header %q is not a text string", headerSigningScheme)
+		}
+		headers.signingScheme = signingScheme
+	}
+	if value, ok := protected[gocose.HeaderLabelCritical]; ok {
+		headers.hasCritical = true
+		labels, ok := value.([]any)
+		if !ok {
+			return nil, fmt.Errorf("protected header %q is not an array", headerCritical)
+		}
+		for _, label := range labels {
+			headers.critical = append(headers.critical, coseHeaderName(label))
+		}
+	}
+	switch certs := msg.Headers.Unprotected[gocose.HeaderLabelX5Chain].(type) {
+	case []byte:
+		headers.certChainLength = 1
+	case []any:
+		headers.certChainLength = len(certs)
+	}
+	return headers, nil
+}
+
+// coseHeaderName returns the name of the COSE header label, using the names
+// of the equivalent JWS headers for the standard labels.
+func coseHeaderName(label any) string {
+	switch label {
+	case gocose.HeaderLabelAlgorithm:
+		return headerAlgorithm
+	case gocose.HeaderLabelCritical:
+		return headerCritical
+	case gocose.HeaderLabelContentType:
+		return headerContentType
+	}
+	return fmt.Sprint(label)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	b64 "encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+func signTestEnvelope(t *testing.T, mediaType string, desc ocispec.Descriptor) []byte {
+	t.Helper()
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, _, err := localSigner.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	return sig
+}
+
+func TestValidateEnvelope(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	other := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:1111111111111111111111111111111111111111111111111111111111111111",
+		Size:      100,
+	}
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig := signTestEnvelope(t, mediaType, desc)
+			if issues := validateEnvelope(mediaType, sig, desc); len(issues) != 0 {
+				t.Fatalf("validateEnvelope() = %q, want no issue", issues)
+			}
+			issues := validateEnvelope(mediaType, sig, other)
+			if len(issues) != 1 || !strings.Contains(issues[0], "payload does not target the artifact") {
+				t.Fatalf("validateEnvelope() = %q, want issue of payload linkage", issues)
+			}
+		})
+	}
+
+	if issues := validateEnvelope(jws.MediaTypeEnvelope, []byte("not a JWS"), desc); len(issues) != 1 {
+		t.Fatalf("validateEnvelope() = %q, want 1 issue for a malformed envelope", issues)
+	}
+	if issues := validateEnvelope("application/unknown", nil, desc); len(issues) != 1 {
+		t.Fatalf("validateEnvelope() = %q, want 1 issue for an unsupported media type", issues)
+	}
+}
+
+func TestValidateEnvelope_JWSHeaders(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	sig := signTestEnvelope(t, jws.MediaTypeEnvelope, desc)
+	var env map[string]any
+	if err := json.Unmarshal(sig, &env); err != nil {
+		t.Fatal(err)
+	}
+	protectedJSON, err := b64.RawURLEncoding.DecodeString(env["protected"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var protected map[string]any
+	if err := json.Unmarshal(protectedJSON, &protected); err != nil {
+		t.Fatal(err)
+	}
+
+	// drop the signing time and list the content type and an absent header
+	// as critical headers
+	delete(protected, headerSigningTime)
+	protected[headerCritical] = []string{headerSigningScheme, headerContentType, "io.example.absent", headerSigningScheme}
+	protectedJSON, err = json.Marshal(protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env["protected"] = b64.RawURLEncoding.EncodeToString(protectedJSON)
+	tampered, err := json.Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers, err := parseJWSHeaders(tampered)
+	if err != nil {
+		t.Fatalf("parseJWSHeaders() error = %v", err)
+	}
+	issues := strings.Join(checkEnvelopeHeaders(headers), "\n")
+	for _, want := range []string{
+		`required protected header "io.cncf.notary.signingTime" is missing`,
+		`standard header "cty" must not be listed as critical header`,
+		`critical header "io.example.absent" is not a protected header`,
+		`critical header "io.cncf.notary.signingScheme" is listed more than once`,
+	} {
+		if !strings.Contains(issues, want) {
+			t.Fatalf("checkEnvelopeHeaders() = %q, want issue %q", issues, want)
+		}
+	}
+	// the signature no longer matches the protected headers
+	if !strings.Contains(strings.Join(validateEnvelope(jws.MediaTypeEnvelope, tampered, desc), "\n"), "signature envelope failed validation") {
+		t.Fatal("validateEnvelope() expects issue of envelope validation for tampered protected headers")
+	}
+}
+
+func TestValidateEnvelope_COSEHeaders(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	sig := signTestEnvelope(t, cose.MediaTypeEnvelope, desc)
+	headers, err := parseCOSEHeaders(sig)
+	if err != nil {
+		t.Fatalf("parseCOSEHeaders() error = %v", err)
+	}
+	if headers.algorithm != "ES256" || headers.certChainLength != 2 {
+		t.Fatalf("parseCOSEHeaders() = (%s, %d certificates), want (ES256, 2 certificates)", headers.algorithm, headers.certChainLength)
+	}
+
+	// remove the signing scheme from the critical headers and the
+	// certificate chain
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(sig); err != nil {
+		t.Fatal(err)
+	}
+	msg.Headers.Protected[gocose.HeaderLabelCritical] = []any{headerSigningTime}
+	delete(msg.Headers.Unprotected, gocose.HeaderLabelX5Chain)
+	msg.Headers.RawProtected = nil
+	msg.Headers.RawUnprotected = nil
+	tampered, err := msg.MarshalCBOR()
+	if err != nil {
+		t.Fatal(err)
+	}
+	headers, err = parseCOSEHeaders(tampered)
+	if err != nil {
+		t.Fatalf("parseCOSEHeaders() error = %v", err)
+	}
+	issues := strings.Join(checkEnvelopeHeaders(headers), "\n")
+	for _, want := range []string{
+		`protected header "io.cncf.notary.signingScheme" is not marked critical`,
+		`unprotected header "x5chain" has no certificate`,
+	} {
+		if !strings.Contains(issues, want) {
+			t.Fatalf("checkEnvelopeHeaders() = %q, want issue %q", issues, want)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// ignoredExpiryError is the failure of an expiry related check ignored by
+// `notation verify --ignore-expiry`.
+type ignoredExpiryError struct {
+	err error
+}
+
+func (e ignoredExpiryError) Error() string {
+	return fmt.Sprintf("ignored by --ignore-expiry: %v", e.err)
+}
+
+func (e ignoredExpiryError) Unwrap() error {
+	return e.err
+}
+
+// expiryIgnoringVerifier wraps a notation.Verifier and treats the expiry of
+// signatures and of the certificates of their certificate chain as non-fatal,
+// for forensic analysis of historical artifacts. All other checks are run as
+// the trust policy configures them. Ignored failures are kept in the results
+// of the outcome as ignoredExpiryError. It must wrap the notation-go verifier
+// directly, so that other wrappers see the re-evaluated outcome.
+type expiryIgnoringVerifier struct {
+	verifierWrapper
+}
+
+// Verify verifies the signature with the wrapped verifier and re-evaluates
+// the expiry and authenticTimestamp checks ignoring expiry.
+func (v *expiryIgnoringVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if outcome == nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	signerInfo := &outcome.EnvelopeContent.SignerInfo
+
+	// verification stops at the first enforced check that fails, so the
+	// checks following the expiry check must be run if its failure is
+	// ignored
+	var aborted bool
+	if result := findVerificationResult(outcome, trustpolicy.TypeExpiry); result != nil && result.Error != nil {
+		aborted = err != nil && errors.Is(err, result.Error)
+		result.Error = ignoredExpiryError{err: result.Error}
+		if aborted {
+			outcome.VerificationResults = append(outcome.VerificationResults, &notation.ValidationResult{
+				Type:   trustpolicy.TypeAuthenticTimestamp,
+				Action: outcome.VerificationLevel.Enforcement[trustpolicy.TypeAuthenticTimestamp],
+			})
+		}
+	}
+	if result := findVerificationResult(outcome, trustpolicy.TypeAuthenticTimestamp); result != nil && result.Action != trustpolicy.ActionSkip && (aborted || result.Error != nil) {
+		aborted = aborted || (err != nil && result.Error != nil && errors.Is(err, result.Error))
+		now := time.Now()
+		if result.Error = verifyAuthenticTimestampIgnoringExpiry(signerInfo, now); result.Error != nil {
+			if result.Action == trustpolicy.ActionEnforce {
+				outcome.Error = result.Error
+				return outcome, result.Error
+			}
+		} else if expiryErr := verifyAuthenticTimestampWithin(signerInfo, now, 0); expiryErr != nil {
+			result.Error = ignoredExpiryError{err: expiryErr}
+		}
+	}
+	if !aborted {
+		return outcome, err
+	}
+	return resumeAbortedVerification(outcome, desc, opts, errors.New("--ignore-expiry is not supported for signatures associated with a verification plugin that fail verification because of expiry"))
+}
+
+// verifyAuthenticTimestampIgnoringExpiry runs the authenticTimestamp check as
+// notation-go does, except that certificates checked at the current time may
+// have expired. Certificates that are not valid yet still fail the check.
+// Signatures of the notary.x509.signingAuthority scheme are checked at their
+// signing time, as their certificates must have been valid when signing.
+func verifyAuthenticTimestampIgnoringExpiry(signerInfo *signature.SignerInfo, now time.Time) error {
+	if signerInfo.SignedAttributes.SigningScheme != signature.SigningSchemeX509 {
+		return verifyAuthenticTimestampWithin(signerInfo, now, 0)
+	}
+	if len(signerInfo.UnsignedAttributes.TimestampSignature) != 0 {
+		return nil
+	}
+	for _, cert := range signerInfo.CertificateChain {
+		if now.Before(cert.NotBefore) {
+			return fmt.Errorf("certificate %q is not valid yet, it will be valid from %q", cert.Subject, cert.NotBefore.Format(time.RFC1123Z))
+		}
+	}
+	return nil
+}
+
+// ignoredExpiryErrors returns the ignored expiry failures in the results of
+// outcome.
+func ignoredExpiryErrors(outcome *notation.VerificationOutcome) []error {
+	var errs []error
+	for _, result := range outcome.VerificationResults {
+		var ignored ignoredExpiryError
+		if result.Error != nil && errors.As(result.Error, &ignored) {
+			errs = append(errs, result.Error)
+		}
+	}
+	return errs
+}
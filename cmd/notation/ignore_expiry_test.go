@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestExpiryIgnoringVerifier(t *testing.T) {
+	ctx := context.Background()
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("artifact"), Size: 8}
+	now := time.Now()
+	notYetValid := errors.New("certificate is not valid yet")
+	expired := errors.New("certificate is not valid anymore")
+	signatureExpired := errors.New("digital signature has expired")
+	expiredOutcome := func(plugin bool) *notation.VerificationOutcome {
+		outcome := outcomeWithCertificateValidity(t, desc, now.Add(-2*time.Hour), now.Add(-time.Hour), nil, plugin)
+		outcome.EnvelopeContent.SignerInfo.SignedAttributes.Expiry = now.Add(-time.Hour)
+		outcome.VerificationResults = []*notation.ValidationResult{
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionEnforce, Error: signatureExpired},
+		}
+		return outcome
+	}
+
+	tests := []struct {
+		name        string
+		outcome     *notation.VerificationOutcome
+		err         error
+		desc        ocispec.Descriptor
+		wantErr     bool
+		wantIgnored int
+	}{
+		{
+			name:        "expired signature and certificate are ignored",
+			outcome:     expiredOutcome(false),
+			err:         signatureExpired,
+			desc:        desc,
+			wantIgnored: 2,
+		},
+		{
+			name:        "expired certificate is ignored",
+			outcome:     outcomeWithCertificateValidity(t, desc, now.Add(-2*time.Hour), now.Add(-time.Hour), expired, false),
+			err:         expired,
+			desc:        desc,
+			wantIgnored: 1,
+		},
+		{
+			name:    "certificate not valid yet still fails",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(time.Hour), now.Add(2*time.Hour), notYetValid, false),
+			err:     notYetValid,
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "resumed verification checks the target artifact",
+			outcome: expiredOutcome(false),
+			err:     signatureExpired,
+			desc:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("other"), Size: 5},
+			wantErr: true,
+		},
+		{
+			name:    "verification aborted for signature with verification plugin",
+			outcome: expiredOutcome(true),
+			err:     signatureExpired,
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "other failures are kept",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(-time.Hour), now.Add(time.Hour), nil, false),
+			err:     errors.New("content descriptor mismatch"),
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "skipped verification",
+			outcome: &notation.VerificationOutcome{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// notation-go sets the returned error as the outcome error
+			tt.outcome.Error = tt.err
+			v := &expiryIgnoringVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: tt.outcome, err: tt.err}},
+			}
+			outcome, err := v.Verify(ctx, tt.desc, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if outcome.Error != err {
+				t.Fatalf("outcome error = %v, want %v", outcome.Error, err)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := len(ignoredExpiryErrors(outcome)); got != tt.wantIgnored {
+				t.Fatalf("ignoredExpiryErrors() = %d errors, want %d", got, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+func TestVerifyAuthenticTimestampIgnoringExpiry(t *testing.T) {
+	now := time.Now()
+	signerInfo := func(scheme signature.SigningScheme, notBefore, notAfter time.Time) *signature.SignerInfo {
+		info := &signature.SignerInfo{
+			CertificateChain: []*x509.Certificate{{NotBefore: notBefore, NotAfter: notAfter}},
+		}
+		info.SignedAttributes.SigningScheme = scheme
+		info.SignedAttributes.SigningTime = now.Add(-time.Hour)
+		return info
+	}
+
+	tests := []struct {
+		name       string
+		signerInfo *signature.SignerInfo
+		wantErr    bool
+	}{
+		{
+			name:       "expired certificate",
+			signerInfo: signerInfo(signature.SigningSchemeX509, now.Add(-2*time.Hour), now.Add(-time.Minute)),
+		},
+		{
+			name:       "certificate not valid yet",
+			signerInfo: signerInfo(signature.SigningSchemeX509, now.Add(time.Hour), now.Add(2*time.Hour)),
+			wantErr:    true,
+		},
+		{
+			name:       "signing authority certificate valid at signing time",
+			signerInfo: signerInfo(signature.SigningSchemeX509SigningAuthority, now.Add(-2*time.Hour), now.Add(-time.Minute)),
+		},
+		{
+			name:       "signing authority certificate expired at signing time",
+			signerInfo: signerInfo(signature.SigningSchemeX509SigningAuthority, now.Add(-3*time.Hour), now.Add(-2*time.Hour)),
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := verifyAuthenticTimestampIgnoringExpiry(tt.signerInfo, now); (err != nil) != tt.wantErr {
+				t.Fatalf("verifyAuthenticTimestampIgnoringExpiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
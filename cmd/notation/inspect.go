@@ -1,48 +1,76 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha1"
+	"crypto/x509"
 	b64 "encoding/base64"
 	"encoding/hex"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
 	"github.com/notaryproject/notation-go/plugin/proto"
 	"github.com/notaryproject/notation-go/registry"
 	"github.com/notaryproject/notation/internal/cmd"
 	"github.com/notaryproject/notation/internal/envelope"
 	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/osutil"
 	"github.com/notaryproject/notation/internal/tree"
+	"github.com/opencontainers/go-digest"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/content"
 )
 
 type inspectOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
-	reference    string
-	outputFormat string
+	reference         string
+	outputFormat      string
+	certChainDir      string
+	signatureDigest   string
+	mediaType         string
+	identity          string
+	countOnly         bool
+	diffReference     string
+	validateEnvelope  bool
+	groupBy           string
+	followSubject     bool
+	jsonSchemaVersion string
 }
 
 type inspectOutput struct {
-	MediaType  string `json:"mediaType"`
-	Signatures []signatureOutput
+	SchemaVersion string `json:"schemaVersion"`
+	MediaType     string `json:"mediaType"`
+	Signatures    []signatureOutput
 }
 
 type signatureOutput struct {
-	MediaType             string              `json:"mediaType"`
-	Digest                string              `json:"digest"`
-	SignatureAlgorithm    string              `json:"signatureAlgorithm"`
-	SignedAttributes      map[string]string   `json:"signedAttributes"`
-	UserDefinedAttributes map[string]string   `json:"userDefinedAttributes"`
-	UnsignedAttributes    map[string]string   `json:"unsignedAttributes"`
-	Certificates          []certificateOutput `json:"certificates"`
-	SignedArtifact        ocispec.Descriptor  `json:"signedArtifact"`
+	MediaType             string                    `json:"mediaType"`
+	Digest                string                    `json:"digest"`
+	SignatureAlgorithm    string                    `json:"signatureAlgorithm"`
+	SignedAttributes      map[string]string         `json:"signedAttributes"`
+	UserDefinedAttributes map[string]string         `json:"userDefinedAttributes"`
+	UnsignedAttributes    map[string]string         `json:"unsignedAttributes"`
+	Certificates          []certificateOutput       `json:"certificates"`
+	SignedArtifact        ocispec.Descriptor        `json:"signedArtifact"`
+	EnvelopeValidation    *envelopeValidationOutput `json:"envelopeValidation,omitempty"`
+	Subject               *subjectOutput            `json:"subject,omitempty"`
+}
+
+type envelopeValidationOutput struct {
+	Conformant bool     `json:"conformant"`
+	Issues     []string `json:"issues"`
 }
 
 type certificateOutput struct {
@@ -69,6 +97,27 @@ Example - Inspect signatures on an OCI artifact identified by a tag  (Notation w
 
 Example - Inspect signatures on an OCI artifact identified by a digest and output as json:
   notation inspect --output json <registry>/<repository>@<digest>
+
+Example - Inspect signatures on an OCI artifact and export the certificate chain of each signature as a PEM file into a directory:
+  notation inspect --cert-chain-pem ./certs <registry>/<repository>@<digest>
+
+Example - Inspect a single signature on an OCI artifact and export its certificate chain:
+  notation inspect --signature-digest <signature_digest> --cert-chain-pem ./certs <registry>/<repository>@<digest>
+
+Example - Print the number of COSE signatures on an OCI artifact signed by a given identity:
+  notation inspect --count-only --media-type application/cose --identity "CN=wabbit-networks.io,O=Notary,L=Seattle,ST=WA,C=US" <registry>/<repository>@<digest>
+
+Example - Check the signature envelopes on an OCI artifact for conformance to the Notary Project signature specification:
+  notation inspect --validate-envelope <registry>/<repository>@<digest>
+
+Example - Compare the signers and user metadata of the signatures on two OCI artifacts and output as json:
+  notation inspect --diff <registry>/<repository>@<other_digest> --output json <registry>/<repository>@<digest>
+
+Example - Inspect signatures on an OCI artifact grouped by signing identity, with the number of signatures per identity:
+  notation inspect --group-by identity <registry>/<repository>@<digest>
+
+Example - Inspect signatures on an OCI artifact and check that the subject each signature attests to exists in the registry:
+  notation inspect --follow-subject <registry>/<repository>@<digest>
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
@@ -78,13 +127,42 @@ Example - Inspect signatures on an OCI artifact identified by a digest and outpu
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateRegistryUserAgent(opts.UserAgent); err != nil {
+				return err
+			}
+			if err := validateJSONSchemaVersion(cmd.Flags(), opts.jsonSchemaVersion, isJSONOutput(opts.outputFormat)); err != nil {
+				return err
+			}
 			return runInspect(cmd, opts)
 		},
 	}
 
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	setFlagRegistryUserAgent(command.Flags(), &opts.UserAgent)
 	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	setFlagJSONSchemaVersion(command.Flags(), &opts.jsonSchemaVersion)
+	command.Flags().StringVar(&opts.certChainDir, "cert-chain-pem", "", "directory to export the certificate chain of each signature to, as PEM files named by signature digest")
+	command.Flags().StringVar(&opts.signatureDigest, "signature-digest", "", "only inspect the signature with the given signature manifest digest")
+	command.Flags().StringVar(&opts.mediaType, "media-type", "", "only inspect signatures with the given signature envelope media type, options: \"application/jose+json\", \"application/cose\"")
+	command.Flags().StringVar(&opts.identity, "identity", "", "only inspect signatures whose signing certificate has the given subject, in the format shown as \"issued to\", for example \"CN=wabbit-networks.io,O=Notary,L=Seattle,ST=WA,C=US\"")
+	command.Flags().BoolVar(&opts.countOnly, "count-only", false, "only print the number of signatures inspected")
+	command.MarkFlagsMutuallyExclusive("count-only", "output")
+	command.MarkFlagsMutuallyExclusive("count-only", "json-schema-version")
+	command.MarkFlagsMutuallyExclusive("count-only", "cert-chain-pem")
+	command.Flags().StringVar(&opts.diffReference, "diff", "", "compare the signers and user metadata of the signatures with the signatures of the given other artifact, and print the differences")
+	for _, name := range []string{"count-only", "cert-chain-pem", "signature-digest"} {
+		command.MarkFlagsMutuallyExclusive("diff", name)
+	}
+	command.Flags().BoolVar(&opts.validateEnvelope, "validate-envelope", false, "check each signature envelope for conformance to the Notary Project signature specification, independent of trust, and report any nonconformance")
+	command.MarkFlagsMutuallyExclusive("validate-envelope", "count-only")
+	command.MarkFlagsMutuallyExclusive("validate-envelope", "diff")
+	command.Flags().StringVar(&opts.groupBy, "group-by", "", "group the displayed signatures by signing identity or by signature envelope format, with the number of signatures per group, options: \""+groupByIdentity+"\", \""+groupByFormat+"\"")
+	command.MarkFlagsMutuallyExclusive("group-by", "count-only")
+	command.MarkFlagsMutuallyExclusive("group-by", "diff")
+	command.Flags().BoolVar(&opts.followSubject, "follow-subject", false, "resolve the subject artifact each signature attests to in the registry, display its media type and size, and report if it is missing or differs from the signed artifact")
+	command.MarkFlagsMutuallyExclusive("follow-subject", "count-only")
+	command.MarkFlagsMutuallyExclusive("follow-subject", "diff")
 	return command
 }
 
@@ -95,17 +173,81 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 	if opts.outputFormat != cmd.OutputJSON && opts.outputFormat != cmd.OutputPlaintext {
 		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
 	}
+	if opts.signatureDigest != "" {
+		if _, err := digest.Parse(opts.signatureDigest); err != nil {
+			return fmt.Errorf("invalid signature digest %q: %w", opts.signatureDigest, err)
+		}
+	}
+	if opts.mediaType != "" {
+		if opts.mediaType != jws.MediaTypeEnvelope && opts.mediaType != cose.MediaTypeEnvelope {
+			return fmt.Errorf("unsupported signature envelope media type %q, options: %q, %q", opts.mediaType, jws.MediaTypeEnvelope, cose.MediaTypeEnvelope)
+		}
+	}
+	if err := validateGroupBy(opts.groupBy); err != nil {
+		return err
+	}
 
-	// initialize
-	reference := opts.reference
-	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	output, artifact, skippedSignatures, err := inspectSignatures(ctx, opts, opts.reference)
 	if err != nil {
 		return err
 	}
+	if opts.diffReference != "" {
+		otherOutput, otherArtifact, otherSkipped, err := inspectSignatures(ctx, opts, opts.diffReference)
+		if err != nil {
+			return err
+		}
+		if err := printDiffOutput(opts.outputFormat, diffSignatures(artifact, output, otherArtifact, otherOutput)); err != nil {
+			return err
+		}
+		if skippedSignatures || otherSkipped {
+			return errors.New("at least one signature was skipped and not compared")
+		}
+		return nil
+	}
+
+	if opts.countOnly {
+		fmt.Println(len(output.Signatures))
+	} else if opts.groupBy != "" {
+		if err := printGroupOutput(opts.outputFormat, artifact, groupSignatures(output, opts.groupBy)); err != nil {
+			return err
+		}
+	} else if err := printOutput(opts.outputFormat, artifact, output); err != nil {
+		return err
+	}
+
+	if skippedSignatures {
+		return errors.New("at least one signature was skipped and not displayed")
+	}
+	if opts.validateEnvelope {
+		for _, sig := range output.Signatures {
+			if !sig.EnvelopeValidation.Conformant {
+				return errors.New("at least one signature envelope is not conformant")
+			}
+		}
+	}
+	if opts.followSubject {
+		for _, sig := range output.Signatures {
+			if len(sig.Subject.Issues) > 0 {
+				return errors.New("at least one signature subject is missing or differs from the signed artifact")
+			}
+		}
+	}
+
+	return nil
+}
+
+// inspectSignatures returns the signatures of the artifact identified by
+// reference that match the filters of opts, and the digest reference of the
+// artifact. skipped is set if any signature cannot be inspected.
+func inspectSignatures(ctx context.Context, opts *inspectOpts, reference string) (output inspectOutput, artifact string, skipped bool, err error) {
+	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return inspectOutput{}, "", false, err
+	}
 
 	manifestDesc, ref, err := getManifestDescriptor(ctx, &opts.SecureFlagOpts, reference, sigRepo)
 	if err != nil {
-		return err
+		return inspectOutput{}, "", false, err
 	}
 
 	// reference is a digest reference
@@ -114,42 +256,65 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 		ref.Reference = manifestDesc.Digest.String()
 	}
 
-	output := inspectOutput{MediaType: manifestDesc.MediaType, Signatures: []signatureOutput{}}
-	skippedSignatures := false
+	var subjectResolver content.Resolver
+	if opts.followSubject {
+		remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+		if err != nil {
+			return inspectOutput{}, "", false, err
+		}
+		subjectResolver = remoteRepo
+	}
+
+	output = inspectOutput{SchemaVersion: opts.jsonSchemaVersion, MediaType: manifestDesc.MediaType, Signatures: []signatureOutput{}}
+	signatureFound := false
 	err = sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
 		for _, sigManifestDesc := range signatureManifests {
+			if opts.signatureDigest != "" && sigManifestDesc.Digest.String() != opts.signatureDigest {
+				continue
+			}
+			signatureFound = true
 			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Warning: unable to fetch signature %s due to error: %v\n", sigManifestDesc.Digest.String(), err)
-				skippedSignatures = true
+				skipped = true
 				continue
 			}
 
+			var envelopeIssues []string
+			if opts.validateEnvelope {
+				envelopeIssues = validateEnvelope(sigDesc.MediaType, sigBlob, manifestDesc)
+			}
+
 			sigEnvelope, err := signature.ParseEnvelope(sigDesc.MediaType, sigBlob)
 			if err != nil {
+				logNonconformantEnvelope(sigManifestDesc, envelopeIssues)
 				logSkippedSignature(sigManifestDesc, err)
-				skippedSignatures = true
+				skipped = true
 				continue
 			}
 
 			envelopeContent, err := sigEnvelope.Content()
 			if err != nil {
+				logNonconformantEnvelope(sigManifestDesc, envelopeIssues)
 				logSkippedSignature(sigManifestDesc, err)
-				skippedSignatures = true
+				skipped = true
+				continue
+			}
+			if !matchSignatureFilters(opts, sigDesc.MediaType, envelopeContent) {
 				continue
 			}
 
 			signedArtifactDesc, err := envelope.DescriptorFromSignaturePayload(&envelopeContent.Payload)
 			if err != nil {
 				logSkippedSignature(sigManifestDesc, err)
-				skippedSignatures = true
+				skipped = true
 				continue
 			}
 
 			signatureAlgorithm, err := proto.EncodeSigningAlgorithm(envelopeContent.SignerInfo.SignatureAlgorithm)
 			if err != nil {
 				logSkippedSignature(sigManifestDesc, err)
-				skippedSignatures = true
+				skipped = true
 				continue
 			}
 
@@ -164,35 +329,105 @@ func runInspect(command *cobra.Command, opts *inspectOpts) error {
 				SignedArtifact:        *signedArtifactDesc,
 			}
 
+			if opts.validateEnvelope {
+				sig.EnvelopeValidation = &envelopeValidationOutput{
+					Conformant: len(envelopeIssues) == 0,
+					Issues:     envelopeIssues,
+				}
+				if sig.EnvelopeValidation.Issues == nil {
+					sig.EnvelopeValidation.Issues = []string{}
+				}
+			}
+
+			if opts.followSubject {
+				sig.Subject, err = followSubject(ctx, subjectResolver, manifestDesc.Digest, *signedArtifactDesc)
+				if err != nil {
+					return err
+				}
+			}
+
 			// clearing annotations from the SignedArtifact field since they're already
 			// displayed as UserDefinedAttributes
 			sig.SignedArtifact.Annotations = nil
 
+			if opts.certChainDir != "" {
+				path, err := writeCertChainPEM(opts.certChainDir, sigManifestDesc.Digest, envelopeContent.SignerInfo.CertificateChain)
+				if err != nil {
+					return fmt.Errorf("failed to export certificate chain of signature %s: %w", sigManifestDesc.Digest, err)
+				}
+				fmt.Fprintf(os.Stderr, "Certificate chain of signature %s exported to %s\n", sigManifestDesc.Digest, path)
+			}
+
 			output.Signatures = append(output.Signatures, sig)
 		}
 		return nil
 	})
 
 	if err != nil {
-		return err
+		return inspectOutput{}, "", false, err
+	}
+	if opts.signatureDigest != "" && !signatureFound {
+		return inspectOutput{}, "", false, fmt.Errorf("signature %s is not associated with %s", opts.signatureDigest, ref.String())
 	}
+	return output, ref.String(), skipped, nil
+}
 
-	err = printOutput(opts.outputFormat, ref.String(), output)
+// writeCertChainPEM writes the certificate chain to a PEM file in dir named
+// after the signature digest, and returns the path of the file. The
+// certificates are written in the order they are embedded in the signature.
+func writeCertChainPEM(dir string, sigDigest digest.Digest, certs []*x509.Certificate) (string, error) {
+	data, err := encodeCertChainPEM(certs)
 	if err != nil {
-		return err
+		return "", err
+	}
+	path := filepath.Join(dir, sigDigest.Algorithm().String()+"-"+sigDigest.Encoded()+".pem")
+	if err := osutil.WriteFile(path, data); err != nil {
+		return "", err
 	}
+	return path, nil
+}
 
-	if skippedSignatures {
-		return errors.New("at least one signature was skipped and not displayed")
+// encodeCertChainPEM returns the PEM encoding of the certificate chain, in
+// the order of certs.
+func encodeCertChainPEM(certs []*x509.Certificate) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		if err := pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}); err != nil {
+			return nil, err
+		}
 	}
+	return buf.Bytes(), nil
+}
 
-	return nil
+// matchSignatureFilters reports whether the signature with the envelope
+// media type mediaType and the content envelopeContent matches the
+// --media-type and --identity filters of opts.
+func matchSignatureFilters(opts *inspectOpts, mediaType string, envelopeContent *signature.EnvelopeContent) bool {
+	if opts.mediaType != "" && mediaType != opts.mediaType {
+		return false
+	}
+	if opts.identity != "" {
+		certs := envelopeContent.SignerInfo.CertificateChain
+		if len(certs) == 0 || certs[0].Subject.String() != opts.identity {
+			return false
+		}
+	}
+	return true
 }
 
 func logSkippedSignature(sigDesc ocispec.Descriptor, err error) {
 	fmt.Fprintf(os.Stderr, "Warning: Skipping signature %s because of error: %v\n", sigDesc.Digest.String(), err)
 }
 
+// logNonconformantEnvelope prints the nonconformances found by
+// --validate-envelope in the envelope of a signature that cannot be
+// displayed.
+func logNonconformantEnvelope(sigDesc ocispec.Descriptor, issues []string) {
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "Warning: signature envelope %s is not conformant: %s\n", sigDesc.Digest.String(), issue)
+	}
+}
+
 func getSignedAttributes(outputFormat string, envContent *signature.EnvelopeContent) map[string]string {
 	signedAttributes := map[string]string{
 		"signingScheme": string(envContent.SignerInfo.SignedAttributes.SigningScheme),
@@ -260,35 +495,63 @@ func printOutput(outputFormat string, ref string, output inspectOutput) error {
 	cncfSigNode := root.Add(registry.ArtifactTypeNotation)
 
 	for _, signature := range output.Signatures {
-		sigNode := cncfSigNode.Add(signature.Digest)
-		sigNode.AddPair("media type", signature.MediaType)
-		sigNode.AddPair("signature algorithm", signature.SignatureAlgorithm)
+		addSignatureToTree(cncfSigNode, signature)
+	}
 
-		signedAttributesNode := sigNode.Add("signed attributes")
-		addMapToTree(signedAttributesNode, signature.SignedAttributes)
+	root.Print()
+	return nil
+}
 
-		userDefinedAttributesNode := sigNode.Add("user defined attributes")
-		addMapToTree(userDefinedAttributesNode, signature.UserDefinedAttributes)
+// addSignatureToTree adds the details of signature to node.
+func addSignatureToTree(node *tree.Node, signature signatureOutput) {
+	sigNode := node.Add(signature.Digest)
+	sigNode.AddPair("media type", signature.MediaType)
+	sigNode.AddPair("signature algorithm", signature.SignatureAlgorithm)
 
-		unsignedAttributesNode := sigNode.Add("unsigned attributes")
-		addMapToTree(unsignedAttributesNode, signature.UnsignedAttributes)
+	signedAttributesNode := sigNode.Add("signed attributes")
+	addMapToTree(signedAttributesNode, signature.SignedAttributes)
 
-		certListNode := sigNode.Add("certificates")
-		for _, cert := range signature.Certificates {
-			certNode := certListNode.AddPair("SHA1 fingerprint", cert.SHA1Fingerprint)
-			certNode.AddPair("issued to", cert.IssuedTo)
-			certNode.AddPair("issued by", cert.IssuedBy)
-			certNode.AddPair("expiry", cert.Expiry)
-		}
+	userDefinedAttributesNode := sigNode.Add("user defined attributes")
+	addMapToTree(userDefinedAttributesNode, signature.UserDefinedAttributes)
+
+	unsignedAttributesNode := sigNode.Add("unsigned attributes")
+	addMapToTree(unsignedAttributesNode, signature.UnsignedAttributes)
 
-		artifactNode := sigNode.Add("signed artifact")
-		artifactNode.AddPair("media type", signature.SignedArtifact.MediaType)
-		artifactNode.AddPair("digest", signature.SignedArtifact.Digest.String())
-		artifactNode.AddPair("size", strconv.FormatInt(signature.SignedArtifact.Size, 10))
+	certListNode := sigNode.Add("certificates")
+	for _, cert := range signature.Certificates {
+		certNode := certListNode.AddPair("SHA1 fingerprint", cert.SHA1Fingerprint)
+		certNode.AddPair("issued to", cert.IssuedTo)
+		certNode.AddPair("issued by", cert.IssuedBy)
+		certNode.AddPair("expiry", cert.Expiry)
 	}
 
-	root.Print()
-	return nil
+	artifactNode := sigNode.Add("signed artifact")
+	artifactNode.AddPair("media type", signature.SignedArtifact.MediaType)
+	artifactNode.AddPair("digest", signature.SignedArtifact.Digest.String())
+	artifactNode.AddPair("size", strconv.FormatInt(signature.SignedArtifact.Size, 10))
+
+	if subject := signature.Subject; subject != nil {
+		subjectNode := sigNode.Add("subject")
+		subjectNode.AddPair("exists", strconv.FormatBool(subject.Exists))
+		if subject.Exists {
+			subjectNode.AddPair("media type", subject.MediaType)
+			subjectNode.AddPair("size", strconv.FormatInt(subject.Size, 10))
+		}
+		for _, issue := range subject.Issues {
+			subjectNode.AddPair("issue", issue)
+		}
+	}
+
+	if validation := signature.EnvelopeValidation; validation != nil {
+		if validation.Conformant {
+			sigNode.AddPair("envelope validation", "conformant")
+		} else {
+			validationNode := sigNode.AddPair("envelope validation", "not conformant")
+			for _, issue := range validation.Issues {
+				validationNode.Add(issue)
+			}
+		}
+	}
 }
 
 func addMapToTree(node *tree.Node, m map[string]string) {
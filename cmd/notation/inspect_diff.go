@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/tree"
+)
+
+// inspectDiffOutput is the difference between the signatures of two
+// artifacts, printed by `notation inspect --diff`.
+type inspectDiffOutput struct {
+	SchemaVersion        string               `json:"schemaVersion"`
+	Reference            string               `json:"reference"`
+	OtherReference       string               `json:"otherReference"`
+	Identical            bool                 `json:"identical"`
+	OnlyInReference      []signerOutput       `json:"onlyInReference"`
+	OnlyInOtherReference []signerOutput       `json:"onlyInOtherReference"`
+	MetadataDifferences  []metadataDiffOutput `json:"metadataDifferences"`
+	Common               []signerOutput       `json:"common"`
+}
+
+// signerOutput is a signing identity, identified by the signing certificate.
+type signerOutput struct {
+	IssuedTo        string `json:"issuedTo"`
+	SHA1Fingerprint string `json:"SHA1Fingerprint"`
+}
+
+// metadataDiffOutput is the difference of the user metadata signed by a
+// signer of both artifacts. User metadata are in the format {key}={value}.
+type metadataDiffOutput struct {
+	Signer               signerOutput `json:"signer"`
+	OnlyInReference      []string     `json:"onlyInReference"`
+	OnlyInOtherReference []string     `json:"onlyInOtherReference"`
+}
+
+// signerSet is the set of signers of an artifact, keyed by the SHA-1
+// fingerprint of the signing certificate, with the set of user metadata
+// signed by each signer across its signatures.
+type signerSet map[string]*signerMetadata
+
+type signerMetadata struct {
+	signer   signerOutput
+	metadata map[string]bool
+}
+
+// newSignerSet returns the signers of the signatures of output.
+func newSignerSet(output inspectOutput) signerSet {
+	set := signerSet{}
+	for _, sig := range output.Signatures {
+		if len(sig.Certificates) == 0 {
+			continue
+		}
+		cert := sig.Certificates[0]
+		entry, ok := set[cert.SHA1Fingerprint]
+		if !ok {
+			entry = &signerMetadata{
+				signer:   signerOutput{IssuedTo: cert.IssuedTo, SHA1Fingerprint: cert.SHA1Fingerprint},
+				metadata: map[string]bool{},
+			}
+			set[cert.SHA1Fingerprint] = entry
+		}
+		for key, value := range sig.UserDefinedAttributes {
+			entry.metadata[key+"="+value] = true
+		}
+	}
+	return set
+}
+
+// sortedSigners returns the fingerprints of the signers in ascending order.
+func (s signerSet) sortedSigners() []string {
+	fingerprints := make([]string, 0, len(s))
+	for fingerprint := range s {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
+
+// diffSignatures compares the signers and user metadata of the signatures
+// output of the artifact ref with the signatures otherOutput of the artifact
+// otherRef.
+func diffSignatures(ref string, output inspectOutput, otherRef string, otherOutput inspectOutput) inspectDiffOutput {
+	signers, otherSigners := newSignerSet(output), newSignerSet(otherOutput)
+	diff := inspectDiffOutput{
+		SchemaVersion:        output.SchemaVersion,
+		Reference:            ref,
+		OtherReference:       otherRef,
+		OnlyInReference:      []signerOutput{},
+		OnlyInOtherReference: []signerOutput{},
+		MetadataDifferences:  []metadataDiffOutput{},
+		Common:               []signerOutput{},
+	}
+	for _, fingerprint := range signers.sortedSigners() {
+		entry := signers[fingerprint]
+		other, ok := otherSigners[fingerprint]
+		if !ok {
+			diff.OnlyInReference = append(diff.OnlyInReference, entry.signer)
+			continue
+		}
+		diff.Common = append(diff.Common, entry.signer)
+		onlyInRef, onlyInOther := diffMetadata(entry.metadata, other.metadata), diffMetadata(other.metadata, entry.metadata)
+		if len(onlyInRef) > 0 || len(onlyInOther) > 0 {
+			diff.MetadataDifferences = append(diff.MetadataDifferences, metadataDiffOutput{
+				Signer:               entry.signer,
+				OnlyInReference:      onlyInRef,
+				OnlyInOtherReference: onlyInOther,
+			})
+		}
+	}
+	for _, fingerprint := range otherSigners.sortedSigners() {
+		if _, ok := signers[fingerprint]; !ok {
+			diff.OnlyInOtherReference = append(diff.OnlyInOtherReference, otherSigners[fingerprint].signer)
+		}
+	}
+	diff.Identical = len(diff.OnlyInReference) == 0 && len(diff.OnlyInOtherReference) == 0 && len(diff.MetadataDifferences) == 0
+	return diff
+}
+
+// diffMetadata returns the user metadata of metadata not in other, in
+// ascending order.
+func diffMetadata(metadata, other map[string]bool) []string {
+	diff := []string{}
+	for entry := range metadata {
+		if !other[entry] {
+			diff = append(diff, entry)
+		}
+	}
+	sort.Strings(diff)
+	return diff
+}
+
+func printDiffOutput(outputFormat string, diff inspectDiffOutput) error {
+	if outputFormat == cmd.OutputJSON {
+		return ioutil.PrintObjectAsJSON(diff)
+	}
+
+	fmt.Printf("Comparing signatures of %s with %s\n", diff.Reference, diff.OtherReference)
+	if diff.Identical {
+		fmt.Println("The signatures have the same signers and user metadata")
+		return nil
+	}
+	root := tree.New(diff.Reference + " <> " + diff.OtherReference)
+	addSignersToTree(root.Add("signers only on "+diff.Reference), diff.OnlyInReference)
+	addSignersToTree(root.Add("signers only on "+diff.OtherReference), diff.OnlyInOtherReference)
+	metadataNode := root.Add("signers with different user metadata")
+	if len(diff.MetadataDifferences) == 0 {
+		metadataNode.Add("(empty)")
+	}
+	for _, metadataDiff := range diff.MetadataDifferences {
+		signerNode := metadataNode.Add(metadataDiff.Signer.IssuedTo)
+		signerNode.AddPair("SHA1 fingerprint", metadataDiff.Signer.SHA1Fingerprint)
+		addMetadataToTree(signerNode.Add("only on "+diff.Reference), metadataDiff.OnlyInReference)
+		addMetadataToTree(signerNode.Add("only on "+diff.OtherReference), metadataDiff.OnlyInOtherReference)
+	}
+	root.Print()
+	return nil
+}
+
+func addSignersToTree(node *tree.Node, signers []signerOutput) {
+	if len(signers) == 0 {
+		node.Add("(empty)")
+		return
+	}
+	for _, signer := range signers {
+		node.Add(signer.IssuedTo).AddPair("SHA1 fingerprint", signer.SHA1Fingerprint)
+	}
+}
+
+func addMetadataToTree(node *tree.Node, metadata []string) {
+	if len(metadata) == 0 {
+		node.Add("(empty)")
+		return
+	}
+	for _, entry := range metadata {
+		node.Add(entry)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSignatures(t *testing.T) {
+	alice := certificateOutput{SHA1Fingerprint: "a1", IssuedTo: "CN=alice"}
+	bob := certificateOutput{SHA1Fingerprint: "b2", IssuedTo: "CN=bob"}
+	carol := certificateOutput{SHA1Fingerprint: "c3", IssuedTo: "CN=carol"}
+	output := inspectOutput{Signatures: []signatureOutput{
+		{Certificates: []certificateOutput{alice}, UserDefinedAttributes: map[string]string{"buildId": "42"}},
+		{Certificates: []certificateOutput{alice}, UserDefinedAttributes: map[string]string{"env": "staging"}},
+		{Certificates: []certificateOutput{bob}},
+	}}
+	otherOutput := inspectOutput{Signatures: []signatureOutput{
+		{Certificates: []certificateOutput{alice}, UserDefinedAttributes: map[string]string{"buildId": "42", "env": "production"}},
+		{Certificates: []certificateOutput{carol}},
+	}}
+
+	diff := diffSignatures("ref", output, "other-ref", otherOutput)
+	want := inspectDiffOutput{
+		Reference:            "ref",
+		OtherReference:       "other-ref",
+		OnlyInReference:      []signerOutput{{IssuedTo: "CN=bob", SHA1Fingerprint: "b2"}},
+		OnlyInOtherReference: []signerOutput{{IssuedTo: "CN=carol", SHA1Fingerprint: "c3"}},
+		MetadataDifferences: []metadataDiffOutput{{
+			Signer:               signerOutput{IssuedTo: "CN=alice", SHA1Fingerprint: "a1"},
+			OnlyInReference:      []string{"env=staging"},
+			OnlyInOtherReference: []string{"env=production"},
+		}},
+		Common: []signerOutput{{IssuedTo: "CN=alice", SHA1Fingerprint: "a1"}},
+	}
+	if !reflect.DeepEqual(diff, want) {
+		t.Fatalf("diffSignatures() = %+v, want %+v", diff, want)
+	}
+
+	diff = diffSignatures("ref", output, "other-ref", output)
+	if !diff.Identical || len(diff.Common) != 2 {
+		t.Fatalf("diffSignatures() of the same signatures = %+v, want identical with 2 common signers", diff)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/tree"
+)
+
+// options of `notation inspect --group-by`.
+const (
+	groupByIdentity = "identity"
+	groupByFormat   = "format"
+)
+
+// inspectGroupOutput is the signatures of an artifact grouped by signing
+// identity or envelope format, printed by `notation inspect --group-by`.
+type inspectGroupOutput struct {
+	SchemaVersion string                 `json:"schemaVersion"`
+	MediaType     string                 `json:"mediaType"`
+	GroupBy       string                 `json:"groupBy"`
+	Groups        []signatureGroupOutput `json:"groups"`
+}
+
+// signatureGroupOutput is a group of signatures sharing the same signing
+// identity or envelope format, named by key.
+type signatureGroupOutput struct {
+	Key        string            `json:"key"`
+	Count      int               `json:"count"`
+	Signatures []signatureOutput `json:"signatures"`
+}
+
+// validateGroupBy returns an error if groupBy is not a supported option of
+// --group-by.
+func validateGroupBy(groupBy string) error {
+	switch groupBy {
+	case "", groupByIdentity, groupByFormat:
+		return nil
+	}
+	return fmt.Errorf("unsupported --group-by %q, options: %q, %q", groupBy, groupByIdentity, groupByFormat)
+}
+
+// groupSignatures groups the signatures of output by groupBy. Groups are
+// ordered by descending number of signatures, then by key, and the
+// signatures of a group keep their order in output.
+func groupSignatures(output inspectOutput, groupBy string) inspectGroupOutput {
+	grouped := inspectGroupOutput{
+		SchemaVersion: output.SchemaVersion,
+		MediaType:     output.MediaType,
+		GroupBy:       groupBy,
+		Groups:        []signatureGroupOutput{},
+	}
+	index := map[string]int{}
+	for _, sig := range output.Signatures {
+		key := signatureGroupKey(sig, groupBy)
+		i, ok := index[key]
+		if !ok {
+			i = len(grouped.Groups)
+			index[key] = i
+			grouped.Groups = append(grouped.Groups, signatureGroupOutput{Key: key, Signatures: []signatureOutput{}})
+		}
+		grouped.Groups[i].Count++
+		grouped.Groups[i].Signatures = append(grouped.Groups[i].Signatures, sig)
+	}
+	sort.SliceStable(grouped.Groups, func(i, j int) bool {
+		if grouped.Groups[i].Count != grouped.Groups[j].Count {
+			return grouped.Groups[i].Count > grouped.Groups[j].Count
+		}
+		return grouped.Groups[i].Key < grouped.Groups[j].Key
+	})
+	return grouped
+}
+
+// signatureGroupKey returns the key of the group of sig: the subject of the
+// signing certificate for identity, or the envelope media type for format.
+func signatureGroupKey(sig signatureOutput, groupBy string) string {
+	if groupBy == groupByFormat {
+		return sig.MediaType
+	}
+	if len(sig.Certificates) == 0 {
+		return "(unknown)"
+	}
+	return sig.Certificates[0].IssuedTo
+}
+
+func printGroupOutput(outputFormat string, ref string, grouped inspectGroupOutput) error {
+	if outputFormat == cmd.OutputJSON {
+		return ioutil.PrintObjectAsJSON(grouped)
+	}
+
+	fmt.Printf("Inspecting all signatures for signed artifact, grouped by %s\n", grouped.GroupBy)
+	root := tree.New(ref)
+	cncfSigNode := root.Add(registry.ArtifactTypeNotation)
+	for _, group := range grouped.Groups {
+		groupNode := cncfSigNode.Add(group.Key)
+		groupNode.AddPair("count", strconv.Itoa(group.Count))
+		for _, signature := range group.Signatures {
+			addSignatureToTree(groupNode, signature)
+		}
+	}
+	root.Print()
+	return nil
+}
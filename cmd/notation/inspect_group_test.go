@@ -0,0 +1,58 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupSignatures(t *testing.T) {
+	alice := certificateOutput{SHA1Fingerprint: "a1", IssuedTo: "CN=alice"}
+	bob := certificateOutput{SHA1Fingerprint: "b2", IssuedTo: "CN=bob"}
+	sig1 := signatureOutput{Digest: "sha256:1", MediaType: "application/cose", Certificates: []certificateOutput{bob}}
+	sig2 := signatureOutput{Digest: "sha256:2", MediaType: "application/jose+json", Certificates: []certificateOutput{alice}}
+	sig3 := signatureOutput{Digest: "sha256:3", MediaType: "application/jose+json", Certificates: []certificateOutput{alice}}
+	sig4 := signatureOutput{Digest: "sha256:4", MediaType: "application/cose"}
+	output := inspectOutput{MediaType: "application/vnd.oci.image.manifest.v1+json", Signatures: []signatureOutput{sig1, sig2, sig3, sig4}}
+
+	got := groupSignatures(output, groupByIdentity)
+	want := inspectGroupOutput{
+		MediaType: output.MediaType,
+		GroupBy:   groupByIdentity,
+		Groups: []signatureGroupOutput{
+			{Key: "CN=alice", Count: 2, Signatures: []signatureOutput{sig2, sig3}},
+			{Key: "(unknown)", Count: 1, Signatures: []signatureOutput{sig4}},
+			{Key: "CN=bob", Count: 1, Signatures: []signatureOutput{sig1}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groupSignatures() by identity = %+v, want %+v", got, want)
+	}
+
+	got = groupSignatures(output, groupByFormat)
+	want = inspectGroupOutput{
+		MediaType: output.MediaType,
+		GroupBy:   groupByFormat,
+		Groups: []signatureGroupOutput{
+			{Key: "application/cose", Count: 2, Signatures: []signatureOutput{sig1, sig4}},
+			{Key: "application/jose+json", Count: 2, Signatures: []signatureOutput{sig2, sig3}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("groupSignatures() by format = %+v, want %+v", got, want)
+	}
+
+	if got := groupSignatures(inspectOutput{}, groupByIdentity); got.Groups == nil || len(got.Groups) != 0 {
+		t.Fatalf("groupSignatures() of no signatures = %+v, want empty groups", got)
+	}
+}
+
+func TestValidateGroupBy(t *testing.T) {
+	for _, groupBy := range []string{"", groupByIdentity, groupByFormat} {
+		if err := validateGroupBy(groupBy); err != nil {
+			t.Fatalf("validateGroupBy(%q) error = %v", groupBy, err)
+		}
+	}
+	if err := validateGroupBy("signer"); err == nil {
+		t.Fatal("validateGroupBy() expects error for unsupported option")
+	}
+}
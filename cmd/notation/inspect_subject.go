@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/errdef"
+)
+
+// subjectOutput is the subject artifact of a signature, resolved in the
+// registry by `notation inspect --follow-subject`.
+type subjectOutput struct {
+	Exists    bool     `json:"exists"`
+	MediaType string   `json:"mediaType,omitempty"`
+	Size      int64    `json:"size,omitempty"`
+	Issues    []string `json:"issues"`
+}
+
+// followSubject resolves in resolver the artifact signedArtifact that a
+// signature attests to, and reports the issues found: the artifact does not
+// exist, its media type or size differ from the ones signed, or it is not
+// the artifact artifactDigest the signature is attached to.
+func followSubject(ctx context.Context, resolver content.Resolver, artifactDigest digest.Digest, signedArtifact ocispec.Descriptor) (*subjectOutput, error) {
+	subject := &subjectOutput{Issues: []string{}}
+	if signedArtifact.Digest != artifactDigest {
+		subject.Issues = append(subject.Issues, fmt.Sprintf("signature is attached to %s but attests to %s", artifactDigest, signedArtifact.Digest))
+	}
+	desc, err := resolver.Resolve(ctx, signedArtifact.Digest.String())
+	if err != nil {
+		if !errors.Is(err, errdef.ErrNotFound) {
+			return nil, fmt.Errorf("failed to resolve subject %s: %w", signedArtifact.Digest, err)
+		}
+		subject.Issues = append(subject.Issues, fmt.Sprintf("subject %s is not found in the registry", signedArtifact.Digest))
+		return subject, nil
+	}
+	subject.Exists = true
+	subject.MediaType = desc.MediaType
+	subject.Size = desc.Size
+	if desc.MediaType != signedArtifact.MediaType {
+		subject.Issues = append(subject.Issues, fmt.Sprintf("subject media type %q differs from the signed media type %q", desc.MediaType, signedArtifact.MediaType))
+	}
+	if desc.Size != signedArtifact.Size {
+		subject.Issues = append(subject.Issues, fmt.Sprintf("subject size %d differs from the signed size %d", desc.Size, signedArtifact.Size))
+	}
+	return subject, nil
+}
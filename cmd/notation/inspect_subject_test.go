@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/errdef"
+)
+
+type testSubjectResolver map[string]ocispec.Descriptor
+
+func (r testSubjectResolver) Resolve(_ context.Context, reference string) (ocispec.Descriptor, error) {
+	desc, ok := r[reference]
+	if !ok {
+		return ocispec.Descriptor{}, errdef.ErrNotFound
+	}
+	return desc, nil
+}
+
+func TestFollowSubject(t *testing.T) {
+	artifact := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      16724,
+	}
+	other := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageIndex,
+		Digest:    digest.FromString("other"),
+		Size:      512,
+	}
+	resolver := testSubjectResolver{
+		artifact.Digest.String(): artifact,
+		other.Digest.String():    other,
+	}
+
+	tests := []struct {
+		name           string
+		signedArtifact ocispec.Descriptor
+		want           *subjectOutput
+	}{
+		{
+			name:           "subject found",
+			signedArtifact: artifact,
+			want:           &subjectOutput{Exists: true, MediaType: artifact.MediaType, Size: artifact.Size, Issues: []string{}},
+		},
+		{
+			name:           "subject not found",
+			signedArtifact: ocispec.Descriptor{MediaType: artifact.MediaType, Digest: digest.FromString("missing"), Size: 1},
+			want: &subjectOutput{Issues: []string{
+				"signature is attached to " + artifact.Digest.String() + " but attests to " + digest.FromString("missing").String(),
+				"subject " + digest.FromString("missing").String() + " is not found in the registry",
+			}},
+		},
+		{
+			name:           "subject differs from the signed artifact",
+			signedArtifact: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: other.Digest, Size: 1024},
+			want: &subjectOutput{Exists: true, MediaType: other.MediaType, Size: other.Size, Issues: []string{
+				"signature is attached to " + artifact.Digest.String() + " but attests to " + other.Digest.String(),
+				`subject media type "application/vnd.oci.image.index.v1+json" differs from the signed media type "application/vnd.oci.image.manifest.v1+json"`,
+				"subject size 512 differs from the signed size 1024",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := followSubject(context.Background(), resolver, artifact.Digest, tt.signedArtifact)
+			if err != nil {
+				t.Fatalf("followSubject() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("followSubject() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFollowSubject_ResolveError(t *testing.T) {
+	resolver := resolverFunc(func(context.Context, string) (ocispec.Descriptor, error) {
+		return ocispec.Descriptor{}, errors.New("connection refused")
+	})
+	signedArtifact := ocispec.Descriptor{Digest: digest.FromString("artifact")}
+	if _, err := followSubject(context.Background(), resolver, signedArtifact.Digest, signedArtifact); err == nil {
+		t.Fatal("followSubject() expects error for a failed resolution, but got nil")
+	}
+}
+
+type resolverFunc func(ctx context.Context, reference string) (ocispec.Descriptor, error)
+
+func (f resolverFunc) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return f(ctx, reference)
+}
@@ -1,9 +1,23 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/notaryproject/notation-core-go/signature"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/opencontainers/go-digest"
 )
 
 func TestInspectCommand_SecretsFromArgs(t *testing.T) {
@@ -16,7 +30,8 @@ func TestInspectCommand_SecretsFromArgs(t *testing.T) {
 			PlainHTTP: true,
 			Username:  "user",
 		},
-		outputFormat: cmd.OutputPlaintext,
+		outputFormat:      cmd.OutputPlaintext,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
 	}
 	if err := command.ParseFlags([]string{
 		"--password", expected.Password,
@@ -29,7 +44,7 @@ func TestInspectCommand_SecretsFromArgs(t *testing.T) {
 	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
 	}
 }
@@ -44,7 +59,8 @@ func TestInspectCommand_SecretsFromEnv(t *testing.T) {
 			Password: "password",
 			Username: "user",
 		},
-		outputFormat: cmd.OutputJSON,
+		outputFormat:      cmd.OutputJSON,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
 	}
 	command := inspectCommand(opts)
 	if err := command.ParseFlags([]string{
@@ -55,7 +71,7 @@ func TestInspectCommand_SecretsFromEnv(t *testing.T) {
 	if err := command.Args(command, command.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
 	}
 }
@@ -69,3 +85,226 @@ func TestInspectCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestInspectCommand_CertChainPEM(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	expected := &inspectOpts{
+		reference:         "ref",
+		outputFormat:      cmd.OutputPlaintext,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		certChainDir:      "./certs",
+		signatureDigest:   "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--cert-chain-pem", expected.certChainDir,
+		"--signature-digest", expected.signatureDigest}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestInspectCommand_CountOnly(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	expected := &inspectOpts{
+		reference:         "ref",
+		outputFormat:      cmd.OutputPlaintext,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		mediaType:         "application/cose",
+		identity:          "CN=wabbit-networks.io",
+		countOnly:         true,
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--count-only",
+		"--media-type", expected.mediaType,
+		"--identity", expected.identity}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestInspectCommand_Diff(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	expected := &inspectOpts{
+		reference:         "ref",
+		outputFormat:      cmd.OutputJSON,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		diffReference:     "other-ref",
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--diff", expected.diffReference,
+		"--output", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect inspect opts: %v, got: %v", expected, opts)
+	}
+
+	command = inspectCommand(nil)
+	command.SetArgs([]string{"ref", "--diff", "other-ref", "--count-only"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "diff") {
+		t.Fatalf("Execute() error = %v, want error of mutually exclusive flags", err)
+	}
+}
+
+func TestMatchSignatureFilters(t *testing.T) {
+	content := &signature.EnvelopeContent{}
+	content.SignerInfo.CertificateChain = []*x509.Certificate{newTestCertificate(t, "wabbit-networks.io")}
+	tests := []struct {
+		name      string
+		opts      inspectOpts
+		mediaType string
+		want      bool
+	}{
+		{name: "no filter", mediaType: "application/jose+json", want: true},
+		{name: "media type matched", opts: inspectOpts{mediaType: "application/cose"}, mediaType: "application/cose", want: true},
+		{name: "media type not matched", opts: inspectOpts{mediaType: "application/cose"}, mediaType: "application/jose+json"},
+		{name: "identity matched", opts: inspectOpts{identity: "CN=wabbit-networks.io"}, mediaType: "application/cose", want: true},
+		{name: "identity not matched", opts: inspectOpts{identity: "CN=acme-rockets.io"}, mediaType: "application/cose"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchSignatureFilters(&tt.opts, tt.mediaType, content); got != tt.want {
+				t.Fatalf("matchSignatureFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteCertChainPEM(t *testing.T) {
+	certs := []*x509.Certificate{newTestCertificate(t, "leaf"), newTestCertificate(t, "root")}
+	dir := t.TempDir()
+	sigDigest := digest.FromString("signature")
+
+	path, err := writeCertChainPEM(dir, sigDigest, certs)
+	if err != nil {
+		t.Fatalf("writeCertChainPEM() error = %v", err)
+	}
+	if want := filepath.Join(dir, "sha256-"+sigDigest.Encoded()+".pem"); path != want {
+		t.Fatalf("writeCertChainPEM() path = %s, want %s", path, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, cert := range certs {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil || block.Type != "CERTIFICATE" {
+			t.Fatalf("expected PEM certificate block at position %d", i)
+		}
+		parsed, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !parsed.Equal(cert) {
+			t.Fatalf("certificate at position %d is %s, want %s", i, parsed.Subject, cert.Subject)
+		}
+	}
+	if len(data) != 0 {
+		t.Fatalf("unexpected trailing data: %q", data)
+	}
+}
+
+func newTestCertificate(t *testing.T, commonName string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestInspectCommand_ValidateEnvelope(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--validate-envelope", "--count-only"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.validateEnvelope {
+		t.Fatal("validateEnvelope = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --validate-envelope with --count-only")
+	}
+}
+
+func TestInspectCommand_GroupBy(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--group-by", "identity", "--output", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.groupBy != groupByIdentity {
+		t.Fatalf("groupBy = %s, want %s", opts.groupBy, groupByIdentity)
+	}
+
+	command = inspectCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--group-by", "format", "--count-only"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --group-by with --count-only")
+	}
+
+	command = inspectCommand(nil)
+	command.SetArgs([]string{"ref", "--group-by", "signer"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--group-by") {
+		t.Fatalf("Execute() error = %v, want error of unsupported --group-by", err)
+	}
+}
+
+func TestInspectCommand_FollowSubject(t *testing.T) {
+	opts := &inspectOpts{}
+	command := inspectCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--follow-subject", "--output", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.followSubject {
+		t.Fatal("followSubject = false, want true")
+	}
+
+	command = inspectCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--follow-subject", "--diff", "other"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --follow-subject with --diff")
+	}
+}
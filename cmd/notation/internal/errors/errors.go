@@ -1,5 +1,20 @@
 package errors
 
+import "errors"
+
+// Exit codes of notation, so that scripts can tell failures that can be
+// handled automatically from other failures.
+const (
+	// ExitCodeFailure is the exit code of failures without a more specific
+	// exit code.
+	ExitCodeFailure = 1
+
+	// ExitCodeArtifactManifestNotSupported is the exit code when the target
+	// registry does not support OCI artifact manifests, so that signing can be
+	// retried with OCI image manifests.
+	ExitCodeArtifactManifestNotSupported = 3
+)
+
 // ErrorReferrersAPINotSupported is used when the target registry does not
 // support the Referrers API
 type ErrorReferrersAPINotSupported struct {
@@ -12,3 +27,34 @@ func (e ErrorReferrersAPINotSupported) Error() string {
 	}
 	return "referrers API not supported"
 }
+
+// ErrorArtifactManifestNotSupported is used when the target registry does not
+// support OCI artifact manifests
+type ErrorArtifactManifestNotSupported struct {
+	Msg string
+	Err error
+}
+
+func (e ErrorArtifactManifestNotSupported) Error() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return "OCI artifact manifest not supported"
+}
+
+func (e ErrorArtifactManifestNotSupported) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the exit code of notation for err, which is 0 if err is
+// nil.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var errArtifactManifestNotSupported ErrorArtifactManifestNotSupported
+	if errors.As(err, &errArtifactManifestNotSupported) {
+		return ExitCodeArtifactManifestNotSupported
+	}
+	return ExitCodeFailure
+}
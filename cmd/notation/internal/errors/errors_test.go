@@ -0,0 +1,40 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestExitCode(t *testing.T) {
+	errPush := errors.New("failed to push signature")
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "success", err: nil, want: 0},
+		{name: "generic failure", err: errPush, want: ExitCodeFailure},
+		{name: "referrers API not supported", err: ErrorReferrersAPINotSupported{}, want: ExitCodeFailure},
+		{name: "artifact manifest not supported", err: ErrorArtifactManifestNotSupported{Err: errPush}, want: ExitCodeArtifactManifestNotSupported},
+		{name: "wrapped artifact manifest not supported", err: fmt.Errorf("sign: %w", ErrorArtifactManifestNotSupported{Err: errPush}), want: ExitCodeArtifactManifestNotSupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Fatalf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorArtifactManifestNotSupported(t *testing.T) {
+	errPush := errors.New("failed to push signature")
+	err := ErrorArtifactManifestNotSupported{Err: errPush}
+	if !errors.Is(err, errPush) {
+		t.Fatal("errors.Is() = false, want the wrapped error to be found")
+	}
+	if err.Error() != "OCI artifact manifest not supported" {
+		t.Fatalf("Error() = %q, want the default message", err.Error())
+	}
+}
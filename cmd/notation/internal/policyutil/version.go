@@ -0,0 +1,79 @@
+// Package policyutil provides helpers for handling trust policy
+// configuration.
+package policyutil
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SupportedVersions are the trust policy document versions supported by this
+// notation binary, in ascending order.
+var SupportedVersions = []string{"1.0"}
+
+// CheckVersion returns an error if the trust policy document version is not
+// supported. A version newer than the supported versions results in an error
+// asking the user to upgrade notation.
+func CheckVersion(version string) error {
+	if version == "" {
+		return nil // reported by trust policy validation
+	}
+	for _, v := range SupportedVersions {
+		if v == version {
+			return nil
+		}
+	}
+	latest := SupportedVersions[len(SupportedVersions)-1]
+	if newer, ok := isNewerVersion(version, latest); ok && newer {
+		return fmt.Errorf("trust policy document uses version %q, which is newer than the versions supported by this notation binary %v. Upgrade notation to use this trust policy", version, SupportedVersions)
+	}
+	return fmt.Errorf("trust policy document uses unsupported version %q, supported versions are %v", version, SupportedVersions)
+}
+
+// CheckDocumentVersion checks the version of the trust policy document in
+// policyJSON. Malformed documents are not reported.
+func CheckDocumentVersion(policyJSON []byte) error {
+	var doc struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(policyJSON, &doc); err != nil {
+		return nil // reported by trust policy parsing
+	}
+	return CheckVersion(doc.Version)
+}
+
+// isNewerVersion reports whether version a is newer than version b, where
+// versions are in the "<major>.<minor>" format. ok is false if either
+// version is malformed.
+func isNewerVersion(a, b string) (newer bool, ok bool) {
+	aMajor, aMinor, ok := parseVersion(a)
+	if !ok {
+		return false, false
+	}
+	bMajor, bMinor, ok := parseVersion(b)
+	if !ok {
+		return false, false
+	}
+	if aMajor != bMajor {
+		return aMajor > bMajor, true
+	}
+	return aMinor > bMinor, true
+}
+
+func parseVersion(version string) (major, minor int, ok bool) {
+	majorStr, minorStr, found := strings.Cut(version, ".")
+	if !found {
+		return 0, 0, false
+	}
+	major, err := strconv.Atoi(majorStr)
+	if err != nil || major < 0 {
+		return 0, 0, false
+	}
+	minor, err = strconv.Atoi(minorStr)
+	if err != nil || minor < 0 {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
@@ -0,0 +1,42 @@
+package policyutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		wantErr     bool
+		wantUpgrade bool
+	}{
+		{version: "1.0"},
+		{version: ""},
+		{version: "1.1", wantErr: true, wantUpgrade: true},
+		{version: "2.0", wantErr: true, wantUpgrade: true},
+		{version: "0.9", wantErr: true},
+		{version: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := CheckVersion(tt.version)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("CheckVersion(%q) error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+		if err != nil && strings.Contains(err.Error(), "Upgrade notation") != tt.wantUpgrade {
+			t.Fatalf("CheckVersion(%q) error = %v, want upgrade hint %v", tt.version, err, tt.wantUpgrade)
+		}
+	}
+}
+
+func TestCheckDocumentVersion(t *testing.T) {
+	if err := CheckDocumentVersion([]byte(`{"version": "1.0", "trustPolicies": []}`)); err != nil {
+		t.Fatalf("CheckDocumentVersion() error = %v", err)
+	}
+	if err := CheckDocumentVersion([]byte(`{"version": "2.0", "trustPolicies": []}`)); err == nil {
+		t.Fatal("CheckDocumentVersion() expected error for newer version, but got nil")
+	}
+	if err := CheckDocumentVersion([]byte(`not json`)); err != nil {
+		t.Fatalf("CheckDocumentVersion() expected malformed document to be ignored, but got %v", err)
+	}
+}
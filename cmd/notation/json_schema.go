@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/slices"
+	"github.com/spf13/pflag"
+)
+
+// versions of the schema of the JSON outputs of notation, set in their
+// schemaVersion field. A new version is added whenever the shape of a JSON
+// output changes incompatibly, and the previous versions are kept supported
+// for automation pinning them with --json-schema-version.
+const (
+	jsonSchemaVersion1 = "1"
+
+	jsonSchemaVersionLatest = jsonSchemaVersion1
+)
+
+var supportedJSONSchemaVersions = []string{jsonSchemaVersion1}
+
+// setFlagJSONSchemaVersion registers the --json-schema-version flag, setting
+// p to the requested version of the schema of the JSON output.
+func setFlagJSONSchemaVersion(fs *pflag.FlagSet, p *string) {
+	fs.StringVar(p, "json-schema-version", jsonSchemaVersionLatest, "version of the schema of the JSON output, to keep the output compatible with automation across notation upgrades, options: "+quotedJSONSchemaVersions())
+}
+
+// validateJSONSchemaVersion returns an error if version is not a supported
+// version of the schema of the JSON outputs, or if it is set by the flags fs
+// while the command prints or writes no JSON output.
+func validateJSONSchemaVersion(fs *pflag.FlagSet, version string, jsonOutput bool) error {
+	if !slices.Contains(supportedJSONSchemaVersions, version) {
+		return fmt.Errorf("unsupported --json-schema-version %q, supported versions: %s", version, quotedJSONSchemaVersions())
+	}
+	if fs.Changed("json-schema-version") && !jsonOutput {
+		return errors.New("--json-schema-version requires a JSON output, such as --output json")
+	}
+	return nil
+}
+
+// isJSONOutput reports whether outputFormat is the JSON format of --output.
+// It is for the RunE functions of the commands, where the cmd package is
+// shadowed by the command.
+func isJSONOutput(outputFormat string) bool {
+	return outputFormat == cmd.OutputJSON
+}
+
+// quotedJSONSchemaVersions returns the supported versions of the schema of
+// the JSON outputs, quoted and separated by commas.
+func quotedJSONSchemaVersions() string {
+	versions := make([]string, len(supportedJSONSchemaVersions))
+	for i, version := range supportedJSONSchemaVersions {
+		versions[i] = strconv.Quote(version)
+	}
+	return strings.Join(versions, ", ")
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+func TestValidateJSONSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		jsonOutput bool
+		wantErr    bool
+	}{
+		{name: "default version"},
+		{name: "supported version", args: []string{"--json-schema-version", "1"}, jsonOutput: true},
+		{name: "unsupported version", args: []string{"--json-schema-version", "2"}, jsonOutput: true, wantErr: true},
+		{name: "not JSON output", args: []string{"--json-schema-version", "1"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var version string
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			setFlagJSONSchemaVersion(fs, &version)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if err := validateJSONSchemaVersion(fs, version, tt.jsonOutput); (err != nil) != tt.wantErr {
+				t.Fatalf("validateJSONSchemaVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
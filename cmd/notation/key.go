@@ -26,11 +26,12 @@ var (
 
 type keyAddOpts struct {
 	cmd.LoggingFlagOpts
-	name         string
-	plugin       string
-	id           string
-	pluginConfig []string
-	isDefault    bool
+	name            string
+	plugin          string
+	id              string
+	pluginConfig    []string
+	isDefault       bool
+	validateSigning bool
 }
 
 type keyUpdateOpts struct {
@@ -44,6 +45,12 @@ type keyDeleteOpts struct {
 	names []string
 }
 
+type keyRenameOpts struct {
+	cmd.LoggingFlagOpts
+	oldName string
+	newName string
+}
+
 func keyCommand() *cobra.Command {
 	command := &cobra.Command{
 		Use:   "key",
@@ -53,6 +60,9 @@ func keyCommand() *cobra.Command {
 Example - Add a key to signing key list:
   notation key add --plugin <plugin_name> --id <key_id> <key_name>
 
+Example - Add a key to signing key list after checking that it can sign:
+  notation key add --validate-signing --plugin <plugin_name> --id <key_id> <key_name>
+
 Example - List keys used for signing:
   notation key ls
 
@@ -61,9 +71,12 @@ Example - Update the default signing key:
 
 Example - Delete the key from signing key list:
   notation key delete <key_name>...
+
+Example - Rename a key in signing key list:
+  notation key rename <old_key_name> <new_key_name>
 `,
 	}
-	command.AddCommand(keyAddCommand(nil), keyUpdateCommand(nil), keyListCommand(), keyDeleteCommand(nil))
+	command.AddCommand(keyAddCommand(nil), keyUpdateCommand(nil), keyListCommand(), keyDeleteCommand(nil), keyRenameCommand(nil))
 
 	return command
 }
@@ -94,6 +107,7 @@ func keyAddCommand(opts *keyAddOpts) *cobra.Command {
 
 	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
 	setKeyDefaultFlag(command.Flags(), &opts.isDefault)
+	command.Flags().BoolVar(&opts.validateSigning, "validate-signing", false, "sign a dummy payload locally with the key before adding it, and fail without adding the key if signing fails. Nothing is pushed")
 
 	return command
 }
@@ -159,6 +173,31 @@ func keyDeleteCommand(opts *keyDeleteOpts) *cobra.Command {
 	return command
 }
 
+func keyRenameCommand(opts *keyRenameOpts) *cobra.Command {
+	if opts == nil {
+		opts = &keyRenameOpts{}
+	}
+
+	command := &cobra.Command{
+		Use:   "rename [flags] <old_key_name> <new_key_name>",
+		Short: "Rename key in signing key list",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) != 2 {
+				return errors.New("requires the old key name and the new key name")
+			}
+			opts.oldName = args[0]
+			opts.newName = args[1]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return renameKey(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+
+	return command
+}
+
 func addKey(ctx context.Context, opts *keyAddOpts) error {
 	// set log level
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
@@ -168,6 +207,14 @@ func addKey(ctx context.Context, opts *keyAddOpts) error {
 		return err
 	}
 
+	if opts.validateSigning {
+		signerInfo, err := validateSigningKey(ctx, opts, pluginConfig)
+		if err != nil {
+			return fmt.Errorf("failed to sign a test payload with key %q, the key is not added: %w", opts.name, err)
+		}
+		fmt.Fprintln(os.Stderr, signingValidationMessage(opts.name, signerInfo))
+	}
+
 	// core process
 	exec := func(s *config.SigningKeys) error {
 		return s.AddPlugin(ctx, opts.name, opts.id, opts.plugin, pluginConfig, opts.isDefault)
@@ -252,3 +299,57 @@ func deleteKeys(ctx context.Context, opts *keyDeleteOpts) error {
 	}
 	return nil
 }
+
+func renameKey(ctx context.Context, opts *keyRenameOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// core process
+	var isDefault bool
+	exec := func(s *config.SigningKeys) error {
+		var err error
+		isDefault, err = renameSigningKey(s, opts.oldName, opts.newName)
+		return err
+	}
+	if err := config.LoadExecSaveSigningKeys(exec); err != nil {
+		return err
+	}
+
+	// write out
+	if isDefault {
+		fmt.Printf("%s: renamed to %s, marked as default\n", opts.oldName, opts.newName)
+	} else {
+		fmt.Printf("%s: renamed to %s\n", opts.oldName, opts.newName)
+	}
+	return nil
+}
+
+// renameSigningKey renames a signing key, keeping its key pair or plugin
+// association and its default status. It reports whether the key is the
+// default signing key.
+func renameSigningKey(s *config.SigningKeys, oldName, newName string) (bool, error) {
+	if oldName == "" || newName == "" {
+		return false, errors.New("key name cannot be empty")
+	}
+	index := -1
+	for i, key := range s.Keys {
+		switch key.Name {
+		case oldName:
+			index = i
+		case newName:
+			return false, fmt.Errorf("signing key with name %q already exists", newName)
+		}
+	}
+	if index < 0 {
+		return false, fmt.Errorf("signing key %q not found", oldName)
+	}
+	if oldName == newName {
+		return s.Default != nil && *s.Default == oldName, nil
+	}
+	s.Keys[index].Name = newName
+	if s.Default != nil && *s.Default == oldName {
+		s.Default = &newName
+		return true, nil
+	}
+	return false, nil
+}
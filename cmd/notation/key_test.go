@@ -3,6 +3,8 @@ package main
 import (
 	"reflect"
 	"testing"
+
+	"github.com/notaryproject/notation-go/config"
 )
 
 func TestKeyAddCommand_BasicArgs(t *testing.T) {
@@ -29,6 +31,17 @@ func TestKeyAddCommand_BasicArgs(t *testing.T) {
 	}
 }
 
+func TestKeyAddCommand_ValidateSigning(t *testing.T) {
+	opts := &keyAddOpts{}
+	command := keyAddCommand(opts)
+	if err := command.ParseFlags([]string{"--plugin", "pluginname", "--id", "pluginid", "--validate-signing", "name"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.validateSigning {
+		t.Fatal("validateSigning = false, want true")
+	}
+}
+
 func TestKeyUpdateCommand_BasicArgs(t *testing.T) {
 	opts := &keyUpdateOpts{}
 	cmd := keyUpdateCommand(opts)
@@ -85,3 +98,84 @@ func TestKeyRemoveCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestKeyRenameCommand_BasicArgs(t *testing.T) {
+	opts := &keyRenameOpts{}
+	cmd := keyRenameCommand(opts)
+	expected := &keyRenameOpts{
+		oldName: "old",
+		newName: "new",
+	}
+	if err := cmd.ParseFlags([]string{expected.oldName, expected.newName}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if *expected != *opts {
+		t.Fatalf("Expect key rename opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestKeyRenameCommand_MissingArgs(t *testing.T) {
+	cmd := keyRenameCommand(nil)
+	if err := cmd.ParseFlags([]string{"old"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestRenameSigningKey(t *testing.T) {
+	newSigningKeys := func() *config.SigningKeys {
+		defaultName := "key0"
+		return &config.SigningKeys{
+			Default: &defaultName,
+			Keys: []config.KeySuite{
+				{Name: "key0", X509KeyPair: &config.X509KeyPair{KeyPath: "key0.key", CertificatePath: "key0.crt"}},
+				{Name: "key1", ExternalKey: &config.ExternalKey{ID: "id1", PluginName: "plugin1"}},
+			},
+		}
+	}
+
+	t.Run("rename default key", func(t *testing.T) {
+		s := newSigningKeys()
+		isDefault, err := renameSigningKey(s, "key0", "renamed")
+		if err != nil {
+			t.Fatalf("renameSigningKey() error = %v", err)
+		}
+		if !isDefault || *s.Default != "renamed" {
+			t.Fatalf("expected default key to be renamed, got %v", *s.Default)
+		}
+		if s.Keys[0].Name != "renamed" || s.Keys[0].X509KeyPair.KeyPath != "key0.key" {
+			t.Fatalf("unexpected renamed key: %+v", s.Keys[0])
+		}
+	})
+
+	t.Run("rename plugin key", func(t *testing.T) {
+		s := newSigningKeys()
+		isDefault, err := renameSigningKey(s, "key1", "renamed")
+		if err != nil {
+			t.Fatalf("renameSigningKey() error = %v", err)
+		}
+		if isDefault || *s.Default != "key0" {
+			t.Fatalf("expected default key unchanged, got %v", *s.Default)
+		}
+		if s.Keys[1].Name != "renamed" || s.Keys[1].ExternalKey.PluginName != "plugin1" {
+			t.Fatalf("unexpected renamed key: %+v", s.Keys[1])
+		}
+	})
+
+	t.Run("new name exists", func(t *testing.T) {
+		if _, err := renameSigningKey(newSigningKeys(), "key0", "key1"); err == nil {
+			t.Fatal("renameSigningKey() expected error, but got nil")
+		}
+	})
+
+	t.Run("old name not found", func(t *testing.T) {
+		if _, err := renameSigningKey(newSigningKeys(), "missing", "renamed"); err == nil {
+			t.Fatal("renameSigningKey() expected error, but got nil")
+		}
+	})
+}
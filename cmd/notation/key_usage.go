@@ -0,0 +1,213 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// oidKeyUsage is the object identifier of the key usage extension.
+var oidKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 15}
+
+// blockedKeyUsages are the key usages a code signing certificate must not
+// have, following the certificate requirements of the Notary Project.
+var blockedKeyUsages = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageContentCommitment, "ContentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "KeyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "DataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "KeyAgreement"},
+	{x509.KeyUsageCertSign, "CertSign"},
+	{x509.KeyUsageCRLSign, "CRLSign"},
+	{x509.KeyUsageEncipherOnly, "EncipherOnly"},
+	{x509.KeyUsageDecipherOnly, "DecipherOnly"},
+}
+
+// blockedExtKeyUsages are the extended key usages a code signing certificate
+// must not have.
+var blockedExtKeyUsages = []struct {
+	usage x509.ExtKeyUsage
+	name  string
+}{
+	{x509.ExtKeyUsageAny, "Any"},
+	{x509.ExtKeyUsageServerAuth, "ServerAuth"},
+	{x509.ExtKeyUsageClientAuth, "ClientAuth"},
+	{x509.ExtKeyUsageEmailProtection, "EmailProtection"},
+	{x509.ExtKeyUsageOCSPSigning, "OCSPSigning"},
+	{x509.ExtKeyUsageTimeStamping, "TimeStamping"},
+}
+
+// checkCodeSigningKeyUsage returns the issues of the key usage and extended
+// key usage of the signing certificate cert for code signing, or nil if there
+// is none.
+func checkCodeSigningKeyUsage(cert *x509.Certificate) []string {
+	var issues []string
+
+	// key usage
+	var keyUsageExt bool
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidKeyUsage) {
+			keyUsageExt = true
+			if !ext.Critical {
+				issues = append(issues, "key usage extension is not marked critical")
+			}
+			break
+		}
+	}
+	if !keyUsageExt {
+		issues = append(issues, "key usage extension is not present")
+	} else {
+		if cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+			issues = append(issues, "key usage does not contain DigitalSignature")
+		}
+		for _, blocked := range blockedKeyUsages {
+			if cert.KeyUsage&blocked.usage != 0 {
+				issues = append(issues, fmt.Sprintf("key usage contains %s", blocked.name))
+			}
+		}
+	}
+
+	// extended key usage
+	if len(cert.ExtKeyUsage) == 0 {
+		return append(issues, "extended key usage does not contain CodeSigning")
+	}
+	var codeSigning bool
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageCodeSigning {
+			codeSigning = true
+			continue
+		}
+		for _, blocked := range blockedExtKeyUsages {
+			if eku == blocked.usage {
+				issues = append(issues, fmt.Sprintf("extended key usage contains %s", blocked.name))
+			}
+		}
+	}
+	if !codeSigning {
+		issues = append(issues, "extended key usage does not contain CodeSigning")
+	}
+	return issues
+}
+
+// checkSigningKeyUsage checks that the signing certificate of the key in opts
+// is appropriate for code signing. Issues are printed as warnings, or
+// returned as an error if strict is set.
+func checkSigningKeyUsage(opts *cmd.SignerFlagOpts, strict bool) error {
+	certs, err := loadSigningCertificates(opts, "--key-usage-check")
+	if err != nil {
+		if strict {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: key usage of the signing certificate is not checked: %v\n", err)
+		return nil
+	}
+	issues := checkCodeSigningKeyUsage(certs[0])
+	if len(issues) == 0 {
+		return nil
+	}
+	message := fmt.Sprintf("signing certificate with subject %q is not appropriate for code signing: %s", certs[0].Subject, strings.Join(issues, "; "))
+	if strict {
+		return errors.New(message)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", message)
+	return nil
+}
+
+// keyUsageNames are the key usages that can be required of signing
+// certificates.
+var keyUsageNames = map[string]x509.KeyUsage{
+	"DigitalSignature":  x509.KeyUsageDigitalSignature,
+	"ContentCommitment": x509.KeyUsageContentCommitment,
+	"KeyEncipherment":   x509.KeyUsageKeyEncipherment,
+	"DataEncipherment":  x509.KeyUsageDataEncipherment,
+	"KeyAgreement":      x509.KeyUsageKeyAgreement,
+	"CertSign":          x509.KeyUsageCertSign,
+	"CRLSign":           x509.KeyUsageCRLSign,
+	"EncipherOnly":      x509.KeyUsageEncipherOnly,
+	"DecipherOnly":      x509.KeyUsageDecipherOnly,
+}
+
+// extKeyUsageNames are the extended key usages that can be required of
+// signing certificates.
+var extKeyUsageNames = map[string]x509.ExtKeyUsage{
+	"CodeSigning":     x509.ExtKeyUsageCodeSigning,
+	"Any":             x509.ExtKeyUsageAny,
+	"ServerAuth":      x509.ExtKeyUsageServerAuth,
+	"ClientAuth":      x509.ExtKeyUsageClientAuth,
+	"EmailProtection": x509.ExtKeyUsageEmailProtection,
+	"OCSPSigning":     x509.ExtKeyUsageOCSPSigning,
+	"TimeStamping":    x509.ExtKeyUsageTimeStamping,
+}
+
+// keyUsageRequirement is a set of key usages and extended key usages a
+// signing certificate must have.
+type keyUsageRequirement struct {
+	keyUsages    []string
+	extKeyUsages []string
+}
+
+// parseKeyUsageRequirement parses the names of the required key usages and
+// extended key usages. Names are case-insensitive.
+func parseKeyUsageRequirement(names []string) (keyUsageRequirement, error) {
+	var req keyUsageRequirement
+	for _, name := range names {
+		if known, ok := findUsageName(name, keyUsageNames); ok {
+			req.keyUsages = append(req.keyUsages, known)
+			continue
+		}
+		if known, ok := findUsageName(name, extKeyUsageNames); ok {
+			req.extKeyUsages = append(req.extKeyUsages, known)
+			continue
+		}
+		return keyUsageRequirement{}, fmt.Errorf("unsupported key usage %q, supported key usages are %q and extended key usages are %q", name, sortedKeys(keyUsageNames), sortedKeys(extKeyUsageNames))
+	}
+	return req, nil
+}
+
+// check returns an error if cert lacks any of the required key usages.
+func (req keyUsageRequirement) check(cert *x509.Certificate) error {
+	var missing []string
+	for _, name := range req.keyUsages {
+		if cert.KeyUsage&keyUsageNames[name] == 0 {
+			missing = append(missing, name)
+		}
+	}
+	for _, name := range req.extKeyUsages {
+		if !slices.Contains(cert.ExtKeyUsage, extKeyUsageNames[name]) {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("signing certificate with subject %q does not have the required key usages %q", cert.Subject, missing)
+	}
+	return nil
+}
+
+// findUsageName returns the key of names equal to name under case folding.
+func findUsageName[T any](name string, names map[string]T) (string, bool) {
+	for known := range names {
+		if strings.EqualFold(known, name) {
+			return known, true
+		}
+	}
+	return "", false
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
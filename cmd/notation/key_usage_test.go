@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCodeSigningKeyUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyUsage    x509.KeyUsage
+		extKeyUsage []x509.ExtKeyUsage
+		want        []string
+	}{
+		{
+			name:        "code signing certificate",
+			keyUsage:    x509.KeyUsageDigitalSignature,
+			extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		},
+		{
+			name: "no key usage",
+			want: []string{"key usage extension is not present", "extended key usage does not contain CodeSigning"},
+		},
+		{
+			name:        "no digital signature",
+			keyUsage:    x509.KeyUsageKeyEncipherment,
+			extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+			want:        []string{"key usage does not contain DigitalSignature", "key usage contains KeyEncipherment"},
+		},
+		{
+			name:        "CA key usage",
+			keyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+			extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+			want:        []string{"key usage contains CertSign"},
+		},
+		{
+			name:        "TLS certificate",
+			keyUsage:    x509.KeyUsageDigitalSignature,
+			extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			want:        []string{"extended key usage contains ServerAuth", "extended key usage does not contain CodeSigning"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cert := newKeyUsageTestCertificate(t, tt.keyUsage, tt.extKeyUsage)
+			if got := checkCodeSigningKeyUsage(cert); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("checkCodeSigningKeyUsage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func newKeyUsageTestCertificate(t *testing.T, keyUsage x509.KeyUsage, extKeyUsage []x509.ExtKeyUsage) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "key usage test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     keyUsage,
+		ExtKeyUsage:  extKeyUsage,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestKeyUsageRequirement(t *testing.T) {
+	req, err := parseKeyUsageRequirement([]string{"digitalsignature", "CodeSigning"})
+	if err != nil {
+		t.Fatalf("parseKeyUsageRequirement() error = %v", err)
+	}
+	want := keyUsageRequirement{keyUsages: []string{"DigitalSignature"}, extKeyUsages: []string{"CodeSigning"}}
+	if !reflect.DeepEqual(req, want) {
+		t.Fatalf("parseKeyUsageRequirement() = %v, want %v", req, want)
+	}
+	if err := req.check(newKeyUsageTestCertificate(t, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning})); err != nil {
+		t.Fatalf("check() error = %v", err)
+	}
+	if err := req.check(newKeyUsageTestCertificate(t, x509.KeyUsageDigitalSignature, nil)); err == nil || !strings.Contains(err.Error(), `["CodeSigning"]`) {
+		t.Fatalf("check() error = %v, want error of missing CodeSigning", err)
+	}
+	if _, err := parseKeyUsageRequirement([]string{"Signing"}); err == nil {
+		t.Fatal("parseKeyUsageRequirement() expects error for an unsupported key usage but got nil")
+	}
+}
+
+func TestVerifyReference_RequireKeyUsageSkipped(t *testing.T) {
+	host, subject := newBatchTestRegistry(t, func(string) time.Duration { return 0 })
+	opts := &verifyOpts{
+		policyInline:      `{"version": "1.0", "trustPolicies": [{"name": "skip", "registryScopes": ["*"], "signatureVerification": {"level": "skip"}, "trustStores": [], "trustedIdentities": []}]}`,
+		requiredKeyUsages: []string{"CodeSigning"},
+	}
+	opts.SecureFlagOpts.PlainHTTP = true
+	_, _, err := verifyReference(context.Background(), opts, host+"/net-monitor@"+subject.String())
+	if err == nil || !strings.Contains(err.Error(), "required key usages were not verified") {
+		t.Fatalf("verifyReference() error = %v, want error of unverified key usages", err)
+	}
+}
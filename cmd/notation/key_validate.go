@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// validationPayload is the content of the dummy artifact signed by
+// `notation key add --validate-signing`.
+var validationPayload = []byte("notation key add --validate-signing")
+
+// validateSigningKey performs a test sign of a dummy artifact with the key of
+// opts, which is not added yet, and returns the signer information of the
+// generated signature. The signature is discarded.
+func validateSigningKey(ctx context.Context, opts *keyAddOpts, pluginConfig map[string]string) (*signature.SignerInfo, error) {
+	mgr := plugin.NewCLIManager(dir.PluginFS())
+	signingPlugin, err := mgr.Get(ctx, opts.plugin)
+	if err != nil {
+		return nil, err
+	}
+	pluginSigner, err := signer.NewFromPlugin(signingPlugin, opts.id, pluginConfig)
+	if err != nil {
+		return nil, err
+	}
+	return testSign(ctx, pluginSigner)
+}
+
+// testSign signs a dummy artifact with s, and returns the signer information
+// of the generated signature.
+func testSign(ctx context.Context, s notation.Signer) (*signature.SignerInfo, error) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(validationPayload),
+		Size:      int64(len(validationPayload)),
+	}
+	_, signerInfo, err := s.Sign(ctx, desc, notation.SignOptions{
+		SignatureMediaType: jws.MediaTypeEnvelope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(signerInfo.CertificateChain) == 0 {
+		return nil, errors.New("generated signature does not contain certificate")
+	}
+	return signerInfo, nil
+}
+
+// signingValidationMessage returns the message reporting a successful test
+// sign with the signer information signerInfo.
+func signingValidationMessage(name string, signerInfo *signature.SignerInfo) string {
+	algorithm, err := proto.EncodeSigningAlgorithm(signerInfo.SignatureAlgorithm)
+	if err != nil {
+		algorithm = proto.SignatureAlgorithm(fmt.Sprintf("unknown (%d)", signerInfo.SignatureAlgorithm))
+	}
+	return fmt.Sprintf("Key %q signed a test payload successfully with signing algorithm %s and signing certificate subject %q", name, algorithm, signerInfo.CertificateChain[0].Subject)
+}
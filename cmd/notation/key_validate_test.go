@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/signer"
+)
+
+func TestTestSign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "validate signing test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localSigner, err := signer.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signerInfo, err := testSign(context.Background(), localSigner)
+	if err != nil {
+		t.Fatalf("testSign() error = %v", err)
+	}
+	message := signingValidationMessage("test", signerInfo)
+	for _, want := range []string{"RSASSA-PSS-SHA-256", "CN=validate signing test"} {
+		if !strings.Contains(message, want) {
+			t.Errorf("signingValidationMessage() = %q, want it to contain %q", message, want)
+		}
+	}
+}
+
+func TestValidateSigningKey_PluginNotFound(t *testing.T) {
+	defer func(oldDir string) { dir.UserLibexecDir = oldDir }(dir.UserLibexecDir)
+	dir.UserLibexecDir = t.TempDir()
+
+	opts := &keyAddOpts{name: "test", plugin: "not-installed", id: "key-1"}
+	if _, err := validateSigningKey(context.Background(), opts, nil); err == nil {
+		t.Fatal("validateSigningKey() expects error for a plugin not installed")
+	}
+}
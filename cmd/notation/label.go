@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+// labelAnnotationKey is the signature manifest annotation storing the label
+// of `notation sign --label` if `--label-annotation` is set.
+const labelAnnotationKey = "org.notaryproject.notation.label"
+
+// maxLabelLength is the maximum length of a signing label.
+const maxLabelLength = 128
+
+// validateLabel checks that a signing label is non-empty, short, and only
+// contains printable characters, so that it is safe to echo in logs.
+func validateLabel(label string) error {
+	if label == "" {
+		return errors.New("label cannot be empty")
+	}
+	if len(label) > maxLabelLength {
+		return fmt.Errorf("label is too long, exceeding %d bytes", maxLabelLength)
+	}
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("label %q contains non-printable characters", label)
+		}
+	}
+	return nil
+}
+
+// signedMessage returns the message printed on successful signing of ref,
+// with the label if it is set.
+func signedMessage(ref, label string) string {
+	if label == "" {
+		return "Successfully signed " + ref
+	}
+	return fmt.Sprintf("Successfully signed %s (label: %s)", ref, label)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		label   string
+		wantErr bool
+	}{
+		{name: "valid", label: "nightly"},
+		{name: "with spaces", label: "release 1.0"},
+		{name: "empty", label: "", wantErr: true},
+		{name: "too long", label: strings.Repeat("a", maxLabelLength+1), wantErr: true},
+		{name: "newline", label: "nightly\nSuccessfully signed", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateLabel(tt.label); (err != nil) != tt.wantErr {
+				t.Fatalf("validateLabel(%q) error = %v, wantErr %v", tt.label, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignedMessage(t *testing.T) {
+	ref := "localhost:5000/net-monitor@sha256:abc"
+	if got, want := signedMessage(ref, ""), "Successfully signed "+ref; got != want {
+		t.Fatalf("signedMessage() = %q, want %q", got, want)
+	}
+	if got, want := signedMessage(ref, "nightly"), "Successfully signed "+ref+" (label: nightly)"; got != want {
+		t.Fatalf("signedMessage() = %q, want %q", got, want)
+	}
+}
@@ -4,10 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"time"
 
+	"github.com/notaryproject/notation-core-go/signature"
 	notationregistry "github.com/notaryproject/notation-go/registry"
 	"github.com/notaryproject/notation/internal/cmd"
-	"github.com/opencontainers/go-digest"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/experimental"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 	"oras.land/oras-go/v2/registry"
@@ -16,18 +20,57 @@ import (
 type listOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
-	reference string
+	reference      string
+	failIfNone     bool
+	olderThan      time.Duration
+	newerThan      time.Duration
+	ociLayout      bool
+	subject        bool
+	outputFormat   string
+	targetRegistry string
+	artifactType   string
+	pushTime       bool
+	pushedAfter    string
+	pushedBefore   string
 }
 
 func listCommand(opts *listOpts) *cobra.Command {
 	if opts == nil {
 		opts = &listOpts{}
 	}
-	cmd := &cobra.Command{
+	command := &cobra.Command{
 		Use:     "list [flags] <reference>",
 		Aliases: []string{"ls"},
 		Short:   "List signatures of the signed artifact",
-		Long:    "List all the signatures associated with signed artifact",
+		Long: `List all the signatures associated with signed artifact
+
+Example - List signatures of an OCI artifact identified by a digest:
+  notation list <registry>/<repository>@<digest>
+
+Example - Exit with a non-zero status if an OCI artifact has no signatures:
+  notation list --fail-if-none <registry>/<repository>@<digest>
+
+Example - List signatures of an OCI artifact signed more than 30 days ago:
+  notation list --older-than 720h <registry>/<repository>@<digest>
+
+Example - List signatures of an OCI artifact along with the subject digest each signature attests to:
+  notation list --subject <registry>/<repository>@<digest>
+
+Example - List signatures of an OCI artifact along with the time each signature was pushed, if exposed by the registry:
+  notation list --push-time <registry>/<repository>@<digest>
+
+Example - List signatures of an OCI artifact pushed after 2023-01-01:
+  notation list --pushed-after 2023-01-01T00:00:00Z <registry>/<repository>@<digest>
+
+Example - List the referrers of an OCI artifact with the artifact type "application/spdx+json":
+  notation list --artifact-type application/spdx+json <registry>/<repository>@<digest>
+
+Example - Generate a script copying an OCI artifact and its signatures to another registry with oras:
+  notation list --output oras-copy-script --target-registry <target_registry> <registry>/<repository>@<digest>
+
+Example - [Experimental] List signatures of an OCI artifact stored in an OCI layout directory:
+  notation list --oci-layout <oci_layout_path>@<digest>
+`,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("no reference specified")
@@ -35,13 +78,43 @@ func listCommand(opts *listOpts) *cobra.Command {
 			opts.reference = args[0]
 			return nil
 		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout")
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.olderThan < 0 || opts.newerThan < 0 {
+				return errors.New("--older-than and --newer-than cannot be negative values")
+			}
+			if err := validateListOutput(opts); err != nil {
+				return err
+			}
+			if err := validateListArtifactType(opts); err != nil {
+				return err
+			}
+			if err := validateListPushTime(opts); err != nil {
+				return err
+			}
+			if err := validateRegistryUserAgent(opts.UserAgent); err != nil {
+				return err
+			}
 			return runList(cmd.Context(), opts)
 		},
 	}
-	opts.LoggingFlagOpts.ApplyFlags(cmd.Flags())
-	opts.SecureFlagOpts.ApplyFlags(cmd.Flags())
-	return cmd
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	setFlagRegistryUserAgent(command.Flags(), &opts.UserAgent)
+	command.Flags().BoolVar(&opts.failIfNone, "fail-if-none", false, "exit with a non-zero status if no signature is associated with the artifact")
+	command.Flags().DurationVar(&opts.olderThan, "older-than", 0, "only list signatures with a signing time older than the given duration")
+	command.Flags().DurationVar(&opts.newerThan, "newer-than", 0, "only list signatures with a signing time newer than the given duration")
+	command.Flags().BoolVar(&opts.pushTime, "push-time", false, "show the time each signature manifest was pushed, as exposed by the registry in the Last-Modified header, which differs from the signing time")
+	command.Flags().StringVar(&opts.pushedAfter, "pushed-after", "", "only list signatures whose manifest was pushed after the given time in RFC 3339 format, as exposed by the registry")
+	command.Flags().StringVar(&opts.pushedBefore, "pushed-before", "", "only list signatures whose manifest was pushed before the given time in RFC 3339 format, as exposed by the registry")
+	command.Flags().BoolVar(&opts.subject, "subject", false, "show the digest of the subject each signature attests to, decoded from the signature envelope, and flag signatures attesting to another artifact")
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputListUsage)
+	command.Flags().StringVar(&opts.targetRegistry, "target-registry", "", "registry, optionally followed by a namespace, to copy the artifact and its signatures to with the script of --output "+cmd.OutputOrasCopyScript+", for example \"registry.example.com/mirror\"")
+	command.Flags().StringVar(&opts.artifactType, "artifact-type", "", "only list referrers with the given artifact type, instead of Notary Project signatures with the artifact type \""+notationregistry.ArtifactTypeNotation+"\"")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] list signatures stored in OCI image layout")
+	return command
 }
 
 func runList(ctx context.Context, opts *listOpts) error {
@@ -49,58 +122,363 @@ func runList(ctx context.Context, opts *listOpts) error {
 	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
 
 	// initialize
-	reference := opts.reference
-	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	var pushTimes *pushTimeClient
+	if opts.pushTime || opts.pushedAfter != "" || opts.pushedBefore != "" {
+		pushTimes = &pushTimeClient{}
+	}
+	sigRepo, manifestDesc, artifact, err := getListTarget(ctx, opts, pushTimes)
 	if err != nil {
 		return err
 	}
-	manifestDesc, ref, err := getManifestDescriptor(ctx, &opts.SecureFlagOpts, reference, sigRepo)
+
+	// print all signature manifest digests
+	return listSignatures(ctx, opts, sigRepo, manifestDesc, artifact, pushTimes)
+}
+
+// listSignatures prints the signatures of the artifact of manifestDesc in
+// sigRepo selected by opts, and returns an error if there is none and
+// --fail-if-none is set. pushTimes records the push times of the signatures
+// if opts requires them.
+func listSignatures(ctx context.Context, opts *listOpts, sigRepo notationregistry.Repository, manifestDesc ocispec.Descriptor, artifact string, pushTimes *pushTimeClient) error {
+	var filters []func(ocispec.Descriptor) bool
+	if opts.pushedAfter != "" || opts.pushedBefore != "" {
+		// validated by validateListPushTime
+		after, _ := parsePushTimeBound("pushed-after", opts.pushedAfter)
+		before, _ := parsePushTimeBound("pushed-before", opts.pushedBefore)
+		filters = append(filters, pushTimeFilter(ctx, sigRepo, pushTimes, after, before))
+	}
+	if opts.olderThan > 0 || opts.newerThan > 0 {
+		filters = append(filters, signingTimeFilter(ctx, sigRepo, time.Now(), opts.olderThan, opts.newerThan))
+	}
+	filter := allFilters(filters)
+	if opts.outputFormat == cmd.OutputOrasCopyScript {
+		return printOrasCopyScript(ctx, opts, sigRepo, manifestDesc, artifact, filter)
+	}
+	var describers []func(ocispec.Descriptor) string
+	if opts.subject {
+		describers = append(describers, signatureSubjectDescriber(ctx, sigRepo, manifestDesc))
+	}
+	if opts.pushTime {
+		describers = append(describers, pushTimeDescriber(ctx, sigRepo, pushTimes))
+	}
+	describe := allDescribers(describers)
+	count, err := printSignatureManifestDigests(ctx, manifestDesc, sigRepo, artifact, listArtifactType(opts), filter, describe)
 	if err != nil {
 		return err
 	}
+	if count == 0 && opts.failIfNone {
+		if opts.artifactType != "" {
+			return fmt.Errorf("no referrers with artifact type %q associated with %s", opts.artifactType, artifact)
+		}
+		return fmt.Errorf("no signatures associated with %s", artifact)
+	}
+	return nil
+}
 
-	// print all signature manifest digests
-	return printSignatureManifestDigests(ctx, manifestDesc, sigRepo, ref)
+// validateListArtifactType checks the artifact type of opts along with the
+// flags it conflicts with. Flags decoding signature envelopes require the
+// referrers to be Notary Project signatures.
+func validateListArtifactType(opts *listOpts) error {
+	if opts.artifactType == "" {
+		return nil
+	}
+	if err := validateMediaType("artifact type", opts.artifactType); err != nil {
+		return err
+	}
+	if opts.artifactType != notationregistry.ArtifactTypeNotation && (opts.olderThan > 0 || opts.newerThan > 0 || opts.subject) {
+		return fmt.Errorf("--older-than, --newer-than and --subject require --artifact-type to be %q", notationregistry.ArtifactTypeNotation)
+	}
+	return nil
 }
 
-// printSignatureManifestDigests returns the signature manifest digests of
-// the subject manifest.
-func printSignatureManifestDigests(ctx context.Context, manifestDesc ocispec.Descriptor, sigRepo notationregistry.Repository, ref registry.Reference) error {
+// validateListPushTime checks the push time flags of opts along with the
+// flags they conflict with. OCI layouts do not record push times.
+func validateListPushTime(opts *listOpts) error {
+	if !opts.pushTime && opts.pushedAfter == "" && opts.pushedBefore == "" {
+		return nil
+	}
+	if opts.ociLayout {
+		return errors.New("--push-time, --pushed-after and --pushed-before cannot be used with --oci-layout")
+	}
+	after, err := parsePushTimeBound("pushed-after", opts.pushedAfter)
+	if err != nil {
+		return err
+	}
+	before, err := parsePushTimeBound("pushed-before", opts.pushedBefore)
+	if err != nil {
+		return err
+	}
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return fmt.Errorf("--pushed-after %s must be before --pushed-before %s", opts.pushedAfter, opts.pushedBefore)
+	}
+	return nil
+}
+
+// allFilters returns a filter accepting signatures accepted by all filters,
+// or nil if there is no filter.
+func allFilters(filters []func(ocispec.Descriptor) bool) func(ocispec.Descriptor) bool {
+	if len(filters) == 0 {
+		return nil
+	}
+	return func(desc ocispec.Descriptor) bool {
+		for _, filter := range filters {
+			if !filter(desc) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// allDescribers returns a function concatenating the descriptions of all
+// describers, or nil if there is no describer.
+func allDescribers(describers []func(ocispec.Descriptor) string) func(ocispec.Descriptor) string {
+	if len(describers) == 0 {
+		return nil
+	}
+	return func(desc ocispec.Descriptor) string {
+		var description string
+		for _, describe := range describers {
+			description += describe(desc)
+		}
+		return description
+	}
+}
+
+// listArtifactType returns the artifact type of the referrers listed.
+func listArtifactType(opts *listOpts) string {
+	if opts.artifactType != "" {
+		return opts.artifactType
+	}
+	return notationregistry.ArtifactTypeNotation
+}
+
+// referrerLister lists the referrers of a manifest with the given artifact
+// type. Remote repositories push the filter to the referrers API request,
+// and filter on the client side if the registry does not apply it.
+type referrerLister interface {
+	Referrers(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error
+}
+
+// referrersRepository is a signature repository listing the referrers of the
+// given artifact type instead of Notary Project signatures.
+type referrersRepository struct {
+	notationregistry.Repository
+	lister       referrerLister
+	artifactType string
+}
+
+// ListSignatures lists the referrers of desc with the artifact type of r.
+func (r *referrersRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	return r.lister.Referrers(ctx, desc, r.artifactType, fn)
+}
+
+// validateListOutput checks the output format of opts along with the flags
+// it requires or conflicts with.
+func validateListOutput(opts *listOpts) error {
+	switch opts.outputFormat {
+	case cmd.OutputPlaintext:
+		if opts.targetRegistry != "" {
+			return fmt.Errorf("--target-registry requires --output %s", cmd.OutputOrasCopyScript)
+		}
+	case cmd.OutputOrasCopyScript:
+		if opts.targetRegistry == "" {
+			return fmt.Errorf("--output %s requires --target-registry", cmd.OutputOrasCopyScript)
+		}
+		if opts.ociLayout || opts.subject || opts.pushTime {
+			return fmt.Errorf("--output %s cannot be used with --oci-layout, --subject or --push-time", cmd.OutputOrasCopyScript)
+		}
+	default:
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	return nil
+}
+
+// printOrasCopyScript prints a script copying the artifact and its signatures
+// to the target registry of opts.
+func printOrasCopyScript(ctx context.Context, opts *listOpts, sigRepo notationregistry.Repository, manifestDesc ocispec.Descriptor, artifact string, filter func(ocispec.Descriptor) bool) error {
+	ref, err := registry.ParseReference(artifact)
+	if err != nil {
+		return err
+	}
+	sigManifests, err := listSignatureManifests(ctx, sigRepo, manifestDesc, filter)
+	if err != nil {
+		return err
+	}
+	if len(sigManifests) == 0 && opts.failIfNone {
+		return fmt.Errorf("no signatures associated with %s", artifact)
+	}
+	return writeOrasCopyScript(os.Stdout, ref, sigManifests, opts.targetRegistry, opts.PlainHTTP)
+}
+
+// getListTarget returns the signature repository and the manifest
+// descriptor of the artifact to list signatures of, along with the digest
+// reference of the artifact. If pushTimes is not nil, the requests to the
+// registry are sent with it to record push times.
+func getListTarget(ctx context.Context, opts *listOpts, pushTimes *pushTimeClient) (notationregistry.Repository, ocispec.Descriptor, string, error) {
+	if opts.ociLayout {
+		layoutPath, reference, err := parseOCILayoutReference(opts.reference)
+		if err != nil {
+			return nil, ocispec.Descriptor{}, "", err
+		}
+		layout, err := newOCILayoutRepository(ctx, layoutPath)
+		if err != nil {
+			return nil, ocispec.Descriptor{}, "", err
+		}
+		sigRepo := withListArtifactType(notationregistry.NewRepository(layout), layout, opts.artifactType)
+		manifestDesc, err := sigRepo.Resolve(ctx, reference)
+		if err != nil {
+			return nil, ocispec.Descriptor{}, "", err
+		}
+		return sigRepo, manifestDesc, layoutPath + "@" + manifestDesc.Digest.String(), nil
+	}
+
+	ref, err := registry.ParseReference(opts.reference)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, "", err
+	}
+	remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, ref)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, "", err
+	}
+	if pushTimes != nil {
+		pushTimes.Client = remoteRepo.Client
+		remoteRepo.Client = pushTimes
+	}
+	sigRepo := withListArtifactType(notationregistry.NewRepository(remoteRepo), remoteRepo, opts.artifactType)
+	manifestDesc, ref, err := getManifestDescriptor(ctx, &opts.SecureFlagOpts, opts.reference, sigRepo)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, "", err
+	}
 	ref.Reference = manifestDesc.Digest.String()
+	return sigRepo, manifestDesc, ref.String(), nil
+}
+
+// withListArtifactType returns sigRepo listing the referrers of artifactType
+// with lister, or sigRepo itself if artifactType is empty.
+func withListArtifactType(sigRepo notationregistry.Repository, lister referrerLister, artifactType string) notationregistry.Repository {
+	if artifactType == "" {
+		return sigRepo
+	}
+	return &referrersRepository{Repository: sigRepo, lister: lister, artifactType: artifactType}
+}
+
+// printSignatureManifestDigests prints the signature manifest digests of
+// the subject manifest under the artifact reference and returns the number of
+// signatures printed, listed under artifactType. If filter is not nil, only
+// signatures accepted by filter are printed. If describe is not nil, its
+// result is appended to the digest of each signature.
+func printSignatureManifestDigests(ctx context.Context, manifestDesc ocispec.Descriptor, sigRepo notationregistry.Repository, artifact, artifactType string, filter func(ocispec.Descriptor) bool, describe func(ocispec.Descriptor) string) (int, error) {
 	titlePrinted := false
 	printTitle := func() {
 		if !titlePrinted {
-			fmt.Println(ref)
-			fmt.Printf("└── %s\n", notationregistry.ArtifactTypeNotation)
+			fmt.Println(artifact)
+			fmt.Printf("└── %s\n", artifactType)
 			titlePrinted = true
 		}
 	}
 
-	var prevDigest digest.Digest
+	var prevSignature string
+	count := 0
 	err := sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
 		for _, sigManifestDesc := range signatureManifests {
-			if prevDigest != "" {
+			if filter != nil && !filter(sigManifestDesc) {
+				continue
+			}
+			count++
+			if prevSignature != "" {
 				// check and print title
 				printTitle()
 
 				// print each signature digest
-				fmt.Printf("    ├── %s\n", prevDigest)
+				fmt.Printf("    ├── %s\n", prevSignature)
+			}
+			prevSignature = sigManifestDesc.Digest.String()
+			if describe != nil {
+				prevSignature += describe(sigManifestDesc)
 			}
-			prevDigest = sigManifestDesc.Digest
 		}
 		return nil
 	})
 
 	if err != nil {
-		return err
+		return 0, err
 	}
 
-	if prevDigest != "" {
+	if prevSignature != "" {
 		// check and print title
 		printTitle()
 
 		// print last signature digest
-		fmt.Printf("    └── %s\n", prevDigest)
+		fmt.Printf("    └── %s\n", prevSignature)
 	}
-	return nil
+	return count, nil
+}
+
+// signingTimeFilter returns a filter accepting signatures signed before
+// now - olderThan and after now - newerThan. A zero duration disables the
+// respective bound. Signatures that cannot be fetched or parsed are skipped.
+func signingTimeFilter(ctx context.Context, sigRepo notationregistry.Repository, now time.Time, olderThan, newerThan time.Duration) func(ocispec.Descriptor) bool {
+	return func(sigManifestDesc ocispec.Descriptor) bool {
+		envelopeContent, err := fetchEnvelopeContent(ctx, sigRepo, sigManifestDesc)
+		if err != nil {
+			logSkippedSignature(sigManifestDesc, err)
+			return false
+		}
+		return signingTimeInRange(envelopeContent.SignerInfo.SignedAttributes.SigningTime, now, olderThan, newerThan)
+	}
+}
+
+// signingTimeInRange reports whether signingTime is older than olderThan and
+// newer than newerThan, relative to now.
+func signingTimeInRange(signingTime, now time.Time, olderThan, newerThan time.Duration) bool {
+	if olderThan > 0 && !signingTime.Before(now.Add(-olderThan)) {
+		return false
+	}
+	if newerThan > 0 && !signingTime.After(now.Add(-newerThan)) {
+		return false
+	}
+	return true
+}
+
+// signatureSubjectDescriber returns a function describing the subject each
+// signature attests to, as signed in its envelope. Signatures attesting to an
+// artifact other than subject are flagged with a warning.
+func signatureSubjectDescriber(ctx context.Context, sigRepo notationregistry.Repository, subject ocispec.Descriptor) func(ocispec.Descriptor) string {
+	return func(sigManifestDesc ocispec.Descriptor) string {
+		signedDesc, err := signedSubject(ctx, sigRepo, sigManifestDesc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to decode the subject of signature %s: %v\n", sigManifestDesc.Digest, err)
+			return " (subject: unknown)"
+		}
+		if signedDesc.Digest != subject.Digest {
+			fmt.Fprintf(os.Stderr, "Warning: Signature %s attests to %s, not to the artifact %s\n", sigManifestDesc.Digest, signedDesc.Digest, subject.Digest)
+			return fmt.Sprintf(" (subject: %s, MISMATCH)", signedDesc.Digest)
+		}
+		return fmt.Sprintf(" (subject: %s)", signedDesc.Digest)
+	}
+}
+
+// signedSubject returns the descriptor of the artifact signed by the
+// signature. The descriptor is decoded without verifying the signature.
+func signedSubject(ctx context.Context, sigRepo notationregistry.Repository, sigManifestDesc ocispec.Descriptor) (*ocispec.Descriptor, error) {
+	envelopeContent, err := fetchEnvelopeContent(ctx, sigRepo, sigManifestDesc)
+	if err != nil {
+		return nil, err
+	}
+	return envelope.DescriptorFromSignaturePayload(&envelopeContent.Payload)
+}
+
+// fetchEnvelopeContent fetches and decodes the signature envelope of the
+// signature manifest.
+func fetchEnvelopeContent(ctx context.Context, sigRepo notationregistry.Repository, sigManifestDesc ocispec.Descriptor) (*signature.EnvelopeContent, error) {
+	sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+	if err != nil {
+		return nil, err
+	}
+	sigEnvelope, err := signature.ParseEnvelope(sigDesc.MediaType, sigBlob)
+	if err != nil {
+		return nil, err
+	}
+	return sigEnvelope.Content()
 }
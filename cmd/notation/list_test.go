@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation/internal/cmd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestListCommand_SecretsFromArgs(t *testing.T) {
 	opts := &listOpts{}
-	cmd := listCommand(opts)
 	expected := &listOpts{
 		reference: "ref",
 		SecureFlagOpts: SecureFlagOpts{
@@ -14,18 +21,22 @@ func TestListCommand_SecretsFromArgs(t *testing.T) {
 			PlainHTTP: true,
 			Username:  "user",
 		},
+		failIfNone:   true,
+		outputFormat: cmd.OutputPlaintext,
 	}
+	cmd := listCommand(opts)
 	if err := cmd.ParseFlags([]string{
 		"--password", expected.Password,
 		expected.reference,
 		"-u", expected.Username,
-		"--plain-http"}); err != nil {
+		"--plain-http",
+		"--fail-if-none"}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
 	}
 	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect list opts: %v, got: %v", expected, opts)
 	}
 }
@@ -40,6 +51,7 @@ func TestListCommand_SecretsFromEnv(t *testing.T) {
 			Password: "password",
 			Username: "user",
 		},
+		outputFormat: cmd.OutputPlaintext,
 	}
 	cmd := listCommand(opts)
 	if err := cmd.ParseFlags([]string{
@@ -49,7 +61,7 @@ func TestListCommand_SecretsFromEnv(t *testing.T) {
 	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Parse Args failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect list opts: %v, got: %v", expected, opts)
 	}
 }
@@ -63,3 +75,186 @@ func TestListCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestListCommand_SigningTimeFilters(t *testing.T) {
+	opts := &listOpts{}
+	expected := &listOpts{
+		reference:    "ref",
+		olderThan:    720 * time.Hour,
+		newerThan:    8760 * time.Hour,
+		outputFormat: cmd.OutputPlaintext,
+	}
+	cmd := listCommand(opts)
+	if err := cmd.ParseFlags([]string{
+		expected.reference,
+		"--older-than", "720h",
+		"--newer-than", "8760h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect list opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestSigningTimeInRange(t *testing.T) {
+	now := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		signingTime time.Time
+		olderThan   time.Duration
+		newerThan   time.Duration
+		want        bool
+	}{
+		{name: "no bounds", signingTime: now, want: true},
+		{name: "older", signingTime: now.Add(-48 * time.Hour), olderThan: 24 * time.Hour, want: true},
+		{name: "not older", signingTime: now.Add(-time.Hour), olderThan: 24 * time.Hour, want: false},
+		{name: "newer", signingTime: now.Add(-time.Hour), newerThan: 24 * time.Hour, want: true},
+		{name: "not newer", signingTime: now.Add(-48 * time.Hour), newerThan: 24 * time.Hour, want: false},
+		{name: "within both", signingTime: now.Add(-48 * time.Hour), olderThan: 24 * time.Hour, newerThan: 72 * time.Hour, want: true},
+		{name: "outside both", signingTime: now.Add(-96 * time.Hour), olderThan: 24 * time.Hour, newerThan: 72 * time.Hour, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signingTimeInRange(tt.signingTime, now, tt.olderThan, tt.newerThan); got != tt.want {
+				t.Fatalf("signingTimeInRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type envelopeRepository struct {
+	mockRepository
+	blob []byte
+}
+
+func (r *envelopeRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	return r.blob, ocispec.Descriptor{MediaType: jws.MediaTypeEnvelope}, nil
+}
+
+func TestSignatureSubjectDescriber(t *testing.T) {
+	ctx := context.Background()
+	sig, _ := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+	sigRepo := &envelopeRepository{blob: sig}
+
+	// the test signature attests to an empty descriptor
+	describe := signatureSubjectDescriber(ctx, sigRepo, ocispec.Descriptor{})
+	if got, want := describe(ocispec.Descriptor{}), " (subject: )"; got != want {
+		t.Fatalf("describe() = %q, want %q", got, want)
+	}
+
+	describe = signatureSubjectDescriber(ctx, sigRepo, ocispec.Descriptor{Digest: "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"})
+	if got, want := describe(ocispec.Descriptor{}), " (subject: , MISMATCH)"; got != want {
+		t.Fatalf("describe() = %q, want %q", got, want)
+	}
+
+	describe = signatureSubjectDescriber(ctx, &envelopeRepository{blob: []byte("invalid")}, ocispec.Descriptor{})
+	if got, want := describe(ocispec.Descriptor{}), " (subject: unknown)"; got != want {
+		t.Fatalf("describe() = %q, want %q", got, want)
+	}
+}
+
+func TestValidateListArtifactType(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    listOpts
+		wantErr bool
+	}{
+		{name: "no artifact type", opts: listOpts{subject: true}},
+		{name: "artifact type", opts: listOpts{artifactType: "application/spdx+json"}},
+		{name: "notation artifact type with signature flags", opts: listOpts{artifactType: "application/vnd.cncf.notary.signature", subject: true, olderThan: time.Hour}},
+		{name: "invalid artifact type", opts: listOpts{artifactType: "spdx"}, wantErr: true},
+		{name: "artifact type with subject", opts: listOpts{artifactType: "application/spdx+json", subject: true}, wantErr: true},
+		{name: "artifact type with signing time filter", opts: listOpts{artifactType: "application/spdx+json", newerThan: time.Hour}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateListArtifactType(&tt.opts); (err != nil) != tt.wantErr {
+				t.Fatalf("validateListArtifactType() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateListPushTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    listOpts
+		wantErr bool
+	}{
+		{name: "no push time flags", opts: listOpts{ociLayout: true}},
+		{name: "push time", opts: listOpts{pushTime: true}},
+		{name: "pushed within", opts: listOpts{pushedAfter: "2023-01-01T00:00:00Z", pushedBefore: "2023-02-01T00:00:00Z"}},
+		{name: "empty range", opts: listOpts{pushedAfter: "2023-02-01T00:00:00Z", pushedBefore: "2023-01-01T00:00:00Z"}, wantErr: true},
+		{name: "invalid time", opts: listOpts{pushedBefore: "2023-01-01"}, wantErr: true},
+		{name: "oci layout", opts: listOpts{ociLayout: true, pushTime: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateListPushTime(&tt.opts); (err != nil) != tt.wantErr {
+				t.Fatalf("validateListPushTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+type signatureListRepository struct {
+	mockRepository
+	signatures []ocispec.Descriptor
+}
+
+func (r *signatureListRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	return fn(r.signatures)
+}
+
+func TestListSignatures_FailIfNone(t *testing.T) {
+	ctx := context.Background()
+	artifact := "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	signature := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: "sha256:6f4b2b5a8f9e6c1d0e0e3e6b9f3b5d6d9d0c4d8b4b6e0a1c9f6a0b7c8d9e0f1a", Size: 728}
+	tests := []struct {
+		name       string
+		opts       listOpts
+		signatures []ocispec.Descriptor
+		wantErr    string
+	}{
+		{name: "no signatures", opts: listOpts{failIfNone: true}, wantErr: "no signatures associated with " + artifact},
+		{name: "no referrers of artifact type", opts: listOpts{failIfNone: true, artifactType: "application/spdx+json"}, wantErr: `no referrers with artifact type "application/spdx+json"`},
+		{name: "no signatures without --fail-if-none", opts: listOpts{}},
+		{name: "signatures", opts: listOpts{failIfNone: true}, signatures: []ocispec.Descriptor{signature}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sigRepo := &signatureListRepository{signatures: tt.signatures}
+			err := listSignatures(ctx, &tt.opts, sigRepo, ocispec.Descriptor{}, artifact, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("listSignatures() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("listSignatures() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintSignatureManifestDigests_Count(t *testing.T) {
+	ctx := context.Background()
+	signatures := []ocispec.Descriptor{
+		{MediaType: ocispec.MediaTypeImageManifest, Digest: "sha256:6f4b2b5a8f9e6c1d0e0e3e6b9f3b5d6d9d0c4d8b4b6e0a1c9f6a0b7c8d9e0f1a", Size: 728},
+		{MediaType: ocispec.MediaTypeImageManifest, Digest: "sha256:647039638efb22a021f59675c9449dd09956c981a44b82c1ff074513c2c9f273", Size: 728},
+	}
+	for _, want := range []int{0, len(signatures)} {
+		sigRepo := &signatureListRepository{signatures: signatures[:want]}
+		count, err := printSignatureManifestDigests(ctx, ocispec.Descriptor{}, sigRepo, "localhost:5000/net-monitor:v1", "application/vnd.cncf.notary.signature", nil, nil)
+		if err != nil {
+			t.Fatalf("printSignatureManifestDigests() error = %v", err)
+		}
+		if count != want {
+			t.Fatalf("printSignatureManifestDigests() = %d, want %d", count, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"os"
+	"reflect"
 	"testing"
 )
 
@@ -29,7 +30,7 @@ func TestLoginCommand_PasswordFromArgs(t *testing.T) {
 	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Get password failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect login opts: %v, got: %v", expected, opts)
 	}
 }
@@ -73,7 +74,7 @@ func TestLogin_PasswordFromStdin(t *testing.T) {
 	if err := cmd.PreRunE(cmd, cmd.Flags().Args()); err != nil {
 		t.Fatalf("Read password from stdin failed: %v", err)
 	}
-	if *opts != *expected {
+	if !reflect.DeepEqual(opts, expected) {
 		t.Fatalf("Expect login opts: %+v, got: %+v", expected, opts)
 	}
 }
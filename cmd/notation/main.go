@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/notaryproject/notation/cmd/notation/cert"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
 	"github.com/notaryproject/notation/cmd/notation/policy"
 	"github.com/spf13/cobra"
 )
@@ -26,8 +27,10 @@ func main() {
 		logoutCommand(nil),
 		versionCommand(),
 		inspectCommand(nil),
+		registryCommand(),
+		blobCommand(),
 	)
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		os.Exit(notationerrors.ExitCode(err))
 	}
 }
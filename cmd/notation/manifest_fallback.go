@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+// errorCodeUnsupported is the distribution spec error code returned for
+// unsupported operations.
+const errorCodeUnsupported = "UNSUPPORTED"
+
+// fallbackRepository pushes signatures using OCI artifact manifest, and
+// retries using OCI image manifest if the registry rejects OCI artifact
+// manifests.
+type fallbackRepository struct {
+	notationregistry.Repository
+	image notationregistry.Repository
+}
+
+// PushSignature pushes the signature using OCI artifact manifest, falling
+// back to OCI image manifest if OCI artifact manifest is not supported.
+func (r *fallbackRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	blobDesc, manifestDesc, err = r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+	if err == nil || !isArtifactManifestUnsupported(err) {
+		return blobDesc, manifestDesc, err
+	}
+	fmt.Fprintf(os.Stderr, "Warning: Target registry does not support OCI artifact manifest, falling back to OCI image manifest to store the signature: %v\n", err)
+	return r.image.PushSignature(ctx, mediaType, blob, subject, annotations)
+}
+
+// pushErrorRepository keeps the error of pushing the signature, which
+// notation.Sign reports only as a message, so that its cause can be
+// inspected.
+type pushErrorRepository struct {
+	notationregistry.Repository
+	err error
+}
+
+// PushSignature pushes the signature and keeps the error, if any.
+func (r *pushErrorRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	blobDesc, manifestDesc, err = r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+	r.err = err
+	return blobDesc, manifestDesc, err
+}
+
+// getSignatureRepositoryForSignAuto returns a repository for Sign that
+// stores signatures using OCI artifact manifest where the target registry
+// supports it, and OCI image manifest otherwise. ociImageManifest is true if
+// the registry is known not to support OCI artifact manifest before pushing.
+func getSignatureRepositoryForSignAuto(ctx context.Context, opts *SecureFlagOpts, reference string) (sigRepo notationregistry.Repository, ociImageManifest bool, err error) {
+	imageRepo, err := getSignatureRepositoryForSign(ctx, opts, reference, true)
+	if err != nil {
+		return nil, false, err
+	}
+	artifactRepo, err := getSignatureRepositoryForSign(ctx, opts, reference, false)
+	if err != nil {
+		var errorReferrersAPINotSupported notationerrors.ErrorReferrersAPINotSupported
+		if !errors.As(err, &errorReferrersAPINotSupported) {
+			return nil, false, err
+		}
+		fmt.Fprintln(os.Stderr, "Warning: Target registry does not support the Referrers API, falling back to OCI image manifest to store the signature")
+		return imageRepo, true, nil
+	}
+	return &fallbackRepository{Repository: artifactRepo, image: imageRepo}, false, nil
+}
+
+// isArtifactManifestUnsupported reports whether err is returned by a registry
+// rejecting an OCI artifact manifest for its media type.
+func isArtifactManifestUnsupported(err error) bool {
+	var errResp *errcode.ErrorResponse
+	if !errors.As(err, &errResp) {
+		return false
+	}
+	switch errResp.StatusCode {
+	case http.StatusUnsupportedMediaType:
+		return true
+	case http.StatusBadRequest, http.StatusMethodNotAllowed:
+		return isErrorCode(errResp, errcode.ErrorCodeManifestInvalid) || isErrorCode(errResp, errorCodeUnsupported)
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+type failingPushRepository struct {
+	mockRepository
+	err    error
+	pushed bool
+}
+
+func (r *failingPushRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	r.pushed = true
+	return ocispec.Descriptor{}, ocispec.Descriptor{}, r.err
+}
+
+func manifestInvalidError() error {
+	return &errcode.ErrorResponse{
+		Method:     http.MethodPut,
+		StatusCode: http.StatusBadRequest,
+		Errors:     errcode.Errors{{Code: errcode.ErrorCodeManifestInvalid, Message: "manifest invalid"}},
+	}
+}
+
+func TestFallbackRepository_PushSignature(t *testing.T) {
+	ctx := context.Background()
+	t.Run("fall back to image manifest", func(t *testing.T) {
+		image := &failingPushRepository{}
+		repo := &fallbackRepository{
+			Repository: &failingPushRepository{err: manifestInvalidError()},
+			image:      image,
+		}
+		if _, _, err := repo.PushSignature(ctx, "", nil, ocispec.Descriptor{}, nil); err != nil {
+			t.Fatalf("PushSignature() error = %v", err)
+		}
+		if !image.pushed {
+			t.Fatal("expected signature to be pushed using image manifest")
+		}
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		pushErr := errors.New("connection reset")
+		image := &failingPushRepository{}
+		repo := &fallbackRepository{
+			Repository: &failingPushRepository{err: pushErr},
+			image:      image,
+		}
+		if _, _, err := repo.PushSignature(ctx, "", nil, ocispec.Descriptor{}, nil); err != pushErr {
+			t.Fatalf("PushSignature() error = %v, want %v", err, pushErr)
+		}
+		if image.pushed {
+			t.Fatal("expected no fallback to image manifest")
+		}
+	})
+}
+
+func TestIsArtifactManifestUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "manifest invalid", err: manifestInvalidError(), want: true},
+		{name: "unsupported media type", err: &errcode.ErrorResponse{StatusCode: http.StatusUnsupportedMediaType}, want: true},
+		{name: "unauthorized", err: &errcode.ErrorResponse{StatusCode: http.StatusUnauthorized}},
+		{name: "bad request with other code", err: &errcode.ErrorResponse{StatusCode: http.StatusBadRequest, Errors: errcode.Errors{{Code: errcode.ErrorCodeDigestInvalid}}}},
+		{name: "not a registry error", err: errors.New("connection reset")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isArtifactManifestUnsupported(tt.err); got != tt.want {
+				t.Fatalf("isArtifactManifestUnsupported() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignReference_ArtifactManifestRejected(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	subject := digest.FromBytes(manifest)
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		wantExitCode int
+	}{
+		{name: "unsupported media type", status: http.StatusUnsupportedMediaType, wantExitCode: notationerrors.ExitCodeArtifactManifestNotSupported},
+		{name: "manifest invalid", status: http.StatusBadRequest, body: `{"errors":[{"code":"MANIFEST_INVALID","message":"manifest invalid"}]}`, wantExitCode: notationerrors.ExitCodeArtifactManifestNotSupported},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `{"errors":[{"code":"UNAUTHORIZED","message":"authentication required"}]}`, wantExitCode: notationerrors.ExitCodeFailure},
+		{name: "forbidden", status: http.StatusForbidden, body: `{"errors":[{"code":"DENIED","message":"requested access to the resource is denied"}]}`, wantExitCode: notationerrors.ExitCodeFailure},
+		{name: "server error", status: http.StatusServiceUnavailable, wantExitCode: notationerrors.ExitCodeFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch {
+				case r.Method == http.MethodHead && r.URL.Path == "/v2/net-monitor/manifests/"+subject.String():
+					w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+					w.Header().Set("Docker-Content-Digest", subject.String())
+					w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+				case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/net-monitor/referrers/"):
+					w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+					w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`))
+				case r.Method == http.MethodPost && r.URL.Path == "/v2/net-monitor/blobs/uploads/":
+					w.Header().Set("Location", "/v2/net-monitor/blobs/uploads/upload")
+					w.WriteHeader(http.StatusAccepted)
+				case r.Method == http.MethodPut && r.URL.Path == "/v2/net-monitor/blobs/uploads/upload":
+					w.WriteHeader(http.StatusCreated)
+				case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v2/net-monitor/manifests/"):
+					if tt.body != "" {
+						w.Header().Set("Content-Type", "application/json")
+					}
+					w.WriteHeader(tt.status)
+					w.Write([]byte(tt.body))
+				default:
+					t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer ts.Close()
+			u, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+
+			opts := newBatchTestSignOpts(1, false)
+			opts.reference = u.Host + "/net-monitor@" + subject.String()
+			opts.signatureManifest = signatureManifestArtifact
+			err = signReference(context.Background(), io.Discard, opts, &batchSigner{}, &signResult{Reference: opts.reference})
+			if err == nil {
+				t.Fatal("signReference() expected error, but got nil")
+			}
+			if got := notationerrors.ExitCode(err); got != tt.wantExitCode {
+				t.Fatalf("ExitCode() = %d, want %d for error %v", got, tt.wantExitCode, err)
+			}
+		})
+	}
+}
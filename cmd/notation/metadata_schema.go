@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxMetadataSchemaSize is the maximum size of a user metadata schema file.
+const maxMetadataSchemaSize = 1024 * 1024 // 1 MiB
+
+// metadataSchema is a JSON-schema-like specification of user metadata.
+//
+// Example:
+//
+//	{
+//	    "required": ["buildId"],
+//	    "properties": {
+//	        "buildId": {"pattern": "^[0-9]+$"},
+//	        "team": {}
+//	    },
+//	    "additionalProperties": false
+//	}
+type metadataSchema struct {
+	// Required lists the keys that must be present.
+	Required []string `json:"required"`
+
+	// Properties specifies the constraints of known keys.
+	Properties map[string]metadataProperty `json:"properties"`
+
+	// AdditionalProperties specifies whether keys not listed in Properties
+	// are allowed. Defaults to true.
+	AdditionalProperties *bool `json:"additionalProperties"`
+
+	patterns map[string]*regexp.Regexp
+}
+
+// metadataProperty specifies the constraints of a user metadata value.
+type metadataProperty struct {
+	// Pattern is a regular expression the value must match.
+	Pattern string `json:"pattern"`
+}
+
+// loadMetadataSchema reads and compiles the user metadata schema at path.
+func loadMetadataSchema(path string) (*metadataSchema, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata schema: %w", err)
+	}
+	if info.Size() > maxMetadataSchemaSize {
+		return nil, fmt.Errorf("metadata schema %s is too large, exceeding %d bytes", path, maxMetadataSchemaSize)
+	}
+	schemaJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata schema: %w", err)
+	}
+	return parseMetadataSchema(schemaJSON)
+}
+
+// parseMetadataSchema parses and compiles a user metadata schema.
+func parseMetadataSchema(schemaJSON []byte) (*metadataSchema, error) {
+	var schema metadataSchema
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata schema: %w", err)
+	}
+	schema.patterns = make(map[string]*regexp.Regexp)
+	for key, property := range schema.Properties {
+		if property.Pattern == "" {
+			continue
+		}
+		pattern, err := regexp.Compile(property.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern of metadata key %q in metadata schema: %w", key, err)
+		}
+		schema.patterns[key] = pattern
+	}
+	return &schema, nil
+}
+
+// Validate returns an error listing all violations of the schema by the user
+// metadata.
+func (s *metadataSchema) Validate(metadata map[string]string) error {
+	var violations []string
+	for _, key := range s.Required {
+		if _, ok := metadata[key]; !ok {
+			violations = append(violations, fmt.Sprintf("required key %q is missing", key))
+		}
+	}
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := s.Properties[key]; !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				violations = append(violations, fmt.Sprintf("key %q is not allowed", key))
+			}
+			continue
+		}
+		if pattern, ok := s.patterns[key]; ok && !pattern.MatchString(metadata[key]) {
+			violations = append(violations, fmt.Sprintf("value %q of key %q does not match pattern %q", metadata[key], key, pattern))
+		}
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("user metadata does not match the metadata schema: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
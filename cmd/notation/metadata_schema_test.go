@@ -0,0 +1,54 @@
+package main
+
+import (
+	"testing"
+)
+
+const testMetadataSchema = `{
+    "required": ["buildId"],
+    "properties": {
+        "buildId": {"pattern": "^[0-9]+$"},
+        "team": {}
+    },
+    "additionalProperties": false
+}`
+
+func TestMetadataSchema_Validate(t *testing.T) {
+	schema, err := parseMetadataSchema([]byte(testMetadataSchema))
+	if err != nil {
+		t.Fatalf("parseMetadataSchema() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{name: "valid", metadata: map[string]string{"buildId": "42", "team": "a"}},
+		{name: "missing required key", metadata: map[string]string{"team": "a"}, wantErr: true},
+		{name: "pattern mismatch", metadata: map[string]string{"buildId": "42a"}, wantErr: true},
+		{name: "additional key", metadata: map[string]string{"buildId": "42", "taem": "a"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := schema.Validate(tt.metadata); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetadataSchema_AdditionalPropertiesDefault(t *testing.T) {
+	schema, err := parseMetadataSchema([]byte(`{"properties": {"buildId": {}}}`))
+	if err != nil {
+		t.Fatalf("parseMetadataSchema() error = %v", err)
+	}
+	if err := schema.Validate(map[string]string{"team": "a"}); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+}
+
+func TestParseMetadataSchema_InvalidPattern(t *testing.T) {
+	if _, err := parseMetadataSchema([]byte(`{"properties": {"buildId": {"pattern": "("}}}`)); err == nil {
+		t.Fatal("parseMetadataSchema() expected error for invalid pattern, but got nil")
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+)
+
+// errReadOnlyOCILayout is returned when pushing content to an OCI image
+// layout opened read-only.
+var errReadOnlyOCILayout = errors.New("the OCI layout is opened read-only")
+
+// ociLayoutStore is the storage of an OCI image layout, either writable or
+// read-only.
+type ociLayoutStore interface {
+	content.ReadOnlyGraphStorage
+	content.Resolver
+	registry.TagLister
+}
+
+// ociLayoutRepository adapts an OCI image layout directory to
+// registry.Repository. Every pushed manifest is recorded in index.json by its
+// digest, and referrers are looked up from the graph rebuilt from index.json
+// when the layout is opened. Signatures pushed by earlier invocations are
+// therefore kept alongside new ones, as they are in a remote registry.
+type ociLayoutRepository struct {
+	ociLayoutStore
+}
+
+// newOCILayoutRepository opens the OCI image layout at path, which must be an
+// existing directory.
+func newOCILayoutRepository(ctx context.Context, path string) (*ociLayoutRepository, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", path, err)
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("failed to open OCI layout %s: not a directory", path)
+	}
+	store, err := oci.NewWithContext(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", path, err)
+	}
+	return &ociLayoutRepository{ociLayoutStore: store}, nil
+}
+
+// newReadOnlyOCILayoutRepository opens the OCI image layout at path, either
+// a directory or a tar archive of a directory, without writing to it.
+func newReadOnlyOCILayoutRepository(ctx context.Context, path string) (*ociLayoutRepository, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", path, err)
+	}
+	var store *oci.ReadOnlyStore
+	if fi.IsDir() {
+		store, err = oci.NewFromFS(ctx, os.DirFS(path))
+	} else {
+		store, err = oci.NewFromTar(ctx, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OCI layout %s: %w", path, err)
+	}
+	return &ociLayoutRepository{ociLayoutStore: store}, nil
+}
+
+// parseOCILayoutReference splits a reference to an artifact in an OCI image
+// layout, in the format <path>@<digest> or <path>:<tag>, into the layout path
+// and the digest or tag.
+func parseOCILayoutReference(raw string) (string, string, error) {
+	path, reference := raw, ""
+	if idx := strings.LastIndex(raw, "@"); idx != -1 {
+		path, reference = raw[:idx], raw[idx+1:]
+	} else if idx := strings.LastIndex(raw, ":"); idx != -1 && idx > strings.LastIndexAny(raw, `/\`) {
+		path, reference = raw[:idx], raw[idx+1:]
+	}
+	if path == "" {
+		return "", "", fmt.Errorf("invalid reference %q: missing OCI layout path", raw)
+	}
+	if reference == "" {
+		return "", "", fmt.Errorf("invalid reference %q: missing digest or tag", raw)
+	}
+	return path, reference, nil
+}
+
+// Blobs returns the layout itself, which stores blobs and manifests together.
+func (r *ociLayoutRepository) Blobs() registry.BlobStore {
+	return r
+}
+
+// Manifests returns the layout itself, which stores blobs and manifests
+// together.
+func (r *ociLayoutRepository) Manifests() registry.ManifestStore {
+	return r
+}
+
+// Push pushes the content to the layout, unless it is read-only.
+func (r *ociLayoutRepository) Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error {
+	pusher, ok := r.ociLayoutStore.(interface {
+		Push(ctx context.Context, expected ocispec.Descriptor, content io.Reader) error
+	})
+	if !ok {
+		return errReadOnlyOCILayout
+	}
+	return pusher.Push(ctx, expected, content)
+}
+
+// Tag tags the content in the layout, unless it is read-only.
+func (r *ociLayoutRepository) Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error {
+	tagger, ok := r.ociLayoutStore.(interface {
+		Tag(ctx context.Context, desc ocispec.Descriptor, reference string) error
+	})
+	if !ok {
+		return errReadOnlyOCILayout
+	}
+	return tagger.Tag(ctx, desc, reference)
+}
+
+// Delete is not supported by OCI image layouts.
+func (r *ociLayoutRepository) Delete(ctx context.Context, target ocispec.Descriptor) error {
+	return errors.New("deleting content from an OCI layout is not supported")
+}
+
+// FetchReference fetches the manifest identified by a digest or tag.
+func (r *ociLayoutRepository) FetchReference(ctx context.Context, reference string) (ocispec.Descriptor, io.ReadCloser, error) {
+	desc, err := r.Resolve(ctx, reference)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	rc, err := r.Fetch(ctx, desc)
+	if err != nil {
+		return ocispec.Descriptor{}, nil, err
+	}
+	return desc, rc, nil
+}
+
+// PushReference pushes the manifest and tags it with reference.
+func (r *ociLayoutRepository) PushReference(ctx context.Context, expected ocispec.Descriptor, content io.Reader, reference string) error {
+	if err := r.Push(ctx, expected, content); err != nil {
+		return err
+	}
+	return r.Tag(ctx, expected, reference)
+}
+
+// Referrers lists the manifests in the layout with desc as their subject,
+// filtered by artifactType if it is not empty.
+func (r *ociLayoutRepository) Referrers(ctx context.Context, desc ocispec.Descriptor, artifactType string, fn func(referrers []ocispec.Descriptor) error) error {
+	predecessors, err := r.Predecessors(ctx, desc)
+	if err != nil {
+		return err
+	}
+	var referrers []ocispec.Descriptor
+	for _, node := range predecessors {
+		referrer, ok, err := r.referrer(ctx, node, desc)
+		if err != nil {
+			return err
+		}
+		if !ok || (artifactType != "" && referrer.ArtifactType != artifactType) {
+			continue
+		}
+		referrers = append(referrers, referrer)
+	}
+	if len(referrers) == 0 {
+		return nil
+	}
+	return fn(referrers)
+}
+
+// referrer returns the descriptor of node as a referrer of subject, with the
+// artifact type and annotations of node. ok is false if node is not a
+// manifest with subject as its subject.
+func (r *ociLayoutRepository) referrer(ctx context.Context, node, subject ocispec.Descriptor) (referrer ocispec.Descriptor, ok bool, err error) {
+	if node.MediaType != ocispec.MediaTypeImageManifest && node.MediaType != ocispec.MediaTypeArtifactManifest {
+		return ocispec.Descriptor{}, false, nil
+	}
+	if node.Size > maxSignatureManifestSize {
+		return ocispec.Descriptor{}, false, nil
+	}
+	manifestJSON, err := content.FetchAll(ctx, r, node)
+	if err != nil {
+		return ocispec.Descriptor{}, false, err
+	}
+	var manifest struct {
+		ArtifactType string              `json:"artifactType"`
+		Config       ocispec.Descriptor  `json:"config"`
+		Subject      *ocispec.Descriptor `json:"subject"`
+		Annotations  map[string]string   `json:"annotations"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return ocispec.Descriptor{}, false, fmt.Errorf("failed to decode manifest %s: %w", node.Digest, err)
+	}
+	if manifest.Subject == nil || manifest.Subject.Digest != subject.Digest {
+		return ocispec.Descriptor{}, false, nil
+	}
+	referrer = ocispec.Descriptor{
+		MediaType:    node.MediaType,
+		Digest:       node.Digest,
+		Size:         node.Size,
+		ArtifactType: manifest.ArtifactType,
+		Annotations:  manifest.Annotations,
+	}
+	if node.MediaType == ocispec.MediaTypeImageManifest {
+		referrer.ArtifactType = manifest.Config.MediaType
+	}
+	return referrer, true, nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestParseOCILayoutReference(t *testing.T) {
+	tests := []struct {
+		name          string
+		raw           string
+		wantPath      string
+		wantReference string
+		wantErr       bool
+	}{
+		{name: "digest", raw: "hello-world@sha256:abc", wantPath: "hello-world", wantReference: "sha256:abc"},
+		{name: "tag", raw: "hello-world:v1", wantPath: "hello-world", wantReference: "v1"},
+		{name: "nested path with tag", raw: "./layouts/hello-world:v1", wantPath: "./layouts/hello-world", wantReference: "v1"},
+		{name: "missing tag", raw: "hello-world", wantErr: true},
+		{name: "colon in path", raw: "host:5000/hello-world", wantErr: true},
+		{name: "missing path", raw: "@sha256:abc", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, reference, err := parseOCILayoutReference(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseOCILayoutReference(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if path != tt.wantPath || reference != tt.wantReference {
+				t.Fatalf("parseOCILayoutReference(%q) = (%q, %q), want (%q, %q)", tt.raw, path, reference, tt.wantPath, tt.wantReference)
+			}
+		})
+	}
+}
+
+func TestNewOCILayoutRepository_NotExist(t *testing.T) {
+	if _, err := newOCILayoutRepository(context.Background(), t.TempDir()+"/not-exist"); err == nil {
+		t.Fatal("newOCILayoutRepository() expected error for missing layout, but got nil")
+	}
+}
+
+func TestOCILayoutRepository_SignTwice(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := oras.Pack(ctx, store, "application/vnd.example.test", nil, oras.PackOptions{PackImageManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, subject, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// each signature is pushed by a new repository, as by separate
+	// invocations of notation, with a different signing key
+	for _, ociImageManifest := range []bool{true, false} {
+		layout, err := newOCILayoutRepository(ctx, layoutPath)
+		if err != nil {
+			t.Fatalf("newOCILayoutRepository() error = %v", err)
+		}
+		sigRepo := notationregistry.NewRepositoryWithOptions(layout, notationregistry.RepositoryOptions{OCIImageManifest: ociImageManifest})
+		sig, _ := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+		if _, _, err := sigRepo.PushSignature(ctx, jws.MediaTypeEnvelope, sig, subject, nil); err != nil {
+			t.Fatalf("PushSignature() error = %v", err)
+		}
+	}
+
+	layout, err := newOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		t.Fatalf("newOCILayoutRepository() error = %v", err)
+	}
+	sigRepo := notationregistry.NewRepository(layout)
+	manifestDesc, err := sigRepo.Resolve(ctx, "v1")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	var signatures []ocispec.Descriptor
+	if err := sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		signatures = append(signatures, signatureManifests...)
+		return nil
+	}); err != nil {
+		t.Fatalf("ListSignatures() error = %v", err)
+	}
+	if len(signatures) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(signatures))
+	}
+	for _, sigManifestDesc := range signatures {
+		if _, _, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc); err != nil {
+			t.Fatalf("FetchSignatureBlob() error = %v", err)
+		}
+	}
+	count, err := printSignatureManifestDigests(ctx, manifestDesc, sigRepo, layoutPath+"@"+manifestDesc.Digest.String(), notationregistry.ArtifactTypeNotation, nil, nil)
+	if err != nil {
+		t.Fatalf("printSignatureManifestDigests() error = %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 signatures listed, got %d", count)
+	}
+}
+
+func TestReferrersRepository_ArtifactType(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := oras.Pack(ctx, store, "application/vnd.example.test", nil, oras.PackOptions{PackImageManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oras.Pack(ctx, store, "application/spdx+json", nil, oras.PackOptions{PackImageManifest: true, Subject: &subject}); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := newOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		t.Fatalf("newOCILayoutRepository() error = %v", err)
+	}
+	sig, _ := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+	if _, _, err := notationregistry.NewRepository(layout).PushSignature(ctx, jws.MediaTypeEnvelope, sig, subject, nil); err != nil {
+		t.Fatalf("PushSignature() error = %v", err)
+	}
+
+	for _, artifactType := range []string{"", "application/spdx+json", notationregistry.ArtifactTypeNotation} {
+		sigRepo := withListArtifactType(notationregistry.NewRepository(layout), layout, artifactType)
+		var referrers []ocispec.Descriptor
+		if err := sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+			referrers = append(referrers, signatureManifests...)
+			return nil
+		}); err != nil {
+			t.Fatalf("ListSignatures() with artifact type %q error = %v", artifactType, err)
+		}
+		want := artifactType
+		if want == "" {
+			want = notationregistry.ArtifactTypeNotation
+		}
+		if len(referrers) != 1 || referrers[0].ArtifactType != want {
+			t.Fatalf("ListSignatures() with artifact type %q = %v, want a single referrer with artifact type %q", artifactType, referrers, want)
+		}
+	}
+}
+
+func TestOCILayoutRepositoryForVerify(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := oras.Pack(ctx, store, "application/vnd.example.test", nil, oras.PackOptions{PackImageManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, subject, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := newOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		t.Fatalf("newOCILayoutRepository() error = %v", err)
+	}
+	sig, _ := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+	if _, _, err := notationregistry.NewRepository(layout).PushSignature(ctx, jws.MediaTypeEnvelope, sig, subject, nil); err != nil {
+		t.Fatalf("PushSignature() error = %v", err)
+	}
+	tarPath := filepath.Join(t.TempDir(), "hello-world.tar")
+	writeTestTar(t, layoutPath, tarPath)
+
+	for _, reference := range []string{
+		layoutPath + "@" + subject.Digest.String(),
+		layoutPath + ":v1",
+		tarPath + "@" + subject.Digest.String(),
+		tarPath + ":v1",
+	} {
+		t.Run(reference, func(t *testing.T) {
+			sigRepo, ref, err := ociLayoutRepositoryForVerify(ctx, reference, "local/hello-world")
+			if err != nil {
+				t.Fatalf("ociLayoutRepositoryForVerify() error = %v", err)
+			}
+			if want := "local/hello-world@" + subject.Digest.String(); ref.String() != want {
+				t.Fatalf("ociLayoutRepositoryForVerify() reference = %s, want %s", ref, want)
+			}
+			var signatures []ocispec.Descriptor
+			if err := sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+				signatures = append(signatures, signatureManifests...)
+				return nil
+			}); err != nil {
+				t.Fatalf("ListSignatures() error = %v", err)
+			}
+			if len(signatures) != 1 {
+				t.Fatalf("expected 1 signature, got %d", len(signatures))
+			}
+			if _, _, err := sigRepo.FetchSignatureBlob(ctx, signatures[0]); err != nil {
+				t.Fatalf("FetchSignatureBlob() error = %v", err)
+			}
+		})
+	}
+
+	if _, _, err := ociLayoutRepositoryForVerify(ctx, layoutPath+":v1", "local/hello-world:v1"); err == nil || !strings.Contains(err.Error(), "--scope") {
+		t.Fatalf("ociLayoutRepositoryForVerify() error = %v, want error of invalid --scope", err)
+	}
+	if _, _, err := ociLayoutRepositoryForVerify(ctx, layoutPath+":v2", "local/hello-world"); err == nil {
+		t.Fatal("ociLayoutRepositoryForVerify() expected error for unknown tag, but got nil")
+	}
+}
+
+func TestNewReadOnlyOCILayoutRepository(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	if _, err := oci.New(layoutPath); err != nil {
+		t.Fatal(err)
+	}
+	layout, err := newReadOnlyOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		t.Fatalf("newReadOnlyOCILayoutRepository() error = %v", err)
+	}
+	blob := []byte("hello")
+	desc := ocispec.Descriptor{MediaType: "application/octet-stream", Digest: digest.FromBytes(blob), Size: int64(len(blob))}
+	if err := layout.Push(ctx, desc, strings.NewReader(string(blob))); !errors.Is(err, errReadOnlyOCILayout) {
+		t.Fatalf("Push() error = %v, want %v", err, errReadOnlyOCILayout)
+	}
+	if err := layout.Tag(ctx, desc, "v1"); !errors.Is(err, errReadOnlyOCILayout) {
+		t.Fatalf("Tag() error = %v, want %v", err, errReadOnlyOCILayout)
+	}
+	if _, err := newReadOnlyOCILayoutRepository(ctx, filepath.Join(layoutPath, "not-exist")); err == nil {
+		t.Fatal("newReadOnlyOCILayoutRepository() expected error for missing layout, but got nil")
+	}
+}
+
+// writeTestTar archives the content of dir into a tarball at path.
+func writeTestTar(t *testing.T, dir, path string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tw := tar.NewWriter(f)
+	err = filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: filepath.ToSlash(rel), Mode: 0600, Size: info.Size()}); err != nil {
+			return err
+		}
+		src, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(tw, src)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// listSignatureManifests returns the signature manifests of the subject
+// manifest accepted by filter, or all of them if filter is nil.
+func listSignatureManifests(ctx context.Context, sigRepo notationregistry.Repository, manifestDesc ocispec.Descriptor, filter func(ocispec.Descriptor) bool) ([]ocispec.Descriptor, error) {
+	var sigManifests []ocispec.Descriptor
+	err := sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			if filter == nil || filter(sigManifestDesc) {
+				sigManifests = append(sigManifests, sigManifestDesc)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sigManifests, nil
+}
+
+// writeOrasCopyScript writes a shell script to w copying the artifact
+// identified by the digest reference artifact, followed by its signature
+// manifests sigManifests, to the same repository under targetRegistry with
+// `oras copy`. targetRegistry is a registry host, optionally followed by a
+// namespace, for example "registry.example.com/mirror".
+func writeOrasCopyScript(w io.Writer, artifact registry.Reference, sigManifests []ocispec.Descriptor, targetRegistry string, plainHTTP bool) error {
+	target, err := registry.ParseReference(strings.TrimSuffix(targetRegistry, "/") + "/" + artifact.Repository + "@" + artifact.Reference)
+	if err != nil {
+		return fmt.Errorf("invalid --target-registry %q: %w", targetRegistry, err)
+	}
+	copyCommand := "oras copy"
+	if plainHTTP {
+		copyCommand += " --from-plain-http"
+	}
+	fmt.Fprintln(w, "#!/bin/sh")
+	fmt.Fprintf(w, "# Copy %s and its %d signature(s) to %s/%s.\n", artifact, len(sigManifests), target.Registry, target.Repository)
+	fmt.Fprintln(w, "# Generated by \"notation list --output oras-copy-script\". Signatures are")
+	fmt.Fprintln(w, "# referrers of the artifact, so the artifact is copied first. Log in to both")
+	fmt.Fprintln(w, "# registries with \"oras login\" before running this script.")
+	fmt.Fprintln(w, "set -e")
+	fmt.Fprintf(w, "%s %s %s\n", copyCommand, shellQuote(artifact.String()), shellQuote(target.String()))
+	for _, sigManifestDesc := range sigManifests {
+		src, dst := artifact, target
+		src.Reference = sigManifestDesc.Digest.String()
+		dst.Reference = sigManifestDesc.Digest.String()
+		fmt.Fprintf(w, "%s %s %s\n", copyCommand, shellQuote(src.String()), shellQuote(dst.String()))
+	}
+	return nil
+}
+
+// shellQuote quotes s as a single word for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestWriteOrasCopyScript(t *testing.T) {
+	artifactDigest := digest.FromString("artifact")
+	sigDigest := digest.FromString("signature")
+	artifact := registry.Reference{Registry: "localhost:5000", Repository: "net-monitor", Reference: artifactDigest.String()}
+	sigManifests := []ocispec.Descriptor{{MediaType: ocispec.MediaTypeImageManifest, Digest: sigDigest}}
+
+	var buf bytes.Buffer
+	if err := writeOrasCopyScript(&buf, artifact, sigManifests, "registry.example.com/mirror/", true); err != nil {
+		t.Fatalf("writeOrasCopyScript() error = %v", err)
+	}
+	script := buf.String()
+	if !strings.HasPrefix(script, "#!/bin/sh\n") {
+		t.Fatalf("script does not start with a shebang:\n%s", script)
+	}
+	want := []string{
+		"oras copy --from-plain-http 'localhost:5000/net-monitor@" + artifactDigest.String() + "' 'registry.example.com/mirror/net-monitor@" + artifactDigest.String() + "'",
+		"oras copy --from-plain-http 'localhost:5000/net-monitor@" + sigDigest.String() + "' 'registry.example.com/mirror/net-monitor@" + sigDigest.String() + "'",
+	}
+	var got []string
+	for _, line := range strings.Split(script, "\n") {
+		if strings.HasPrefix(line, "oras copy") {
+			got = append(got, line)
+		}
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("copy commands = %q, want %q", got, want)
+	}
+
+	if err := writeOrasCopyScript(&buf, artifact, sigManifests, "Invalid Registry", false); err == nil {
+		t.Fatal("writeOrasCopyScript() expects error for an invalid target registry but got nil")
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	if got, want := shellQuote("it's"), `'it'\''s'`; got != want {
+		t.Fatalf("shellQuote() = %s, want %s", got, want)
+	}
+}
+
+func TestValidateListOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *listOpts
+		wantErr bool
+	}{
+		{name: "text", opts: &listOpts{outputFormat: "text"}},
+		{name: "script", opts: &listOpts{outputFormat: "oras-copy-script", targetRegistry: "registry.example.com"}},
+		{name: "script without target registry", opts: &listOpts{outputFormat: "oras-copy-script"}, wantErr: true},
+		{name: "target registry without script", opts: &listOpts{outputFormat: "text", targetRegistry: "registry.example.com"}, wantErr: true},
+		{name: "script with subject", opts: &listOpts{outputFormat: "oras-copy-script", targetRegistry: "registry.example.com", subject: true}, wantErr: true},
+		{name: "unknown output", opts: &listOpts{outputFormat: "json"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateListOutput(tt.opts); (err != nil) != tt.wantErr {
+				t.Fatalf("validateListOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// expiringSignature is an existing signature of an artifact that expires
+// soon and may be replaced by a new signature.
+type expiringSignature struct {
+	manifestDesc ocispec.Descriptor
+	signingCert  *x509.Certificate
+	expiry       time.Time
+}
+
+// signatureRecorder is a repository that records the signature it pushes.
+type signatureRecorder struct {
+	notationregistry.Repository
+	mediaType    string
+	blob         []byte
+	manifestDesc ocispec.Descriptor
+}
+
+// PushSignature pushes the signature and records it on success. If the
+// signature is pushed but the outdated referrers index cannot be deleted,
+// the signature is looked up in the repository and recorded, and the error
+// is still returned.
+func (r *signatureRecorder) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	blobDesc, manifestDesc, err = r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+	if err != nil {
+		if strings.Contains(err.Error(), referrersTagSchemaDeleteError) {
+			if manifestDesc, findErr := findSignatureManifest(ctx, r.Repository, subject, blob); findErr == nil {
+				r.mediaType = mediaType
+				r.blob = blob
+				r.manifestDesc = manifestDesc
+			}
+		}
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, err
+	}
+	r.mediaType = mediaType
+	r.blob = blob
+	r.manifestDesc = manifestDesc
+	return blobDesc, manifestDesc, nil
+}
+
+// findSignatureManifest returns the descriptor of the signature manifest of
+// subject in sigRepo whose signature is blob.
+func findSignatureManifest(ctx context.Context, sigRepo notationregistry.Repository, subject ocispec.Descriptor, blob []byte) (ocispec.Descriptor, error) {
+	var found ocispec.Descriptor
+	err := sigRepo.ListSignatures(ctx, subject, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			if found.Digest != "" {
+				return nil
+			}
+			sigBlob, _, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+			if err != nil {
+				continue
+			}
+			if bytes.Equal(sigBlob, blob) {
+				found = sigManifestDesc
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	if found.Digest == "" {
+		return ocispec.Descriptor{}, errors.New("signature manifest not found")
+	}
+	return found, nil
+}
+
+// listExpiringSignatures returns the intact signatures of the artifact that
+// expire within the given duration. Signatures without expiry, or that cannot
+// be fetched or fail the integrity check, are never replaced.
+func listExpiringSignatures(ctx context.Context, sigRepo notationregistry.Repository, ref registry.Reference, within time.Duration) ([]expiringSignature, error) {
+	manifestDesc, err := sigRepo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(within)
+	var expiring []expiringSignature
+	err = sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		for _, sigManifestDesc := range signatureManifests {
+			sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+			if err != nil {
+				continue
+			}
+			signerInfo, err := parseSignature(sigDesc.MediaType, sigBlob)
+			if err != nil {
+				continue
+			}
+			expiry := signerInfo.SignedAttributes.Expiry
+			if expiry.IsZero() || expiry.After(deadline) {
+				continue
+			}
+			expiring = append(expiring, expiringSignature{
+				manifestDesc: sigManifestDesc,
+				signingCert:  signerInfo.CertificateChain[0],
+				expiry:       expiry,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expiring, nil
+}
+
+// parseSignature verifies the integrity of a signature envelope and returns
+// its signer information, which has a non-empty certificate chain.
+func parseSignature(mediaType string, sigBlob []byte) (*signature.SignerInfo, error) {
+	sigEnvelope, err := signature.ParseEnvelope(mediaType, sigBlob)
+	if err != nil {
+		return nil, err
+	}
+	envelopeContent, err := sigEnvelope.Verify()
+	if err != nil {
+		return nil, err
+	}
+	if len(envelopeContent.SignerInfo.CertificateChain) == 0 {
+		return nil, errors.New("signature envelope has no certificate chain")
+	}
+	return &envelopeContent.SignerInfo, nil
+}
+
+// selectReplacedSignatures returns the signature manifests of the expiring
+// signatures signed with exactly the same signing certificate.
+func selectReplacedSignatures(expiring []expiringSignature, signingCert *x509.Certificate, newManifestDesc ocispec.Descriptor) []ocispec.Descriptor {
+	var replaced []ocispec.Descriptor
+	for _, sig := range expiring {
+		if sig.manifestDesc.Digest == newManifestDesc.Digest {
+			continue
+		}
+		if sig.signingCert.Equal(signingCert) {
+			replaced = append(replaced, sig.manifestDesc)
+		}
+	}
+	return replaced
+}
+
+// replaceExpiringSignatures deletes the expiring signatures signed by the
+// same identity as the newly pushed signature recorded by recorder.
+func replaceExpiringSignatures(ctx context.Context, w io.Writer, opts *SecureFlagOpts, ref registry.Reference, expiring []expiringSignature, recorder *signatureRecorder) error {
+	if len(expiring) == 0 || recorder.blob == nil {
+		return nil
+	}
+	signerInfo, err := parseSignature(recorder.mediaType, recorder.blob)
+	if err != nil {
+		return fmt.Errorf("failed to parse the new signature %s: %w", recorder.manifestDesc.Digest, err)
+	}
+	replaced := selectReplacedSignatures(expiring, signerInfo.CertificateChain[0], recorder.manifestDesc)
+	if len(replaced) == 0 {
+		return nil
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return err
+	}
+	for _, sigManifestDesc := range replaced {
+		if err := remoteRepo.Manifests().Delete(ctx, sigManifestDesc); err != nil {
+			return fmt.Errorf("signed %s with signature %s, but failed to delete the replaced signature %s: %w", ref, recorder.manifestDesc.Digest, sigManifestDesc.Digest, err)
+		}
+		fmt.Fprintf(w, "Replaced signature %s with %s\n", sigManifestDesc.Digest, recorder.manifestDesc.Digest)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestSignatureRecorder_PushSignature(t *testing.T) {
+	recorder := &signatureRecorder{Repository: &mockRepository{}}
+	blob := []byte("signature")
+	if _, _, err := recorder.PushSignature(context.Background(), "application/jose+json", blob, ocispec.Descriptor{}, nil); err != nil {
+		t.Fatalf("PushSignature() error = %v", err)
+	}
+	if recorder.mediaType != "application/jose+json" || !reflect.DeepEqual(recorder.blob, blob) {
+		t.Fatalf("recorded signature = (%s, %s), want (application/jose+json, %s)", recorder.mediaType, recorder.blob, blob)
+	}
+}
+
+func TestSelectReplacedSignatures(t *testing.T) {
+	signingCert := newTestCertificate(t, "signer")
+	otherCert := newTestCertificate(t, "signer")
+	newManifestDesc := ocispec.Descriptor{Digest: digest.FromString("new")}
+	expiring := []expiringSignature{
+		{manifestDesc: ocispec.Descriptor{Digest: digest.FromString("same")}, signingCert: signingCert, expiry: time.Now()},
+		{manifestDesc: ocispec.Descriptor{Digest: digest.FromString("other")}, signingCert: otherCert, expiry: time.Now()},
+		{manifestDesc: newManifestDesc, signingCert: signingCert, expiry: time.Now()},
+	}
+
+	got := selectReplacedSignatures(expiring, signingCert, newManifestDesc)
+	want := []ocispec.Descriptor{{Digest: digest.FromString("same")}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selectReplacedSignatures() = %v, want %v", got, want)
+	}
+}
+
+// newTagSchemaTestRegistry returns a registry without the Referrers API,
+// serving subject in the repository "net-monitor" with an existing referrers
+// index, which cannot be deleted as manifest deletion is not supported. The
+// returned manifests are the manifests in the registry by tag or digest.
+func newTagSchemaTestRegistry(t *testing.T) (host string, subject digest.Digest, manifests map[string][]byte) {
+	subjectManifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	index := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`)
+	subject = digest.FromBytes(subjectManifest)
+	manifests = map[string][]byte{
+		subject.String(): subjectManifest,
+		strings.Replace(subject.String(), ":", "-", 1): index,
+		digest.FromBytes(index).String():               index,
+	}
+	blobs := make(map[string][]byte)
+	var mu sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		path := strings.TrimPrefix(r.URL.Path, "/v2/net-monitor")
+		switch {
+		case strings.HasPrefix(path, "/manifests/") && (r.Method == http.MethodHead || r.Method == http.MethodGet):
+			manifest, ok := manifests[strings.TrimPrefix(path, "/manifests/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			var mediaType struct {
+				MediaType string `json:"mediaType"`
+			}
+			json.Unmarshal(manifest, &mediaType)
+			w.Header().Set("Content-Type", mediaType.MediaType)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+			w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+			if r.Method == http.MethodGet {
+				w.Write(manifest)
+			}
+		case strings.HasPrefix(path, "/manifests/") && r.Method == http.MethodPut:
+			manifest, _ := io.ReadAll(r.Body)
+			manifests[strings.TrimPrefix(path, "/manifests/")] = manifest
+			manifests[digest.FromBytes(manifest).String()] = manifest
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "/manifests/") && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case strings.HasPrefix(path, "/referrers/"):
+			w.WriteHeader(http.StatusNotFound)
+		case path == "/blobs/uploads/" && r.Method == http.MethodPost:
+			w.Header().Set("Location", "/v2/net-monitor/blobs/uploads/upload")
+			w.WriteHeader(http.StatusAccepted)
+		case path == "/blobs/uploads/upload" && r.Method == http.MethodPut:
+			blobs[r.URL.Query().Get("digest")], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case strings.HasPrefix(path, "/blobs/") && (r.Method == http.MethodHead || r.Method == http.MethodGet):
+			blob, ok := blobs[strings.TrimPrefix(path, "/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Length", fmt.Sprint(len(blob)))
+			if r.Method == http.MethodGet {
+				w.Write(blob)
+			}
+		default:
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	return u.Host, subject, manifests
+}
+
+func TestSignReference_ReferrersIndexNotDeleted(t *testing.T) {
+	host, subject, manifests := newTagSchemaTestRegistry(t)
+	opts := newBatchTestSignOpts(1, false)
+	opts.reference = host + "/net-monitor@" + subject.String()
+	opts.tagAs = "signature"
+	var out bytes.Buffer
+	result := &signResult{Reference: opts.reference}
+	if err := signReference(context.Background(), &out, opts, &batchSigner{}, result); err != nil {
+		t.Fatalf("signReference() error = %v", err)
+	}
+	if result.SignatureDigest == "" {
+		t.Fatal("signReference() signature digest is not set")
+	}
+	tagged, ok := manifests[opts.tagAs]
+	if !ok {
+		t.Fatalf("signature is not tagged as %s", opts.tagAs)
+	}
+	if got := digest.FromBytes(tagged).String(); got != result.SignatureDigest {
+		t.Fatalf("tagged signature = %s, want %s", got, result.SignatureDigest)
+	}
+	if !strings.Contains(out.String(), "Successfully signed") {
+		t.Fatalf("signReference() output = %q, want it to report the signature", out.String())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/pkg/configutil"
+)
+
+// pemHeadersAnnotationKey is the signature manifest annotation storing the
+// PEM headers of the certificates of the signing key preserved by
+// `notation sign --pem-headers-preserve`.
+const pemHeadersAnnotationKey = "org.notaryproject.notation.pem-headers"
+
+// pemBagAttributes is the line introducing the bag attributes written before
+// PEM blocks by PKCS #12 exports, such as `openssl pkcs12`.
+const pemBagAttributes = "Bag Attributes"
+
+// pemCertificateHeaders are the preserved PEM headers of a certificate of the
+// signing key, identified by its SHA-256 fingerprint.
+type pemCertificateHeaders struct {
+	SHA256  string            `json:"sha256"`
+	Headers map[string]string `json:"headers"`
+}
+
+// validatePEMHeaderNames returns an error if any of the names of
+// --pem-headers-preserve is empty.
+func validatePEMHeaderNames(names []string) error {
+	for _, name := range names {
+		if strings.TrimSpace(name) == "" {
+			return errors.New("PEM header name cannot be empty")
+		}
+	}
+	return nil
+}
+
+// getPEMHeaderAnnotations returns the signature manifest annotation storing
+// the PEM headers of the given names of the certificates of the local key in
+// opts. It returns nil if none of the certificates has such a header.
+func getPEMHeaderAnnotations(opts *cmd.SignerFlagOpts, names []string) (map[string]string, error) {
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		return nil, errors.New("--pem-headers-preserve is not supported with on-demand plugin keys")
+	}
+	key, err := configutil.ResolveKey(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key.X509KeyPair == nil {
+		return nil, fmt.Errorf("--pem-headers-preserve is not supported with plugin key %q, use a local key", key.Name)
+	}
+	data, err := os.ReadFile(key.X509KeyPair.CertificatePath)
+	if err != nil {
+		return nil, err
+	}
+	certHeaders, err := readPEMCertificateHeaders(data, names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PEM headers of %q: %w", key.X509KeyPair.CertificatePath, err)
+	}
+	if len(certHeaders) == 0 {
+		return nil, nil
+	}
+	value, err := json.Marshal(certHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{pemHeadersAnnotationKey: string(value)}, nil
+}
+
+// readPEMCertificateHeaders returns the headers of the given names of the
+// certificates in the PEM data, in order. Both the headers of the PEM blocks
+// and the bag attributes preceding them are read, the former taking
+// precedence. Certificates without such headers are skipped.
+func readPEMCertificateHeaders(data []byte, names []string) ([]pemCertificateHeaders, error) {
+	var certHeaders []pemCertificateHeaders
+	rest := data
+	for {
+		start := bytes.Index(rest, []byte("-----BEGIN "))
+		if start < 0 {
+			break
+		}
+		preamble := rest[:start]
+		var block *pem.Block
+		block, rest = pem.Decode(rest[start:])
+		if block == nil {
+			return nil, errors.New("invalid PEM block")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		headers := make(map[string]string)
+		for name, value := range parseBagAttributes(preamble) {
+			if containsHeaderName(names, name) {
+				headers[name] = value
+			}
+		}
+		for name, value := range block.Headers {
+			if containsHeaderName(names, name) {
+				headers[name] = value
+			}
+		}
+		if len(headers) == 0 {
+			continue
+		}
+		fingerprint := sha256.Sum256(block.Bytes)
+		certHeaders = append(certHeaders, pemCertificateHeaders{
+			SHA256:  hex.EncodeToString(fingerprint[:]),
+			Headers: headers,
+		})
+	}
+	return certHeaders, nil
+}
+
+// parseBagAttributes returns the bag attributes and the subject and issuer
+// lines of the text preceding a PEM block, in the format written by
+// `openssl pkcs12`, for example "friendlyName: signer" and "subject=CN=signer".
+func parseBagAttributes(preamble []byte) map[string]string {
+	attributes := make(map[string]string)
+	for _, line := range strings.Split(string(preamble), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line == pemBagAttributes {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if i := strings.Index(line, "="); i >= 0 && (!ok || i < len(name)) {
+			name, value, ok = line[:i], line[i+1:], true
+		}
+		if !ok || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		attributes[name] = strings.TrimSpace(value)
+	}
+	return attributes
+}
+
+// containsHeaderName reports whether name is one of names, ignoring case.
+func containsHeaderName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"reflect"
+	"testing"
+)
+
+func TestReadPEMCertificateHeaders(t *testing.T) {
+	signingCert := []byte("signing certificate")
+	rootCert := []byte("root certificate")
+	data := []byte("Bag Attributes\n" +
+		"    localKeyID: 01 00 00 00\n" +
+		"    friendlyName: signer\n" +
+		"subject=CN=signer,O=Notary\n" +
+		"issuer=CN=root,O=Notary\n")
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: signingCert})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Headers: map[string]string{"Comment": "root"}, Bytes: rootCert})...)
+	data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Headers: map[string]string{"Comment": "key"}, Bytes: []byte("key")})...)
+	fingerprint := func(der []byte) string {
+		sum := sha256.Sum256(der)
+		return hex.EncodeToString(sum[:])
+	}
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []pemCertificateHeaders
+	}{
+		{
+			name:  "bag attributes",
+			names: []string{"friendlyName", "subject"},
+			want: []pemCertificateHeaders{
+				{SHA256: fingerprint(signingCert), Headers: map[string]string{"friendlyName": "signer", "subject": "CN=signer,O=Notary"}},
+			},
+		},
+		{
+			name:  "PEM block headers ignoring case",
+			names: []string{"comment"},
+			want: []pemCertificateHeaders{
+				{SHA256: fingerprint(rootCert), Headers: map[string]string{"Comment": "root"}},
+			},
+		},
+		{
+			name:  "absent headers",
+			names: []string{"Proc-Type"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readPEMCertificateHeaders(data, tt.names)
+			if err != nil {
+				t.Fatalf("readPEMCertificateHeaders() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("readPEMCertificateHeaders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadPEMCertificateHeaders_Invalid(t *testing.T) {
+	if _, err := readPEMCertificateHeaders([]byte("-----BEGIN CERTIFICATE-----\nnot base64"), []string{"friendlyName"}); err == nil {
+		t.Fatal("readPEMCertificateHeaders() expects error for invalid PEM block")
+	}
+}
+
+func TestValidatePEMHeaderNames(t *testing.T) {
+	if err := validatePEMHeaderNames([]string{"friendlyName", "localKeyID"}); err != nil {
+		t.Fatalf("validatePEMHeaderNames() error = %v", err)
+	}
+	if err := validatePEMHeaderNames([]string{"friendlyName", " "}); err == nil {
+		t.Fatal("validatePEMHeaderNames() expects error for empty name")
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"text/tabwriter"
@@ -16,10 +18,15 @@ func pluginCommand() *cobra.Command {
 		Use:   "plugin",
 		Short: "Manage plugins",
 	}
-	cmd.AddCommand(pluginListCommand())
+	cmd.AddCommand(pluginListCommand(), pluginVerifyCommand(nil), pluginUpdateCommand(nil))
 	return cmd
 }
 
+type pluginVerifyOpts struct {
+	name string
+	all  bool
+}
+
 func pluginListCommand() *cobra.Command {
 	return &cobra.Command{
 		Use:     "list [flags]",
@@ -62,3 +69,86 @@ func listPlugins(command *cobra.Command) error {
 	}
 	return tw.Flush()
 }
+
+func pluginVerifyCommand(opts *pluginVerifyOpts) *cobra.Command {
+	if opts == nil {
+		opts = &pluginVerifyOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "verify [flags] <plugin_name>",
+		Short: "Verify that installed plugins are functional",
+		Long: `Verify that installed plugins are functional by invoking their metadata command
+
+Example - Verify that the plugin "azure-kv" is functional:
+  notation plugin verify azure-kv
+
+Example - Verify that all installed plugins are functional:
+  notation plugin verify --all
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if opts.all {
+				if len(args) != 0 {
+					return errors.New("plugin name cannot be specified with --all")
+				}
+				return nil
+			}
+			if len(args) == 0 {
+				return errors.New("missing plugin name")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginVerify(cmd.Context(), plugin.NewCLIManager(dir.PluginFS()), opts)
+		},
+	}
+	command.Flags().BoolVar(&opts.all, "all", false, "verify all installed plugins")
+	return command
+}
+
+func runPluginVerify(ctx context.Context, mgr plugin.Manager, opts *pluginVerifyOpts) error {
+	pluginNames := []string{opts.name}
+	if opts.all {
+		var err error
+		pluginNames, err = mgr.List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(pluginNames) == 0 {
+			return errors.New("no plugins installed")
+		}
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tSTATUS\tVERSION\tCAPABILITIES\tERROR\t")
+	failed := 0
+	for _, name := range pluginNames {
+		metadata, err := verifyPlugin(ctx, mgr, name)
+		if err != nil {
+			failed++
+			fmt.Fprintf(tw, "%s\tFAIL\t\t\t%v\t\n", name, err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\tPASS\t%s\t%v\t\t\n", name, metadata.Version, metadata.Capabilities)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d plugins failed verification", failed, len(pluginNames))
+	}
+	return nil
+}
+
+// verifyPlugin invokes the metadata command of the plugin, which validates
+// the returned metadata, and returns the metadata.
+func verifyPlugin(ctx context.Context, mgr plugin.Manager, name string) (*proto.GetMetadataResponse, error) {
+	pl, err := mgr.Get(ctx, name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("plugin %q is not installed, use `notation plugin list` to show installed plugins", name)
+		}
+		return nil, err
+	}
+	return pl.GetMetadata(ctx, &proto.GetMetadataRequest{})
+}
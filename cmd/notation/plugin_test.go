@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+)
+
+type mockPlugin struct {
+	plugin.Plugin
+	metadata *proto.GetMetadataResponse
+	err      error
+}
+
+func (p *mockPlugin) GetMetadata(ctx context.Context, req *proto.GetMetadataRequest) (*proto.GetMetadataResponse, error) {
+	return p.metadata, p.err
+}
+
+type mockPluginManager struct {
+	plugins map[string]*mockPlugin
+}
+
+func (m *mockPluginManager) Get(ctx context.Context, name string) (plugin.Plugin, error) {
+	pl, ok := m.plugins[name]
+	if !ok {
+		return nil, fmt.Errorf("plugin not found: %w", os.ErrNotExist)
+	}
+	return pl, nil
+}
+
+func (m *mockPluginManager) List(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range m.plugins {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func TestPluginVerifyCommand_Args(t *testing.T) {
+	opts := &pluginVerifyOpts{}
+	command := pluginVerifyCommand(opts)
+	if err := command.Args(command, []string{"azure-kv"}); err != nil || opts.name != "azure-kv" {
+		t.Fatalf("Args() error = %v, name = %q", err, opts.name)
+	}
+	if err := command.Args(command, nil); err == nil {
+		t.Fatal("Args() expected error for missing plugin name, but got nil")
+	}
+
+	opts = &pluginVerifyOpts{}
+	command = pluginVerifyCommand(opts)
+	if err := command.ParseFlags([]string{"--all"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, nil); err != nil {
+		t.Fatalf("Args() error = %v", err)
+	}
+	if err := command.Args(command, []string{"azure-kv"}); err == nil {
+		t.Fatal("Args() expected error for plugin name with --all, but got nil")
+	}
+}
+
+func TestRunPluginVerify(t *testing.T) {
+	ctx := context.Background()
+	mgr := &mockPluginManager{plugins: map[string]*mockPlugin{
+		"good": {metadata: &proto.GetMetadataResponse{Version: "v1.0.0", Capabilities: []proto.Capability{proto.CapabilitySignatureGenerator}}},
+		"bad":  {err: errors.New("permission denied")},
+	}}
+
+	if err := runPluginVerify(ctx, mgr, &pluginVerifyOpts{name: "good"}); err != nil {
+		t.Fatalf("runPluginVerify() error = %v", err)
+	}
+	if err := runPluginVerify(ctx, mgr, &pluginVerifyOpts{name: "bad"}); err == nil {
+		t.Fatal("runPluginVerify() expected error for broken plugin, but got nil")
+	}
+	if err := runPluginVerify(ctx, mgr, &pluginVerifyOpts{name: "missing"}); err == nil {
+		t.Fatal("runPluginVerify() expected error for missing plugin, but got nil")
+	}
+	if err := runPluginVerify(ctx, mgr, &pluginVerifyOpts{all: true}); err == nil {
+		t.Fatal("runPluginVerify() expected error for --all with a broken plugin, but got nil")
+	}
+	if err := runPluginVerify(ctx, &mockPluginManager{}, &pluginVerifyOpts{all: true}); err == nil {
+		t.Fatal("runPluginVerify() expected error for no installed plugins, but got nil")
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// maxPluginSize is the largest plugin binary accepted by
+	// `notation plugin update`, in bytes.
+	maxPluginSize = 256 << 20
+
+	// pluginDownloadTimeout is the timeout of downloading a plugin binary.
+	pluginDownloadTimeout = 5 * time.Minute
+
+	// pluginBackupSuffix is the suffix of the backup of the plugin binary
+	// kept while it is replaced.
+	pluginBackupSuffix = ".bak"
+)
+
+type pluginUpdateOpts struct {
+	name   string
+	url    string
+	sha256 string
+	file   string
+}
+
+func pluginUpdateCommand(opts *pluginUpdateOpts) *cobra.Command {
+	if opts == nil {
+		opts = &pluginUpdateOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "update [flags] <plugin_name>",
+		Short: "Update an installed plugin",
+		Long: `Update an installed plugin by replacing its executable in place
+
+The new executable must report the name of the installed plugin in its metadata.
+The installed executable is kept as a backup until the new one is in place, and
+is restored if the update fails.
+
+Example - Update the plugin "azure-kv" with an executable downloaded over HTTPS:
+  notation plugin update --url https://example.com/notation-azure-kv --sha256 <sha256_hex> azure-kv
+
+Example - Update the plugin "azure-kv" with a local executable:
+  notation plugin update --file ./notation-azure-kv azure-kv
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("missing plugin name")
+			}
+			opts.name = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.url == "" && opts.file == "" {
+				return errors.New("either --url or --file must be specified")
+			}
+			if opts.url != "" && opts.sha256 == "" {
+				return errors.New("--sha256 is required with --url")
+			}
+			if opts.sha256 != "" {
+				if err := validateSHA256Hex(opts.sha256); err != nil {
+					return err
+				}
+			}
+			client := &http.Client{Timeout: pluginDownloadTimeout}
+			return runPluginUpdate(cmd.Context(), dir.PluginFS(), client, opts)
+		},
+	}
+	command.Flags().StringVar(&opts.url, "url", "", "HTTPS URL to download the new plugin executable from")
+	command.Flags().StringVar(&opts.sha256, "sha256", "", "SHA-256 digest of the new plugin executable in hex, required with --url")
+	command.Flags().StringVar(&opts.file, "file", "", "path to the new plugin executable")
+	command.MarkFlagsMutuallyExclusive("url", "file")
+	return command
+}
+
+func runPluginUpdate(ctx context.Context, pluginFS dir.SysFS, client *http.Client, opts *pluginUpdateOpts) error {
+	mgr := plugin.NewCLIManager(pluginFS)
+	oldVersion := "unknown"
+	pl, err := mgr.Get(ctx, opts.name)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("plugin %q is not installed, use `notation plugin list` to show installed plugins", opts.name)
+		}
+		return err
+	}
+	if metadata, err := pl.GetMetadata(ctx, &proto.GetMetadataRequest{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to get the metadata of the installed plugin %q: %v\n", opts.name, err)
+	} else {
+		oldVersion = metadata.Version
+	}
+	binPath, err := pluginFS.SysPath(opts.name, pluginBinaryName(opts.name))
+	if err != nil {
+		return err
+	}
+
+	// stage the new executable next to the installed one
+	staged, err := os.CreateTemp(filepath.Dir(binPath), "."+pluginBinaryName(opts.name)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to stage the new plugin executable: %w", err)
+	}
+	stagedPath := staged.Name()
+	defer os.Remove(stagedPath)
+	err = copyPluginBinary(ctx, client, opts, staged)
+	if closeErr := staged.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(stagedPath, 0755); err != nil {
+		return err
+	}
+	newPlugin, err := plugin.NewCLIPlugin(ctx, opts.name, stagedPath)
+	if err != nil {
+		return err
+	}
+	metadata, err := newPlugin.GetMetadata(ctx, &proto.GetMetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("the new executable is not a valid executable of plugin %q: %w", opts.name, err)
+	}
+
+	// swap, keeping a backup to roll back on failure
+	backupPath := binPath + pluginBackupSuffix
+	if err := os.Rename(binPath, backupPath); err != nil {
+		return fmt.Errorf("failed to back up the installed plugin executable: %w", err)
+	}
+	if err := os.Rename(stagedPath, binPath); err != nil {
+		if rollbackErr := os.Rename(backupPath, binPath); rollbackErr != nil {
+			return fmt.Errorf("failed to install the new plugin executable: %v, and failed to restore the backup %s: %w", err, backupPath, rollbackErr)
+		}
+		return fmt.Errorf("failed to install the new plugin executable, the installed plugin is restored: %w", err)
+	}
+	if _, err := verifyPlugin(ctx, mgr, opts.name); err != nil {
+		if rollbackErr := os.Rename(backupPath, binPath); rollbackErr != nil {
+			return fmt.Errorf("the updated plugin failed verification: %v, and failed to restore the backup %s: %w", err, backupPath, rollbackErr)
+		}
+		return fmt.Errorf("the updated plugin failed verification, the installed plugin is restored: %w", err)
+	}
+	if err := os.Remove(backupPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to remove the backup %s: %v\n", backupPath, err)
+	}
+	fmt.Printf("Successfully updated plugin %s from version %s to %s\n", opts.name, oldVersion, metadata.Version)
+	return nil
+}
+
+// copyPluginBinary writes the new plugin executable of opts to w, and checks
+// its SHA-256 digest if --sha256 is set.
+func copyPluginBinary(ctx context.Context, client *http.Client, opts *pluginUpdateOpts, w io.Writer) error {
+	var r io.Reader
+	if opts.file != "" {
+		f, err := os.Open(opts.file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		body, err := downloadPluginBinary(ctx, client, opts.url)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+		r = body
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, h), io.LimitReader(r, maxPluginSize+1))
+	if err != nil {
+		return fmt.Errorf("failed to copy the new plugin executable: %w", err)
+	}
+	if n > maxPluginSize {
+		return fmt.Errorf("the new plugin executable exceeds %d bytes", maxPluginSize)
+	}
+	if opts.sha256 != "" {
+		if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(opts.sha256) {
+			return fmt.Errorf("SHA-256 digest of the new plugin executable is %s, not %s", got, strings.ToLower(opts.sha256))
+		}
+	}
+	return nil
+}
+
+// downloadPluginBinary returns the body of the HTTPS URL rawURL.
+func downloadPluginBinary(ctx context.Context, client *http.Client, rawURL string) (io.ReadCloser, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --url %q: %w", rawURL, err)
+	}
+	if u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid --url %q, only HTTPS URLs are supported", rawURL)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download the new plugin executable: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to download the new plugin executable from %s: %s", rawURL, resp.Status)
+	}
+	return resp.Body, nil
+}
+
+// validateSHA256Hex returns an error if digest is not a SHA-256 digest in
+// hex.
+func validateSHA256Hex(digest string) error {
+	if len(digest) != sha256.Size*2 {
+		return fmt.Errorf("invalid --sha256 %q, it must be %d hex characters", digest, sha256.Size*2)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return fmt.Errorf("invalid --sha256 %q, it must be %d hex characters", digest, sha256.Size*2)
+	}
+	return nil
+}
+
+// pluginBinaryName returns the file name of the executable of the plugin
+// name.
+func pluginBinaryName(name string) string {
+	if runtime.GOOS == "windows" {
+		return proto.Prefix + name + ".exe"
+	}
+	return proto.Prefix + name
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/dir"
+)
+
+// pluginScript returns a shell script printing the metadata of the plugin
+// name with the given version.
+func pluginScript(name, version string) []byte {
+	return []byte(fmt.Sprintf(`#!/bin/sh
+echo '{"name":"%s","description":"test plugin","version":"%s","url":"https://example.com","supportedContractVersions":["1.0"],"capabilities":["SIGNATURE_GENERATOR.RAW"]}'
+`, name, version))
+}
+
+// installTestPlugin installs the plugin name with the given version into a
+// new plugin directory, and returns the directory and the executable path.
+func installTestPlugin(t *testing.T, name, version string) (string, string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("plugin scripts are not supported on Windows")
+	}
+	pluginDir := t.TempDir()
+	binPath := filepath.Join(pluginDir, name, pluginBinaryName(name))
+	if err := os.MkdirAll(filepath.Dir(binPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binPath, pluginScript(name, version), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return pluginDir, binPath
+}
+
+func TestRunPluginUpdate_File(t *testing.T) {
+	pluginDir, binPath := installTestPlugin(t, "test", "v1.0.0")
+	newPath := filepath.Join(t.TempDir(), "notation-test")
+	if err := os.WriteFile(newPath, pluginScript("test", "v1.1.0"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	opts := &pluginUpdateOpts{name: "test", file: newPath}
+	if err := runPluginUpdate(context.Background(), dir.NewSysFS(pluginDir), nil, opts); err != nil {
+		t.Fatalf("runPluginUpdate() error = %v", err)
+	}
+	got, err := os.ReadFile(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "v1.1.0") {
+		t.Fatal("plugin executable is not updated")
+	}
+	if _, err := os.Stat(binPath + pluginBackupSuffix); !os.IsNotExist(err) {
+		t.Fatalf("backup is not removed after update, stat error = %v", err)
+	}
+}
+
+func TestRunPluginUpdate_URL(t *testing.T) {
+	pluginDir, binPath := installTestPlugin(t, "test", "v1.0.0")
+	newBinary := pluginScript("test", "v2.0.0")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(newBinary)
+	}))
+	defer server.Close()
+	digest := sha256.Sum256(newBinary)
+
+	// mismatched digest
+	opts := &pluginUpdateOpts{name: "test", url: server.URL + "/notation-test", sha256: strings.Repeat("0", 64)}
+	if err := runPluginUpdate(context.Background(), dir.NewSysFS(pluginDir), server.Client(), opts); err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Fatalf("runPluginUpdate() error = %v, want error of mismatched digest", err)
+	}
+
+	opts.sha256 = hex.EncodeToString(digest[:])
+	if err := runPluginUpdate(context.Background(), dir.NewSysFS(pluginDir), server.Client(), opts); err != nil {
+		t.Fatalf("runPluginUpdate() error = %v", err)
+	}
+	got, err := os.ReadFile(binPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "v2.0.0") {
+		t.Fatal("plugin executable is not updated")
+	}
+}
+
+func TestRunPluginUpdate_Invalid(t *testing.T) {
+	pluginDir, binPath := installTestPlugin(t, "test", "v1.0.0")
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{name: "executable of another plugin", content: pluginScript("other", "v1.1.0")},
+		{name: "not a plugin", content: []byte("#!/bin/sh\necho not a plugin\n")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newPath := filepath.Join(t.TempDir(), "notation-test")
+			if err := os.WriteFile(newPath, tt.content, 0600); err != nil {
+				t.Fatal(err)
+			}
+			opts := &pluginUpdateOpts{name: "test", file: newPath}
+			if err := runPluginUpdate(context.Background(), dir.NewSysFS(pluginDir), nil, opts); err == nil {
+				t.Fatal("runPluginUpdate() expects error for an invalid executable")
+			}
+			got, err := os.ReadFile(binPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), "v1.0.0") {
+				t.Fatal("installed plugin executable is changed")
+			}
+		})
+	}
+
+	opts := &pluginUpdateOpts{name: "missing", file: binPath}
+	if err := runPluginUpdate(context.Background(), dir.NewSysFS(pluginDir), nil, opts); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Fatalf("runPluginUpdate() error = %v, want error of plugin not installed", err)
+	}
+}
+
+func TestPluginUpdateCommand_Flags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "no source", args: []string{"test"}, wantErr: "either --url or --file"},
+		{name: "url without digest", args: []string{"test", "--url", "https://example.com/notation-test"}, wantErr: "--sha256 is required"},
+		{name: "invalid digest", args: []string{"test", "--file", "notation-test", "--sha256", "abc"}, wantErr: "invalid --sha256"},
+		{name: "url and file", args: []string{"test", "--file", "notation-test", "--url", "https://example.com/notation-test"}, wantErr: "none of the others can be"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := pluginUpdateCommand(nil)
+			command.SetArgs(tt.args)
+			command.SilenceUsage = true
+			command.SilenceErrors = true
+			if err := command.Execute(); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadPluginBinary_HTTP(t *testing.T) {
+	if _, err := downloadPluginBinary(context.Background(), http.DefaultClient, "http://example.com/notation-test"); err == nil || !strings.Contains(err.Error(), "HTTPS") {
+		t.Fatalf("downloadPluginBinary() error = %v, want error of non-HTTPS URL", err)
+	}
+}
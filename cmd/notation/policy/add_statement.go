@@ -0,0 +1,119 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/spf13/cobra"
+)
+
+type addStatementOpts struct {
+	name              string
+	registryScopes    []string
+	trustStores       []string
+	trustedIdentities []string
+	verificationLevel string
+	dryRun            bool
+}
+
+func addStatementCmd() *cobra.Command {
+	var opts addStatementOpts
+	command := &cobra.Command{
+		Use:   "add-statement [flags] --name <name> --scope <registry_scope>...",
+		Short: "Add a statement to the trust policy configuration",
+		Long: `Add a statement to the trust policy configuration.
+
+The statement is validated together with the existing statements before the
+trust policy configuration is written, so that no registry scope is claimed by
+more than one statement.
+
+** This command is in preview and under development. **
+
+Example - Add a statement verifying the images of a repository signed by Wabbit Networks:
+  notation policy add-statement --name wabbit-networks-images --scope registry.acme-rockets.io/software/net-monitor --trust-store ca:wabbit-networks --trusted-identity "x509.subject: C=US, ST=WA, L=Seattle, O=wabbit-networks.io, OU=Security Tools"
+
+Example - Add a statement skipping the verification of the images of a repository:
+  notation policy add-statement --name unsigned-image --scope registry.acme-rockets.io/software/unsigned/net-utils --verification-level skip
+
+Example - Preview the trust policy configuration with the statement added, without writing it:
+  notation policy add-statement --name global-policy --scope "*" --trust-store ca:acme-rockets --trusted-identity "*" --dry-run
+`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAddStatement(cmd, opts)
+		},
+	}
+	command.Flags().StringVar(&opts.name, "name", "", "name of the trust policy statement")
+	command.Flags().StringArrayVar(&opts.registryScopes, "scope", nil, "registry scope of the trust policy statement, in the format {registry}/{repository} or \"*\", can be used multiple times")
+	command.Flags().StringArrayVar(&opts.trustStores, "trust-store", nil, "trust store of the trust policy statement, in the format {type}:{name}, can be used multiple times")
+	command.Flags().StringArrayVar(&opts.trustedIdentities, "trusted-identity", nil, "trusted identity of the trust policy statement, for example \"x509.subject: C=US, O=acme-rockets.io\" or \"*\", can be used multiple times")
+	command.Flags().StringVar(&opts.verificationLevel, "verification-level", trustpolicy.LevelStrict.Name, "signature verification level of the trust policy statement, options: \"strict\", \"permissive\", \"audit\", \"skip\"")
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "print the resulting trust policy configuration instead of writing it")
+	command.MarkFlagRequired("name")
+	command.MarkFlagRequired("scope")
+	return command
+}
+
+func runAddStatement(command *cobra.Command, opts addStatementOpts) error {
+	policyPath, doc, err := loadPolicyDocument()
+	if err != nil {
+		return err
+	}
+
+	// add and validate the new statement
+	statement := trustpolicy.TrustPolicy{
+		Name:           opts.name,
+		RegistryScopes: opts.registryScopes,
+		SignatureVerification: trustpolicy.SignatureVerification{
+			VerificationLevel: opts.verificationLevel,
+		},
+		TrustStores:       opts.trustStores,
+		TrustedIdentities: opts.trustedIdentities,
+	}
+	if err = checkStatementConflicts(doc.TrustPolicies, statement); err != nil {
+		return err
+	}
+	doc.TrustPolicies = append(doc.TrustPolicies, statement)
+	if err = doc.Validate(); err != nil {
+		return fmt.Errorf("failed to validate trust policy statement %q: %w", opts.name, err)
+	}
+	policyJSON, err := marshalPolicyDocument(doc)
+	if err != nil {
+		return err
+	}
+
+	// preview or write
+	if opts.dryRun {
+		_, err = os.Stdout.Write(policyJSON)
+		return err
+	}
+	if err = writePolicyDocument(policyPath, policyJSON); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "Trust policy statement %q added successfully.\n", opts.name)
+	return err
+}
+
+// checkStatementConflicts returns an error if statement reuses the name or a
+// registry scope of any of the existing statements. Conflicts are reported
+// with the existing statement, which the trust policy validation does not.
+func checkStatementConflicts(statements []trustpolicy.TrustPolicy, statement trustpolicy.TrustPolicy) error {
+	if statement.Name == "" {
+		return errors.New("trust policy statement name must not be empty")
+	}
+	for _, existing := range statements {
+		if existing.Name == statement.Name {
+			return fmt.Errorf("trust policy statement %q already exists", statement.Name)
+		}
+		for _, scope := range statement.RegistryScopes {
+			for _, existingScope := range existing.RegistryScopes {
+				if scope == existingScope {
+					return fmt.Errorf("registry scope %q of trust policy statement %q is already used by trust policy statement %q", scope, statement.Name, existing.Name)
+				}
+			}
+		}
+	}
+	return nil
+}
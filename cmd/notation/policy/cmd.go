@@ -11,7 +11,10 @@ func Cmd() *cobra.Command {
 
 	command.AddCommand(
 		showCmd(),
+		addStatementCmd(),
+		removeStatementCmd(),
 		importCmd(),
+		versionCmd(),
 	)
 
 	return command
@@ -0,0 +1,61 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/cmd/notation/internal/policyutil"
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// loadPolicyDocument loads and validates the existing trust policy
+// configuration for editing, returning it with the path of its file.
+func loadPolicyDocument() (string, *trustpolicy.Document, error) {
+	// get policy file path
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to obtain path of trust policy configuration file: %w", err)
+	}
+
+	// load the existing trust policy configuration
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to load trust policy configuration, you may import one via `notation policy import <path-to-policy.json>`: %w", err)
+	}
+	if err = policyutil.CheckDocumentVersion(policyJSON); err != nil {
+		return "", nil, err
+	}
+	var doc trustpolicy.Document
+	if err = json.Unmarshal(policyJSON, &doc); err != nil {
+		return "", nil, fmt.Errorf("failed to parse trust policy configuration: %w", err)
+	}
+	// a configuration without statements, as left by remove-statement --force,
+	// is accepted so that statements can be added to it
+	if len(doc.TrustPolicies) == 0 && doc.Version != "" {
+		return policyPath, &doc, nil
+	}
+	if err = doc.Validate(); err != nil {
+		return "", nil, fmt.Errorf("existing trust policy configuration is invalid, you may update it via `notation policy import <path-to-policy.json>`: %w", err)
+	}
+	return policyPath, &doc, nil
+}
+
+// marshalPolicyDocument returns the indented JSON of doc.
+func marshalPolicyDocument(doc *trustpolicy.Document) ([]byte, error) {
+	policyJSON, err := json.MarshalIndent(doc, "", "    ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal trust policy configuration: %w", err)
+	}
+	return append(policyJSON, '\n'), nil
+}
+
+// writePolicyDocument writes policyJSON to the trust policy file policyPath.
+func writePolicyDocument(policyPath string, policyJSON []byte) error {
+	if err := osutil.WriteFile(policyPath, policyJSON); err != nil {
+		return fmt.Errorf("failed to write trust policy file: %w", err)
+	}
+	return nil
+}
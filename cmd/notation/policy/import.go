@@ -8,6 +8,7 @@ import (
 	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
 	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
+	"github.com/notaryproject/notation/cmd/notation/internal/policyutil"
 	"github.com/notaryproject/notation/internal/osutil"
 	"github.com/spf13/cobra"
 )
@@ -62,6 +63,9 @@ func runImport(command *cobra.Command, opts importOpts) error {
 	}
 
 	// parse and validate
+	if err = policyutil.CheckDocumentVersion(policyJSON); err != nil {
+		return err
+	}
 	var doc trustpolicy.Document
 	if err = json.Unmarshal(policyJSON, &doc); err != nil {
 		return fmt.Errorf("failed to parse trust policy configuration: %w", err)
@@ -0,0 +1,90 @@
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type removeStatementOpts struct {
+	name  string
+	force bool
+}
+
+func removeStatementCmd() *cobra.Command {
+	var opts removeStatementOpts
+	command := &cobra.Command{
+		Use:   "remove-statement [flags] <name>",
+		Short: "Remove a statement from the trust policy configuration",
+		Long: `Remove a statement from the trust policy configuration.
+
+The remaining statements are validated before the trust policy configuration is
+written. Removing the last statement requires the --force flag, as signature
+verification fails with a trust policy configuration without statements.
+
+** This command is in preview and under development. **
+
+Example - Remove the statement named "wabbit-networks-images":
+  notation policy remove-statement wabbit-networks-images
+
+Example - Remove the last statement, leaving a trust policy configuration without statements:
+  notation policy remove-statement --force global-policy
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.name = args[0]
+			return runRemoveStatement(cmd, opts)
+		},
+	}
+	command.Flags().BoolVar(&opts.force, "force", false, "remove the statement even if it is the last statement of the trust policy configuration")
+	return command
+}
+
+func runRemoveStatement(command *cobra.Command, opts removeStatementOpts) error {
+	policyPath, doc, err := loadPolicyDocument()
+	if err != nil {
+		return err
+	}
+
+	// remove the statement
+	index := -1
+	for i, statement := range doc.TrustPolicies {
+		if statement.Name == opts.name {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return fmt.Errorf("trust policy statement %q does not exist", opts.name)
+	}
+	removed := doc.TrustPolicies[index]
+	doc.TrustPolicies = append(doc.TrustPolicies[:index:index], doc.TrustPolicies[index+1:]...)
+
+	// validate the remaining statements
+	if len(doc.TrustPolicies) == 0 {
+		if !opts.force {
+			return fmt.Errorf("trust policy statement %q is the last statement of the trust policy configuration, use --force to remove it", opts.name)
+		}
+		fmt.Fprintln(os.Stderr, "Warning: the trust policy configuration has no statement left, signature verification fails until a statement is added")
+	} else if err = doc.Validate(); err != nil {
+		return fmt.Errorf("failed to validate trust policy configuration without statement %q: %w", opts.name, err)
+	}
+
+	// write
+	policyJSON, err := marshalPolicyDocument(doc)
+	if err != nil {
+		return err
+	}
+	if err = writePolicyDocument(policyPath, policyJSON); err != nil {
+		return err
+	}
+	removedJSON, err := json.MarshalIndent(removed, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal trust policy statement: %w", err)
+	}
+	fmt.Fprintf(os.Stdout, "Trust policy statement %q removed successfully:\n", opts.name)
+	_, err = fmt.Fprintln(os.Stdout, string(removedJSON))
+	return err
+}
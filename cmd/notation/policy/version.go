@@ -0,0 +1,63 @@
+package policy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/cmd/notation/internal/policyutil"
+	"github.com/spf13/cobra"
+)
+
+func versionCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "version [flags]",
+		Short: "Show trust policy versions",
+		Long: `Show the version of the trust policy configuration and the versions supported by notation.
+
+** This command is in preview and under development. **
+
+Example - Show trust policy versions:
+  notation policy version
+`,
+		Args: cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runVersion(cmd)
+		},
+	}
+	return command
+}
+
+func runVersion(command *cobra.Command) error {
+	// get policy file path
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return fmt.Errorf("failed to obtain path of trust policy configuration file: %w", err)
+	}
+
+	// read the version of the existing trust policy configuration, if any
+	policyJSON, err := os.ReadFile(policyPath)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		fmt.Println("Trust policy version: not configured")
+	case err != nil:
+		return fmt.Errorf("failed to load trust policy configuration: %w", err)
+	default:
+		var doc struct {
+			Version string `json:"version"`
+		}
+		if err := json.Unmarshal(policyJSON, &doc); err != nil {
+			return fmt.Errorf("failed to parse trust policy configuration: %w", err)
+		}
+		fmt.Println("Trust policy version:", doc.Version)
+		if err := policyutil.CheckVersion(doc.Version); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+	fmt.Println("Supported trust policy versions:", strings.Join(policyutil.SupportedVersions, ", "))
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/slices"
+	"oras.land/oras-go/v2/registry"
+)
+
+// wildcardScope is the registry scope matching all artifacts.
+const wildcardScope = "*"
+
+// applicablePolicy is a trust policy statement whose registry scope matches
+// an artifact.
+type applicablePolicy struct {
+	statement *trustpolicy.TrustPolicy
+	scope     string
+	applied   bool
+}
+
+// findApplicablePolicies returns the trust policy statements with a registry
+// scope matching the artifact path, in document order. The statement with an
+// exact scope takes precedence over the wildcard statement, and is marked as
+// applied.
+func findApplicablePolicies(doc *trustpolicy.Document, artifactPath string) []applicablePolicy {
+	var policies []applicablePolicy
+	exactMatch := false
+	for i := range doc.TrustPolicies {
+		statement := &doc.TrustPolicies[i]
+		switch {
+		case slices.Contains(statement.RegistryScopes, artifactPath):
+			policies = append(policies, applicablePolicy{statement: statement, scope: artifactPath})
+			exactMatch = true
+		case slices.Contains(statement.RegistryScopes, wildcardScope):
+			policies = append(policies, applicablePolicy{statement: statement, scope: wildcardScope})
+		}
+	}
+	for i := range policies {
+		policies[i].applied = (policies[i].scope != wildcardScope) == exactMatch
+	}
+	return policies
+}
+
+// loadTrustPolicyDocument loads the trust policy selected by opts.
+func loadTrustPolicyDocument(ctx context.Context, opts *verifyOpts) (*trustpolicy.Document, error) {
+	if opts.policyRef != "" {
+		return getRegistryTrustPolicy(ctx, opts)
+	}
+	if opts.policyInline != "" {
+		return parseInlineTrustPolicy(opts.policyInline)
+	}
+	if err := checkLocalTrustPolicyVersion(); err != nil {
+		return nil, err
+	}
+	return trustpolicy.LoadDocument()
+}
+
+// printApplicablePolicies prints the trust policy statements applicable to the
+// artifact reference.
+func printApplicablePolicies(ctx context.Context, w io.Writer, opts *verifyOpts) error {
+	ref, err := registry.ParseReference(opts.reference)
+	if err != nil {
+		return err
+	}
+	if ref, err = policyReference(opts, ref); err != nil {
+		return err
+	}
+	doc, err := loadTrustPolicyDocument(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to load trust policy: %w", err)
+	}
+	artifactPath := ref.Registry + "/" + ref.Repository
+	policies := findApplicablePolicies(doc, artifactPath)
+	if len(policies) == 0 {
+		fmt.Fprintf(w, "No trust policy statement is applicable to %s\n", artifactPath)
+		return nil
+	}
+
+	fmt.Fprintf(w, "Trust policy statements applicable to %s:\n", artifactPath)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tMATCHED SCOPE\tVERIFICATION LEVEL\tAPPLIED\t")
+	for _, policy := range policies {
+		applied := "no"
+		if policy.applied {
+			applied = "yes"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t\n", policy.statement.Name, policy.scope, policy.statement.SignatureVerification.VerificationLevel, applied)
+	}
+	return tw.Flush()
+}
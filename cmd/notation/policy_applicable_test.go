@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+func TestFindApplicablePolicies(t *testing.T) {
+	doc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{Name: "net-monitor", RegistryScopes: []string{"registry.acme-rockets.io/software/net-monitor"}},
+			{Name: "net-logger", RegistryScopes: []string{"registry.acme-rockets.io/software/net-logger"}},
+			{Name: "global", RegistryScopes: []string{"*"}},
+		},
+	}
+
+	tests := []struct {
+		artifactPath string
+		want         map[string]bool
+	}{
+		{artifactPath: "registry.acme-rockets.io/software/net-monitor", want: map[string]bool{"net-monitor": true, "global": false}},
+		{artifactPath: "registry.acme-rockets.io/software/other", want: map[string]bool{"global": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.artifactPath, func(t *testing.T) {
+			policies := findApplicablePolicies(doc, tt.artifactPath)
+			if len(policies) != len(tt.want) {
+				t.Fatalf("findApplicablePolicies() returned %d statements, want %d", len(policies), len(tt.want))
+			}
+			for _, policy := range policies {
+				applied, ok := tt.want[policy.statement.Name]
+				if !ok || applied != policy.applied {
+					t.Fatalf("statement %q applied = %v, want %v (expected: %v)", policy.statement.Name, policy.applied, applied, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestFindApplicablePolicies_NoMatch(t *testing.T) {
+	doc := &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{Name: "net-monitor", RegistryScopes: []string{"registry.acme-rockets.io/software/net-monitor"}},
+		},
+	}
+	if policies := findApplicablePolicies(doc, "registry.acme-rockets.io/software/other"); len(policies) != 0 {
+		t.Fatalf("findApplicablePolicies() = %v, want none", policies)
+	}
+}
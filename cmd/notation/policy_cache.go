@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/opencontainers/go-digest"
+)
+
+// trustPolicyCacheDir is the directory under the user cache directory storing
+// trust policies fetched from registries.
+const trustPolicyCacheDir = "notation/trustpolicy"
+
+// trustPolicyCachePath returns the cache path of the trust policy with the
+// given digest.
+func trustPolicyCachePath(pin digest.Digest) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, trustPolicyCacheDir, pin.Algorithm().String(), pin.Encoded()+".json"), nil
+}
+
+// getRegistryTrustPolicy returns the trust policy fetched from the registry
+// as selected by opts. If a cache TTL is set, a cached trust policy younger
+// than the TTL is used, unless a reload is forced. Cache failures are logged
+// and fall back to fetching from the registry.
+func getRegistryTrustPolicy(ctx context.Context, opts *verifyOpts) (*trustpolicy.Document, error) {
+	pin := digest.Digest(opts.policyDigest)
+	if opts.policyCacheTTL <= 0 {
+		return fetchTrustPolicyFromRegistry(ctx, &opts.SecureFlagOpts, opts.policyRef, pin)
+	}
+	logger := log.GetLogger(ctx)
+	if err := pin.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trust policy digest %q: %w", pin, err)
+	}
+	cachePath, err := trustPolicyCachePath(pin)
+	if err != nil {
+		logger.Warnf("Unable to locate trust policy cache: %v", err)
+		return fetchTrustPolicyFromRegistry(ctx, &opts.SecureFlagOpts, opts.policyRef, pin)
+	}
+
+	if !opts.reloadPolicy {
+		policyJSON, err := readCachedTrustPolicy(cachePath, pin, opts.policyCacheTTL, time.Now())
+		if err == nil {
+			logger.Infof("Using cached trust policy %s", cachePath)
+			return parseTrustPolicy(policyJSON)
+		}
+		logger.Infof("Cached trust policy is not used: %v", err)
+	}
+
+	policyJSON, err := fetchTrustPolicyJSON(ctx, &opts.SecureFlagOpts, opts.policyRef, pin)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseTrustPolicy(policyJSON)
+	if err != nil {
+		return nil, err
+	}
+	if err := osutil.WriteFile(cachePath, policyJSON); err != nil {
+		logger.Warnf("Unable to cache trust policy: %v", err)
+	}
+	return doc, nil
+}
+
+// readCachedTrustPolicy reads the cached trust policy at path, which must be
+// younger than ttl and match pin.
+func readCachedTrustPolicy(path string, pin digest.Digest, ttl time.Duration, now time.Time) ([]byte, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if age := now.Sub(info.ModTime()); age > ttl {
+		return nil, fmt.Errorf("cached trust policy is expired, cached %s ago", age.Truncate(time.Second))
+	}
+	if info.Size() > maxTrustPolicySize {
+		return nil, fmt.Errorf("cached trust policy is too large, exceeding %d bytes", maxTrustPolicySize)
+	}
+	policyJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if got := pin.Algorithm().FromBytes(policyJSON); got != pin {
+		return nil, fmt.Errorf("cached trust policy digest mismatch: expected %s, got %s", pin, got)
+	}
+	return policyJSON, nil
+}
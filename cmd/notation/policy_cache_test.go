@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+)
+
+func TestGetRegistryTrustPolicy_Cache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	policy := []byte(testTrustPolicy)
+	ts, reference := newTrustPolicyRegistry(t, policy)
+	opts := &verifyOpts{
+		SecureFlagOpts: SecureFlagOpts{Username: "user", Password: "password", PlainHTTP: true},
+		policyRef:      reference,
+		policyDigest:   digest.FromBytes(policy).String(),
+		policyCacheTTL: time.Hour,
+	}
+	ctx := context.Background()
+
+	// first verification fetches and caches the trust policy
+	if _, err := getRegistryTrustPolicy(ctx, opts); err != nil {
+		t.Fatalf("getRegistryTrustPolicy() error = %v", err)
+	}
+	ts.Close()
+
+	// later verifications use the cache while the registry is unavailable
+	doc, err := getRegistryTrustPolicy(ctx, opts)
+	if err != nil {
+		t.Fatalf("getRegistryTrustPolicy() with cache error = %v", err)
+	}
+	if len(doc.TrustPolicies) != 1 || doc.TrustPolicies[0].Name != "wabbit-networks-images" {
+		t.Fatalf("unexpected trust policy document: %+v", doc)
+	}
+
+	// reload forces fetching from the registry
+	opts.reloadPolicy = true
+	if _, err := getRegistryTrustPolicy(ctx, opts); err == nil {
+		t.Fatal("getRegistryTrustPolicy() expected error reloading from unavailable registry, but got nil")
+	}
+}
+
+func TestReadCachedTrustPolicy(t *testing.T) {
+	policy := []byte(testTrustPolicy)
+	pin := digest.FromBytes(policy)
+	path := t.TempDir() + "/policy.json"
+	if err := os.WriteFile(path, policy, 0600); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+
+	if _, err := readCachedTrustPolicy(path, pin, time.Hour, now); err != nil {
+		t.Fatalf("readCachedTrustPolicy() error = %v", err)
+	}
+	if _, err := readCachedTrustPolicy(path, pin, time.Hour, now.Add(2*time.Hour)); err == nil {
+		t.Fatal("readCachedTrustPolicy() expected error for expired cache, but got nil")
+	}
+	if _, err := readCachedTrustPolicy(path, digest.FromString("tampered"), time.Hour, now); err == nil {
+		t.Fatal("readCachedTrustPolicy() expected error for digest mismatch, but got nil")
+	}
+}
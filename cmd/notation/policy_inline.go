@@ -0,0 +1,17 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// parseInlineTrustPolicy parses and validates the trust policy document of
+// `notation verify --trust-policy-inline`, as the trust policy file is.
+func parseInlineTrustPolicy(policyJSON string) (*trustpolicy.Document, error) {
+	doc, err := parseTrustPolicy([]byte(policyJSON))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --trust-policy-inline: %w", err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseInlineTrustPolicy(t *testing.T) {
+	doc, err := parseInlineTrustPolicy(testTrustPolicy)
+	if err != nil {
+		t.Fatalf("parseInlineTrustPolicy() error = %v", err)
+	}
+	if len(doc.TrustPolicies) != 1 || doc.TrustPolicies[0].Name != "wabbit-networks-images" {
+		t.Fatalf("parseInlineTrustPolicy() = %+v, want the wabbit-networks-images statement", doc.TrustPolicies)
+	}
+
+	for _, policy := range []string{
+		`{"version": "1.0", "trustPolicies": []}`,
+		`{"version": "1.0", "trustPolicies": [`,
+		strings.Replace(testTrustPolicy, `"version": "1.0"`, `"version": "2.0"`, 1),
+	} {
+		if _, err := parseInlineTrustPolicy(policy); err == nil || !strings.Contains(err.Error(), "--trust-policy-inline") {
+			t.Fatalf("parseInlineTrustPolicy(%s) error = %v, want error of invalid --trust-policy-inline", policy, err)
+		}
+	}
+}
+
+func TestLoadTrustPolicyDocument_Inline(t *testing.T) {
+	opts := &verifyOpts{policyInline: testTrustPolicy}
+	doc, err := loadTrustPolicyDocument(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("loadTrustPolicyDocument() error = %v", err)
+	}
+	if got := findApplicablePolicies(doc, "localhost:5000/net-monitor"); len(got) != 1 {
+		t.Fatalf("findApplicablePolicies() = %d statements, want 1", len(got))
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/cmd/notation/internal/policyutil"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+)
+
+const (
+	// maxTrustPolicyManifestSize is the maximum size of the manifest of a
+	// trust policy artifact.
+	maxTrustPolicyManifestSize = 4 * 1024 * 1024 // 4 MiB
+
+	// maxTrustPolicySize is the maximum size of a trust policy document
+	// fetched from a registry.
+	maxTrustPolicySize = 1024 * 1024 // 1 MiB
+)
+
+// fetchTrustPolicyFromRegistry fetches the trust policy document stored as the
+// only blob of the artifact identified by reference. The digest of the
+// document must match pin.
+func fetchTrustPolicyFromRegistry(ctx context.Context, opts *SecureFlagOpts, reference string, pin digest.Digest) (*trustpolicy.Document, error) {
+	policyJSON, err := fetchTrustPolicyJSON(ctx, opts, reference, pin)
+	if err != nil {
+		return nil, err
+	}
+	return parseTrustPolicy(policyJSON)
+}
+
+// fetchTrustPolicyJSON fetches the raw trust policy document stored as the
+// only blob of the artifact identified by reference, verified against pin.
+func fetchTrustPolicyJSON(ctx context.Context, opts *SecureFlagOpts, reference string, pin digest.Digest) ([]byte, error) {
+	if err := pin.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trust policy digest %q: %w", pin, err)
+	}
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Reference == "" {
+		return nil, fmt.Errorf("trust policy reference %s is missing digest or tag", reference)
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch manifest
+	_, rc, err := remoteRepo.Manifests().FetchReference(ctx, ref.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trust policy artifact %s: %w", reference, err)
+	}
+	defer rc.Close()
+	manifestJSON, err := io.ReadAll(io.LimitReader(rc, maxTrustPolicyManifestSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trust policy artifact %s: %w", reference, err)
+	}
+	if len(manifestJSON) > maxTrustPolicyManifestSize {
+		return nil, fmt.Errorf("trust policy artifact manifest %s is too large, exceeding %d bytes", reference, maxTrustPolicyManifestSize)
+	}
+	blobDesc, err := trustPolicyBlobDescriptor(manifestJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trust policy artifact %s: %w", reference, err)
+	}
+	if blobDesc.Digest != pin {
+		return nil, fmt.Errorf("trust policy digest mismatch: expected %s, got %s", pin, blobDesc.Digest)
+	}
+	if blobDesc.Size > maxTrustPolicySize {
+		return nil, fmt.Errorf("trust policy %s is too large, exceeding %d bytes", blobDesc.Digest, maxTrustPolicySize)
+	}
+
+	// fetch and verify trust policy against its descriptor
+	policyJSON, err := content.FetchAll(ctx, remoteRepo.Blobs(), blobDesc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch trust policy %s: %w", blobDesc.Digest, err)
+	}
+	return policyJSON, nil
+}
+
+// trustPolicyBlobDescriptor returns the descriptor of the only blob of an OCI
+// image manifest or an OCI artifact manifest.
+func trustPolicyBlobDescriptor(manifestJSON []byte) (ocispec.Descriptor, error) {
+	var manifest struct {
+		Layers []ocispec.Descriptor `json:"layers"`
+		Blobs  []ocispec.Descriptor `json:"blobs"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	blobs := append(manifest.Layers, manifest.Blobs...)
+	if len(blobs) != 1 {
+		return ocispec.Descriptor{}, fmt.Errorf("trust policy artifact requires exactly one blob, got %d", len(blobs))
+	}
+	return blobs[0], nil
+}
+
+// parseTrustPolicy parses and validates a trust policy document.
+func parseTrustPolicy(policyJSON []byte) (*trustpolicy.Document, error) {
+	if err := policyutil.CheckDocumentVersion(policyJSON); err != nil {
+		return nil, err
+	}
+	var doc trustpolicy.Document
+	if err := json.Unmarshal(policyJSON, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse trust policy configuration: %w", err)
+	}
+	if err := doc.Validate(); err != nil {
+		return nil, fmt.Errorf("failed to validate trust policy: %w", err)
+	}
+	return &doc, nil
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const testTrustPolicy = `{
+    "version": "1.0",
+    "trustPolicies": [
+        {
+            "name": "wabbit-networks-images",
+            "registryScopes": [ "*" ],
+            "signatureVerification": {
+                "level" : "strict"
+            },
+            "trustStores": [ "ca:wabbit-networks" ],
+            "trustedIdentities": [ "*" ]
+        }
+    ]
+}`
+
+func newTrustPolicyRegistry(t *testing.T, policy []byte) (*httptest.Server, string) {
+	t.Helper()
+	policyDesc := ocispec.Descriptor{
+		MediaType: "application/vnd.cncf.notary.trustpolicy+json",
+		Digest:    digest.FromBytes(policy),
+		Size:      int64(len(policy)),
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config: ocispec.Descriptor{
+			MediaType: "application/vnd.oci.empty.v1+json",
+			Digest:    digest.FromString("{}"),
+			Size:      2,
+		},
+		Layers: []ocispec.Descriptor{policyDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/policy/manifests/v1":
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", digest.FromBytes(manifest).String())
+			w.Write(manifest)
+		case "/v2/policy/blobs/" + policyDesc.Digest.String():
+			w.Write(policy)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+	return ts, uri.Host + "/policy:v1"
+}
+
+func TestFetchTrustPolicyFromRegistry(t *testing.T) {
+	policy := []byte(testTrustPolicy)
+	ts, reference := newTrustPolicyRegistry(t, policy)
+	defer ts.Close()
+	opts := &SecureFlagOpts{Username: "user", Password: "password", PlainHTTP: true}
+
+	doc, err := fetchTrustPolicyFromRegistry(context.Background(), opts, reference, digest.FromBytes(policy))
+	if err != nil {
+		t.Fatalf("fetchTrustPolicyFromRegistry() error = %v", err)
+	}
+	if len(doc.TrustPolicies) != 1 || doc.TrustPolicies[0].Name != "wabbit-networks-images" {
+		t.Fatalf("unexpected trust policy document: %+v", doc)
+	}
+
+	if _, err := fetchTrustPolicyFromRegistry(context.Background(), opts, reference, digest.FromString("tampered")); err == nil {
+		t.Fatal("fetchTrustPolicyFromRegistry() expected digest mismatch error, but got nil")
+	}
+	if _, err := fetchTrustPolicyFromRegistry(context.Background(), opts, reference, "sha256:invalid"); err == nil {
+		t.Fatal("fetchTrustPolicyFromRegistry() expected invalid digest error, but got nil")
+	}
+}
+
+func TestFetchTrustPolicyFromRegistry_InvalidPolicy(t *testing.T) {
+	policy := []byte(`{"version": "1.0", "trustPolicies": []}`)
+	ts, reference := newTrustPolicyRegistry(t, policy)
+	defer ts.Close()
+	opts := &SecureFlagOpts{Username: "user", Password: "password", PlainHTTP: true}
+
+	if _, err := fetchTrustPolicyFromRegistry(context.Background(), opts, reference, digest.FromBytes(policy)); err == nil {
+		t.Fatal("fetchTrustPolicyFromRegistry() expected validation error, but got nil")
+	}
+}
+
+func TestParseTrustPolicy_NewerVersion(t *testing.T) {
+	policy := strings.Replace(testTrustPolicy, `"version": "1.0"`, `"version": "2.0"`, 1)
+	_, err := parseTrustPolicy([]byte(policy))
+	if err == nil || !strings.Contains(err.Error(), "Upgrade notation") {
+		t.Fatalf("parseTrustPolicy() expected upgrade error, but got %v", err)
+	}
+}
+
+func TestTrustPolicyBlobDescriptor(t *testing.T) {
+	if _, err := trustPolicyBlobDescriptor([]byte(`{"blobs": [{"digest": "sha256:abc"}]}`)); err != nil {
+		t.Fatalf("trustPolicyBlobDescriptor() error = %v", err)
+	}
+	if _, err := trustPolicyBlobDescriptor([]byte(`{"layers": []}`)); err == nil {
+		t.Fatal("trustPolicyBlobDescriptor() expected error for manifest without blobs, but got nil")
+	}
+	if _, err := trustPolicyBlobDescriptor([]byte(`{"layers": [{}, {}]}`)); err == nil {
+		t.Fatal("trustPolicyBlobDescriptor() expected error for manifest with multiple blobs, but got nil")
+	}
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// prefetchCacheDir is the directory under the user cache directory storing
+// the signatures and revocation data prefetched by `notation verify
+// --prefetch`.
+const prefetchCacheDir = "notation/prefetch"
+
+// prefetchIndexFile is the file of a prefetched repository indexing its
+// artifacts, signatures and CRLs.
+const prefetchIndexFile = "index.json"
+
+// maxPrefetchArtifacts is the maximum number of tags of a repository
+// prefetched by `notation verify --prefetch`.
+const maxPrefetchArtifacts = 1000
+
+// maxPrefetchIndexSize is the maximum size of a prefetch index read from the
+// cache.
+const maxPrefetchIndexSize = 16 * 1024 * 1024 // 16 MiB
+
+// prefetchIndex indexes the prefetched artifacts, signatures and CRLs of a
+// repository.
+type prefetchIndex struct {
+	// Repository is the prefetched repository, in the format
+	// {registry}/{repository}.
+	Repository string `json:"repository"`
+
+	// PrefetchedAt is the time the repository was prefetched.
+	PrefetchedAt time.Time `json:"prefetchedAt"`
+
+	// Artifacts maps the tags and digests of the artifacts of the repository
+	// to their manifest descriptors.
+	Artifacts map[string]ocispec.Descriptor `json:"artifacts"`
+
+	// Signatures maps the digests of the artifacts to their signatures.
+	Signatures map[string][]prefetchedSignature `json:"signatures"`
+
+	// CRLs are the SHA-256 fingerprints of the signing certificates whose
+	// CRL is prefetched.
+	CRLs []string `json:"crls,omitempty"`
+}
+
+// prefetchedSignature is a prefetched signature of an artifact.
+type prefetchedSignature struct {
+	// Manifest is the descriptor of the signature manifest.
+	Manifest ocispec.Descriptor `json:"manifest"`
+
+	// Envelope is the descriptor of the signature envelope blob.
+	Envelope ocispec.Descriptor `json:"envelope"`
+}
+
+// prefetchReport counts what `notation verify --prefetch` cached.
+type prefetchReport struct {
+	artifacts  int
+	signatures int
+	crls       int
+	failures   int
+}
+
+// runPrefetch prefetches the repository of opts.reference into the cache and
+// reports what was cached.
+func runPrefetch(ctx context.Context, opts *verifyOpts) error {
+	ref, err := registry.ParseReference(opts.reference)
+	if err != nil {
+		return err
+	}
+	repository := ref.Registry + "/" + ref.Repository
+	cacheDir, err := prefetchCachePath(repository)
+	if err != nil {
+		return err
+	}
+	report, err := prefetchRepository(ctx, &opts.SecureFlagOpts, opts.reference, cacheDir, time.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Prefetched %d artifacts with %d signatures and %d CRLs of %s into %s\n", report.artifacts, report.signatures, report.crls, repository, cacheDir)
+	if report.failures > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: %d items of %s could not be prefetched and will be fetched from the registry on verification, run with --verbose for details\n", report.failures, repository)
+	}
+	return nil
+}
+
+// getPrefetchCache returns the prefetched repository of reference if it was
+// prefetched within ttl.
+func getPrefetchCache(reference string, ttl time.Duration) (*prefetchCache, error) {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	repository := ref.Registry + "/" + ref.Repository
+	cacheDir, err := prefetchCachePath(repository)
+	if err != nil {
+		return nil, err
+	}
+	cache, err := loadPrefetchCache(cacheDir, ttl, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if cache.index.Repository != repository {
+		return nil, fmt.Errorf("prefetched repository %s does not match %s", cache.index.Repository, repository)
+	}
+	return cache, nil
+}
+
+// prefetchCachePath returns the cache directory of the prefetched repository
+// in the format {registry}/{repository}.
+func prefetchCachePath(repository string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, prefetchCacheDir, digest.FromString(repository).Encoded()), nil
+}
+
+// prefetchRepository lists the tags of the repository referenced by
+// reference, and caches the manifest descriptors, the signatures and the CRLs
+// of the signing certificates of its artifacts under cacheDir. Failures to
+// fetch a signature or a CRL are logged and counted, so that the other
+// artifacts are still cached.
+func prefetchRepository(ctx context.Context, opts *SecureFlagOpts, reference string, cacheDir string, now time.Time) (*prefetchReport, error) {
+	logger := log.GetLogger(ctx)
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Reference != "" {
+		return nil, fmt.Errorf("--prefetch requires a repository in the format {registry}/{repository} without tag or digest, got %s", reference)
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return nil, err
+	}
+	var tags []string
+	err = remoteRepo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		if len(tags) >= maxPrefetchArtifacts {
+			return errPrefetchLimitReached
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPrefetchLimitReached) {
+		return nil, fmt.Errorf("failed to list the tags of %s: %w", reference, err)
+	}
+	if len(tags) > maxPrefetchArtifacts {
+		tags = tags[:maxPrefetchArtifacts]
+	}
+	if errors.Is(err, errPrefetchLimitReached) {
+		fmt.Fprintf(os.Stderr, "Warning: only the first %d tags of %s are prefetched\n", maxPrefetchArtifacts, reference)
+	}
+
+	index := &prefetchIndex{
+		Repository:   ref.Registry + "/" + ref.Repository,
+		PrefetchedAt: now,
+		Artifacts:    make(map[string]ocispec.Descriptor),
+		Signatures:   make(map[string][]prefetchedSignature),
+	}
+	report := &prefetchReport{}
+	sigRepo := notationregistry.NewRepository(remoteRepo)
+	client := &http.Client{Timeout: crlFetchTimeout}
+	crls := make(map[string]bool)
+	for _, tag := range tags {
+		desc, err := sigRepo.Resolve(ctx, tag)
+		if err != nil {
+			logger.Warnf("Failed to resolve %s:%s: %v", reference, tag, err)
+			report.failures++
+			continue
+		}
+		index.Artifacts[tag] = desc
+		artifactDigest := desc.Digest.String()
+		if _, ok := index.Signatures[artifactDigest]; ok {
+			continue
+		}
+		index.Artifacts[artifactDigest] = desc
+		report.artifacts++
+		signatures := []prefetchedSignature{}
+		err = sigRepo.ListSignatures(ctx, desc, func(manifests []ocispec.Descriptor) error {
+			for _, manifestDesc := range manifests {
+				blob, blobDesc, err := sigRepo.FetchSignatureBlob(ctx, manifestDesc)
+				if err != nil {
+					logger.Warnf("Failed to fetch signature %s of %s@%s: %v", manifestDesc.Digest, reference, artifactDigest, err)
+					report.failures++
+					continue
+				}
+				if err := osutil.WriteFile(prefetchBlobPath(cacheDir, blobDesc.Digest), blob); err != nil {
+					return err
+				}
+				signatures = append(signatures, prefetchedSignature{Manifest: manifestDesc, Envelope: blobDesc})
+				report.signatures++
+				signerInfo, err := parseSignature(blobDesc.MediaType, blob)
+				if err != nil || len(signerInfo.CertificateChain) < 2 || len(signerInfo.CertificateChain[0].CRLDistributionPoints) == 0 {
+					continue
+				}
+				fingerprint := certificateFingerprint(signerInfo.CertificateChain[0])
+				if crls[fingerprint] {
+					continue
+				}
+				crl, err := fetchCRL(ctx, client, signerInfo.CertificateChain[0], signerInfo.CertificateChain[1])
+				if err != nil {
+					logger.Warnf("Failed to fetch the CRL of the signing certificate of signature %s: %v", manifestDesc.Digest, err)
+					report.failures++
+					continue
+				}
+				if err := osutil.WriteFile(prefetchCRLPath(cacheDir, fingerprint), crl); err != nil {
+					return err
+				}
+				crls[fingerprint] = true
+				index.CRLs = append(index.CRLs, fingerprint)
+				report.crls++
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Warnf("Failed to list the signatures of %s@%s: %v", reference, artifactDigest, err)
+			report.failures++
+			continue
+		}
+		index.Signatures[artifactDigest] = signatures
+	}
+
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	if err := osutil.WriteFile(filepath.Join(cacheDir, prefetchIndexFile), indexJSON); err != nil {
+		return nil, fmt.Errorf("failed to write the prefetch index: %w", err)
+	}
+	return report, nil
+}
+
+// errPrefetchLimitReached stops listing the tags of a repository once
+// maxPrefetchArtifacts tags are listed.
+var errPrefetchLimitReached = errors.New("prefetch limit reached")
+
+// prefetchBlobPath returns the cache path of the prefetched signature
+// envelope with the given digest.
+func prefetchBlobPath(cacheDir string, blobDigest digest.Digest) string {
+	return filepath.Join(cacheDir, "blobs", blobDigest.Algorithm().String(), blobDigest.Encoded())
+}
+
+// prefetchCRLPath returns the cache path of the prefetched CRL of the signing
+// certificate with the given SHA-256 fingerprint.
+func prefetchCRLPath(cacheDir, fingerprint string) string {
+	return filepath.Join(cacheDir, "crls", fingerprint+".crl")
+}
+
+// certificateFingerprint returns the SHA-256 fingerprint of cert in
+// hexadecimal.
+func certificateFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// prefetchCache is a repository prefetched by `notation verify --prefetch`.
+type prefetchCache struct {
+	dir   string
+	index *prefetchIndex
+}
+
+// loadPrefetchCache loads the repository prefetched under cacheDir, which
+// must have been prefetched within ttl before now.
+func loadPrefetchCache(cacheDir string, ttl time.Duration, now time.Time) (*prefetchCache, error) {
+	path := filepath.Join(cacheDir, prefetchIndexFile)
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxPrefetchIndexSize {
+		return nil, fmt.Errorf("prefetch index is too large, exceeding %d bytes", maxPrefetchIndexSize)
+	}
+	indexJSON, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var index prefetchIndex
+	if err := json.Unmarshal(indexJSON, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse the prefetch index: %w", err)
+	}
+	if age := now.Sub(index.PrefetchedAt); age > ttl {
+		return nil, fmt.Errorf("prefetched data is expired, prefetched %s ago", age.Truncate(time.Second))
+	}
+	return &prefetchCache{dir: cacheDir, index: &index}, nil
+}
+
+// crl returns the prefetched CRL of the signing certificate cert, or nil if
+// it is not prefetched.
+func (c *prefetchCache) crl(cert *x509.Certificate) []byte {
+	fingerprint := certificateFingerprint(cert)
+	for _, f := range c.index.CRLs {
+		if f == fingerprint {
+			crl, err := os.ReadFile(prefetchCRLPath(c.dir, fingerprint))
+			if err != nil {
+				return nil
+			}
+			return crl
+		}
+	}
+	return nil
+}
+
+// prefetchedRepository wraps a notationregistry.Repository and serves the
+// artifacts and signatures prefetched by `notation verify --prefetch` from
+// the cache, falling back to the registry for the others. Signature
+// envelopes are checked against their digest before use.
+type prefetchedRepository struct {
+	notationregistry.Repository
+	cache *prefetchCache
+}
+
+// Resolve resolves the reference from the prefetched artifacts if it is
+// prefetched.
+func (r *prefetchedRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	key := reference
+	if ref, err := registry.ParseReference(reference); err == nil {
+		key = ref.Reference
+	}
+	if desc, ok := r.cache.index.Artifacts[key]; ok {
+		log.GetLogger(ctx).Infof("Resolved %s from the prefetched artifacts", reference)
+		return desc, nil
+	}
+	return r.Repository.Resolve(ctx, reference)
+}
+
+// ListSignatures lists the prefetched signatures of the artifact if it is
+// prefetched.
+func (r *prefetchedRepository) ListSignatures(ctx context.Context, desc ocispec.Descriptor, fn func(signatureManifests []ocispec.Descriptor) error) error {
+	signatures, ok := r.cache.index.Signatures[desc.Digest.String()]
+	if !ok {
+		return r.Repository.ListSignatures(ctx, desc, fn)
+	}
+	manifests := make([]ocispec.Descriptor, 0, len(signatures))
+	for _, sig := range signatures {
+		manifests = append(manifests, sig.Manifest)
+	}
+	return fn(manifests)
+}
+
+// FetchSignatureBlob returns the prefetched signature envelope of the
+// signature manifest desc if it is prefetched and intact.
+func (r *prefetchedRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	for _, sigs := range r.cache.index.Signatures {
+		for _, sig := range sigs {
+			if sig.Manifest.Digest != desc.Digest {
+				continue
+			}
+			blob, err := os.ReadFile(prefetchBlobPath(r.cache.dir, sig.Envelope.Digest))
+			if err == nil && int64(len(blob)) == sig.Envelope.Size && sig.Envelope.Digest.Algorithm().FromBytes(blob) == sig.Envelope.Digest {
+				return blob, sig.Envelope, nil
+			}
+			log.GetLogger(ctx).Warnf("Prefetched signature %s is missing or corrupted, fetching it from the registry", desc.Digest)
+			return r.Repository.FetchSignatureBlob(ctx, desc)
+		}
+	}
+	return r.Repository.FetchSignatureBlob(ctx, desc)
+}
+
+// prefetchedRevocationVerifier wraps a notation.Verifier and evaluates the
+// revocation status of the signing certificate of signatures with the CRLs
+// prefetched by `notation verify --prefetch`, following the revocation action
+// of the trust policy. Signatures without prefetched CRL are left to the
+// wrapped verifier. Errors of rejected signatures are recorded so that they
+// can be reported if no signature passes verification.
+type prefetchedRevocationVerifier struct {
+	verifierWrapper
+	cache *prefetchCache
+	errs  []error
+}
+
+// Verify verifies the signature with the wrapped verifier, then checks the
+// prefetched CRL of the signing certificate if any.
+func (v *prefetchedRevocationVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil || outcome.VerificationLevel == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	action := outcome.VerificationLevel.Enforcement[trustpolicy.TypeRevocation]
+	certs := outcome.EnvelopeContent.SignerInfo.CertificateChain
+	if action == trustpolicy.ActionSkip || len(certs) < 2 {
+		return outcome, nil
+	}
+	crl := v.cache.crl(certs[0])
+	if crl == nil {
+		return outcome, nil
+	}
+	log.GetLogger(ctx).Info("Checking revocation with the prefetched CRL")
+	if err := checkRevocationWithCRL(crl, certs[0], certs[1], time.Now()); err != nil {
+		if err := recordRevocationFailure(outcome, action, fmt.Errorf("prefetched revocation check failed: %w", err)); err != nil {
+			v.errs = append(v.errs, err)
+			return outcome, err
+		}
+	}
+	return outcome, nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// newTestPrefetchCache writes a prefetch index of one artifact tagged "v1"
+// with one signature envelope under a temporary directory.
+func newTestPrefetchCache(t *testing.T, prefetchedAt time.Time) (*prefetchCache, prefetchIndex) {
+	t.Helper()
+	cacheDir := t.TempDir()
+	envelope := []byte(`{"payload":"test"}`)
+	artifact := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromString("artifact"),
+		Size:      8,
+	}
+	sig := prefetchedSignature{
+		Manifest: ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("manifest"), Size: 8},
+		Envelope: ocispec.Descriptor{MediaType: "application/jose+json", Digest: digest.FromBytes(envelope), Size: int64(len(envelope))},
+	}
+	index := prefetchIndex{
+		Repository:   "localhost:5000/net-monitor",
+		PrefetchedAt: prefetchedAt,
+		Artifacts: map[string]ocispec.Descriptor{
+			"v1":                     artifact,
+			artifact.Digest.String(): artifact,
+		},
+		Signatures: map[string][]prefetchedSignature{
+			artifact.Digest.String(): {sig},
+		},
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, prefetchIndexFile), indexJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	blobPath := prefetchBlobPath(cacheDir, sig.Envelope.Digest)
+	if err := os.MkdirAll(filepath.Dir(blobPath), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blobPath, envelope, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return &prefetchCache{dir: cacheDir, index: &index}, index
+}
+
+func TestLoadPrefetchCache(t *testing.T) {
+	now := time.Now()
+	cache, _ := newTestPrefetchCache(t, now)
+
+	if _, err := loadPrefetchCache(cache.dir, time.Hour, now.Add(time.Minute)); err != nil {
+		t.Fatalf("loadPrefetchCache() error = %v", err)
+	}
+	if _, err := loadPrefetchCache(cache.dir, time.Hour, now.Add(2*time.Hour)); err == nil {
+		t.Fatal("loadPrefetchCache() expected error for expired prefetched data, but got nil")
+	}
+	if _, err := loadPrefetchCache(t.TempDir(), time.Hour, now); err == nil {
+		t.Fatal("loadPrefetchCache() expected error for missing prefetched data, but got nil")
+	}
+}
+
+func TestGetPrefetchCache_RepositoryMismatch(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	reference := "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if _, err := getPrefetchCache(reference, time.Hour); err == nil {
+		t.Fatal("getPrefetchCache() expected error for repository not prefetched, but got nil")
+	}
+
+	cacheDir, err := prefetchCachePath("localhost:5000/net-monitor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := prefetchIndex{Repository: "localhost:5000/other", PrefetchedAt: time.Now()}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cacheDir, prefetchIndexFile), indexJSON, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getPrefetchCache(reference, time.Hour); err == nil {
+		t.Fatal("getPrefetchCache() expected error for mismatched repository, but got nil")
+	}
+}
+
+func TestPrefetchedRepository(t *testing.T) {
+	cache, index := newTestPrefetchCache(t, time.Now())
+	repo := &prefetchedRepository{Repository: &mockRepository{}, cache: cache}
+	ctx := context.Background()
+	artifact := index.Artifacts["v1"]
+	sig := index.Signatures[artifact.Digest.String()][0]
+
+	for _, reference := range []string{"v1", artifact.Digest.String(), "localhost:5000/net-monitor@" + artifact.Digest.String()} {
+		desc, err := repo.Resolve(ctx, reference)
+		if err != nil {
+			t.Fatalf("Resolve(%q) error = %v", reference, err)
+		}
+		if desc.Digest != artifact.Digest {
+			t.Fatalf("Resolve(%q) = %s, want %s", reference, desc.Digest, artifact.Digest)
+		}
+	}
+
+	var manifests []ocispec.Descriptor
+	err := repo.ListSignatures(ctx, artifact, func(signatureManifests []ocispec.Descriptor) error {
+		manifests = append(manifests, signatureManifests...)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ListSignatures() error = %v", err)
+	}
+	if len(manifests) != 1 || manifests[0].Digest != sig.Manifest.Digest {
+		t.Fatalf("ListSignatures() = %v, want %v", manifests, sig.Manifest)
+	}
+
+	blob, blobDesc, err := repo.FetchSignatureBlob(ctx, sig.Manifest)
+	if err != nil {
+		t.Fatalf("FetchSignatureBlob() error = %v", err)
+	}
+	if blobDesc.Digest != sig.Envelope.Digest || digest.FromBytes(blob) != sig.Envelope.Digest {
+		t.Fatalf("FetchSignatureBlob() = %s, want %s", blobDesc.Digest, sig.Envelope.Digest)
+	}
+
+	// a corrupted envelope is fetched from the registry again
+	if err := os.WriteFile(prefetchBlobPath(cache.dir, sig.Envelope.Digest), []byte("tampered"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	blob, _, err = repo.FetchSignatureBlob(ctx, sig.Manifest)
+	if err != nil {
+		t.Fatalf("FetchSignatureBlob() error = %v", err)
+	}
+	if blob != nil {
+		t.Fatalf("FetchSignatureBlob() = %q, want the envelope fetched from the registry", blob)
+	}
+}
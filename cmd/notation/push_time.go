@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// pushTimeClient is a remote.Client recording the Last-Modified header of
+// manifest responses, which registries exposing it set to the time the
+// manifest was pushed. The OCI distribution specification does not define
+// the push time of manifests, so that registries may not expose it.
+type pushTimeClient struct {
+	remote.Client
+
+	lock  sync.Mutex
+	times map[string]time.Time
+}
+
+// Do sends req with the wrapped client, and records the Last-Modified header
+// of successful manifest responses by the reference requested.
+func (c *pushTimeClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.Client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !strings.Contains(req.URL.Path, "/manifests/") {
+		return resp, err
+	}
+	if pushTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		reference := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+		c.lock.Lock()
+		if c.times == nil {
+			c.times = map[string]time.Time{}
+		}
+		c.times[reference] = pushTime
+		c.lock.Unlock()
+	}
+	return resp, nil
+}
+
+// pushTime returns the time the signature manifest sigManifestDesc was
+// pushed, resolving it in sigRepo, whose requests are sent with c. It
+// returns false if the registry does not expose the push time.
+func (c *pushTimeClient) pushTime(ctx context.Context, sigRepo notationregistry.Repository, sigManifestDesc ocispec.Descriptor) (time.Time, bool, error) {
+	reference := sigManifestDesc.Digest.String()
+	c.lock.Lock()
+	pushTime, ok := c.times[reference]
+	c.lock.Unlock()
+	if ok {
+		return pushTime, true, nil
+	}
+	if _, err := sigRepo.Resolve(ctx, reference); err != nil {
+		return time.Time{}, false, err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	pushTime, ok = c.times[reference]
+	return pushTime, ok, nil
+}
+
+// pushTimeDescriber returns a function describing the time each signature
+// manifest was pushed, as exposed by the registry.
+func pushTimeDescriber(ctx context.Context, sigRepo notationregistry.Repository, client *pushTimeClient) func(ocispec.Descriptor) string {
+	return func(sigManifestDesc ocispec.Descriptor) string {
+		pushTime, ok, err := client.pushTime(ctx, sigRepo, sigManifestDesc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to resolve signature %s: %v\n", sigManifestDesc.Digest, err)
+			return " (pushed: unknown)"
+		}
+		if !ok {
+			return " (pushed: unknown)"
+		}
+		return fmt.Sprintf(" (pushed: %s)", pushTime.UTC().Format(time.RFC3339))
+	}
+}
+
+// pushTimeFilter returns a filter accepting signatures pushed after the
+// time after and before the time before. A zero time disables the
+// respective bound. Signatures whose push time is not exposed by the
+// registry are skipped with a warning.
+func pushTimeFilter(ctx context.Context, sigRepo notationregistry.Repository, client *pushTimeClient, after, before time.Time) func(ocispec.Descriptor) bool {
+	return func(sigManifestDesc ocispec.Descriptor) bool {
+		pushTime, ok, err := client.pushTime(ctx, sigRepo, sigManifestDesc)
+		if err != nil {
+			logSkippedSignature(sigManifestDesc, err)
+			return false
+		}
+		if !ok {
+			logSkippedSignature(sigManifestDesc, fmt.Errorf("the registry does not expose the push time of the signature manifest"))
+			return false
+		}
+		if !after.IsZero() && !pushTime.After(after) {
+			return false
+		}
+		if !before.IsZero() && !pushTime.Before(before) {
+			return false
+		}
+		return true
+	}
+}
+
+// parsePushTimeBound parses the value of the flag name in RFC 3339 format.
+// An empty value is the zero time.
+func parsePushTimeBound(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	bound, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q, it must be in RFC 3339 format, for example \"2023-01-01T00:00:00Z\": %w", name, value, err)
+	}
+	return bound, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// newPushTimeTestRepository returns a signature repository on a test
+// registry exposing pushTimes as the Last-Modified header of the manifests
+// of the given digests, along with the pushTimeClient of its requests.
+func newPushTimeTestRepository(t *testing.T, pushTimes map[digest.Digest]time.Time, known ...digest.Digest) (notationregistry.Repository, *pushTimeClient) {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dgst := digest.Digest(r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+		if r.Method != http.MethodHead || !strings.HasPrefix(r.URL.Path, "/v2/net-monitor/manifests/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		found := false
+		for _, d := range known {
+			found = found || d == dgst
+		}
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", dgst.String())
+		w.Header().Set("Content-Length", "100")
+		if pushTime, ok := pushTimes[dgst]; ok {
+			w.Header().Set("Last-Modified", pushTime.Format(http.TimeFormat))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &pushTimeClient{Client: http.DefaultClient}
+	remoteRepo := &remote.Repository{
+		Client:    client,
+		Reference: registry.Reference{Registry: u.Host, Repository: "net-monitor"},
+		PlainHTTP: true,
+	}
+	return notationregistry.NewRepository(remoteRepo), client
+}
+
+func TestPushTimeDescriber(t *testing.T) {
+	ctx := context.Background()
+	pushed := digest.FromString("pushed")
+	unexposed := digest.FromString("unexposed")
+	missing := digest.FromString("missing")
+	pushTime := time.Date(2023, 1, 31, 12, 0, 0, 0, time.UTC)
+	sigRepo, client := newPushTimeTestRepository(t, map[digest.Digest]time.Time{pushed: pushTime}, pushed, unexposed)
+
+	describe := pushTimeDescriber(ctx, sigRepo, client)
+	for _, tt := range []struct {
+		digest digest.Digest
+		want   string
+	}{
+		{pushed, " (pushed: 2023-01-31T12:00:00Z)"},
+		{unexposed, " (pushed: unknown)"},
+		{missing, " (pushed: unknown)"},
+	} {
+		if got := describe(ocispec.Descriptor{Digest: tt.digest}); got != tt.want {
+			t.Fatalf("describe(%s) = %q, want %q", tt.digest, got, tt.want)
+		}
+	}
+}
+
+func TestPushTimeFilter(t *testing.T) {
+	ctx := context.Background()
+	early := digest.FromString("early")
+	late := digest.FromString("late")
+	unexposed := digest.FromString("unexposed")
+	bound := time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)
+	sigRepo, client := newPushTimeTestRepository(t, map[digest.Digest]time.Time{
+		early: bound.Add(-time.Hour),
+		late:  bound.Add(time.Hour),
+	}, early, late, unexposed)
+
+	tests := []struct {
+		name   string
+		after  time.Time
+		before time.Time
+		want   map[digest.Digest]bool
+	}{
+		{name: "pushed after", after: bound, want: map[digest.Digest]bool{early: false, late: true, unexposed: false}},
+		{name: "pushed before", before: bound, want: map[digest.Digest]bool{early: true, late: false, unexposed: false}},
+		{name: "pushed within", after: bound.Add(-2 * time.Hour), before: bound.Add(2 * time.Hour), want: map[digest.Digest]bool{early: true, late: true, unexposed: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := pushTimeFilter(ctx, sigRepo, client, tt.after, tt.before)
+			for dgst, want := range tt.want {
+				if got := filter(ocispec.Descriptor{Digest: dgst}); got != want {
+					t.Fatalf("filter(%s) = %v, want %v", dgst, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParsePushTimeBound(t *testing.T) {
+	if bound, err := parsePushTimeBound("pushed-after", ""); err != nil || !bound.IsZero() {
+		t.Fatalf("parsePushTimeBound() = (%v, %v), want zero time", bound, err)
+	}
+	if bound, err := parsePushTimeBound("pushed-after", "2023-01-31T00:00:00Z"); err != nil || !bound.Equal(time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("parsePushTimeBound() = (%v, %v), want 2023-01-31T00:00:00Z", bound, err)
+	}
+	if _, err := parsePushTimeBound("pushed-after", "yesterday"); err == nil || !strings.Contains(err.Error(), "--pushed-after") {
+		t.Fatalf("parsePushTimeBound() error = %v, want error of invalid --pushed-after", err)
+	}
+}
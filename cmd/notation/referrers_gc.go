@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/notaryproject/notation-go/log"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// noReferrersGCKey is the context key disabling the deletion of outdated
+// referrers indexes.
+type noReferrersGCKey struct{}
+
+// withoutReferrersGC returns a context under which the signature repositories
+// for Sign do not delete the outdated referrers index when pushing signatures
+// with the referrers tag schema.
+func withoutReferrersGC(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReferrersGCKey{}, true)
+}
+
+// referrersGCDisabled reports whether ctx disables the deletion of outdated
+// referrers indexes.
+func referrersGCDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noReferrersGCKey{}).(bool)
+	return disabled
+}
+
+// noReferrersGCClient is a remote.Client that does not send manifest delete
+// requests, reporting them as accepted instead. Pushing a signature with the
+// referrers tag schema deletes no manifest other than the outdated referrers
+// index, which is left to external garbage collection.
+type noReferrersGCClient struct {
+	remote.Client
+}
+
+// Do sends req with the wrapped client, except for manifest delete requests.
+func (c *noReferrersGCClient) Do(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodDelete || !strings.Contains(req.URL.Path, "/manifests/") {
+		return c.Client.Do(req)
+	}
+	log.GetLogger(req.Context()).Infof("Skipped deleting the outdated referrers index %s", req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:])
+	return &http.Response{
+		Status:     http.StatusText(http.StatusAccepted),
+		StatusCode: http.StatusAccepted,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}, nil
+}
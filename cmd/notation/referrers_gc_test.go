@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// countingClient is a remote.Client recording the requests it sends.
+type countingClient struct {
+	requests []*http.Request
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req)
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
+
+func TestNoReferrersGCClient(t *testing.T) {
+	inner := &countingClient{}
+	client := &noReferrersGCClient{Client: inner}
+
+	deleteReq := httptest.NewRequest(http.MethodDelete, "https://registry.example/v2/net-monitor/manifests/sha256:abc", nil)
+	resp, err := client.Do(deleteReq)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("manifest delete status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if len(inner.requests) != 0 {
+		t.Fatalf("manifest delete request was sent to the registry")
+	}
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "https://registry.example/v2/net-monitor/manifests/sha256:abc", nil),
+		httptest.NewRequest(http.MethodPut, "https://registry.example/v2/net-monitor/manifests/sha256-abc", nil),
+		httptest.NewRequest(http.MethodDelete, "https://registry.example/v2/net-monitor/blobs/sha256:abc", nil),
+	} {
+		if _, err := client.Do(req); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+	if len(inner.requests) != 3 {
+		t.Fatalf("expected 3 requests sent to the registry, got %d", len(inner.requests))
+	}
+}
+
+func TestWithoutReferrersGC(t *testing.T) {
+	ctx := context.Background()
+	if referrersGCDisabled(ctx) {
+		t.Fatal("referrersGCDisabled() = true for a context without the setting")
+	}
+	if !referrersGCDisabled(withoutReferrersGC(ctx)) {
+		t.Fatal("referrersGCDisabled() = false, want true")
+	}
+}
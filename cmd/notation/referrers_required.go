@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-go/log"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+// discovery mechanisms of signatures
+const (
+	discoveryReferrersAPI       = "Referrers API"
+	discoveryReferrersTagSchema = "Referrers tag schema"
+)
+
+// modes of storing signatures set by `notation sign --referrers-mode`
+const (
+	referrersModeAuto      = "auto"
+	referrersModeAPI       = "api"
+	referrersModeTagSchema = "tag-schema"
+)
+
+var supportedReferrersModes = []string{referrersModeAuto, referrersModeAPI, referrersModeTagSchema}
+
+// referrersAPIRequiredKey is the context key requiring the Referrers API to
+// store signatures.
+type referrersAPIRequiredKey struct{}
+
+// withReferrersAPIRequired returns a context under which the signature
+// repositories for Sign fail if the registry does not support the Referrers
+// API, instead of falling back to the Referrers tag schema.
+func withReferrersAPIRequired(ctx context.Context) context.Context {
+	return context.WithValue(ctx, referrersAPIRequiredKey{}, true)
+}
+
+// referrersAPIRequired reports whether ctx requires the Referrers API to
+// store signatures.
+func referrersAPIRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(referrersAPIRequiredKey{}).(bool)
+	return required
+}
+
+// referrersTagSchemaForcedKey is the context key forcing the Referrers tag
+// schema to store signatures.
+type referrersTagSchemaForcedKey struct{}
+
+// withReferrersTagSchemaForced returns a context under which the signature
+// repositories for Sign store signatures with the Referrers tag schema,
+// without using the Referrers API even if the registry supports it.
+func withReferrersTagSchemaForced(ctx context.Context) context.Context {
+	return context.WithValue(ctx, referrersTagSchemaForcedKey{}, true)
+}
+
+// referrersTagSchemaForced reports whether ctx forces the Referrers tag
+// schema to store signatures.
+func referrersTagSchemaForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(referrersTagSchemaForcedKey{}).(bool)
+	return forced
+}
+
+// withReferrersMode returns a context storing signatures with the Referrers
+// API or the Referrers tag schema as required by mode.
+func withReferrersMode(ctx context.Context, mode string) context.Context {
+	switch mode {
+	case referrersModeAPI:
+		return withReferrersAPIRequired(ctx)
+	case referrersModeTagSchema:
+		return withReferrersTagSchemaForced(ctx)
+	}
+	return ctx
+}
+
+// requireReferrersAPI returns an error if the registry of remoteRepo does
+// not support the Referrers API. On success, remoteRepo is set to use the
+// Referrers API without falling back to the Referrers tag schema.
+func requireReferrersAPI(ctx context.Context, remoteRepo *remote.Repository) error {
+	if err := pingReferrersAPI(ctx, remoteRepo); err != nil {
+		var errorReferrersAPINotSupported notationerrors.ErrorReferrersAPINotSupported
+		if errors.As(err, &errorReferrersAPINotSupported) {
+			return errors.New("target registry does not support the Referrers API, and --fail-if-referrers-unsupported or --referrers-mode api forbids falling back to the Referrers tag schema to store the signature")
+		}
+		return err
+	}
+	log.GetLogger(ctx).Info("Successfully pinged Referrers API on target registry, the Referrers tag schema is not used")
+	return nil
+}
+
+// referrersDiscovery returns the mechanism discovering the signatures
+// stored in remoteRepo: the Referrers API if the registry supports it, and
+// the Referrers tag schema otherwise.
+func referrersDiscovery(ctx context.Context, remoteRepo *remote.Repository) (string, error) {
+	if err := pingReferrersAPI(ctx, remoteRepo); err != nil {
+		var errorReferrersAPINotSupported notationerrors.ErrorReferrersAPINotSupported
+		if errors.As(err, &errorReferrersAPINotSupported) {
+			return discoveryReferrersTagSchema, nil
+		}
+		return "", err
+	}
+	return discoveryReferrersAPI, nil
+}
+
+// checkReferrersDiscovery returns an error if the signatures of reference
+// are only discoverable with the Referrers tag schema, as required by
+// `notation verify --require-referrers-api`.
+func checkReferrersDiscovery(ctx context.Context, opts *SecureFlagOpts, reference string) error {
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return err
+	}
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return err
+	}
+	discovery, err := referrersDiscovery(ctx, remoteRepo)
+	if err != nil {
+		return fmt.Errorf("failed to check the Referrers API support of %s: %w", ref.Registry, err)
+	}
+	if discovery != discoveryReferrersAPI {
+		return fmt.Errorf("signatures of %s are only discoverable with the %s, as registry %s does not support the %s required by --require-referrers-api", reference, discovery, ref.Registry, discoveryReferrersAPI)
+	}
+	log.GetLogger(ctx).Infof("Signatures of %s are discovered with the %s", reference, discovery)
+	return nil
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+func TestWithReferrersAPIRequired(t *testing.T) {
+	ctx := context.Background()
+	if referrersAPIRequired(ctx) {
+		t.Fatal("referrersAPIRequired() = true for a context without the setting")
+	}
+	if !referrersAPIRequired(withReferrersAPIRequired(ctx)) {
+		t.Fatal("referrersAPIRequired() = false, want true")
+	}
+}
+
+func TestRequireReferrersAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "Referrers API supported", status: http.StatusOK},
+		{name: "Referrers API not supported", status: http.StatusNotFound, wantErr: "--fail-if-referrers-unsupported"},
+		{name: "registry error", status: http.StatusForbidden, wantErr: "403"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+zeroDigest {
+					w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+					w.WriteHeader(tt.status)
+					w.Write([]byte(`{ "manifests": [] }`))
+					return
+				}
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+			repo, err := remote.NewRepository(uri.Host + "/test")
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+			repo.PlainHTTP = true
+			err = requireReferrersAPI(context.Background(), repo)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("requireReferrersAPI() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("requireReferrersAPI() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckReferrersDiscovery(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "Referrers API", status: http.StatusOK},
+		{name: "Referrers tag schema", status: http.StatusNotFound, wantErr: "only discoverable with the Referrers tag schema"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && r.URL.Path == "/v2/test/referrers/"+zeroDigest {
+					w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+					w.WriteHeader(tt.status)
+					w.Write([]byte(`{ "manifests": [] }`))
+					return
+				}
+				t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+			opts := &SecureFlagOpts{Username: "user", Password: "password", PlainHTTP: true}
+			err = checkReferrersDiscovery(context.Background(), opts, uri.Host+"/test@"+zeroDigest)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkReferrersDiscovery() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkReferrersDiscovery() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithReferrersMode(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		mode                string
+		wantAPIRequired     bool
+		wantTagSchemaForced bool
+	}{
+		{mode: referrersModeAuto},
+		{mode: referrersModeAPI, wantAPIRequired: true},
+		{mode: referrersModeTagSchema, wantTagSchemaForced: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			modeCtx := withReferrersMode(ctx, tt.mode)
+			if got := referrersAPIRequired(modeCtx); got != tt.wantAPIRequired {
+				t.Fatalf("referrersAPIRequired() = %v, want %v", got, tt.wantAPIRequired)
+			}
+			if got := referrersTagSchemaForced(modeCtx); got != tt.wantTagSchemaForced {
+				t.Fatalf("referrersTagSchemaForced() = %v, want %v", got, tt.wantTagSchemaForced)
+			}
+		})
+	}
+}
+
+func TestGetSignatureRepositoryForSign_ReferrersMode(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		wantPings int
+		wantErr   string
+	}{
+		{name: "auto", mode: referrersModeAuto},
+		{name: "api", mode: referrersModeAPI, wantPings: 1, wantErr: "--referrers-mode api"},
+		{name: "tag schema", mode: referrersModeTagSchema},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var pings int
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/v2/test/referrers/"+zeroDigest {
+					pings++
+				}
+				w.WriteHeader(http.StatusNotFound)
+			}))
+			defer ts.Close()
+			uri, err := url.Parse(ts.URL)
+			if err != nil {
+				t.Fatalf("invalid test http server: %v", err)
+			}
+			ctx := withReferrersMode(context.Background(), tt.mode)
+			_, err = getSignatureRepositoryForSign(ctx, &SecureFlagOpts{PlainHTTP: true}, uri.Host+"/test:v1", true)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("getSignatureRepositoryForSign() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("getSignatureRepositoryForSign() error = %v, want error containing %q", err, tt.wantErr)
+			}
+			if pings != tt.wantPings {
+				t.Fatalf("Referrers API pinged %d times, want %d", pings, tt.wantPings)
+			}
+		})
+	}
+}
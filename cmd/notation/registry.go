@@ -3,8 +3,10 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/notaryproject/notation-go/log"
 	notationregistry "github.com/notaryproject/notation-go/registry"
@@ -55,6 +57,9 @@ func getSignatureRepositoryForSign(ctx context.Context, opts *SecureFlagOpts, re
 	if err != nil {
 		return nil, err
 	}
+	if referrersGCDisabled(ctx) {
+		remoteRepo.Client = &noReferrersGCClient{Client: remoteRepo.Client}
+	}
 
 	// Notation enforces the following two paths during Sign process:
 	// 1. OCI artifact manifest uses the Referrers API.
@@ -69,6 +74,15 @@ func getSignatureRepositoryForSign(ctx context.Context, opts *SecureFlagOpts, re
 			return nil, err
 		}
 		logger.Info("Successfully pinged Referrers API on target registry")
+	} else if referrersAPIRequired(ctx) {
+		if err := requireReferrersAPI(ctx, remoteRepo); err != nil {
+			return nil, err
+		}
+	} else if referrersTagSchemaForced(ctx) {
+		if err := remoteRepo.SetReferrersCapability(false); err != nil {
+			return nil, err
+		}
+		logger.Info("Use the Referrers tag schema to store signature, without using the Referrers API")
 	}
 	repositoryOpts := notationregistry.RepositoryOptions{
 		OCIImageManifest: ociImageManifest,
@@ -82,11 +96,59 @@ func getRepositoryClient(ctx context.Context, opts *SecureFlagOpts, ref registry
 		return nil, err
 	}
 
-	return &remote.Repository{
+	repo := &remote.Repository{
 		Client:    authClient,
 		Reference: ref,
 		PlainHTTP: plainHTTP,
-	}, nil
+	}
+	if len(opts.RegistryScopes) > 0 {
+		scopes, err := parseRegistryScopes(opts.RegistryScopes, ref.Repository)
+		if err != nil {
+			return nil, err
+		}
+		repo.Client = &registryScopesClient{Client: authClient, scopes: scopes}
+	}
+	return repo, nil
+}
+
+// registryScopesClient is a remote.Client hinting the wrapped auth client to
+// request tokens covering scopes, in addition to the scopes of each request.
+type registryScopesClient struct {
+	remote.Client
+	scopes []string
+}
+
+// Do sends req with the wrapped client, with scopes appended to the auth
+// scopes of the request context.
+func (c *registryScopesClient) Do(req *http.Request) (*http.Response, error) {
+	return c.Client.Do(req.WithContext(auth.AppendScopes(req.Context(), c.scopes...)))
+}
+
+// parseRegistryScopes parses the values of the --registry-scope flag into
+// auth scopes. A value is either a scope in the format
+// "<resource type>:<resource name>:<actions>", or a comma-separated list of
+// actions on repository.
+func parseRegistryScopes(values []string, repository string) ([]string, error) {
+	var scopes []string
+	for _, value := range values {
+		if !strings.Contains(value, ":") {
+			actions := strings.Split(value, ",")
+			for _, action := range actions {
+				if action == "" {
+					return nil, fmt.Errorf("invalid --registry-scope %q: empty action", value)
+				}
+			}
+			scopes = append(scopes, auth.ScopeRepository(repository, actions...))
+			continue
+		}
+		resourceType, rest, _ := strings.Cut(value, ":")
+		i := strings.LastIndex(rest, ":")
+		if resourceType == "" || i <= 0 || i == len(rest)-1 {
+			return nil, fmt.Errorf("invalid --registry-scope %q: expect the format <resource type>:<resource name>:<actions>, for example \"repository:<repository>:pull,push\"", value)
+		}
+		scopes = append(scopes, value)
+	}
+	return auth.CleanScopes(scopes), nil
 }
 
 func getRegistryClient(ctx context.Context, opts *SecureFlagOpts, serverAddress string) (*remote.Registry, error) {
@@ -115,6 +177,28 @@ func setHttpDebugLog(ctx context.Context, authClient *auth.Client) {
 	authClient.Client.Transport = trace.NewTransport(authClient.Client.Transport)
 }
 
+// maxRegistryUserAgentLength is the maximum length of the User-Agent set by
+// --registry-user-agent.
+const maxRegistryUserAgentLength = 256
+
+// validateRegistryUserAgent checks that the User-Agent set by
+// --registry-user-agent or its environment variable, if any, is a valid
+// User-Agent header of registry requests.
+func validateRegistryUserAgent(userAgent string) error {
+	if userAgent == "" {
+		return nil
+	}
+	if len(userAgent) > maxRegistryUserAgentLength {
+		return fmt.Errorf("invalid --registry-user-agent or %s: longer than %d characters", registryUserAgentEnv, maxRegistryUserAgentLength)
+	}
+	for _, c := range userAgent {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("invalid --registry-user-agent or %s: %q contains characters other than printable ASCII characters", registryUserAgentEnv, userAgent)
+		}
+	}
+	return nil
+}
+
 func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference) (*auth.Client, bool, error) {
 	var plainHTTP bool
 
@@ -146,7 +230,18 @@ func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Refer
 		}
 	}
 
+	tlsConfig, err := getRegistryTLSConfig(opts)
+	if err != nil {
+		return nil, false, err
+	}
+	cache := opts.AuthCache
+	if cache == nil {
+		cache = auth.NewCache()
+	}
 	authClient := &auth.Client{
+		Client: &http.Client{
+			Transport: getRegistryTransport(tlsConfig),
+		},
 		Credential: func(ctx context.Context, registry string) (auth.Credential, error) {
 			switch registry {
 			case ref.Host():
@@ -155,10 +250,14 @@ func getAuthClient(ctx context.Context, opts *SecureFlagOpts, ref registry.Refer
 				return auth.EmptyCredential, nil
 			}
 		},
-		Cache:    auth.NewCache(),
+		Cache:    cache,
 		ClientID: "notation",
 	}
-	authClient.SetUserAgent("notation/" + version.GetVersion())
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = "notation/" + version.GetVersion()
+	}
+	authClient.SetUserAgent(userAgent)
 
 	// update authClient
 	setHttpDebugLog(ctx, authClient)
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+)
+
+type registryPingOpts struct {
+	cmd.LoggingFlagOpts
+	SecureFlagOpts
+	target string
+}
+
+func registryCommand() *cobra.Command {
+	command := &cobra.Command{
+		Use:   "registry",
+		Short: "Diagnose registry access",
+	}
+	command.AddCommand(registryPingCommand(nil))
+	return command
+}
+
+func registryPingCommand(opts *registryPingOpts) *cobra.Command {
+	if opts == nil {
+		opts = &registryPingOpts{}
+	}
+	command := &cobra.Command{
+		Use:   "ping [flags] <registry>[/<repository>]",
+		Short: "Check connectivity and authentication to a registry",
+		Long: `Check connectivity and authentication to a registry
+
+The registry API version check is performed with the configured credentials.
+If a repository is specified, the support of the Referrers API is checked as
+well.
+
+Example - Check connectivity to a registry:
+  notation registry ping registry.example.com
+
+Example - Check connectivity to a registry and whether the Referrers API is supported for a repository:
+  notation registry ping registry.example.com/net-monitor
+`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return errors.New("no registry specified")
+			}
+			opts.target = args[0]
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runRegistryPing(cmd.Context(), opts)
+		},
+	}
+	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
+	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	return command
+}
+
+func runRegistryPing(ctx context.Context, opts *registryPingOpts) error {
+	// set log level
+	ctx = opts.LoggingFlagOpts.SetLoggerLevel(ctx)
+
+	// initialize
+	serverAddress, repository := parsePingTarget(opts.target)
+	reg, err := getRegistryClient(ctx, &opts.SecureFlagOpts, serverAddress)
+	if err != nil {
+		return err
+	}
+	authClient, ok := reg.Client.(*auth.Client)
+	if !ok {
+		return errors.New("unexpected registry client")
+	}
+	recorder := recordTLSState(authClient)
+
+	fmt.Println("Registry:", serverAddress)
+	cred, err := authClient.Credential(ctx, reg.Reference.Host())
+	if err != nil {
+		return err
+	}
+	if cred == auth.EmptyCredential {
+		fmt.Println("Credentials: none, accessing anonymously")
+	} else {
+		fmt.Println("Credentials: configured")
+	}
+
+	// registry API version check
+	if err := reg.Ping(ctx); err != nil {
+		printTLSState(reg.PlainHTTP, recorder.state)
+		var errResp *errcode.ErrorResponse
+		if errors.As(err, &errResp) && errResp.StatusCode == http.StatusUnauthorized {
+			fmt.Println("Authentication: failed")
+		}
+		return fmt.Errorf("registry API version check failed: %w", err)
+	}
+	printTLSState(reg.PlainHTTP, recorder.state)
+	fmt.Println("Authentication: succeeded")
+	fmt.Println("API version check: succeeded")
+
+	// Referrers API check
+	if repository == "" {
+		fmt.Println("Referrers API: unknown, specify a repository to check")
+		return nil
+	}
+	remoteRepo := &remote.Repository{
+		Client: reg.Client,
+		Reference: registry.Reference{
+			Registry:   reg.Reference.Registry,
+			Repository: repository,
+		},
+		PlainHTTP: reg.PlainHTTP,
+	}
+	if err := pingReferrersAPI(ctx, remoteRepo); err != nil {
+		var errNotSupported notationerrors.ErrorReferrersAPINotSupported
+		if !errors.As(err, &errNotSupported) {
+			return fmt.Errorf("referrers API check failed: %w", err)
+		}
+		fmt.Println("Referrers API: not supported, signatures will be stored using the Referrers tag schema")
+		return nil
+	}
+	fmt.Println("Referrers API: supported")
+	return nil
+}
+
+// parsePingTarget splits target into the registry server address and the
+// optional repository.
+func parsePingTarget(target string) (serverAddress, repository string) {
+	serverAddress, repository, _ = strings.Cut(target, "/")
+	return serverAddress, repository
+}
+
+// tlsStateRecorder is an http.RoundTripper that records the TLS connection
+// state of the last response.
+type tlsStateRecorder struct {
+	base  http.RoundTripper
+	state *tls.ConnectionState
+}
+
+// RoundTrip executes the request with the base transport and records the TLS
+// connection state of the response.
+func (t *tlsStateRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil && resp.TLS != nil {
+		t.state = resp.TLS
+	}
+	return resp, err
+}
+
+// recordTLSState installs a tlsStateRecorder into the transport of the auth
+// client.
+func recordTLSState(authClient *auth.Client) *tlsStateRecorder {
+	client := http.DefaultClient
+	if authClient.Client != nil {
+		client = authClient.Client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	recorder := &tlsStateRecorder{base: base}
+	authClient.Client = &http.Client{
+		Transport:     recorder,
+		CheckRedirect: client.CheckRedirect,
+		Jar:           client.Jar,
+		Timeout:       client.Timeout,
+	}
+	return recorder
+}
+
+func printTLSState(plainHTTP bool, state *tls.ConnectionState) {
+	if plainHTTP {
+		fmt.Println("Protocol: plain HTTP")
+		return
+	}
+	if state == nil {
+		fmt.Println("Protocol: HTTPS, TLS connection not established")
+		return
+	}
+	fmt.Printf("Protocol: HTTPS, %s, %s\n", tls.VersionName(state.Version), tls.CipherSuiteName(state.CipherSuite))
+	if len(state.PeerCertificates) > 0 {
+		cert := state.PeerCertificates[0]
+		fmt.Println("Server certificate subject:", cert.Subject.String())
+		fmt.Println("Server certificate issuer:", cert.Issuer.String())
+		fmt.Println("Server certificate expiry:", cert.NotAfter.Format(time.ANSIC))
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+)
+
+func TestRegistryPingCommand_BasicArgs(t *testing.T) {
+	opts := &registryPingOpts{}
+	cmd := registryPingCommand(opts)
+	expected := &registryPingOpts{
+		target: "registry.example.com/net-monitor",
+		SecureFlagOpts: SecureFlagOpts{
+			Username:  "user",
+			Password:  "password",
+			PlainHTTP: true,
+		},
+	}
+	if err := cmd.ParseFlags([]string{
+		expected.target,
+		"-u", expected.Username,
+		"-p", expected.Password,
+		"--plain-http"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err != nil {
+		t.Fatalf("Parse Args failed: %v", err)
+	}
+	if !reflect.DeepEqual(opts, expected) {
+		t.Fatalf("Expect registry ping opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestRegistryPingCommand_MissingArgs(t *testing.T) {
+	cmd := registryPingCommand(nil)
+	if err := cmd.ParseFlags(nil); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := cmd.Args(cmd, cmd.Flags().Args()); err == nil {
+		t.Fatal("Parse Args expected error, but ok")
+	}
+}
+
+func TestParsePingTarget(t *testing.T) {
+	tests := []struct {
+		target         string
+		wantServer     string
+		wantRepository string
+	}{
+		{"registry.example.com", "registry.example.com", ""},
+		{"localhost:5000/net-monitor", "localhost:5000", "net-monitor"},
+		{"registry.example.com/org/net-monitor", "registry.example.com", "org/net-monitor"},
+	}
+	for _, tt := range tests {
+		server, repository := parsePingTarget(tt.target)
+		if server != tt.wantServer || repository != tt.wantRepository {
+			t.Errorf("parsePingTarget(%q) = %q, %q, want %q, %q", tt.target, server, repository, tt.wantServer, tt.wantRepository)
+		}
+	}
+}
+
+func TestRecordTLSState(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	authClient := &auth.Client{Client: ts.Client()}
+	recorder := recordTLSState(authClient)
+	req, err := http.NewRequest(http.MethodGet, ts.URL+"/v2/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := authClient.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	resp.Body.Close()
+	if recorder.state == nil {
+		t.Fatal("expected TLS connection state to be recorded")
+	}
+	if len(recorder.state.PeerCertificates) == 0 {
+		t.Fatal("expected peer certificates to be recorded")
+	}
+}
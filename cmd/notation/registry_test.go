@@ -3,14 +3,21 @@ package main
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/spf13/cobra"
+	"oras.land/oras-go/v2/registry"
 	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
 	"oras.land/oras-go/v2/registry/remote/errcode"
 )
 
@@ -133,3 +140,231 @@ func TestRegistry_pingReferrersAPI_RepositoryNotFound(t *testing.T) {
 		t.Errorf("pingReferrersAPI() expected error: %v, but got: %v", expectedErr, err)
 	}
 }
+
+func TestGetRepositoryClient_RegistryScopes(t *testing.T) {
+	var scopes []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			scopes = r.URL.Query()["scope"]
+			fmt.Fprint(w, `{"token": "token"}`)
+		case "/v2/app/net-monitor/manifests/latest":
+			if r.Header.Get("Authorization") == "" {
+				w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="http://%s/token",service="test",scope="repository:app/net-monitor:pull"`, r.Host))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", zeroDigest)
+			w.Header().Set("Content-Length", "0")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &SecureFlagOpts{PlainHTTP: true, RegistryScopes: []string{"pull,push", "repository:base/image:pull"}}
+	repo, err := getRepositoryClient(context.Background(), opts, registry.Reference{Registry: uri.Host, Repository: "app/net-monitor", Reference: "latest"})
+	if err != nil {
+		t.Fatalf("getRepositoryClient() error = %v", err)
+	}
+	if _, err := repo.Resolve(context.Background(), "latest"); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	want := []string{"repository:app/net-monitor:pull,push", "repository:base/image:pull"}
+	if !reflect.DeepEqual(scopes, want) {
+		t.Fatalf("requested token scopes = %v, want %v", scopes, want)
+	}
+
+	for _, value := range []string{"pull,,push", "repository:pull", "repository:app:", ":app:pull"} {
+		opts := &SecureFlagOpts{RegistryScopes: []string{value}}
+		if _, err := getRepositoryClient(context.Background(), opts, registry.Reference{Registry: uri.Host, Repository: "app"}); err == nil {
+			t.Errorf("getRepositoryClient() with --registry-scope %q expects error but got nil", value)
+		}
+	}
+}
+
+// newTokenAuthRegistry returns a registry requiring bearer tokens to resolve
+// the manifest "test:latest", and the number of tokens it issued. Only the
+// last issued token is accepted, earlier tokens are rejected as expired.
+func newTokenAuthRegistry(t testing.TB) (*httptest.Server, *atomic.Int64) {
+	var issued atomic.Int64
+	var ts *httptest.Server
+	ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			fmt.Fprintf(w, `{"token": "token-%d"}`, issued.Add(1))
+		case "/v2/test/manifests/latest":
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token-%d", issued.Load()) {
+				w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:test:pull"`, ts.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.oci.image.manifest.v1+json")
+			w.Header().Set("Docker-Content-Digest", zeroDigest)
+			w.Header().Set("Content-Length", "2")
+		default:
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return ts, &issued
+}
+
+// resolveWithNewClient resolves the manifest "test:latest" of the registry at
+// serverURL with a new repository client caching tokens in cache.
+func resolveWithNewClient(ctx context.Context, serverURL string, cache auth.Cache) error {
+	uri, err := url.Parse(serverURL)
+	if err != nil {
+		return err
+	}
+	opts := &SecureFlagOpts{Username: "user", Password: "password", PlainHTTP: true, AuthCache: cache}
+	remoteRepo, err := getRepositoryClient(ctx, opts, registry.Reference{Registry: uri.Host, Repository: "test", Reference: "latest"})
+	if err != nil {
+		return err
+	}
+	_, err = remoteRepo.Resolve(ctx, "latest")
+	return err
+}
+
+func TestGetRepositoryClient_SharedAuthCache(t *testing.T) {
+	ts, issued := newTokenAuthRegistry(t)
+	defer ts.Close()
+	ctx := context.Background()
+	cache := auth.NewCache()
+
+	for i := 0; i < 3; i++ {
+		if err := resolveWithNewClient(ctx, ts.URL, cache); err != nil {
+			t.Fatalf("Resolve() error = %v", err)
+		}
+	}
+	if got := issued.Load(); got != 1 {
+		t.Fatalf("issued tokens = %d, want 1 shared by the clients", got)
+	}
+
+	// expire the cached token, the next client authenticates again
+	issued.Add(1)
+	if err := resolveWithNewClient(ctx, ts.URL, cache); err != nil {
+		t.Fatalf("Resolve() with expired token error = %v", err)
+	}
+	if got := issued.Load(); got != 3 {
+		t.Fatalf("issued tokens = %d, want 3", got)
+	}
+}
+
+func BenchmarkGetRepositoryClient_AuthCalls(b *testing.B) {
+	ts, issued := newTokenAuthRegistry(b)
+	defer ts.Close()
+	ctx := context.Background()
+
+	for _, bm := range []struct {
+		name   string
+		shared bool
+	}{
+		{name: "shared auth cache", shared: true},
+		{name: "auth cache per client"},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			cache := auth.NewCache()
+			start := issued.Load()
+			for i := 0; i < b.N; i++ {
+				if !bm.shared {
+					cache = nil
+				}
+				if err := resolveWithNewClient(ctx, ts.URL, cache); err != nil {
+					b.Fatalf("Resolve() error = %v", err)
+				}
+			}
+			b.ReportMetric(float64(issued.Load()-start)/float64(b.N), "auth-calls/op")
+		})
+	}
+}
+
+func TestGetRepositoryClient_UserAgent(t *testing.T) {
+	var userAgent string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := registry.Reference{Registry: u.Host, Repository: "net-monitor", Reference: "v1"}
+
+	for _, tt := range []struct {
+		userAgent string
+		want      string
+	}{
+		{want: "notation/"},
+		{userAgent: "acme-pipeline/1.2", want: "acme-pipeline/1.2"},
+	} {
+		repo, err := getRepositoryClient(context.Background(), &SecureFlagOpts{PlainHTTP: true, UserAgent: tt.userAgent}, ref)
+		if err != nil {
+			t.Fatalf("getRepositoryClient() error = %v", err)
+		}
+		repo.Resolve(context.Background(), ref.Reference)
+		if !strings.HasPrefix(userAgent, tt.want) {
+			t.Fatalf("User-Agent = %q, want %q", userAgent, tt.want)
+		}
+	}
+}
+
+func TestValidateRegistryUserAgent(t *testing.T) {
+	for _, tt := range []struct {
+		userAgent string
+		wantErr   bool
+	}{
+		{userAgent: ""},
+		{userAgent: "tenant-a/1.0 (quota-group 7)"},
+		{userAgent: strings.Repeat("a", maxRegistryUserAgentLength)},
+		{userAgent: strings.Repeat("a", maxRegistryUserAgentLength+1), wantErr: true},
+		{userAgent: "tenant-a\r\nX-Injected: 1", wantErr: true},
+		{userAgent: "tenant-ä", wantErr: true},
+	} {
+		if err := validateRegistryUserAgent(tt.userAgent); (err != nil) != tt.wantErr {
+			t.Fatalf("validateRegistryUserAgent(%q) error = %v, wantErr %v", tt.userAgent, err, tt.wantErr)
+		}
+	}
+}
+
+func TestRegistryUserAgentFlag(t *testing.T) {
+	t.Setenv(registryUserAgentEnv, "tenant-a/1.0")
+	verify := &verifyOpts{}
+	list := &listOpts{}
+	inspect := &inspectOpts{}
+	for _, tt := range []struct {
+		name      string
+		command   *cobra.Command
+		userAgent *string
+	}{
+		{name: "verify", command: verifyCommand(verify), userAgent: &verify.UserAgent},
+		{name: "list", command: listCommand(list), userAgent: &list.UserAgent},
+		{name: "inspect", command: inspectCommand(inspect), userAgent: &inspect.UserAgent},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if *tt.userAgent != "tenant-a/1.0" {
+				t.Fatalf("UserAgent = %q, want tenant-a/1.0 of %s", *tt.userAgent, registryUserAgentEnv)
+			}
+			if err := tt.command.ParseFlags([]string{"ref", "--registry-user-agent", "tenant-b/2.0"}); err != nil {
+				t.Fatalf("Parse Flag failed: %v", err)
+			}
+			if *tt.userAgent != "tenant-b/2.0" {
+				t.Fatalf("UserAgent = %q, want tenant-b/2.0", *tt.userAgent)
+			}
+		})
+	}
+
+	command := verifyCommand(nil)
+	command.SetArgs([]string{"localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", "--registry-user-agent", "tenant-a\n"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--registry-user-agent") {
+		t.Fatalf("Execute() error = %v, want error of invalid --registry-user-agent", err)
+	}
+}
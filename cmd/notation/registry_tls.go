@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/notaryproject/notation/internal/slices"
+)
+
+// defaultMinTLSVersion is the minimum TLS version of connections to
+// registries if --min-tls-version is not set.
+const defaultMinTLSVersion = "1.2"
+
+// supportedMinTLSVersions are the values of --min-tls-version. Versions older
+// than TLS 1.2 are insecure and not supported.
+var supportedMinTLSVersions = []string{"1.2", "1.3"}
+
+// registryTransports are the transports to registries by TLS configuration,
+// shared by all the clients of an invocation so that connections are reused.
+var registryTransports = struct {
+	sync.Mutex
+	byConfig map[registryTLSConfig]http.RoundTripper
+}{byConfig: make(map[registryTLSConfig]http.RoundTripper)}
+
+// registryTLSConfig is the TLS configuration of connections to registries
+// set by --min-tls-version and --cipher-suites.
+type registryTLSConfig struct {
+	minVersion uint16
+	// cipherSuites are the comma separated names of the allowed cipher
+	// suites, or empty for the default cipher suites of Go, as a string so
+	// that the configuration is comparable.
+	cipherSuites string
+}
+
+// parseMinTLSVersion parses the value of --min-tls-version. An empty value is
+// the default minimum TLS version.
+func parseMinTLSVersion(value string) (uint16, error) {
+	if value == "" {
+		value = defaultMinTLSVersion
+	}
+	switch value {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("--min-tls-version must be one of the following %v but got %s", supportedMinTLSVersions, value)
+	}
+}
+
+// parseCipherSuites parses the comma separated cipher suite names of
+// --cipher-suites into cipher suite IDs. Names must be of the secure cipher
+// suites of TLS 1.2 supported by Go, as Go does not allow to configure the
+// cipher suites of TLS 1.3. An empty value is the default cipher suites of
+// Go.
+func parseCipherSuites(value string) ([]uint16, error) {
+	if value == "" {
+		return nil, nil
+	}
+	supported := make(map[string]*tls.CipherSuite)
+	for _, suite := range tls.CipherSuites() {
+		supported[suite.Name] = suite
+	}
+	insecure := make(map[string]bool)
+	for _, suite := range tls.InsecureCipherSuites() {
+		insecure[suite.Name] = true
+	}
+	names := strings.Split(value, ",")
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		suite, ok := supported[name]
+		switch {
+		case insecure[name]:
+			return nil, fmt.Errorf("--cipher-suites: cipher suite %s is insecure and not supported", name)
+		case !ok:
+			return nil, fmt.Errorf("--cipher-suites: unknown cipher suite %s, supported cipher suites are %v", name, supportedCipherSuiteNames())
+		case !slices.Contains(suite.SupportedVersions, tls.VersionTLS12):
+			return nil, fmt.Errorf("--cipher-suites: cipher suite %s is a TLS 1.3 cipher suite, which cannot be configured", name)
+		}
+		if !slices.Contains(ids, suite.ID) {
+			ids = append(ids, suite.ID)
+		}
+	}
+	return ids, nil
+}
+
+// supportedCipherSuiteNames returns the names of the cipher suites accepted by
+// --cipher-suites.
+func supportedCipherSuiteNames() []string {
+	var names []string
+	for _, suite := range tls.CipherSuites() {
+		if slices.Contains(suite.SupportedVersions, tls.VersionTLS12) {
+			names = append(names, suite.Name)
+		}
+	}
+	return names
+}
+
+// getRegistryTLSConfig parses the TLS flags of opts into the TLS
+// configuration of connections to registries.
+func getRegistryTLSConfig(opts *SecureFlagOpts) (registryTLSConfig, error) {
+	minVersion, err := parseMinTLSVersion(opts.MinTLSVersion)
+	if err != nil {
+		return registryTLSConfig{}, err
+	}
+	cipherSuites, err := parseCipherSuites(opts.CipherSuites)
+	if err != nil {
+		return registryTLSConfig{}, err
+	}
+	if len(cipherSuites) > 0 && minVersion == tls.VersionTLS13 {
+		return registryTLSConfig{}, errors.New("--cipher-suites cannot be used with --min-tls-version 1.3, as the cipher suites of TLS 1.3 cannot be configured")
+	}
+	names := make([]string, 0, len(cipherSuites))
+	for _, id := range cipherSuites {
+		names = append(names, tls.CipherSuiteName(id))
+	}
+	return registryTLSConfig{minVersion: minVersion, cipherSuites: strings.Join(names, ",")}, nil
+}
+
+// getRegistryTransport returns the transport to registries with the TLS
+// configuration config.
+func getRegistryTransport(config registryTLSConfig) http.RoundTripper {
+	registryTransports.Lock()
+	defer registryTransports.Unlock()
+	if transport, ok := registryTransports.byConfig[config]; ok {
+		return transport
+	}
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	if base.TLSClientConfig == nil {
+		base.TLSClientConfig = &tls.Config{}
+	}
+	base.TLSClientConfig.MinVersion = config.minVersion
+	if config.cipherSuites != "" {
+		// names are validated by getRegistryTLSConfig
+		cipherSuites, _ := parseCipherSuites(config.cipherSuites)
+		base.TLSClientConfig.CipherSuites = cipherSuites
+	}
+	transport := &registryTLSTransport{base: base, config: config}
+	registryTransports.byConfig[config] = transport
+	return transport
+}
+
+// registryTLSTransport is an http.RoundTripper explaining the failures of
+// TLS handshakes with servers not supporting the minimum TLS version or the
+// allowed cipher suites.
+type registryTLSTransport struct {
+	base   http.RoundTripper
+	config registryTLSConfig
+}
+
+// RoundTrip executes the request with the base transport.
+func (t *registryTLSTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		return resp, nil
+	}
+	switch {
+	case strings.Contains(err.Error(), "protocol version"):
+		return nil, fmt.Errorf("%s does not support %s or higher, required by --min-tls-version: %w", req.URL.Host, tls.VersionName(t.config.minVersion), err)
+	case t.config.cipherSuites != "" && strings.Contains(err.Error(), "handshake failure"):
+		return nil, fmt.Errorf("%s does not support any of the cipher suites %s allowed by --cipher-suites: %w", req.URL.Host, t.config.cipherSuites, err)
+	}
+	return resp, err
+}
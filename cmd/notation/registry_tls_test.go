@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestParseMinTLSVersion(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint16
+		wantErr bool
+	}{
+		{value: "", want: tls.VersionTLS12},
+		{value: "1.2", want: tls.VersionTLS12},
+		{value: "1.3", want: tls.VersionTLS13},
+		{value: "1.1", wantErr: true},
+		{value: "1.0", wantErr: true},
+		{value: "TLS1.3", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseMinTLSVersion(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMinTLSVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("parseMinTLSVersion() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRegistryTransport_Shared(t *testing.T) {
+	tls12 := registryTLSConfig{minVersion: tls.VersionTLS12}
+	tls13 := registryTLSConfig{minVersion: tls.VersionTLS13}
+	if getRegistryTransport(tls12) != getRegistryTransport(tls12) {
+		t.Fatal("getRegistryTransport() returned different transports for the same minimum TLS version")
+	}
+	if getRegistryTransport(tls12) == getRegistryTransport(tls13) {
+		t.Fatal("getRegistryTransport() returned the same transport for different minimum TLS versions")
+	}
+	withCipherSuites := registryTLSConfig{minVersion: tls.VersionTLS12, cipherSuites: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}
+	if getRegistryTransport(tls12) == getRegistryTransport(withCipherSuites) {
+		t.Fatal("getRegistryTransport() returned the same transport for different cipher suites")
+	}
+}
+
+func TestGetRepositoryClient_MinTLSVersion(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+
+	ref, err := registry.ParseReference(strings.TrimPrefix(ts.URL, "https://") + "/net-monitor:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := getRepositoryClient(context.Background(), &SecureFlagOpts{MinTLSVersion: "1.3"}, ref)
+	if err != nil {
+		t.Fatalf("getRepositoryClient() error = %v", err)
+	}
+	_, err = repo.Resolve(context.Background(), ref.Reference)
+	if err == nil || !strings.Contains(err.Error(), "--min-tls-version") {
+		t.Fatalf("Resolve() error = %v, want error of unsupported minimum TLS version", err)
+	}
+
+	if _, err := getRepositoryClient(context.Background(), &SecureFlagOpts{MinTLSVersion: "1.1"}, ref); err == nil {
+		t.Fatal("getRepositoryClient() expected error for unsupported --min-tls-version, but got nil")
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    []uint16
+		wantErr string
+	}{
+		{name: "default", value: ""},
+		{
+			name:  "TLS 1.2 cipher suites",
+			value: "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+			want:  []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384},
+		},
+		{name: "unknown", value: "TLS_ECDHE_ECDSA_WITH_AES_512_GCM_SHA384", wantErr: "unknown cipher suite"},
+		{name: "insecure", value: "TLS_RSA_WITH_RC4_128_SHA", wantErr: "insecure"},
+		{name: "TLS 1.3", value: "TLS_AES_128_GCM_SHA256", wantErr: "TLS 1.3 cipher suite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCipherSuites(tt.value)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("parseCipherSuites() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCipherSuites() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseCipherSuites() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRegistryTLSConfig(t *testing.T) {
+	config, err := getRegistryTLSConfig(&SecureFlagOpts{CipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+	if err != nil {
+		t.Fatalf("getRegistryTLSConfig() error = %v", err)
+	}
+	want := registryTLSConfig{minVersion: tls.VersionTLS12, cipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"}
+	if config != want {
+		t.Fatalf("getRegistryTLSConfig() = %+v, want %+v", config, want)
+	}
+
+	if _, err := getRegistryTLSConfig(&SecureFlagOpts{MinTLSVersion: "1.3", CipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}); err == nil {
+		t.Fatal("getRegistryTLSConfig() expected error for --cipher-suites with --min-tls-version 1.3, but got nil")
+	}
+}
+
+func TestGetRepositoryClient_CipherSuites(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	ts.TLS = &tls.Config{
+		MaxVersion:   tls.VersionTLS12,
+		CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256},
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	ref, err := registry.ParseReference(strings.TrimPrefix(ts.URL, "https://") + "/net-monitor:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo, err := getRepositoryClient(context.Background(), &SecureFlagOpts{CipherSuites: "TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"}, ref)
+	if err != nil {
+		t.Fatalf("getRepositoryClient() error = %v", err)
+	}
+	_, err = repo.Resolve(context.Background(), ref.Reference)
+	if err == nil || !strings.Contains(err.Error(), "--cipher-suites") {
+		t.Fatalf("Resolve() error = %v, want error of unsupported cipher suites", err)
+	}
+
+	if _, err := getRepositoryClient(context.Background(), &SecureFlagOpts{CipherSuites: "TLS_UNKNOWN"}, ref); err == nil {
+		t.Fatal("getRepositoryClient() expected error for unknown --cipher-suites, but got nil")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// referenceRewrite rewrites the artifact paths, in the format
+// {registry}/{repository}, under the prefix from to the prefix to.
+type referenceRewrite struct {
+	from string
+	to   string
+}
+
+// parseReferenceRewrites parses the {from}={to} values of the --rewrite flag.
+// Both sides are a registry, optionally followed by a namespace or a
+// repository, for example "mirror.example.com/library".
+func parseReferenceRewrites(values []string) ([]referenceRewrite, error) {
+	rewrites := make([]referenceRewrite, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --rewrite %q, it must be in the format {from}={to}", value)
+		}
+		for _, prefix := range []string{from, to} {
+			if err := validateRewritePrefix(prefix); err != nil {
+				return nil, fmt.Errorf("invalid --rewrite %q: %w", value, err)
+			}
+		}
+		rewrites = append(rewrites, referenceRewrite{from: from, to: to})
+	}
+	return rewrites, nil
+}
+
+// validateRewritePrefix checks that prefix is a registry, optionally followed
+// by a namespace or a repository, without tag or digest.
+func validateRewritePrefix(prefix string) error {
+	if prefix == "" {
+		return fmt.Errorf("prefix must not be empty")
+	}
+	if strings.ContainsAny(prefix, "@ ") || strings.HasSuffix(prefix, "/") {
+		return fmt.Errorf("prefix %q must be a registry, optionally followed by a repository, without tag or digest", prefix)
+	}
+	if _, err := registry.ParseReference(prefix + "/repository"); err != nil {
+		return fmt.Errorf("prefix %q is not a valid registry or repository: %w", prefix, err)
+	}
+	return nil
+}
+
+// rewriteReference returns ref with its artifact path rewritten by the first
+// rewrite whose prefix from matches it. ok is false if no rewrite matches.
+func rewriteReference(ref registry.Reference, rewrites []referenceRewrite) (rewritten registry.Reference, ok bool, err error) {
+	artifactPath := ref.Registry + "/" + ref.Repository
+	for _, rewrite := range rewrites {
+		if artifactPath != rewrite.from && !strings.HasPrefix(artifactPath, rewrite.from+"/") {
+			continue
+		}
+		rewrittenPath := rewrite.to + strings.TrimPrefix(artifactPath, rewrite.from)
+		registryName, repository, _ := strings.Cut(rewrittenPath, "/")
+		rewritten = registry.Reference{Registry: registryName, Repository: repository, Reference: ref.Reference}
+		if err := rewritten.Validate(); err != nil {
+			return registry.Reference{}, false, fmt.Errorf("failed to rewrite %s with --rewrite %s=%s: %w", ref, rewrite.from, rewrite.to, err)
+		}
+		return rewritten, true, nil
+	}
+	return ref, false, nil
+}
+
+// policyReference returns the reference the trust policy is matched against
+// for ref, which is ref rewritten by the --rewrite flags of opts.
+func policyReference(opts *verifyOpts, ref registry.Reference) (registry.Reference, error) {
+	rewrites, err := parseReferenceRewrites(opts.rewrites)
+	if err != nil {
+		return registry.Reference{}, err
+	}
+	rewritten, _, err := rewriteReference(ref, rewrites)
+	return rewritten, err
+}
+
+// rewrittenRepository is a signature repository of an artifact verified under
+// a rewritten reference. The rewritten reference is resolved by its digest in
+// the repository the artifact is pulled from.
+type rewrittenRepository struct {
+	notationregistry.Repository
+}
+
+// Resolve resolves the tag or digest of reference, ignoring its registry and
+// repository.
+func (r *rewrittenRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if ref, err := registry.ParseReference(reference); err == nil {
+		reference = ref.Reference
+	}
+	return r.Repository.Resolve(ctx, reference)
+}
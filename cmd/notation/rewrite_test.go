@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestParseReferenceRewrites(t *testing.T) {
+	rewrites, err := parseReferenceRewrites([]string{"mirror.example.com/library=docker.io/library", "localhost:5000=registry.example.com"})
+	if err != nil {
+		t.Fatalf("parseReferenceRewrites() error = %v", err)
+	}
+	want := []referenceRewrite{
+		{from: "mirror.example.com/library", to: "docker.io/library"},
+		{from: "localhost:5000", to: "registry.example.com"},
+	}
+	if len(rewrites) != len(want) {
+		t.Fatalf("parseReferenceRewrites() = %v, want %v", rewrites, want)
+	}
+	for i := range want {
+		if rewrites[i] != want[i] {
+			t.Fatalf("parseReferenceRewrites()[%d] = %v, want %v", i, rewrites[i], want[i])
+		}
+	}
+
+	for _, value := range []string{
+		"mirror.example.com",
+		"=docker.io",
+		"mirror.example.com=",
+		"mirror.example.com/=docker.io",
+		"mirror.example.com/library@sha256:abc=docker.io/library",
+		"mirror.example.com/Library=docker.io/library",
+	} {
+		if _, err := parseReferenceRewrites([]string{value}); err == nil {
+			t.Errorf("parseReferenceRewrites(%q) expects error", value)
+		}
+	}
+}
+
+func TestRewriteReference(t *testing.T) {
+	rewrites := []referenceRewrite{
+		{from: "mirror.example.com/library", to: "docker.io/library"},
+		{from: "mirror.example.com", to: "registry.example.com/mirrored"},
+	}
+	tests := []struct {
+		name      string
+		reference string
+		want      string
+		rewritten bool
+	}{
+		{
+			name:      "namespace",
+			reference: "mirror.example.com/library/nginx@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			want:      "docker.io/library/nginx@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9",
+			rewritten: true,
+		},
+		{
+			name:      "first match wins over registry",
+			reference: "mirror.example.com/library/nginx:v1",
+			want:      "docker.io/library/nginx:v1",
+			rewritten: true,
+		},
+		{
+			name:      "registry",
+			reference: "mirror.example.com/library-legacy/nginx:v1",
+			want:      "registry.example.com/mirrored/library-legacy/nginx:v1",
+			rewritten: true,
+		},
+		{
+			name:      "no match",
+			reference: "localhost:5000/net-monitor:v1",
+			want:      "localhost:5000/net-monitor:v1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref, err := registry.ParseReference(tt.reference)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, rewritten, err := rewriteReference(ref, rewrites)
+			if err != nil {
+				t.Fatalf("rewriteReference() error = %v", err)
+			}
+			if got.String() != tt.want || rewritten != tt.rewritten {
+				t.Fatalf("rewriteReference() = (%s, %v), want (%s, %v)", got, rewritten, tt.want, tt.rewritten)
+			}
+		})
+	}
+}
+
+func TestRewriteReference_Invalid(t *testing.T) {
+	ref, err := registry.ParseReference("mirror.example.com/nginx:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rewriting the whole repository to a registry leaves no repository
+	if _, _, err := rewriteReference(ref, []referenceRewrite{{from: "mirror.example.com/nginx", to: "docker.io"}}); err == nil {
+		t.Fatal("rewriteReference() expects error")
+	}
+}
+
+type resolveRecorder struct {
+	notationregistry.Repository
+	reference string
+}
+
+func (r *resolveRecorder) Resolve(_ context.Context, reference string) (ocispec.Descriptor, error) {
+	r.reference = reference
+	return ocispec.Descriptor{}, nil
+}
+
+func TestRewrittenRepository_Resolve(t *testing.T) {
+	recorder := &resolveRecorder{}
+	repo := &rewrittenRepository{Repository: recorder}
+	digest := "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if _, err := repo.Resolve(context.Background(), "docker.io/library/nginx@"+digest); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if recorder.reference != digest {
+		t.Fatalf("resolved %q, want %q", recorder.reference, digest)
+	}
+}
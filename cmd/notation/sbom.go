@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
+)
+
+// maxSBOMSize is the maximum size of an SBOM file attached by
+// `notation sign --attach-sbom`.
+const maxSBOMSize = 64 * 1024 * 1024 // 64 MiB
+
+// validateSBOMMediaType returns an error if mediaType is not a valid media
+// type without parameters.
+func validateSBOMMediaType(mediaType string) error {
+	return validateMediaType("SBOM media type", mediaType)
+}
+
+// validateMediaType checks that mediaType, described by kind in errors, is a
+// lower case "type/subtype" media type without parameters.
+func validateMediaType(kind, mediaType string) error {
+	parsed, params, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", kind, mediaType, err)
+	}
+	if len(params) > 0 || parsed != mediaType || !strings.Contains(mediaType, "/") {
+		return fmt.Errorf("invalid %s %q: media type must be in the lower case \"type/subtype\" format without parameters", kind, mediaType)
+	}
+	return nil
+}
+
+// attachSBOM pushes the SBOM file at path to the repository of ref, as a
+// referrer of the subject manifest with the SBOM media type as its artifact
+// type. It returns the descriptor of the SBOM manifest.
+func attachSBOM(ctx context.Context, opts *SecureFlagOpts, ref registry.Reference, subject ocispec.Descriptor, path, mediaType string, ociImageManifest bool) (ocispec.Descriptor, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+	if info.Size() > maxSBOMSize {
+		return ocispec.Descriptor{}, fmt.Errorf("SBOM %s is too large, exceeding %d bytes", path, maxSBOMSize)
+	}
+	sbom, err := os.ReadFile(path)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to read SBOM: %w", err)
+	}
+
+	remoteRepo, err := getRepositoryClient(ctx, opts, ref)
+	if err != nil {
+		return ocispec.Descriptor{}, err
+	}
+	blobDesc, err := oras.PushBytes(ctx, remoteRepo.Blobs(), mediaType, sbom)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push SBOM: %w", err)
+	}
+	blobDesc.Annotations = map[string]string{
+		ocispec.AnnotationTitle: filepath.Base(path),
+	}
+	packOpts := oras.PackOptions{
+		Subject:           &subject,
+		PackImageManifest: ociImageManifest,
+	}
+	manifestDesc, err := oras.Pack(ctx, remoteRepo, mediaType, []ocispec.Descriptor{blobDesc}, packOpts)
+	if err != nil {
+		return ocispec.Descriptor{}, fmt.Errorf("failed to push SBOM manifest: %w", err)
+	}
+	return manifestDesc, nil
+}
@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestValidateSBOMMediaType(t *testing.T) {
+	valid := []string{"application/spdx+json", "application/vnd.cyclonedx+json", "text/spdx"}
+	for _, mediaType := range valid {
+		if err := validateSBOMMediaType(mediaType); err != nil {
+			t.Fatalf("validateSBOMMediaType(%q) error = %v", mediaType, err)
+		}
+	}
+	invalid := []string{"spdx", "application/spdx+json; charset=utf-8", "Application/SPDX+json", ""}
+	for _, mediaType := range invalid {
+		if err := validateSBOMMediaType(mediaType); err == nil {
+			t.Fatalf("validateSBOMMediaType(%q) expected error, but got nil", mediaType)
+		}
+	}
+}
@@ -4,38 +4,109 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
 	notationregistry "github.com/notaryproject/notation-go/registry"
+	notationerrors "github.com/notaryproject/notation/cmd/notation/internal/errors"
 	"github.com/notaryproject/notation/internal/cmd"
 	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/experimental"
 	"github.com/notaryproject/notation/internal/slices"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/spf13/cobra"
 	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
 const (
 	signatureManifestArtifact = "artifact"
 	signatureManifestImage    = "image"
+	signatureManifestAuto     = "auto"
 )
 
 const referrersTagSchemaDeleteError = "failed to delete dangling referrers index"
 
-var supportedSignatureManifest = []string{signatureManifestArtifact, signatureManifestImage}
+var supportedSignatureManifest = []string{signatureManifestArtifact, signatureManifestImage, signatureManifestAuto}
+
+const (
+	subjectTypeImage    = "image"
+	subjectTypeIndex    = "index"
+	subjectTypeArtifact = "artifact"
+)
+
+// subjectTypeMediaTypes maps the subject types accepted by `--attach-to` to
+// the manifest media types they cover.
+var subjectTypeMediaTypes = map[string][]string{
+	subjectTypeImage:    {ocispec.MediaTypeImageManifest, defaultMediaType},
+	subjectTypeIndex:    {ocispec.MediaTypeImageIndex, mediaTypeDockerManifestList},
+	subjectTypeArtifact: {ocispec.MediaTypeArtifactManifest},
+}
+
+var supportedSubjectTypes = []string{subjectTypeImage, subjectTypeIndex, subjectTypeArtifact}
 
 type signOpts struct {
 	cmd.LoggingFlagOpts
 	cmd.SignerFlagOpts
 	SecureFlagOpts
 	expiry            time.Duration
+	expiryJitter      time.Duration
+	overwriteExpiry   time.Duration
+	clockSkew         time.Duration
+	metadataSchema    string
+	signerInfoFile    string
+	sbomPath          string
+	timestampToken    string
+	sbomMediaType     string
+	dumpTBS           string
+	trustStore        string
+	certChainStore    string
+	sourceDateEpoch   string
+	sourceDate        bool
+	label             string
+	labelAnnotation   bool
+	noReferrersGC     bool
+	referrersRequired bool
+	referrersMode     string
+	embedCRL          bool
+	tsaURL            string
+	tsaRootCert       string
+	stripUnsigned     bool
+	signingAgent      string
+	trustStoreWarn    bool
+	emptyMetadataOK   bool
+	keyUsageCheck     bool
+	outputType        bool
+	summaryFile       string
+	summaryFormat     string
+	printCertChain    string
+	sigRepository     string
+	strict            bool
+	verifyAfterSign   bool
+	tagAs             string
 	pluginConfig      []string
+	pluginConfigFile  string
 	userMetadata      []string
+	userMetadataFile  string
 	reference         string
+	moreReferences    []string
+	continueOnError   bool
 	signatureManifest string
+	attachTo          string
+	maxArtifactSize   string
+	copyAnnotations   []string
+	pemHeaders        []string
+	outputFormat      string
+	jsonSchemaVersion string
+	maxConcurrency    int
+	parallelEnvelopes int
+	dryRun            bool
+	ociLayout         bool
 }
 
 func signCommand(opts *signOpts) *cobra.Command {
@@ -43,7 +114,7 @@ func signCommand(opts *signOpts) *cobra.Command {
 		opts = &signOpts{}
 	}
 	command := &cobra.Command{
-		Use:   "sign [flags] <reference>",
+		Use:   "sign [flags] <reference>...",
 		Short: "Sign artifacts",
 		Long: `Sign artifacts
 
@@ -55,6 +126,9 @@ Example - Sign an OCI artifact using the default signing key, with the default J
 Example - Sign an OCI artifact using the default signing key, with the COSE envelope:
   notation sign --signature-format cose <registry>/<repository>@<digest> 
 
+Example - Sign an OCI artifact using an on-demand remote key, checking that the plugin can generate signatures before signing
+  notation sign --plugin-capability-check --plugin <plugin_name> --id <remote_key_id> <registry>/<repository>@<digest>
+
 Example - Sign an OCI artifact using a specified key
   notation sign --key <key_name> <registry>/<repository>@<digest>
 
@@ -64,109 +138,796 @@ Example - Sign an OCI artifact identified by a tag (Notation will resolve tag to
 Example - Sign an OCI artifact stored in a registry and specify the signature expiry duration, for example 24 hours
   notation sign --expiry 24h <registry>/<repository>@<digest>
 
+Example - Sign an OCI artifact with the signing time set to the source date epoch of the SOURCE_DATE_EPOCH environment variable
+  notation sign --deterministic-signing-time-from-source <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with an expiry duration randomized within 24 hours +/- 2 hours
+  notation sign --expiry 24h --expiry-jitter 2h <registry>/<repository>@<digest>
+
+Example - Renew the signature of an OCI artifact, replacing existing signatures of the same signing certificate that expire within 7 days
+  notation sign --expiry 720h --overwrite-expiry 168h <registry>/<repository>@<digest>
+
 Example - [Experimental] Sign an OCI artifact and use OCI artifact manifest to store the signature:
   notation sign --signature-manifest artifact <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact and use OCI artifact manifest to store the signature if the registry supports it, or OCI image manifest otherwise:
+  notation sign --signature-manifest auto <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact only if it is an image manifest
+  notation sign --attach-to image <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact only if its manifest and the content it references are at most 500 MiB
+  notation sign --max-artifact-size 500MiB <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and copy the annotation "com.example.retention" from the artifact manifest to the signature manifest
+  notation sign --copy-annotation com.example.retention <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and preserve the "friendlyName" bag attribute of the certificates of the signing key in the signature manifest
+  notation sign --pem-headers-preserve friendlyName <registry>/<repository>@<digest>
+
+Example - Attach an SPDX SBOM to an OCI artifact and sign the artifact
+  notation sign --attach-sbom sbom.spdx.json --sbom-media-type application/spdx+json <registry>/<repository>@<digest>
+
+Example - [Advanced] Sign an OCI artifact and push the signature to the dedicated repository "<registry>/signatures"
+  notation sign --signature-repository <registry>/signatures <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with a local key whose certificate file only contains the signing certificate, completing the certificate chain from the trust store "ca:acme-rockets"
+  notation sign --cert-chain-from-store ca:acme-rockets <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and print the certificate chain embedded in the signature, to check that the expected intermediate certificates are included
+  notation sign --print-cert-chain summary <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and write a provenance record of the signature to a file
+  notation sign --signer-info-file signer_info.json <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and check that the signature verifies against the trust store "ca:acme-rockets"
+  notation sign --require-trust-store ca:acme-rockets <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact, verify the signature against the configured trust policy, and delete the signature if it does not pass
+  notation sign --verify-after-sign --strict <registry>/<repository>@<digest>
+
+Example - [Debugging] Sign an OCI artifact and tag the signature manifest as "debug-signature" to find it on a registry without Referrers API
+  notation sign --tag-as debug-signature <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and tag the signing operation with the label "nightly" in the output and signature manifest
+  notation sign --label nightly --label-annotation <registry>/<repository>@<digest>
+
+Example - Write the to-be-signed bytes of the signature of an OCI artifact to a file, without signing the artifact
+  notation sign --dump-tbs tbs.bin <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with the signing time backdated by 30 seconds for verifiers with lagging clocks
+  notation sign --clock-skew-tolerance 30s <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and print the artifact type of the signature manifest
+  notation sign --output-artifact-type <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact without deleting the outdated referrers index, for registries with external garbage collection
+  notation sign --no-referrers-gc <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact, failing if the registry does not support the Referrers API
+  notation sign --fail-if-referrers-unsupported <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and store the signature with the Referrers tag schema, even if the registry advertises the Referrers API
+  notation sign --referrers-mode tag-schema <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and embed the CRL of the signing certificate for offline revocation checks
+  notation sign --embed-crl <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and timestamp the signature with an RFC 3161 TSA, so that it stays verifiable after the signing certificate expires
+  notation sign --timestamp-url https://timestamp.example.com --timestamp-root-cert tsa_root.pem <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and identify the signing pipeline in the signature and in the User-Agent of registry requests
+  notation sign --signing-agent acme-pipeline/1.2 <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with a minimal signature envelope, without the unsigned attributes not required for verification
+  notation sign --strip-unsigned-attributes <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact and print the result in JSON, with the human-readable messages on stderr
+  notation sign --output json <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact, requiring TLS 1.3 for the connections to the registry
+  notation sign --min-tls-version 1.3 <registry>/<repository>@<digest>
+
+Example - Sign multiple OCI artifacts with the signing key loaded once, signing the remaining artifacts after a failure
+  notation sign --continue-on-error <registry>/<repository>@<digest> <registry>/<repository>@<digest>
+
+Example - [Experimental] Sign an OCI artifact stored in an OCI layout directory:
+  notation sign --oci-layout <oci_layout_path>@<digest>
+
+Example - Check that an OCI artifact can be signed, without pushing the signature
+  notation sign --dry-run <registry>/<repository>@<digest>
+
+Example - Sign multiple OCI artifacts, up to 8 at a time
+  notation sign --max-concurrency 8 <registry>/<repository>@<digest> <registry>/<repository>@<digest> ...
+
+Example - Sign an OCI artifact and write a summary of the signing operation in CSV for CI jobs
+  notation sign --summary-file summary.csv --summary-format csv <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with user metadata read from a file of {key}={value} lines, overriding the key "buildId"
+  notation sign --user-metadata-file metadata.txt --user-metadata buildId=42 <registry>/<repository>@<digest>
+
+Example - Sign an OCI artifact with user metadata validated against a metadata schema
+  notation sign --user-metadata buildId=42 --metadata-schema metadata_schema.json <registry>/<repository>@<digest>
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("missing reference")
 			}
 			opts.reference = args[0]
+			if len(args) > 1 {
+				opts.moreReferences = args[1:]
+			}
 			return nil
 		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout")
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// sanity check
+			references := append([]string{opts.reference}, opts.moreReferences...)
+			if len(references) > 1 {
+				if err := checkSignBatch(opts, references); err != nil {
+					return err
+				}
+			}
+			if opts.maxConcurrency < 1 {
+				return fmt.Errorf("--max-concurrency must be at least 1 but got %d", opts.maxConcurrency)
+			}
+			if opts.parallelEnvelopes < 0 {
+				return fmt.Errorf("--parallel-envelope-construction cannot be a negative value, got %d", opts.parallelEnvelopes)
+			}
 			if !validateSignatureManifest(opts.signatureManifest) {
 				return fmt.Errorf("signature manifest must be one of the following %v but got %s", supportedSignatureManifest, opts.signatureManifest)
 			}
-			return runSign(cmd, opts)
+			if opts.attachTo != "" && !slices.Contains(supportedSubjectTypes, opts.attachTo) {
+				return fmt.Errorf("--attach-to must be one of the following %v but got %s", supportedSubjectTypes, opts.attachTo)
+			}
+			if !slices.Contains(supportedReferrersModes, opts.referrersMode) {
+				return fmt.Errorf("--referrers-mode must be one of the following %v but got %s", supportedReferrersModes, opts.referrersMode)
+			}
+			if opts.referrersMode == referrersModeTagSchema && opts.signatureManifest != signatureManifestImage {
+				return fmt.Errorf("--referrers-mode %s requires --signature-manifest %s, as OCI artifact manifests are only discoverable with the Referrers API", referrersModeTagSchema, signatureManifestImage)
+			}
+			if opts.referrersMode == referrersModeAPI && opts.noReferrersGC {
+				return fmt.Errorf("--no-referrers-gc cannot be used with --referrers-mode %s, which does not use the Referrers tag schema", referrersModeAPI)
+			}
+			// the repository of the scopes given as actions depends on the
+			// reference, and is not needed to validate them
+			if _, err := parseRegistryScopes(opts.SecureFlagOpts.RegistryScopes, ""); err != nil {
+				return err
+			}
+			if opts.maxArtifactSize != "" {
+				if _, err := parseArtifactSize(opts.maxArtifactSize); err != nil {
+					return err
+				}
+			}
+			if err := validateExpiryJitter(opts.expiry, opts.expiryJitter); err != nil {
+				return err
+			}
+			if opts.sbomMediaType != "" {
+				if err := validateSBOMMediaType(opts.sbomMediaType); err != nil {
+					return err
+				}
+			}
+			if opts.trustStore != "" {
+				if err := validateTrustStoreName(opts.trustStore); err != nil {
+					return fmt.Errorf("invalid --require-trust-store: %w", err)
+				}
+			}
+			if opts.certChainStore != "" {
+				if err := validateTrustStoreName(opts.certChainStore); err != nil {
+					return fmt.Errorf("invalid --cert-chain-from-store: %w", err)
+				}
+			}
+			if opts.label != "" {
+				if err := validateLabel(opts.label); err != nil {
+					return fmt.Errorf("invalid --label: %w", err)
+				}
+			} else if opts.labelAnnotation {
+				return errors.New("--label-annotation requires --label")
+			}
+			if opts.strict && !opts.keyUsageCheck && !opts.verifyAfterSign {
+				return errors.New("--strict requires --key-usage-check or --verify-after-sign")
+			}
+			if opts.overwriteExpiry < 0 {
+				return fmt.Errorf("--overwrite-expiry cannot be a negative value, got %s", opts.overwriteExpiry)
+			}
+			if err := validateClockSkewTolerance(opts.clockSkew); err != nil {
+				return err
+			}
+			if opts.sourceDateEpoch != "" && !opts.sourceDate {
+				return errors.New("--source-date-epoch requires --deterministic-signing-time-from-source")
+			}
+			if err := validateAnnotationKeys(opts.copyAnnotations); err != nil {
+				return fmt.Errorf("invalid --copy-annotation: %w", err)
+			}
+			if err := validatePEMHeaderNames(opts.pemHeaders); err != nil {
+				return fmt.Errorf("invalid --pem-headers-preserve: %w", err)
+			}
+			if !slices.Contains(supportedSignOutputFormats, opts.outputFormat) {
+				return fmt.Errorf("--output must be one of the following %v but got %s", supportedSignOutputFormats, opts.outputFormat)
+			}
+			jsonOutput := isJSONOutput(opts.outputFormat) || (opts.summaryFile != "" && opts.summaryFormat == signSummaryFormatJSON)
+			if err := validateJSONSchemaVersion(cmd.Flags(), opts.jsonSchemaVersion, jsonOutput); err != nil {
+				return err
+			}
+			if !slices.Contains(supportedSignSummaryFormats, opts.summaryFormat) {
+				return fmt.Errorf("--summary-format must be one of the following %v but got %s", supportedSignSummaryFormats, opts.summaryFormat)
+			}
+			if opts.printCertChain != "" && !slices.Contains(supportedCertChainFormats, opts.printCertChain) {
+				return fmt.Errorf("--print-cert-chain must be one of the following %v but got %s", supportedCertChainFormats, opts.printCertChain)
+			}
+			if opts.tagAs != "" {
+				if err := validateSignatureTag(opts.tagAs); err != nil {
+					return fmt.Errorf("invalid --tag-as: %w", err)
+				}
+			}
+			if envAgent := os.Getenv(signingAgentEnv); envAgent != "" && !cmd.Flags().Changed("signing-agent") {
+				// the environment variable is resolved here, as the flags
+				// conflicting with --signing-agent only check the flag
+				if conflicting := signingAgentConflict(opts); conflicting != "" {
+					fmt.Fprintf(os.Stderr, "Warning: %s is ignored with %s\n", signingAgentEnv, conflicting)
+				} else {
+					opts.signingAgent = envAgent
+				}
+			}
+			if opts.signingAgent != "" {
+				if err := validateSigningAgent(opts.signingAgent); err != nil {
+					return fmt.Errorf("invalid --signing-agent or %s: %w", signingAgentEnv, err)
+				}
+				opts.SecureFlagOpts.UserAgent = opts.signingAgent
+			}
+			if opts.tsaURL != "" {
+				if err := validateTimestampFlags(opts.tsaURL, opts.tsaRootCert); err != nil {
+					return err
+				}
+			}
+			if opts.sigRepository != "" {
+				for _, reference := range references {
+					if _, err := parseSignatureRepository(opts.sigRepository, reference); err != nil {
+						return err
+					}
+				}
+			}
+			results, err := runSign(cmd, opts)
+			if opts.summaryFile == "" {
+				return err
+			}
+			if writeErr := writeSignSummary(opts.summaryFile, opts.summaryFormat, opts.jsonSchemaVersion, results...); writeErr != nil {
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: failed to write sign summary: %v\n", writeErr)
+					return err
+				}
+				return fmt.Errorf("failed to write sign summary: %w", writeErr)
+			}
+			fmt.Fprintln(os.Stderr, "Sign summary written to", opts.summaryFile)
+			return err
 		},
 	}
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SignerFlagOpts.ApplyFlagsToCommand(command)
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
 	cmd.SetPflagExpiry(command.Flags(), &opts.expiry)
+	command.Flags().DurationVar(&opts.expiryJitter, "expiry-jitter", 0, "randomize the expiry duration of each signature within +/- the given duration around --expiry, with a granularity of seconds")
+	command.Flags().DurationVar(&opts.overwriteExpiry, "overwrite-expiry", 0, "replace existing signatures signed with the same signing certificate that expire within the given duration, instead of adding a new signature alongside them")
+	command.Flags().DurationVar(&opts.clockSkew, "clock-skew-tolerance", 0, "backdate the signing time by the given duration, so that verifiers with lagging clocks do not reject the signature as not yet valid. Only local keys are supported")
+	command.Flags().BoolVar(&opts.sourceDate, "deterministic-signing-time-from-source", false, "set the signing time to the source date epoch of --source-date-epoch or of the "+sourceDateEpochEnv+" environment variable, so that re-signing the same source produces the same signing time. Only local keys are supported")
+	command.Flags().StringVar(&opts.sourceDateEpoch, "source-date-epoch", "", "source date epoch for --deterministic-signing-time-from-source in seconds since the Unix epoch, overriding the "+sourceDateEpochEnv+" environment variable")
+	command.MarkFlagsMutuallyExclusive("deterministic-signing-time-from-source", "clock-skew-tolerance")
+	command.MarkFlagsMutuallyExclusive("deterministic-signing-time-from-source", "expiry-jitter")
 	cmd.SetPflagPluginConfig(command.Flags(), &opts.pluginConfig)
-	command.Flags().StringVar(&opts.signatureManifest, "signature-manifest", signatureManifestImage, "[Experimental] manifest type for signature. options: \"image\", \"artifact\"")
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputUsage)
+	setFlagJSONSchemaVersion(command.Flags(), &opts.jsonSchemaVersion)
+	command.Flags().BoolVar(&opts.noReferrersGC, "no-referrers-gc", false, "do not delete the outdated referrers index when storing the signature with the referrers tag schema, leaving dangling indexes to external garbage collection")
+	command.Flags().BoolVar(&opts.referrersRequired, "fail-if-referrers-unsupported", false, "fail if the registry does not support the Referrers API, instead of falling back to the Referrers tag schema to store the signature")
+	command.Flags().StringVar(&opts.referrersMode, "referrers-mode", referrersModeAuto, "how to store the signature as a referrer of the artifact: \"auto\" uses the Referrers API and falls back to the Referrers tag schema if the registry does not support it, \"api\" fails instead of falling back, \"tag-schema\" always uses the Referrers tag schema, options: \"auto\", \"api\", \"tag-schema\"")
+	command.MarkFlagsMutuallyExclusive("referrers-mode", "fail-if-referrers-unsupported")
+	command.Flags().StringVar(&opts.signatureManifest, "signature-manifest", signatureManifestImage, "[Experimental] manifest type for signature. options: \"image\", \"artifact\", \"auto\"")
+	cmd.SetPflagPluginConfigFile(command.Flags(), &opts.pluginConfigFile)
 	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataSignUsage)
+	cmd.SetPflagUserMetadataFile(command.Flags(), &opts.userMetadataFile)
+	cmd.SetPflagEmptyUserMetadataOK(command.Flags(), &opts.emptyMetadataOK)
+	command.Flags().StringVar(&opts.metadataSchema, "metadata-schema", "", "path to a JSON file specifying required keys and value patterns of the user metadata, signing fails if the user metadata does not match")
+	command.Flags().StringArrayVar(&opts.copyAnnotations, "copy-annotation", nil, "annotation key to copy from the artifact manifest to the signature manifest, can be used multiple times")
+	command.Flags().StringSliceVar(&opts.pemHeaders, "pem-headers-preserve", nil, "names of PEM headers and bag attributes of the certificates of the signing key to preserve in the signature manifest annotation \""+pemHeadersAnnotationKey+"\", for example \"friendlyName,localKeyID\". PEM headers are stripped by default. Only local keys are supported")
+	command.Flags().StringVar(&opts.timestampToken, "output-timestamp-token", "", "path to write the DER encoded RFC 3161 timestamp token of the signature to, if the signature is timestamped")
+	command.Flags().StringVar(&opts.signerInfoFile, "signer-info-file", "", "path to write a provenance record of the signature to, with the signer, host, signing time, key name and certificate fingerprint")
+	command.Flags().BoolVar(&opts.embedCRL, "embed-crl", false, "fetch the CRL of the signing certificate and embed it into the unsigned attributes of the signature, so that offline verifiers can check revocation")
+	command.Flags().StringVar(&opts.tsaURL, "timestamp-url", "", "HTTPS URL of an RFC 3161 timestamping authority (TSA) to timestamp the signature with, so that the signature stays verifiable after the signing certificate expires")
+	command.Flags().StringVar(&opts.tsaRootCert, "timestamp-root-cert", "", "path to the PEM or DER encoded root certificates the certificate of the TSA of --timestamp-url must chain to")
+	command.MarkFlagsRequiredTogether("timestamp-url", "timestamp-root-cert")
+	command.Flags().BoolVar(&opts.stripUnsigned, "strip-unsigned-attributes", false, "strip the unsigned attributes of the signature not required for verification, such as the signing agent, for minimal and reproducible signatures. The certificate chain and the timestamp token are kept")
+	command.Flags().StringVar(&opts.signingAgent, "signing-agent", "", "signing agent stored in the signature and sent as the User-Agent of registry requests, to identify the tool, version or pipeline signing, for example \"acme-pipeline/1.2\" (default to $"+signingAgentEnv+" if set, otherwise to the signing agent and User-Agent of notation)")
+	command.Flags().BoolVar(&opts.continueOnError, "continue-on-error", false, "when signing multiple references, sign the remaining references after a failure instead of stopping, and fail at the end if any reference failed")
+	command.Flags().IntVar(&opts.maxConcurrency, "max-concurrency", 1, "when signing multiple references, maximum number of references signed concurrently, to overlap the round-trips to the registry. Only remote references are supported")
+	command.Flags().IntVar(&opts.parallelEnvelopes, "parallel-envelope-construction", 0, "when signing multiple references, number of references whose signature envelopes are built concurrently, while the signatures are pushed to the registry at most --max-concurrency at a time. Only remote references are supported")
+	command.Flags().StringVar(&opts.summaryFile, "summary-file", "", "path to write a summary of the signing operation to, with the status, artifact digest, signature digest and error of the reference, in addition to the console output")
+	command.Flags().StringVar(&opts.summaryFormat, "summary-format", signSummaryFormatJSON, "format of the --summary-file, options: \"json\", \"csv\"")
+	command.Flags().StringVar(&opts.printCertChain, "print-cert-chain", "", "print the certificate chain of the signature, from the signing certificate to the root, after signing, options: \"summary\", \"pem\"")
+	command.Flags().StringVar(&opts.sigRepository, "signature-repository", "", "[Advanced] repository to push the signature to, in the format {registry}/{repository}, instead of the repository of the artifact. The signed artifact stays the given reference")
+	command.Flags().StringArrayVar(&opts.SecureFlagOpts.RegistryScopes, "registry-scope", nil, "additional auth scope to request registry tokens for, either a scope such as \"repository:<repository>:pull,push\" or actions on the repository of the artifact such as \"pull,push\", can be used multiple times")
+	command.Flags().StringVar(&opts.sbomPath, "attach-sbom", "", "path to an SBOM file to push as a referrer of the artifact before signing it")
+	command.Flags().StringVar(&opts.sbomMediaType, "sbom-media-type", "", "media type of the SBOM file attached by --attach-sbom, for example \"application/spdx+json\"")
+	command.MarkFlagsRequiredTogether("attach-sbom", "sbom-media-type")
+	command.Flags().StringVar(&opts.trustStore, "require-trust-store", "", "check that the new signature verifies against the named trust store, in the format {type}:{name}, for example \"ca:acme-rockets\"")
+	command.Flags().StringVar(&opts.certChainStore, "cert-chain-from-store", "", "complete the certificate chain of the signing key to a root certificate with the certificates of the named trust store, in the format {type}:{name}, for example \"ca:acme-rockets\". Only local keys are supported")
+	command.Flags().BoolVar(&opts.trustStoreWarn, "require-trust-store-warn", false, "print a warning instead of failing if the new signature does not verify against --require-trust-store")
+	command.Flags().StringVar(&opts.label, "label", "", "label echoed in the output and logs of the signing operation to correlate signing events, for example \"nightly\"")
+	command.Flags().BoolVar(&opts.labelAnnotation, "label-annotation", false, "store the --label in the signature manifest annotation \""+labelAnnotationKey+"\"")
+	command.Flags().BoolVar(&opts.verifyAfterSign, "verify-after-sign", false, "verify the signature against the configured trust policy right after pushing it, printing a warning if it would not pass verification")
+	command.Flags().StringVar(&opts.tagAs, "tag-as", "", "[Debugging] tag the pushed signature manifest with the given tag, to find the signature manually on registries without Referrers API. This is non-standard and the tag is ignored by verifiers")
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "resolve the artifact and build the signature without pushing it, printing the descriptor of the signature manifest that would be pushed. For local references, only resolve the artifact, without writing to the OCI layout")
+	command.Flags().StringVar(&opts.dumpTBS, "dump-tbs", "", "path to write the bytes the signature envelope signs over to, without signing the artifact. Only local keys are supported")
+	command.Flags().BoolVar(&opts.outputType, "output-artifact-type", false, "[Advanced] print the artifact type of the pushed signature manifest, which referrers queries filter on, and fail if it is not \""+notationregistry.ArtifactTypeNotation+"\"")
+	for _, name := range []string{"attach-sbom", "overwrite-expiry", "signer-info-file", "output-timestamp-token", "require-trust-store", "label-annotation", "output-artifact-type", "summary-file", "embed-crl", "timestamp-url", "signing-agent", "print-cert-chain", "signature-repository", "strip-unsigned-attributes", "fail-if-referrers-unsupported", "referrers-mode", "cert-chain-from-store", "deterministic-signing-time-from-source", "verify-after-sign", "tag-as", "pem-headers-preserve", "output", "json-schema-version", "max-concurrency", "dry-run"} {
+		command.MarkFlagsMutuallyExclusive("dump-tbs", name)
+	}
+	for _, name := range []string{"overwrite-expiry", "attach-sbom", "tag-as", "output-artifact-type", "verify-after-sign", "require-trust-store", "summary-file"} {
+		command.MarkFlagsMutuallyExclusive("dry-run", name)
+	}
+	command.MarkFlagsMutuallyExclusive("signature-repository", "overwrite-expiry")
+	command.MarkFlagsMutuallyExclusive("signature-repository", "output-artifact-type")
+	command.MarkFlagsMutuallyExclusive("strip-unsigned-attributes", "embed-crl")
+	command.MarkFlagsMutuallyExclusive("strip-unsigned-attributes", "signing-agent")
+	command.MarkFlagsMutuallyExclusive("fail-if-referrers-unsupported", "no-referrers-gc")
+	command.Flags().BoolVar(&opts.keyUsageCheck, "key-usage-check", false, "check that the key usage and extended key usage of the signing certificate are appropriate for code signing before signing, printing a warning otherwise. Only local keys are supported")
+	command.Flags().BoolVar(&opts.strict, "strict", false, "fail instead of printing a warning if --key-usage-check finds issues, or delete the signature and fail if it does not pass --verify-after-sign")
+	command.Flags().StringVar(&opts.attachTo, "attach-to", "", "abort signing if the artifact to be signed is not of the given type, options: \"image\", \"index\", \"artifact\"")
+	command.Flags().StringVar(&opts.maxArtifactSize, "max-artifact-size", "", "abort signing if the size of the artifact to be signed, that is its manifest and the content the manifest references, exceeds the given size in bytes, optionally followed by a unit such as \"MiB\" or \"GB\", for example \"500MiB\"")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] sign the artifact stored as OCI image layout, in the format <path>@<digest> or <path>:<tag>, storing the signature in the same layout, also accepted as --local-content")
+	setFlagOCILayoutAlias(command.Flags())
+	for _, name := range []string{"dump-tbs", "overwrite-expiry", "no-referrers-gc", "fail-if-referrers-unsupported", "referrers-mode", "copy-annotation", "pem-headers-preserve", "output-timestamp-token", "signer-info-file", "print-cert-chain", "signature-repository", "attach-sbom", "require-trust-store", "label-annotation", "verify-after-sign", "tag-as", "output-artifact-type", "max-artifact-size", "max-concurrency", "parallel-envelope-construction", "registry-scope", "output"} {
+		command.MarkFlagsMutuallyExclusive("oci-layout", name)
+	}
 	return command
 }
 
-func runSign(command *cobra.Command, cmdOpts *signOpts) error {
+// runSign signs the artifacts of cmdOpts with a signer built once and reused
+// for all of them, and returns the outcome of each signed reference. Signing
+// stops at the first failure unless --continue-on-error is set.
+func runSign(command *cobra.Command, cmdOpts *signOpts) ([]*signResult, error) {
 	// set log level
 	ctx := cmdOpts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+	// tokens expiring mid-batch are rejected by the registry and fetched
+	// again transparently by the auth client
+	cmdOpts.SecureFlagOpts.AuthCache = auth.NewCache()
+	if cmdOpts.dumpTBS != "" {
+		return nil, dumpTBS(ctx, cmdOpts, cmdOpts.dumpTBS)
+	}
+	if cmdOpts.noReferrersGC {
+		ctx = withoutReferrersGC(ctx)
+	}
+	if cmdOpts.referrersRequired {
+		ctx = withReferrersAPIRequired(ctx)
+	}
+	ctx = withReferrersMode(ctx, cmdOpts.referrersMode)
+	references := append([]string{cmdOpts.reference}, cmdOpts.moreReferences...)
+	failAll := func(err error) ([]*signResult, error) {
+		results := make([]*signResult, 0, len(references))
+		for _, reference := range references {
+			result := &signResult{Reference: reference, Label: cmdOpts.label}
+			result.complete(err)
+			results = append(results, result)
+		}
+		return results, err
+	}
+
+	if cmdOpts.keyUsageCheck {
+		if err := checkSigningKeyUsage(&cmdOpts.SignerFlagOpts, cmdOpts.strict); err != nil {
+			return failAll(err)
+		}
+	}
+
+	// human-readable messages go to stderr with the JSON output
+	out := signMessageWriter(cmdOpts.outputFormat)
 
 	// initialize
-	signer, err := cmd.GetSigner(ctx, &cmdOpts.SignerFlagOpts)
+	signer, err := newSigner(ctx, cmdOpts)
 	if err != nil {
-		return err
+		return failAll(err)
+	}
+
+	// core process
+	if len(references) == 1 {
+		result := &signResult{Reference: cmdOpts.reference, Label: cmdOpts.label}
+		err := signReference(ctx, out, cmdOpts, signer, result)
+		result.complete(err)
+		return []*signResult{result}, err
+	}
+	results, skipped := signReferences(ctx, out, cmdOpts, signer, references)
+	failed := printSignBatchSummary(out, results, skipped)
+	if failed > 0 {
+		// the exit codes of the failed references, such as the one of
+		// rejected OCI artifact manifests, apply to single references only
+		return results, fmt.Errorf("%d of %d references were not signed", failed, len(references))
+	}
+	return results, nil
+}
+
+// signReference signs the artifact of cmdOpts.reference with signer. The
+// outcome is recorded in result.
+func signReference(ctx context.Context, out io.Writer, cmdOpts *signOpts, signer notation.Signer, result *signResult) error {
+	if cmdOpts.ociLayout {
+		if cmdOpts.dryRun {
+			return dryRunSignLocal(ctx, out, cmdOpts.reference, result)
+		}
+		return signLocal(ctx, out, cmdOpts, signer, result)
+	}
+	var sigRepo notationregistry.Repository
+	var err error
+	pushReference := cmdOpts.reference
+	if cmdOpts.sigRepository != "" {
+		pushReference = cmdOpts.sigRepository
 	}
 	ociImageManifest := cmdOpts.signatureManifest == signatureManifestImage
-	sigRepo, err := getSignatureRepositoryForSign(ctx, &cmdOpts.SecureFlagOpts, cmdOpts.reference, ociImageManifest)
+	if cmdOpts.signatureManifest == signatureManifestAuto {
+		sigRepo, ociImageManifest, err = getSignatureRepositoryForSignAuto(ctx, &cmdOpts.SecureFlagOpts, pushReference)
+	} else {
+		sigRepo, err = getSignatureRepositoryForSign(ctx, &cmdOpts.SecureFlagOpts, pushReference, ociImageManifest)
+	}
 	if err != nil {
 		return err
 	}
+	if cmdOpts.sigRepository != "" {
+		subjectRepo, err := getSignatureRepository(ctx, &cmdOpts.SecureFlagOpts, cmdOpts.reference)
+		if err != nil {
+			return err
+		}
+		sigRepo = &signatureRepositoryOverride{Repository: sigRepo, subject: subjectRepo}
+	}
+	if limit := signaturePushLimit(ctx); limit != nil {
+		sigRepo = &pushLimitedRepository{Repository: sigRepo, limit: limit}
+	}
+	if cmdOpts.dryRun {
+		sigRepo = &dryRunRepository{Repository: sigRepo, ociImageManifest: ociImageManifest}
+	}
 	opts, ref, err := prepareSigningContent(ctx, cmdOpts, sigRepo)
 	if err != nil {
 		return err
 	}
+	result.Digest = ref.Reference
+	if len(cmdOpts.copyAnnotations) > 0 {
+		annotations, err := getSubjectAnnotations(ctx, &cmdOpts.SecureFlagOpts, ref, cmdOpts.copyAnnotations)
+		if err != nil {
+			return err
+		}
+		sigRepo = &annotatedRepository{Repository: sigRepo, annotations: annotations}
+	}
+	if cmdOpts.labelAnnotation {
+		sigRepo = &annotatedRepository{Repository: sigRepo, annotations: map[string]string{labelAnnotationKey: cmdOpts.label}}
+	}
+	if len(cmdOpts.pemHeaders) > 0 {
+		annotations, err := getPEMHeaderAnnotations(&cmdOpts.SignerFlagOpts, cmdOpts.pemHeaders)
+		if err != nil {
+			return err
+		}
+		if annotations == nil {
+			fmt.Fprintf(os.Stderr, "Warning: none of the PEM headers %q is present in the certificates of the signing key\n", cmdOpts.pemHeaders)
+		} else {
+			sigRepo = &annotatedRepository{Repository: sigRepo, annotations: annotations}
+		}
+	}
+	if cmdOpts.label != "" {
+		log.GetLogger(ctx).Infof("Signing %s with label %q", ref, cmdOpts.label)
+	}
+	var expiring []expiringSignature
+	if cmdOpts.overwriteExpiry > 0 {
+		expiring, err = listExpiringSignatures(ctx, sigRepo, ref, cmdOpts.overwriteExpiry)
+		if err != nil {
+			return fmt.Errorf("failed to list existing signatures of %s: %w", ref, err)
+		}
+	}
+	recorder := &signatureRecorder{Repository: sigRepo}
+	if needsRecorder(cmdOpts) {
+		sigRepo = recorder
+	}
+	if cmdOpts.sbomPath != "" {
+		subject, err := sigRepo.Resolve(ctx, ref.Reference)
+		if err != nil {
+			return err
+		}
+		sbomDesc, err := attachSBOM(ctx, &cmdOpts.SecureFlagOpts, ref, subject, cmdOpts.sbomPath, cmdOpts.sbomMediaType, ociImageManifest)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Attached SBOM", sbomDesc.Digest, "to", ref)
+	}
 
 	// core process
-	_, err = notation.Sign(ctx, signer, sigRepo, opts)
+	pushErrRepo := &pushErrorRepository{Repository: sigRepo}
+	_, err = notation.Sign(ctx, signer, pushErrRepo, opts)
 	if err != nil {
 		var errorPushSignatureFailed notation.ErrorPushSignatureFailed
-		if errors.As(err, &errorPushSignatureFailed) {
-			if !ociImageManifest {
-				return fmt.Errorf("%v. Possible reason: target registry does not support OCI artifact manifest. Try removing the flag `--signature-manifest artifact` to store signatures using OCI image manifest", err)
-			}
-			if strings.Contains(err.Error(), referrersTagSchemaDeleteError) {
-				fmt.Fprintln(os.Stderr, "Warning: Removal of outdated referrers index is not supported by the remote registry. Garbage collection may be required.")
-				// write out
-				fmt.Println("Successfully signed", ref)
-				return nil
+		if !errors.As(err, &errorPushSignatureFailed) {
+			return err
+		}
+		if !strings.Contains(err.Error(), referrersTagSchemaDeleteError) {
+			// only a rejected media type of the signature manifest is told
+			// apart, not failures such as authentication or network errors
+			if cmdOpts.signatureManifest == signatureManifestArtifact && isArtifactManifestUnsupported(pushErrRepo.err) {
+				return notationerrors.ErrorArtifactManifestNotSupported{
+					Msg: fmt.Sprintf("%v. Possible reason: target registry does not support OCI artifact manifest. Try removing the flag `--signature-manifest artifact` to store signatures using OCI image manifest", err),
+					Err: err,
+				}
 			}
+			return err
+		}
+		// the signature is pushed, and only the outdated referrers index is
+		// left behind, so the signature goes through the steps below
+		fmt.Fprintln(os.Stderr, "Warning: Removal of outdated referrers index is not supported by the remote registry. Garbage collection may be required.")
+		if needsRecorder(cmdOpts) && recorder.blob == nil {
+			return fmt.Errorf("signature of %s was pushed but cannot be found for the remaining steps: %w", ref, err)
 		}
-		return err
 	}
 
 	// write out
-	fmt.Println("Successfully signed", ref)
+	if cmdOpts.dryRun {
+		fmt.Fprintf(out, "Dry run, %s not signed\n", ref)
+		printDryRunDescriptor(out, "Signature manifest that would be pushed:", recorder.manifestDesc)
+	} else {
+		result.SignatureDigest = recorder.manifestDesc.Digest.String()
+		fmt.Fprintln(out, signedMessage(ref.String(), cmdOpts.label))
+	}
+	if cmdOpts.sigRepository != "" && !cmdOpts.dryRun {
+		fmt.Fprintf(out, "Signature stored in %s@%s\n", cmdOpts.sigRepository, recorder.manifestDesc.Digest)
+	}
+	if cmdOpts.sbomPath != "" {
+		fmt.Fprintln(out, "Signature digest:", recorder.manifestDesc.Digest)
+	}
+	if cmdOpts.tagAs != "" {
+		if err := tagSignature(ctx, out, cmdOpts, ref, recorder); err != nil {
+			return err
+		}
+	}
+	if cmdOpts.printCertChain != "" {
+		signerInfo, err := parseSignature(recorder.mediaType, recorder.blob)
+		if err != nil {
+			return fmt.Errorf("failed to parse the new signature: %w", err)
+		}
+		if err := printCertChain(out, cmdOpts.printCertChain, signerInfo.CertificateChain); err != nil {
+			return fmt.Errorf("failed to print certificate chain: %w", err)
+		}
+	}
+	if cmdOpts.outputType {
+		if err := outputSignatureArtifactType(ctx, out, &cmdOpts.SecureFlagOpts, ref, recorder); err != nil {
+			return err
+		}
+	}
+	if cmdOpts.trustStore != "" {
+		if err := selfCheckSignature(ctx, cmdOpts, sigRepo, ref, recorder); err != nil {
+			return err
+		}
+	}
+	if cmdOpts.verifyAfterSign {
+		if err := verifyAfterSign(ctx, cmdOpts, sigRepo, ref, recorder); err != nil {
+			return err
+		}
+	}
+	if cmdOpts.timestampToken != "" {
+		if err := writeTimestampToken(cmdOpts.timestampToken, recorder); err != nil {
+			return fmt.Errorf("failed to write timestamp token: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Timestamp token written to", cmdOpts.timestampToken)
+	}
+	if cmdOpts.signerInfoFile != "" {
+		info, err := newSignerInfo(&cmdOpts.SignerFlagOpts, ref.String(), recorder)
+		if err != nil {
+			return fmt.Errorf("failed to generate signer information: %w", err)
+		}
+		info.Label = cmdOpts.label
+		if err := writeSignerInfo(cmdOpts.signerInfoFile, info); err != nil {
+			return fmt.Errorf("failed to write signer information: %w", err)
+		}
+		fmt.Fprintln(os.Stderr, "Signer information written to", cmdOpts.signerInfoFile)
+	}
+	if err := replaceExpiringSignatures(ctx, out, &cmdOpts.SecureFlagOpts, ref, expiring, recorder); err != nil {
+		return err
+	}
+	if cmdOpts.outputFormat == cmd.OutputJSON {
+		return printSignOutput(cmdOpts, ref, opts.SignatureMediaType, recorder.manifestDesc)
+	}
+	return nil
+}
+
+// signerWrapper is embedded by the signers wrapping a notation.Signer, and
+// forwards PluginAnnotations to the wrapped signer.
+type signerWrapper struct {
+	notation.Signer
+}
+
+// PluginAnnotations forwards to the wrapped signer, so that the annotations
+// of signing plugins are kept in the signature manifest.
+func (s signerWrapper) PluginAnnotations() map[string]string {
+	if annotator, ok := s.Signer.(interface{ PluginAnnotations() map[string]string }); ok {
+		return annotator.PluginAnnotations()
+	}
 	return nil
 }
 
+// newSigner returns the signer selected by opts. Constructing a signer may
+// load the signing key, start a plugin or open a session with a KMS, so the
+// signer is built once and reused for all the signatures of an invocation.
+func newSigner(ctx context.Context, opts *signOpts) (signer notation.Signer, err error) {
+	var signingTime time.Time
+	if opts.sourceDate {
+		signingTime, err = resolveSourceDate(opts.sourceDateEpoch, time.Now(), opts.expiry)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case opts.certChainStore != "":
+		signer, err = newCertChainCompletingSigner(ctx, &opts.SignerFlagOpts, opts.certChainStore, opts.clockSkew, signingTime)
+	case opts.sourceDate:
+		signer, err = newSourceDateSigner(&opts.SignerFlagOpts, signingTime)
+	case opts.clockSkew > 0:
+		signer, err = newBackdatedSigner(&opts.SignerFlagOpts, opts.clockSkew)
+	default:
+		signer, err = cmd.GetSigner(ctx, &opts.SignerFlagOpts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.signingAgent != "" {
+		signer = &signingAgentSigner{signerWrapper: signerWrapper{Signer: signer}, agent: opts.signingAgent}
+	}
+	if opts.embedCRL {
+		signer = newCRLEmbeddingSigner(signer)
+	}
+	if opts.tsaURL != "" {
+		signer, err = newTimestampingSigner(signer, opts.tsaURL, opts.tsaRootCert)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if opts.stripUnsigned {
+		signer = &unsignedAttributeStrippingSigner{signerWrapper: signerWrapper{Signer: signer}}
+	}
+	return signer, nil
+}
+
 func prepareSigningContent(ctx context.Context, opts *signOpts, sigRepo notationregistry.Repository) (notation.RemoteSignOptions, registry.Reference, error) {
-	ref, err := resolveReference(ctx, &opts.SecureFlagOpts, opts.reference, sigRepo, func(ref registry.Reference, manifestDesc ocispec.Descriptor) {
+	manifestDesc, ref, err := resolveReference(ctx, &opts.SecureFlagOpts, opts.reference, sigRepo, func(ref registry.Reference, manifestDesc ocispec.Descriptor) {
 		fmt.Fprintf(os.Stderr, "Warning: Always sign the artifact using digest(@sha256:...) rather than a tag(:%s) because tags are mutable and a tag reference can point to a different artifact than the one signed.\n", ref.Reference)
 	})
 	if err != nil {
 		return notation.RemoteSignOptions{}, registry.Reference{}, err
 	}
+	if opts.attachTo != "" {
+		if err := checkSubjectType(manifestDesc, opts.attachTo); err != nil {
+			return notation.RemoteSignOptions{}, registry.Reference{}, err
+		}
+	}
+	if opts.maxArtifactSize != "" {
+		limit, err := parseArtifactSize(opts.maxArtifactSize)
+		if err != nil {
+			return notation.RemoteSignOptions{}, registry.Reference{}, err
+		}
+		if err := checkArtifactSize(ctx, &opts.SecureFlagOpts, ref, manifestDesc, limit); err != nil {
+			return notation.RemoteSignOptions{}, registry.Reference{}, err
+		}
+	}
+	signOpts, err := newSignOptions(opts, ref.String())
+	if err != nil {
+		return notation.RemoteSignOptions{}, registry.Reference{}, err
+	}
+	return signOpts, ref, nil
+}
 
+// needsRecorder reports whether signing with opts uses the pushed signature
+// after notation.Sign, in which case the signature repository records it.
+func needsRecorder(opts *signOpts) bool {
+	// outputs of the signature
+	if opts.outputFormat == cmd.OutputJSON || opts.summaryFile != "" || opts.printCertChain != "" || opts.outputType || opts.timestampToken != "" || opts.signerInfoFile != "" {
+		return true
+	}
+	// checks of the signature
+	if opts.trustStore != "" || opts.verifyAfterSign {
+		return true
+	}
+	// operations on the signature manifest, or reporting its digest
+	return opts.overwriteExpiry > 0 || opts.tagAs != "" || opts.sbomPath != "" || opts.sigRepository != "" || opts.dryRun
+}
+
+// newSignOptions returns the options of signing the artifact reference with
+// the envelope format, plugin config, user metadata and expiry of opts.
+func newSignOptions(opts *signOpts, reference string) (notation.RemoteSignOptions, error) {
 	mediaType, err := envelope.GetEnvelopeMediaType(opts.SignerFlagOpts.SignatureFormat)
 	if err != nil {
-		return notation.RemoteSignOptions{}, registry.Reference{}, err
+		return notation.RemoteSignOptions{}, err
 	}
 	pluginConfig, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
 	if err != nil {
-		return notation.RemoteSignOptions{}, registry.Reference{}, err
+		return notation.RemoteSignOptions{}, err
 	}
-	userMetadata, err := cmd.ParseFlagMap(opts.userMetadata, cmd.PflagUserMetadata.Name)
+	if opts.pluginConfigFile != "" {
+		fileConfig, err := cmd.ParseFlagMapFile(opts.pluginConfigFile, cmd.PflagPluginConfigFile.Name, cmd.ParseFlagMap)
+		if err != nil {
+			return notation.RemoteSignOptions{}, err
+		}
+		pluginConfig = cmd.MergeFlagMaps(fileConfig, pluginConfig)
+	}
+	parseUserMetadata := cmd.ParseUserMetadata
+	if opts.emptyMetadataOK {
+		parseUserMetadata = cmd.ParseUserMetadataAllowEmptyValues
+	}
+	userMetadata, err := parseUserMetadata(opts.userMetadata, cmd.PflagUserMetadata.Name)
 	if err != nil {
-		return notation.RemoteSignOptions{}, registry.Reference{}, err
+		return notation.RemoteSignOptions{}, err
+	}
+	if opts.userMetadataFile != "" {
+		fileMetadata, err := cmd.ParseFlagMapFile(opts.userMetadataFile, cmd.PflagUserMetadataFile.Name, parseUserMetadata)
+		if err != nil {
+			return notation.RemoteSignOptions{}, err
+		}
+		userMetadata = cmd.MergeFlagMaps(fileMetadata, userMetadata)
+	}
+	if opts.metadataSchema != "" {
+		schema, err := loadMetadataSchema(opts.metadataSchema)
+		if err != nil {
+			return notation.RemoteSignOptions{}, err
+		}
+		if err := schema.Validate(userMetadata); err != nil {
+			return notation.RemoteSignOptions{}, err
+		}
+	}
+
+	expiry := opts.expiry
+	if opts.expiryJitter > 0 {
+		expiry = jitterExpiry(opts.expiry, opts.expiryJitter, rand.Int63n)
+		fmt.Fprintf(os.Stderr, "Expiry duration of the signature for %s is set to %s\n", reference, expiry)
 	}
 
 	signOpts := notation.RemoteSignOptions{
 		SignOptions: notation.SignOptions{
-			ArtifactReference:  ref.String(),
+			ArtifactReference:  reference,
 			SignatureMediaType: mediaType,
-			ExpiryDuration:     opts.expiry,
+			ExpiryDuration:     expiry,
 			PluginConfig:       pluginConfig,
 		},
 		UserMetadata: userMetadata,
 	}
-	return signOpts, ref, nil
+	return signOpts, nil
 }
 
 func validateSignatureManifest(signatureManifest string) bool {
 	return slices.Contains(supportedSignatureManifest, signatureManifest)
 }
+
+// checkSubjectType returns an error if the media type of the subject manifest
+// does not belong to the given subject type.
+func checkSubjectType(manifestDesc ocispec.Descriptor, subjectType string) error {
+	if slices.Contains(subjectTypeMediaTypes[subjectType], manifestDesc.MediaType) {
+		return nil
+	}
+	return fmt.Errorf("artifact %s is not of type %q: got media type %s", manifestDesc.Digest, subjectType, manifestDesc.MediaType)
+}
+
+// validateExpiryJitter checks that the expiry jitter can be applied to the
+// expiry duration.
+func validateExpiryJitter(expiry, jitter time.Duration) error {
+	if jitter == 0 {
+		return nil
+	}
+	if jitter < 0 {
+		return errors.New("--expiry-jitter cannot be a negative value")
+	}
+	if expiry == 0 {
+		return errors.New("--expiry-jitter requires --expiry to be set")
+	}
+	if jitter < time.Second {
+		return errors.New("--expiry-jitter supports minimum granularity of seconds")
+	}
+	if jitter >= expiry {
+		return fmt.Errorf("--expiry-jitter %s must be less than --expiry %s", jitter, expiry)
+	}
+	return nil
+}
+
+// jitterExpiry returns expiry randomly shifted within +/- jitter, truncated
+// to seconds. int63n returns a non-negative random number in [0, n).
+func jitterExpiry(expiry, jitter time.Duration, int63n func(n int64) int64) time.Duration {
+	jitterSeconds := int64(jitter / time.Second)
+	offset := int63n(2*jitterSeconds+1) - jitterSeconds
+	return (expiry + time.Duration(offset)*time.Second).Truncate(time.Second)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/cmd"
+)
+
+// checkSignBatch returns an error if the references of a `notation sign`
+// invocation signing multiple references mix OCI layout and remote
+// references, or if opts has flags supporting a single reference only.
+func checkSignBatch(opts *signOpts, references []string) error {
+	var local, remote []string
+	for _, reference := range references {
+		if isLocalReference(reference) {
+			local = append(local, reference)
+		} else {
+			remote = append(remote, reference)
+		}
+	}
+	if len(local) > 0 && len(remote) > 0 {
+		return fmt.Errorf("cannot sign local references %q and remote references %q in one invocation, sign them separately", local, remote)
+	}
+	if len(local) > 0 && (opts.maxConcurrency > 1 || opts.parallelEnvelopes > 0) {
+		return errors.New("--max-concurrency and --parallel-envelope-construction support remote references only, as local references may share an OCI image layout directory")
+	}
+	if opts.dumpTBS != "" || opts.timestampToken != "" || opts.signerInfoFile != "" || opts.tagAs != "" || opts.outputFormat == cmd.OutputJSON {
+		return errors.New("--dump-tbs, --output-timestamp-token, --signer-info-file, --tag-as and --output json support a single reference")
+	}
+	return nil
+}
+
+// isLocalReference reports whether reference refers to an artifact in an OCI
+// image layout directory on the local file system, in the format
+// <path>@<digest> or <path>:<tag>, rather than to an artifact in a registry.
+func isLocalReference(reference string) bool {
+	path, _, err := parseOCILayoutReference(reference)
+	if err != nil {
+		return false
+	}
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// signTask is the signature of a reference by signReferences.
+type signTask struct {
+	reference string
+	result    *signResult
+	err       error
+
+	// started is set if the signature of the reference was started.
+	started bool
+
+	// output buffers the messages of the signature, so that they are printed
+	// in the order of the references.
+	output bytes.Buffer
+
+	// done is closed once the signature completes or is skipped.
+	done chan struct{}
+}
+
+// signReferences signs references with signer, running the signatures of at
+// most cmdOpts.maxConcurrency references at a time. With a larger
+// --parallel-envelope-construction, cmdOpts.parallelEnvelopes references are
+// resolved and have their envelopes built at a time instead, and only their
+// pushes are limited to cmdOpts.maxConcurrency. The results and the messages
+// of the references are printed to out in the order of references,
+// regardless of the order in which the signatures complete. No signature is
+// started after the first failure unless --continue-on-error is set, nor
+// after an interrupt, which aborts the signatures in flight. The references
+// whose signature was not started are returned as skipped.
+func signReferences(ctx context.Context, out io.Writer, cmdOpts *signOpts, signer notation.Signer, references []string) (results []*signResult, skipped []string) {
+	ctx, stopInterrupt := signal.NotifyContext(ctx, os.Interrupt)
+	defer stopInterrupt()
+	// the workers sign under signCtx, so that ctx is not written once the
+	// goroutines reading it are started
+	signCtx := ctx
+	workers := cmdOpts.maxConcurrency
+	if cmdOpts.parallelEnvelopes > workers {
+		workers = cmdOpts.parallelEnvelopes
+		signCtx = withSignaturePushLimit(ctx, cmdOpts.maxConcurrency)
+	}
+	go func() {
+		// a second interrupt terminates the process as usual
+		<-ctx.Done()
+		stopInterrupt()
+	}()
+
+	tasks := make([]*signTask, len(references))
+	for i, reference := range references {
+		tasks[i] = &signTask{
+			reference: reference,
+			result:    &signResult{Reference: reference, Label: cmdOpts.label},
+			done:      make(chan struct{}),
+		}
+	}
+
+	// stop is closed at the first failure without --continue-on-error
+	stop := make(chan struct{})
+	var stopOnce sync.Once
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		case <-ctx.Done():
+			return true
+		default:
+			return false
+		}
+	}
+
+	// dispatch the references in order, skipping the remaining ones once
+	// stopped
+	jobs := make(chan *signTask)
+	go func() {
+		defer close(jobs)
+		for i, task := range tasks {
+			select {
+			case jobs <- task:
+				continue
+			case <-stop:
+			case <-ctx.Done():
+			}
+			for _, task := range tasks[i:] {
+				close(task.done)
+			}
+			return
+		}
+	}()
+	for i := 0; i < workers; i++ {
+		go func() {
+			workerCtx, cancel := context.WithCancel(signCtx)
+			defer cancel()
+			for task := range jobs {
+				if !stopped() {
+					task.started = true
+					refOpts := *cmdOpts
+					refOpts.reference = task.reference
+					task.err = signReference(workerCtx, &task.output, &refOpts, signer, task.result)
+					task.result.complete(task.err)
+					if task.err != nil && !cmdOpts.continueOnError {
+						stopOnce.Do(func() { close(stop) })
+					}
+				}
+				close(task.done)
+			}
+		}()
+	}
+
+	// collect the results in order
+	for _, task := range tasks {
+		<-task.done
+		if !task.started {
+			skipped = append(skipped, task.reference)
+			continue
+		}
+		out.Write(task.output.Bytes())
+		results = append(results, task.result)
+		if task.err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", task.reference, task.err)
+		}
+	}
+	return results, skipped
+}
+
+// printSignBatchSummary writes the summary of signing multiple references to
+// w, listing the signed, failed and skipped references, and returns the
+// number of failed and skipped references.
+func printSignBatchSummary(w io.Writer, results []*signResult, skipped []string) int {
+	var signed, failed []*signResult
+	for _, result := range results {
+		if result.Status == signStatusSigned {
+			signed = append(signed, result)
+		} else {
+			failed = append(failed, result)
+		}
+	}
+	fmt.Fprintln(w, "Signing summary:")
+	fmt.Fprintf(w, "  Total:   %d\n", len(results)+len(skipped))
+	fmt.Fprintf(w, "  Signed:  %d\n", len(signed))
+	fmt.Fprintf(w, "  Failed:  %d\n", len(failed))
+	fmt.Fprintf(w, "  Skipped: %d\n", len(skipped))
+	if len(signed) > 0 {
+		fmt.Fprintln(w, "Signed references:")
+		for _, result := range signed {
+			fmt.Fprintf(w, "  %s\n", result.Reference)
+		}
+	}
+	if len(failed) > 0 {
+		fmt.Fprintln(w, "Failed references:")
+		for _, result := range failed {
+			fmt.Fprintf(w, "  %s: %s\n", result.Reference, result.Error)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintln(w, "Skipped references, not signed after the first failure without --continue-on-error or after an interrupt:")
+		for _, reference := range skipped {
+			fmt.Fprintf(w, "  %s\n", reference)
+		}
+	}
+	return len(failed) + len(skipped)
+}
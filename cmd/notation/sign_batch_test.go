@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestCheckSignBatch(t *testing.T) {
+	layout := t.TempDir()
+	tests := []struct {
+		name       string
+		opts       *signOpts
+		references []string
+		wantErr    bool
+	}{
+		{
+			name:       "remote references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext},
+			references: []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+		},
+		{
+			name:       "local references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext},
+			references: []string{layout + ":v1", layout + ":v2"},
+		},
+		{
+			name:       "mixed local and remote references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext},
+			references: []string{layout + ":v1", "localhost:5000/net-monitor:v2"},
+			wantErr:    true,
+		},
+		{
+			name:       "concurrent remote references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext, maxConcurrency: 4},
+			references: []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+		},
+		{
+			name:       "concurrent local references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext, maxConcurrency: 4},
+			references: []string{layout + ":v1", layout + ":v2"},
+			wantErr:    true,
+		},
+		{
+			name:       "parallel envelope construction of local references",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext, maxConcurrency: 1, parallelEnvelopes: 4},
+			references: []string{layout + ":v1", layout + ":v2"},
+			wantErr:    true,
+		},
+		{
+			name:       "JSON output",
+			opts:       &signOpts{outputFormat: cmd.OutputJSON},
+			references: []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+			wantErr:    true,
+		},
+		{
+			name:       "signer info file",
+			opts:       &signOpts{outputFormat: cmd.OutputPlaintext, signerInfoFile: "signer.json"},
+			references: []string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2"},
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSignBatch(tt.opts, tt.references); (err != nil) != tt.wantErr {
+				t.Fatalf("checkSignBatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrintSignBatchSummary(t *testing.T) {
+	results := []*signResult{
+		{Reference: "localhost:5000/net-monitor:v1", Status: signStatusSigned},
+		{Reference: "localhost:5000/net-monitor:v2", Status: signStatusFailed, Error: "not found"},
+	}
+	var buf bytes.Buffer
+	failed := printSignBatchSummary(&buf, results, []string{"localhost:5000/net-monitor:v3"})
+	if failed != 2 {
+		t.Fatalf("printSignBatchSummary() = %d, want 2", failed)
+	}
+	for _, want := range []string{
+		"Total:   3",
+		"Signed:  1",
+		"Failed:  1",
+		"Skipped: 1",
+		"  localhost:5000/net-monitor:v1\n",
+		"  localhost:5000/net-monitor:v2: not found\n",
+		"  localhost:5000/net-monitor:v3\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("printSignBatchSummary() output = %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+// batchSigner is a notation.Signer returning a placeholder signature after
+// delay, and recording the maximum number of concurrent signatures.
+type batchSigner struct {
+	delay       time.Duration
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (s *batchSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	n := s.inFlight.Add(1)
+	defer s.inFlight.Add(-1)
+	for {
+		max := s.maxInFlight.Load()
+		if n <= max || s.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(s.delay)
+	return []byte("signature"), &signature.SignerInfo{}, nil
+}
+
+// newBatchTestRegistry returns a registry serving the same subject manifest
+// and accepting signatures in all repositories but "missing", answering every
+// request after the latency of the repository given by latency.
+func newBatchTestRegistry(tb testing.TB, latency func(repository string) time.Duration) (host string, subject digest.Digest) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	subject = digest.FromBytes(manifest)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repository, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/v2/"), "/")
+		time.Sleep(latency(repository))
+		switch {
+		case repository == "missing":
+			w.WriteHeader(http.StatusNotFound)
+		case (r.Method == http.MethodHead || r.Method == http.MethodGet) && r.URL.Path == "/v2/"+repository+"/manifests/"+subject.String():
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", subject.String())
+			w.Header().Set("Content-Length", fmt.Sprint(len(manifest)))
+			if r.Method == http.MethodGet {
+				w.Write(manifest)
+			}
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/"+repository+"/referrers/"):
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v2/"+repository+"/blobs/uploads/":
+			w.Header().Set("Location", "/v2/"+repository+"/blobs/uploads/upload")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/"+repository+"/blobs/uploads/upload":
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/v2/"+repository+"/manifests/"):
+			w.Header().Set("OCI-Subject", subject.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			tb.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	tb.Cleanup(ts.Close)
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		tb.Fatalf("invalid test http server: %v", err)
+	}
+	return u.Host, subject
+}
+
+func newBatchTestSignOpts(maxConcurrency int, continueOnError bool) *signOpts {
+	opts := &signOpts{
+		SignerFlagOpts:    cmd.SignerFlagOpts{SignatureFormat: "jws"},
+		signatureManifest: signatureManifestImage,
+		referrersMode:     referrersModeAuto,
+		outputFormat:      cmd.OutputPlaintext,
+		maxConcurrency:    maxConcurrency,
+		continueOnError:   continueOnError,
+	}
+	opts.SecureFlagOpts.PlainHTTP = true
+	return opts
+}
+
+func TestSignReferences(t *testing.T) {
+	// later repositories respond faster, so that their signatures complete
+	// first when signed concurrently
+	host, subject := newBatchTestRegistry(t, func(repository string) time.Duration {
+		var i int
+		fmt.Sscanf(repository, "net-monitor-%d", &i)
+		return time.Duration(4-i) * time.Millisecond
+	})
+	reference := func(repository string) string {
+		return host + "/" + repository + "@" + subject.String()
+	}
+	tests := []struct {
+		name            string
+		maxConcurrency  int
+		continueOnError bool
+		repositories    []string
+		wantSigned      []string
+		wantFailed      []string
+		wantSkipped     []string
+	}{
+		{
+			name:           "sequential",
+			maxConcurrency: 1,
+			repositories:   []string{"net-monitor-1", "net-monitor-2", "net-monitor-3"},
+			wantSigned:     []string{"net-monitor-1", "net-monitor-2", "net-monitor-3"},
+		},
+		{
+			name:           "concurrent",
+			maxConcurrency: 3,
+			repositories:   []string{"net-monitor-1", "net-monitor-2", "net-monitor-3"},
+			wantSigned:     []string{"net-monitor-1", "net-monitor-2", "net-monitor-3"},
+		},
+		{
+			name:           "stop after failure",
+			maxConcurrency: 1,
+			repositories:   []string{"net-monitor-1", "missing", "net-monitor-3"},
+			wantSigned:     []string{"net-monitor-1"},
+			wantFailed:     []string{"missing"},
+			wantSkipped:    []string{"net-monitor-3"},
+		},
+		{
+			name:            "continue on error",
+			maxConcurrency:  3,
+			continueOnError: true,
+			repositories:    []string{"net-monitor-1", "missing", "net-monitor-3"},
+			wantSigned:      []string{"net-monitor-1", "net-monitor-3"},
+			wantFailed:      []string{"missing"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var references []string
+			for _, repository := range tt.repositories {
+				references = append(references, reference(repository))
+			}
+			signer := &batchSigner{}
+			var out bytes.Buffer
+			results, skipped := signReferences(context.Background(), &out, newBatchTestSignOpts(tt.maxConcurrency, tt.continueOnError), signer, references)
+
+			var gotSigned, gotFailed, gotSkipped []string
+			for i, result := range results {
+				if result.Reference != references[i] {
+					t.Fatalf("signReferences() result %d = %s, want %s", i, result.Reference, references[i])
+				}
+				repository := tt.repositories[i]
+				if result.Status == signStatusSigned {
+					gotSigned = append(gotSigned, repository)
+				} else {
+					gotFailed = append(gotFailed, repository)
+				}
+			}
+			for _, reference := range skipped {
+				gotSkipped = append(gotSkipped, strings.TrimSuffix(strings.TrimPrefix(reference, host+"/"), "@"+subject.String()))
+			}
+			if fmt.Sprint(gotSigned) != fmt.Sprint(tt.wantSigned) || fmt.Sprint(gotFailed) != fmt.Sprint(tt.wantFailed) || fmt.Sprint(gotSkipped) != fmt.Sprint(tt.wantSkipped) {
+				t.Fatalf("signReferences() signed %v, failed %v, skipped %v, want signed %v, failed %v, skipped %v", gotSigned, gotFailed, gotSkipped, tt.wantSigned, tt.wantFailed, tt.wantSkipped)
+			}
+			var want string
+			for _, repository := range tt.wantSigned {
+				want += signedMessage(reference(repository), "") + "\n"
+			}
+			if out.String() != want {
+				t.Fatalf("signReferences() output = %q, want %q", out.String(), want)
+			}
+			if max := signer.maxInFlight.Load(); int(max) > tt.maxConcurrency {
+				t.Fatalf("signReferences() signed %d references concurrently, want at most %d", max, tt.maxConcurrency)
+			}
+		})
+	}
+}
+
+func TestSignReferences_Canceled(t *testing.T) {
+	host, subject := newBatchTestRegistry(t, func(string) time.Duration { return 0 })
+	references := []string{
+		host + "/net-monitor-1@" + subject.String(),
+		host + "/net-monitor-2@" + subject.String(),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var out bytes.Buffer
+	results, skipped := signReferences(ctx, &out, newBatchTestSignOpts(2, true), &batchSigner{}, references)
+	if len(results) != 0 || fmt.Sprint(skipped) != fmt.Sprint(references) {
+		t.Fatalf("signReferences() results = %v, skipped = %v, want all references skipped", results, skipped)
+	}
+}
+
+func TestSignReferences_ParallelEnvelopeConstruction(t *testing.T) {
+	host, subject := newBatchTestRegistry(t, func(string) time.Duration { return 0 })
+	var references []string
+	for i := 0; i < 4; i++ {
+		references = append(references, fmt.Sprintf("%s/net-monitor-%d@%s", host, i, subject))
+	}
+	opts := newBatchTestSignOpts(1, false)
+	opts.parallelEnvelopes = 4
+	signer := &batchSigner{delay: 20 * time.Millisecond}
+	var out bytes.Buffer
+	results, skipped := signReferences(context.Background(), &out, opts, signer, references)
+	if len(results) != len(references) || len(skipped) != 0 {
+		t.Fatalf("signReferences() results = %v, skipped = %v, want all references signed", results, skipped)
+	}
+	var want string
+	for i, result := range results {
+		if result.Reference != references[i] || result.Status != signStatusSigned {
+			t.Fatalf("signReferences() result %d = %+v, want %s signed", i, result, references[i])
+		}
+		want += signedMessage(references[i], "") + "\n"
+	}
+	if out.String() != want {
+		t.Fatalf("signReferences() output = %q, want %q", out.String(), want)
+	}
+	if max := signer.maxInFlight.Load(); max < 2 {
+		t.Fatalf("signReferences() built %d envelopes concurrently, want more than 1", max)
+	}
+}
+
+// pushCountingRepository is a notationregistry.Repository recording the
+// maximum number of concurrent pushes.
+type pushCountingRepository struct {
+	notationregistry.Repository
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (r *pushCountingRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	n := r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	for {
+		max := r.maxInFlight.Load()
+		if n <= max || r.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return ocispec.Descriptor{}, ocispec.Descriptor{}, nil
+}
+
+func TestPushLimitedRepository(t *testing.T) {
+	ctx := withSignaturePushLimit(context.Background(), 2)
+	inner := &pushCountingRepository{}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo := &pushLimitedRepository{Repository: inner, limit: signaturePushLimit(ctx)}
+			if _, _, err := repo.PushSignature(ctx, "application/jose+json", nil, ocispec.Descriptor{}, nil); err != nil {
+				t.Errorf("PushSignature() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if max := inner.maxInFlight.Load(); max > 2 {
+		t.Fatalf("pushed %d signatures concurrently, want at most 2", max)
+	}
+
+	canceled, cancel := context.WithCancel(withSignaturePushLimit(context.Background(), 1))
+	limit := signaturePushLimit(canceled)
+	limit <- struct{}{}
+	cancel()
+	repo := &pushLimitedRepository{Repository: inner, limit: limit}
+	if _, _, err := repo.PushSignature(canceled, "application/jose+json", nil, ocispec.Descriptor{}, nil); err != context.Canceled {
+		t.Fatalf("PushSignature() error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func BenchmarkSignReferences(b *testing.B) {
+	host, subject := newBatchTestRegistry(b, func(string) time.Duration { return 2 * time.Millisecond })
+	references := make([]string, 32)
+	for i := range references {
+		references[i] = fmt.Sprintf("%s/net-monitor-%d@%s", host, i, subject)
+	}
+	for _, maxConcurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("max concurrency %d", maxConcurrency), func(b *testing.B) {
+			opts := newBatchTestSignOpts(maxConcurrency, false)
+			for i := 0; i < b.N; i++ {
+				var out bytes.Buffer
+				results, _ := signReferences(context.Background(), &out, opts, &batchSigner{}, references)
+				for _, result := range results {
+					if result.Status != signStatusSigned {
+						b.Fatalf("signReferences() failed to sign %s: %s", result.Reference, result.Error)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Milliseconds())/float64(b.N*len(references)), "ms/reference")
+		})
+	}
+}
+
+// BenchmarkSignReferences_ParallelEnvelopeConstruction signs many small
+// artifacts with a local key, pushing the signatures one at a time, with an
+// increasing number of envelopes built concurrently.
+func BenchmarkSignReferences_ParallelEnvelopeConstruction(b *testing.B) {
+	host, subject := newBatchTestRegistry(b, func(string) time.Duration { return time.Millisecond })
+	references := make([]string, 64)
+	for i := range references {
+		references[i] = fmt.Sprintf("%s/net-monitor-%d@%s", host, i, subject)
+	}
+	key, err := rsa.GenerateKey(rand.Reader, 3072)
+	if err != nil {
+		b.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "batch benchmark"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		b.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		b.Fatal(err)
+	}
+	localSigner, err := signer.New(key, []*x509.Certificate{cert})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, parallelEnvelopes := range []int{0, 4, 16} {
+		b.Run(fmt.Sprintf("parallel envelopes %d", parallelEnvelopes), func(b *testing.B) {
+			opts := newBatchTestSignOpts(1, false)
+			opts.parallelEnvelopes = parallelEnvelopes
+			for i := 0; i < b.N; i++ {
+				var out bytes.Buffer
+				results, _ := signReferences(context.Background(), &out, opts, localSigner, references)
+				for _, result := range results {
+					if result.Status != signStatusSigned {
+						b.Fatalf("signReferences() failed to sign %s: %s", result.Reference, result.Error)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.Elapsed().Milliseconds())/float64(b.N*len(references)), "ms/reference")
+		})
+	}
+}
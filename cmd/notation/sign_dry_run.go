@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+)
+
+// dryRunRepository is a notationregistry.Repository building the signature
+// manifest in memory instead of pushing it, so that --dry-run goes through
+// the whole signing process without writing to the registry.
+type dryRunRepository struct {
+	notationregistry.Repository
+	ociImageManifest bool
+}
+
+// PushSignature builds the signature manifest of the signature blob as
+// notationregistry.Repository does, and returns the descriptors of the blob
+// and the manifest that would be pushed.
+func (r *dryRunRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	store := memory.New()
+	blobDesc, err = oras.PushBytes(ctx, store, mediaType, blob)
+	if err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, err
+	}
+	manifestDesc, err = oras.Pack(ctx, store, notationregistry.ArtifactTypeNotation, []ocispec.Descriptor{blobDesc}, oras.PackOptions{
+		Subject:             &subject,
+		ManifestAnnotations: annotations,
+		PackImageManifest:   r.ociImageManifest,
+	})
+	if err != nil {
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, err
+	}
+	return blobDesc, manifestDesc, nil
+}
+
+// dryRunSignLocal resolves the artifact of the local reference in its OCI
+// image layout, opened read-only so that index.json is left untouched, and
+// prints the descriptor of the artifact that would be signed.
+func dryRunSignLocal(ctx context.Context, out io.Writer, reference string, result *signResult) error {
+	layoutPath, tagOrDigest, err := parseOCILayoutReference(reference)
+	if err != nil {
+		return err
+	}
+	layout, err := newReadOnlyOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		return err
+	}
+	desc, err := layout.Resolve(ctx, tagOrDigest)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", reference, err)
+	}
+	result.Digest = desc.Digest.String()
+	fmt.Fprintf(out, "Dry run, %s@%s not signed\n", layoutPath, desc.Digest)
+	printDryRunDescriptor(out, "Artifact that would be signed:", desc)
+	return nil
+}
+
+// printDryRunDescriptor prints desc under the given title.
+func printDryRunDescriptor(out io.Writer, title string, desc ocispec.Descriptor) {
+	fmt.Fprintln(out, title)
+	fmt.Fprintln(out, "  Media type:", desc.MediaType)
+	fmt.Fprintln(out, "  Digest:    ", desc.Digest)
+	fmt.Fprintln(out, "  Size:      ", desc.Size)
+}
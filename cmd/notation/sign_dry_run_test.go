@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+func TestDryRunRepository_PushSignature(t *testing.T) {
+	ctx := context.Background()
+	blob := []byte("signature")
+	subject := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("subject"), Size: 7}
+	for _, tt := range []struct {
+		ociImageManifest bool
+		wantMediaType    string
+	}{
+		{ociImageManifest: true, wantMediaType: ocispec.MediaTypeImageManifest},
+		{ociImageManifest: false, wantMediaType: ocispec.MediaTypeArtifactManifest},
+	} {
+		t.Run(tt.wantMediaType, func(t *testing.T) {
+			// the underlying repository is nil, so any push to it panics
+			repo := &dryRunRepository{ociImageManifest: tt.ociImageManifest}
+			blobDesc, manifestDesc, err := repo.PushSignature(ctx, jws.MediaTypeEnvelope, blob, subject, map[string]string{"key": "value"})
+			if err != nil {
+				t.Fatalf("PushSignature() error = %v", err)
+			}
+			if blobDesc.Digest != digest.FromBytes(blob) || blobDesc.MediaType != jws.MediaTypeEnvelope {
+				t.Fatalf("PushSignature() blob descriptor = %+v, want the descriptor of the signature", blobDesc)
+			}
+			if manifestDesc.MediaType != tt.wantMediaType || manifestDesc.Digest == "" || manifestDesc.Size == 0 {
+				t.Fatalf("PushSignature() manifest descriptor = %+v, want a %s", manifestDesc, tt.wantMediaType)
+			}
+		})
+	}
+}
+
+func TestDryRunSignLocal(t *testing.T) {
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := oras.Pack(ctx, store, "application/vnd.example.test", nil, oras.PackOptions{PackImageManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, subject, "v1"); err != nil {
+		t.Fatal(err)
+	}
+	index, err := os.ReadFile(filepath.Join(layoutPath, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	result := &signResult{}
+	if err := dryRunSignLocal(ctx, &out, layoutPath+":v1", result); err != nil {
+		t.Fatalf("dryRunSignLocal() error = %v", err)
+	}
+	if result.Digest != subject.Digest.String() {
+		t.Fatalf("dryRunSignLocal() digest = %s, want %s", result.Digest, subject.Digest)
+	}
+	if !strings.Contains(out.String(), subject.Digest.String()) {
+		t.Fatalf("dryRunSignLocal() output = %q, want it to contain %s", out.String(), subject.Digest)
+	}
+	got, err := os.ReadFile(filepath.Join(layoutPath, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, index) {
+		t.Fatal("dryRunSignLocal() modified index.json")
+	}
+
+	if err := dryRunSignLocal(ctx, &out, layoutPath+":v2", &signResult{}); err == nil {
+		t.Fatal("dryRunSignLocal() expected error for missing tag, but got nil")
+	}
+}
+
+func TestSignReference_DryRun(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[]}`)
+	subject := digest.FromBytes(manifest)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead && r.URL.Path == "/v2/net-monitor/manifests/"+subject.String():
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+			w.Header().Set("Docker-Content-Digest", subject.String())
+			w.Header().Set("Content-Length", "0")
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/v2/net-monitor/referrers/"):
+			w.Header().Set("Content-Type", ocispec.MediaTypeImageIndex)
+			w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`))
+		default:
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	opts := newBatchTestSignOpts(1, false)
+	opts.reference = u.Host + "/net-monitor@" + subject.String()
+	opts.dryRun = true
+	var out bytes.Buffer
+	result := &signResult{Reference: opts.reference}
+	if err := signReference(context.Background(), &out, opts, &batchSigner{}, result); err != nil {
+		t.Fatalf("signReference() error = %v", err)
+	}
+	for _, want := range []string{"Dry run, " + opts.reference + " not signed", "Signature manifest that would be pushed:", ocispec.MediaTypeImageManifest} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("signReference() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+	if result.SignatureDigest != "" {
+		t.Fatalf("signReference() signature digest = %s, want none", result.SignatureDigest)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// localArtifactRegistry is the placeholder registry of the artifact reference
+// given to notation.Sign for artifacts in OCI image layouts. notation.Sign
+// only accepts references in the registry format, and the artifact is
+// resolved by its digest by ociLayoutSignatureRepository.
+const localArtifactRegistry = "localhost"
+
+// ociLayoutSignatureRepository is the signature repository of an OCI image
+// layout, resolving the artifact references given to notation.Sign by their
+// tag or digest in the layout.
+type ociLayoutSignatureRepository struct {
+	notationregistry.Repository
+}
+
+// Resolve resolves the tag or digest of reference, in the format
+// {registry}/{repository}@{digest}, or a tag or a digest.
+func (r *ociLayoutSignatureRepository) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	if ref, err := registry.ParseReference(reference); err == nil {
+		reference = ref.Reference
+	}
+	return r.Repository.Resolve(ctx, reference)
+}
+
+// signLocal signs the artifact of cmdOpts.reference, in the format
+// <path>@<digest> or <path>:<tag>, in an OCI image layout directory with
+// signer. The signature is stored in the same layout, alongside the existing
+// signatures of the artifact. The outcome is recorded in result.
+func signLocal(ctx context.Context, out io.Writer, cmdOpts *signOpts, signer notation.Signer, result *signResult) error {
+	layoutPath, layoutReference, err := parseOCILayoutReference(cmdOpts.reference)
+	if err != nil {
+		return err
+	}
+	layout, err := newOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		return err
+	}
+	ociImageManifest := cmdOpts.signatureManifest != signatureManifestArtifact
+	sigRepo := &ociLayoutSignatureRepository{
+		Repository: notationregistry.NewRepositoryWithOptions(layout, notationregistry.RepositoryOptions{OCIImageManifest: ociImageManifest}),
+	}
+	manifestDesc, err := sigRepo.Resolve(ctx, layoutReference)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", cmdOpts.reference, err)
+	}
+	if layoutReference != manifestDesc.Digest.String() {
+		fmt.Fprintf(os.Stderr, "Warning: Always sign the artifact using digest(@sha256:...) rather than a tag(:%s) because tags are mutable and a tag reference can point to a different artifact than the one signed.\n", layoutReference)
+	}
+	result.Digest = manifestDesc.Digest.String()
+	if cmdOpts.attachTo != "" {
+		if err := checkSubjectType(manifestDesc, cmdOpts.attachTo); err != nil {
+			return err
+		}
+	}
+	name := layoutPath + "@" + manifestDesc.Digest.String()
+	opts, err := newSignOptions(cmdOpts, name)
+	if err != nil {
+		return err
+	}
+	opts.ArtifactReference = registry.Reference{
+		Registry:   localArtifactRegistry,
+		Repository: "oci-layout",
+		Reference:  manifestDesc.Digest.String(),
+	}.String()
+
+	// core process
+	recorder := &signatureRecorder{Repository: sigRepo}
+	if _, err := notation.Sign(ctx, signer, recorder, opts); err != nil {
+		return err
+	}
+
+	// write out
+	result.SignatureDigest = recorder.manifestDesc.Digest.String()
+	fmt.Fprintln(out, signedMessage(name, cmdOpts.label))
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation/internal/cmd"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+)
+
+// saveTestSigningKeys saves a signing key with a certificate chain of a new
+// PKI for each of names, and returns the leaf certificates by name.
+func saveTestSigningKeys(t *testing.T, names ...string) map[string]*x509.Certificate {
+	t.Helper()
+	leaves := make(map[string]*x509.Certificate)
+	signingKeys := &config.SigningKeys{}
+	for _, name := range names {
+		pki := newChainTestPKI(t)
+		keyDER, err := x509.MarshalPKCS8PrivateKey(pki.leafKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyPath := filepath.Join(dir.UserConfigDir, "localkeys", name+".key")
+		certPath := filepath.Join(dir.UserConfigDir, "localkeys", name+".crt")
+		if err := os.MkdirAll(filepath.Dir(keyPath), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+			t.Fatal(err)
+		}
+		var certPEM []byte
+		for _, cert := range [][]byte{pki.leaf.Raw, pki.intermediate.Raw, pki.root.Raw} {
+			certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert})...)
+		}
+		if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+			t.Fatal(err)
+		}
+		signingKeys.Keys = append(signingKeys.Keys, config.KeySuite{
+			Name:        name,
+			X509KeyPair: &config.X509KeyPair{KeyPath: keyPath, CertificatePath: certPath},
+		})
+		leaves[name] = pki.leaf
+	}
+	if err := signingKeys.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return leaves
+}
+
+func TestSignCommand_OCILayoutTwice(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	t.Setenv("NOTATION_EXPERIMENTAL", "1")
+	leaves := saveTestSigningKeys(t, "first", "second")
+
+	ctx := context.Background()
+	layoutPath := t.TempDir()
+	store, err := oci.New(layoutPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := oras.Pack(ctx, store, "application/vnd.example.test", nil, oras.PackOptions{PackImageManifest: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag(ctx, subject, "v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// sign the artifact twice with different keys, as by separate
+	// invocations of notation
+	reference := layoutPath + "@" + subject.Digest.String()
+	for _, key := range []string{"first", "second"} {
+		command := signCommand(nil)
+		command.SetArgs([]string{"--oci-layout", "--key", key, reference})
+		command.SetContext(ctx)
+		command.SilenceUsage = true
+		if err := command.Execute(); err != nil {
+			t.Fatalf("Execute() with key %s error = %v", key, err)
+		}
+	}
+
+	sigRepo, manifestDesc, artifact, err := getListTarget(ctx, &listOpts{reference: layoutPath + ":v1", ociLayout: true}, nil)
+	if err != nil {
+		t.Fatalf("getListTarget() error = %v", err)
+	}
+	if artifact != reference {
+		t.Fatalf("getListTarget() artifact = %s, want %s", artifact, reference)
+	}
+	var signatures []ocispec.Descriptor
+	if err := sigRepo.ListSignatures(ctx, manifestDesc, func(signatureManifests []ocispec.Descriptor) error {
+		signatures = append(signatures, signatureManifests...)
+		return nil
+	}); err != nil {
+		t.Fatalf("ListSignatures() error = %v", err)
+	}
+	if len(signatures) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(signatures))
+	}
+	signedBy := make(map[string]bool)
+	for _, sigManifestDesc := range signatures {
+		sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+		if err != nil {
+			t.Fatalf("FetchSignatureBlob() error = %v", err)
+		}
+		sigEnv, err := signature.ParseEnvelope(sigDesc.MediaType, sigBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		envContent, err := sigEnv.Verify()
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		for key, leaf := range leaves {
+			if bytes.Equal(envContent.SignerInfo.CertificateChain[0].Raw, leaf.Raw) {
+				signedBy[key] = true
+			}
+		}
+	}
+	if !signedBy["first"] || !signedBy["second"] {
+		t.Fatalf("signatures signed by %v, want both keys", signedBy)
+	}
+}
+
+func TestLoadLocalSigningKey_NoDefaultPluginConfig(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	saveTestSigningKeys(t, "local")
+	opts := &cmd.SignerFlagOpts{Key: "local"}
+	if _, _, err := loadLocalSigningKey(opts, "--clock-skew-tolerance"); err != nil {
+		t.Fatalf("loadLocalSigningKey() error = %v", err)
+	}
+	opts.NoDefaultPluginConfig = true
+	if _, _, err := loadLocalSigningKey(opts, "--clock-skew-tolerance"); err == nil || !strings.Contains(err.Error(), "--no-default-plugin-config") {
+		t.Fatalf("loadLocalSigningKey() error = %v, want error of unsupported --no-default-plugin-config", err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/ioutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+var supportedSignOutputFormats = []string{cmd.OutputPlaintext, cmd.OutputJSON}
+
+// signOutput is the result of `notation sign --output json`.
+type signOutput struct {
+	// SchemaVersion is the version of the schema of the output.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Reference is the digest reference of the signed artifact.
+	Reference string `json:"reference"`
+
+	// Digest is the digest of the signed artifact.
+	Digest string `json:"digest"`
+
+	// TagResolved is true if the given reference is a tag reference, which
+	// was resolved to Digest.
+	TagResolved bool `json:"tagResolved"`
+
+	// Signature is the descriptor of the pushed signature manifest.
+	Signature *signatureManifestOutput `json:"signature,omitempty"`
+
+	// EnvelopeMediaType is the media type of the signature envelope.
+	EnvelopeMediaType string `json:"envelopeMediaType"`
+
+	// DryRun is true if the signature was not pushed by --dry-run, Signature
+	// being the descriptor of the signature manifest that would be pushed.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// signatureManifestOutput is the descriptor of a signature manifest in the
+// result of `notation sign --output json`.
+type signatureManifestOutput struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
+}
+
+// newSignOutput returns the result of signing the artifact reference, given
+// as reference and resolved to ref, with a signature envelope of
+// envelopeMediaType. The signature manifest desc is omitted if it is not
+// known.
+func newSignOutput(reference string, ref registry.Reference, envelopeMediaType string, desc ocispec.Descriptor) *signOutput {
+	output := &signOutput{
+		Reference:         ref.String(),
+		Digest:            ref.Reference,
+		EnvelopeMediaType: envelopeMediaType,
+	}
+	if given, err := registry.ParseReference(reference); err == nil {
+		output.TagResolved = given.ValidateReferenceAsDigest() != nil
+	}
+	if desc.Digest != "" {
+		output.Signature = &signatureManifestOutput{
+			MediaType: desc.MediaType,
+			Digest:    desc.Digest.String(),
+			Size:      desc.Size,
+		}
+	}
+	return output
+}
+
+// printSignOutput prints the result of `notation sign --output json` in the
+// schema version requested by cmdOpts.
+func printSignOutput(cmdOpts *signOpts, ref registry.Reference, envelopeMediaType string, desc ocispec.Descriptor) error {
+	output := newSignOutput(cmdOpts.reference, ref, envelopeMediaType, desc)
+	output.SchemaVersion = cmdOpts.jsonSchemaVersion
+	output.DryRun = cmdOpts.dryRun
+	return ioutil.PrintObjectAsJSON(output)
+}
+
+// signMessageWriter returns the writer of the human-readable messages of
+// `notation sign`, which is stderr with the JSON output, so that stdout only
+// has the JSON result.
+func signMessageWriter(outputFormat string) io.Writer {
+	if outputFormat == cmd.OutputJSON {
+		return os.Stderr
+	}
+	return os.Stdout
+}
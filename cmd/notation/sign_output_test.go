@@ -0,0 +1,75 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestNewSignOutput(t *testing.T) {
+	artifactDigest := digest.FromString("artifact")
+	ref := registry.Reference{Registry: "localhost:5000", Repository: "net-monitor", Reference: artifactDigest.String()}
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("signature"), Size: 42}
+
+	tests := []struct {
+		name      string
+		reference string
+		desc      ocispec.Descriptor
+		want      *signOutput
+	}{
+		{
+			name:      "digest reference",
+			reference: ref.String(),
+			desc:      desc,
+			want: &signOutput{
+				Reference:         ref.String(),
+				Digest:            artifactDigest.String(),
+				Signature:         &signatureManifestOutput{MediaType: desc.MediaType, Digest: desc.Digest.String(), Size: 42},
+				EnvelopeMediaType: jws.MediaTypeEnvelope,
+			},
+		},
+		{
+			name:      "tag reference",
+			reference: "localhost:5000/net-monitor:v1",
+			desc:      desc,
+			want: &signOutput{
+				Reference:         ref.String(),
+				Digest:            artifactDigest.String(),
+				TagResolved:       true,
+				Signature:         &signatureManifestOutput{MediaType: desc.MediaType, Digest: desc.Digest.String(), Size: 42},
+				EnvelopeMediaType: jws.MediaTypeEnvelope,
+			},
+		},
+		{
+			name:      "unknown signature manifest",
+			reference: ref.String(),
+			want: &signOutput{
+				Reference:         ref.String(),
+				Digest:            artifactDigest.String(),
+				EnvelopeMediaType: jws.MediaTypeEnvelope,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newSignOutput(tt.reference, ref, jws.MediaTypeEnvelope, tt.desc); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("newSignOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignMessageWriter(t *testing.T) {
+	if got := signMessageWriter(cmd.OutputPlaintext); got != os.Stdout {
+		t.Fatal("signMessageWriter() with text output is not stdout")
+	}
+	if got := signMessageWriter(cmd.OutputJSON); got != os.Stderr {
+		t.Fatal("signMessageWriter() with JSON output is not stderr")
+	}
+}
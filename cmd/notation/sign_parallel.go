@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// signaturePushLimitKey is the context key limiting the number of signatures
+// pushed concurrently.
+type signaturePushLimitKey struct{}
+
+// withSignaturePushLimit returns a context under which the signature
+// repositories for Sign push at most limit signatures at a time, across all
+// the references signed concurrently.
+func withSignaturePushLimit(ctx context.Context, limit int) context.Context {
+	return context.WithValue(ctx, signaturePushLimitKey{}, make(chan struct{}, limit))
+}
+
+// signaturePushLimit returns the semaphore limiting the signatures pushed
+// concurrently under ctx, or nil if they are not limited.
+func signaturePushLimit(ctx context.Context) chan struct{} {
+	limit, _ := ctx.Value(signaturePushLimitKey{}).(chan struct{})
+	return limit
+}
+
+// pushLimitedRepository is a notationregistry.Repository pushing signatures
+// only while holding the semaphore limit, so that the envelopes of several
+// references are built concurrently while their pushes are bounded.
+type pushLimitedRepository struct {
+	notationregistry.Repository
+	limit chan struct{}
+}
+
+// PushSignature waits for the semaphore and pushes the signature with the
+// wrapped repository.
+func (r *pushLimitedRepository) PushSignature(ctx context.Context, mediaType string, blob []byte, subject ocispec.Descriptor, annotations map[string]string) (blobDesc, manifestDesc ocispec.Descriptor, err error) {
+	select {
+	case r.limit <- struct{}{}:
+	case <-ctx.Done():
+		return ocispec.Descriptor{}, ocispec.Descriptor{}, ctx.Err()
+	}
+	defer func() { <-r.limit }()
+	return r.Repository.PushSignature(ctx, mediaType, blob, subject, annotations)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/notation/internal/osutil"
+)
+
+// signSummaryVersion is the version of the sign summary schema.
+const signSummaryVersion = "1.0"
+
+// supported formats of the sign summary file
+const (
+	signSummaryFormatJSON = "json"
+	signSummaryFormatCSV  = "csv"
+)
+
+var supportedSignSummaryFormats = []string{signSummaryFormatJSON, signSummaryFormatCSV}
+
+// statuses of a reference in the sign summary
+const (
+	signStatusSigned = "signed"
+	signStatusFailed = "failed"
+)
+
+// signSummary is the summary of a signing operation written by
+// `notation sign --summary-file`.
+type signSummary struct {
+	// Version is the version of the summary schema.
+	Version string `json:"version"`
+
+	// SchemaVersion is the version of the schema of the JSON outputs of
+	// notation, set by --json-schema-version.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Results are the results of the signed references.
+	Results []*signResult `json:"results"`
+}
+
+// signResult is the result of signing a reference.
+type signResult struct {
+	// Reference is the reference given to `notation sign`.
+	Reference string `json:"reference"`
+
+	// Status is either "signed" or "failed".
+	Status string `json:"status"`
+
+	// Digest is the digest of the artifact, if it is resolved.
+	Digest string `json:"digest,omitempty"`
+
+	// SignatureDigest is the digest of the pushed signature manifest.
+	SignatureDigest string `json:"signatureDigest,omitempty"`
+
+	// Label is the --label of the signing operation.
+	Label string `json:"label,omitempty"`
+
+	// Error is the error message of a failed signing operation.
+	Error string `json:"error,omitempty"`
+}
+
+// complete sets the status of the result by the error of the signing
+// operation.
+func (r *signResult) complete(err error) {
+	if err != nil {
+		r.Status = signStatusFailed
+		r.Error = err.Error()
+		return
+	}
+	r.Status = signStatusSigned
+}
+
+// marshalSignSummary encodes summary in format.
+func marshalSignSummary(summary *signSummary, format string) ([]byte, error) {
+	switch format {
+	case signSummaryFormatJSON:
+		summaryJSON, err := json.MarshalIndent(summary, "", "    ")
+		if err != nil {
+			return nil, err
+		}
+		return append(summaryJSON, '\n'), nil
+	case signSummaryFormatCSV:
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		if err := w.Write([]string{"reference", "status", "digest", "signatureDigest", "label", "error"}); err != nil {
+			return nil, err
+		}
+		for _, result := range summary.Results {
+			if err := w.Write([]string{result.Reference, result.Status, result.Digest, result.SignatureDigest, result.Label, result.Error}); err != nil {
+				return nil, err
+			}
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported summary format %q", format)
+	}
+}
+
+// writeSignSummary atomically writes the summary of results to path in
+// format, with the given version of the schema of the JSON outputs.
+func writeSignSummary(path, format, schemaVersion string, results ...*signResult) error {
+	data, err := marshalSignSummary(&signSummary{Version: signSummaryVersion, SchemaVersion: schemaVersion, Results: results}, format)
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFileAtomic(path, data)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSignSummary(t *testing.T) {
+	signed := &signResult{Reference: "localhost:5000/net-monitor:v1", Label: "nightly"}
+	signed.Digest = "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	signed.SignatureDigest = "sha256:6f4b2b5a8f9e6c1d0e0e3e6b9f3b5d6d9d0c4d8b4b6e0a1c9f6a0b7c8d9e0f1a"
+	signed.complete(nil)
+	failed := &signResult{Reference: "localhost:5000/net-monitor:v2"}
+	failed.complete(errors.New("failed to resolve, \"v2\" not found"))
+
+	t.Run("json", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "summary.json")
+		if err := writeSignSummary(path, signSummaryFormatJSON, jsonSchemaVersion1, signed, failed); err != nil {
+			t.Fatalf("writeSignSummary() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var summary signSummary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			t.Fatalf("malformed summary: %v", err)
+		}
+		if summary.Version != signSummaryVersion || len(summary.Results) != 2 {
+			t.Fatalf("summary = %+v, want version %s with 2 results", summary, signSummaryVersion)
+		}
+		if summary.SchemaVersion != jsonSchemaVersion1 {
+			t.Fatalf("summary schemaVersion = %q, want %q", summary.SchemaVersion, jsonSchemaVersion1)
+		}
+		if *summary.Results[0] != *signed || *summary.Results[1] != *failed {
+			t.Fatalf("results = (%+v, %+v), want (%+v, %+v)", summary.Results[0], summary.Results[1], signed, failed)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "summary.csv")
+		if err := writeSignSummary(path, signSummaryFormatCSV, jsonSchemaVersion1, signed, failed); err != nil {
+			t.Fatalf("writeSignSummary() error = %v", err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "reference,status,digest,signatureDigest,label,error\n" +
+			"localhost:5000/net-monitor:v1,signed,sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9,sha256:6f4b2b5a8f9e6c1d0e0e3e6b9f3b5d6d9d0c4d8b4b6e0a1c9f6a0b7c8d9e0f1a,nightly,\n" +
+			"localhost:5000/net-monitor:v2,failed,,,,\"failed to resolve, \"\"v2\"\" not found\"\n"
+		if string(data) != want {
+			t.Fatalf("summary = %q, want %q", data, want)
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "summary.xml")
+		if err := writeSignSummary(path, "xml", jsonSchemaVersion1, signed); err == nil {
+			t.Fatal("writeSignSummary() expects error")
+		}
+		if _, err := os.Stat(path); err == nil {
+			t.Fatal("summary file is written for unsupported format")
+		}
+	})
+}
@@ -2,12 +2,15 @@ package main
 
 import (
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/notaryproject/notation/internal/cmd"
 	"github.com/notaryproject/notation/internal/envelope"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 func TestSignCommand_BasicArgs(t *testing.T) {
@@ -24,6 +27,11 @@ func TestSignCommand_BasicArgs(t *testing.T) {
 			SignatureFormat: envelope.JWS,
 		},
 		signatureManifest: "image",
+		summaryFormat:     signSummaryFormatJSON,
+		referrersMode:     referrersModeAuto,
+		maxConcurrency:    1,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		outputFormat:      cmd.OutputPlaintext,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -56,6 +64,11 @@ func TestSignCommand_MoreArgs(t *testing.T) {
 		},
 		expiry:            24 * time.Hour,
 		signatureManifest: signatureManifestImage,
+		summaryFormat:     signSummaryFormatJSON,
+		referrersMode:     referrersModeAuto,
+		maxConcurrency:    1,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		outputFormat:      cmd.OutputPlaintext,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -88,6 +101,11 @@ func TestSignCommand_CorrectConfig(t *testing.T) {
 		expiry:            365 * 24 * time.Hour,
 		pluginConfig:      []string{"key0=val0", "key1=val1"},
 		signatureManifest: "image",
+		summaryFormat:     signSummaryFormatJSON,
+		referrersMode:     referrersModeAuto,
+		maxConcurrency:    1,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		outputFormat:      cmd.OutputPlaintext,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -138,6 +156,11 @@ func TestSignCommmand_OnDemandKeyOptions(t *testing.T) {
 			SignatureFormat: envelope.JWS,
 		},
 		signatureManifest: "image",
+		summaryFormat:     signSummaryFormatJSON,
+		referrersMode:     referrersModeAuto,
+		maxConcurrency:    1,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		outputFormat:      cmd.OutputPlaintext,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -172,6 +195,11 @@ func TestSignCommmand_OnDemandKeyBadOptions(t *testing.T) {
 				SignatureFormat: envelope.JWS,
 			},
 			signatureManifest: "image",
+			summaryFormat:     signSummaryFormatJSON,
+			referrersMode:     referrersModeAuto,
+			maxConcurrency:    1,
+			jsonSchemaVersion: jsonSchemaVersionLatest,
+			outputFormat:      cmd.OutputPlaintext,
 		}
 		if err := command.ParseFlags([]string{
 			expected.reference,
@@ -208,6 +236,11 @@ func TestSignCommmand_OnDemandKeyBadOptions(t *testing.T) {
 				SignatureFormat: envelope.JWS,
 			},
 			signatureManifest: "image",
+			summaryFormat:     signSummaryFormatJSON,
+			referrersMode:     referrersModeAuto,
+			maxConcurrency:    1,
+			jsonSchemaVersion: jsonSchemaVersionLatest,
+			outputFormat:      cmd.OutputPlaintext,
 		}
 		if err := command.ParseFlags([]string{
 			expected.reference,
@@ -243,6 +276,11 @@ func TestSignCommmand_OnDemandKeyBadOptions(t *testing.T) {
 				SignatureFormat: envelope.JWS,
 			},
 			signatureManifest: "image",
+			summaryFormat:     signSummaryFormatJSON,
+			referrersMode:     referrersModeAuto,
+			maxConcurrency:    1,
+			jsonSchemaVersion: jsonSchemaVersionLatest,
+			outputFormat:      cmd.OutputPlaintext,
 		}
 		if err := command.ParseFlags([]string{
 			expected.reference,
@@ -277,6 +315,11 @@ func TestSignCommmand_OnDemandKeyBadOptions(t *testing.T) {
 				SignatureFormat: envelope.JWS,
 			},
 			signatureManifest: "image",
+			summaryFormat:     signSummaryFormatJSON,
+			referrersMode:     referrersModeAuto,
+			maxConcurrency:    1,
+			jsonSchemaVersion: jsonSchemaVersionLatest,
+			outputFormat:      cmd.OutputPlaintext,
 		}
 		if err := command.ParseFlags([]string{
 			expected.reference,
@@ -310,6 +353,11 @@ func TestSignCommmand_OnDemandKeyBadOptions(t *testing.T) {
 				SignatureFormat: envelope.JWS,
 			},
 			signatureManifest: "image",
+			summaryFormat:     signSummaryFormatJSON,
+			referrersMode:     referrersModeAuto,
+			maxConcurrency:    1,
+			jsonSchemaVersion: jsonSchemaVersionLatest,
+			outputFormat:      cmd.OutputPlaintext,
 		}
 		if err := command.ParseFlags([]string{
 			expected.reference,
@@ -340,3 +388,684 @@ func TestSignCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestSignCommand_AttachTo(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	expected := &signOpts{
+		reference: "ref",
+		SignerFlagOpts: cmd.SignerFlagOpts{
+			Key:             "key",
+			SignatureFormat: envelope.JWS,
+		},
+		signatureManifest: signatureManifestImage,
+		summaryFormat:     signSummaryFormatJSON,
+		referrersMode:     referrersModeAuto,
+		maxConcurrency:    1,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		outputFormat:      cmd.OutputPlaintext,
+		attachTo:          subjectTypeImage,
+	}
+	if err := command.ParseFlags([]string{
+		expected.reference,
+		"--key", expected.Key,
+		"--attach-to", expected.attachTo}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if !reflect.DeepEqual(*expected, *opts) {
+		t.Fatalf("Expect sign opts: %v, got: %v", expected, opts)
+	}
+}
+
+func TestSignCommand_MaxArtifactSize(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--max-artifact-size", "500MiB"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.maxArtifactSize != "500MiB" {
+		t.Fatalf("maxArtifactSize = %q, want 500MiB", opts.maxArtifactSize)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", "--max-artifact-size", "500XB"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--max-artifact-size") {
+		t.Fatalf("Execute() error = %v, want error of invalid --max-artifact-size", err)
+	}
+}
+
+func TestSignCommand_ReferrersMode(t *testing.T) {
+	for _, args := range [][]string{
+		{"--referrers-mode", "tag"},
+		{"--referrers-mode", referrersModeTagSchema, "--signature-manifest", signatureManifestArtifact},
+		{"--referrers-mode", referrersModeAPI, "--no-referrers-gc"},
+	} {
+		command := signCommand(nil)
+		command.SetArgs(append([]string{"localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"}, args...))
+		command.SilenceUsage = true
+		command.SilenceErrors = true
+		if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--referrers-mode") {
+			t.Fatalf("Execute() error = %v, want error of invalid --referrers-mode for %v", err, args)
+		}
+	}
+
+	command := signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--referrers-mode", referrersModeAPI, "--fail-if-referrers-unsupported"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --referrers-mode with --fail-if-referrers-unsupported")
+	}
+}
+
+func TestCheckSubjectType(t *testing.T) {
+	tests := []struct {
+		mediaType   string
+		subjectType string
+		wantErr     bool
+	}{
+		{ocispec.MediaTypeImageManifest, subjectTypeImage, false},
+		{defaultMediaType, subjectTypeImage, false},
+		{ocispec.MediaTypeImageIndex, subjectTypeIndex, false},
+		{mediaTypeDockerManifestList, subjectTypeIndex, false},
+		{ocispec.MediaTypeArtifactManifest, subjectTypeArtifact, false},
+		{ocispec.MediaTypeImageIndex, subjectTypeImage, true},
+		{ocispec.MediaTypeArtifactManifest, subjectTypeImage, true},
+		{ocispec.MediaTypeImageManifest, subjectTypeIndex, true},
+	}
+	for _, tt := range tests {
+		err := checkSubjectType(ocispec.Descriptor{MediaType: tt.mediaType}, tt.subjectType)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("checkSubjectType(%s, %s) error = %v, wantErr %v", tt.mediaType, tt.subjectType, err, tt.wantErr)
+		}
+	}
+}
+
+func TestValidateExpiryJitter(t *testing.T) {
+	tests := []struct {
+		expiry  time.Duration
+		jitter  time.Duration
+		wantErr bool
+	}{
+		{24 * time.Hour, 0, false},
+		{0, 0, false},
+		{24 * time.Hour, 2 * time.Hour, false},
+		{24 * time.Hour, -time.Hour, true},
+		{0, time.Hour, true},
+		{24 * time.Hour, time.Millisecond, true},
+		{time.Hour, time.Hour, true},
+	}
+	for _, tt := range tests {
+		err := validateExpiryJitter(tt.expiry, tt.jitter)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateExpiryJitter(%s, %s) error = %v, wantErr %v", tt.expiry, tt.jitter, err, tt.wantErr)
+		}
+	}
+}
+
+func TestJitterExpiry(t *testing.T) {
+	expiry := 24 * time.Hour
+	jitter := 2 * time.Hour
+	tests := []struct {
+		random int64
+		want   time.Duration
+	}{
+		{0, 22 * time.Hour},
+		{int64(jitter / time.Second), 24 * time.Hour},
+		{2 * int64(jitter/time.Second), 26 * time.Hour},
+	}
+	for _, tt := range tests {
+		got := jitterExpiry(expiry, jitter, func(n int64) int64 {
+			if n != 2*int64(jitter/time.Second)+1 {
+				t.Fatalf("unexpected random range %d", n)
+			}
+			return tt.random
+		})
+		if got != tt.want {
+			t.Errorf("jitterExpiry() with random %d = %s, want %s", tt.random, got, tt.want)
+		}
+	}
+}
+
+func TestSignCommand_OverwriteExpiry(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--overwrite-expiry", "168h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.overwriteExpiry != 168*time.Hour {
+		t.Fatalf("overwriteExpiry = %s, want %s", opts.overwriteExpiry, 168*time.Hour)
+	}
+}
+
+func TestSignCommand_AttachSBOM(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--attach-sbom", "sbom.spdx.json", "--sbom-media-type", "application/spdx+json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateRequiredFlags(); err != nil {
+		t.Fatalf("ValidateRequiredFlags() error = %v", err)
+	}
+	if opts.sbomPath != "sbom.spdx.json" || opts.sbomMediaType != "application/spdx+json" {
+		t.Fatalf("sbom = (%q, %q), want (%q, %q)", opts.sbomPath, opts.sbomMediaType, "sbom.spdx.json", "application/spdx+json")
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--attach-sbom", "sbom.spdx.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expected error for --attach-sbom without --sbom-media-type, but got nil")
+	}
+}
+
+func TestSignCommand_RequireTrustStore(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--require-trust-store", "ca:acme-rockets", "--require-trust-store-warn"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.trustStore != "ca:acme-rockets" || !opts.trustStoreWarn {
+		t.Fatalf("trust store check = (%q, %v), want (%q, %v)", opts.trustStore, opts.trustStoreWarn, "ca:acme-rockets", true)
+	}
+}
+
+func TestSignCommand_DumpTBS(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--dump-tbs", "tbs.bin"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.dumpTBS != "tbs.bin" {
+		t.Fatalf("dump tbs path = %q, want %q", opts.dumpTBS, "tbs.bin")
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--dump-tbs", "tbs.bin", "--signer-info-file", "signer_info.json"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "dump-tbs") {
+		t.Fatalf("Execute() error = %v, want error of mutually exclusive flags", err)
+	}
+}
+
+func TestSignCommand_EmptyUserMetadataOK(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--user-metadata", "note=", "--empty-user-metadata-ok"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.emptyMetadataOK {
+		t.Fatal("empty user metadata ok = false, want true")
+	}
+	if !reflect.DeepEqual(opts.userMetadata, []string{"note="}) {
+		t.Fatalf("user metadata = %v, want %v", opts.userMetadata, []string{"note="})
+	}
+}
+
+func TestSignCommand_KeyUsageCheck(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--key-usage-check", "--strict"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.keyUsageCheck || !opts.strict {
+		t.Fatalf("key usage check = (%v, %v), want (true, true)", opts.keyUsageCheck, opts.strict)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--strict"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || err.Error() != "--strict requires --key-usage-check or --verify-after-sign" {
+		t.Fatalf("Execute() error = %v, want error of --strict requiring --key-usage-check", err)
+	}
+}
+
+func TestSignCommand_ClockSkewTolerance(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--clock-skew-tolerance", "30s"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.clockSkew != 30*time.Second {
+		t.Fatalf("clockSkew = %s, want %s", opts.clockSkew, 30*time.Second)
+	}
+}
+
+func TestSignCommand_OutputArtifactType(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--output-artifact-type", "--dump-tbs", "tbs.bin"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.outputType {
+		t.Fatal("outputType = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --output-artifact-type with --dump-tbs")
+	}
+}
+
+func TestSignCommand_SummaryFile(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--summary-file", "summary.csv", "--summary-format", "csv"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.summaryFile != "summary.csv" || opts.summaryFormat != signSummaryFormatCSV {
+		t.Fatalf("summary = (%s, %s), want (summary.csv, csv)", opts.summaryFile, opts.summaryFormat)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--summary-file", "summary.xml", "--summary-format", "xml"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--summary-format") {
+		t.Fatalf("Execute() error = %v, want error of unsupported --summary-format", err)
+	}
+}
+
+func TestSignCommand_EmbedCRL(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--embed-crl", "--dump-tbs", "tbs.bin"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.embedCRL {
+		t.Fatal("embedCRL = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --embed-crl with --dump-tbs")
+	}
+}
+
+func TestSignCommand_PrintCertChain(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--print-cert-chain", "pem"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.printCertChain != certChainFormatPEM {
+		t.Fatalf("printCertChain = %s, want pem", opts.printCertChain)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--print-cert-chain", "text"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--print-cert-chain") {
+		t.Fatalf("Execute() error = %v, want error of unsupported --print-cert-chain", err)
+	}
+}
+
+func TestSignCommand_SignatureRepository(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--signature-repository", "localhost:5000/signatures", "--overwrite-expiry", "24h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.sigRepository != "localhost:5000/signatures" {
+		t.Fatalf("sigRepository = %s, want localhost:5000/signatures", opts.sigRepository)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --signature-repository with --overwrite-expiry")
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"localhost:5000/net-monitor:v1", "--signature-repository", "localhost:5000/net-monitor"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--signature-repository") {
+		t.Fatalf("Execute() error = %v, want error of --signature-repository being the repository of the artifact", err)
+	}
+}
+
+func TestSignCommand_StripUnsignedAttributes(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--strip-unsigned-attributes", "--embed-crl"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.stripUnsigned {
+		t.Fatal("stripUnsigned = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --strip-unsigned-attributes with --embed-crl")
+	}
+}
+
+func TestSignCommand_FailIfReferrersUnsupported(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--fail-if-referrers-unsupported", "--no-referrers-gc"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.referrersRequired {
+		t.Fatal("referrersRequired = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --fail-if-referrers-unsupported with --no-referrers-gc")
+	}
+}
+
+func TestSignCommand_CertChainFromStore(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--cert-chain-from-store", "ca:acme-rockets"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.certChainStore != "ca:acme-rockets" {
+		t.Fatalf("certChainStore = %s, want ca:acme-rockets", opts.certChainStore)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--cert-chain-from-store", "acme-rockets"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--cert-chain-from-store") {
+		t.Fatalf("Execute() error = %v, want error of invalid --cert-chain-from-store", err)
+	}
+}
+
+func TestSignCommand_DeterministicSigningTimeFromSource(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--deterministic-signing-time-from-source", "--source-date-epoch", "1672531200"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.sourceDate || opts.sourceDateEpoch != "1672531200" {
+		t.Fatalf("sourceDate = %v, sourceDateEpoch = %s, want true, 1672531200", opts.sourceDate, opts.sourceDateEpoch)
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--deterministic-signing-time-from-source", "--clock-skew-tolerance", "30s"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --deterministic-signing-time-from-source with --clock-skew-tolerance")
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--source-date-epoch", "1672531200"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--deterministic-signing-time-from-source") {
+		t.Fatalf("Execute() error = %v, want error of --source-date-epoch without --deterministic-signing-time-from-source", err)
+	}
+}
+
+func TestSignCommand_VerifyAfterSign(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--verify-after-sign", "--strict"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.verifyAfterSign || !opts.strict {
+		t.Fatalf("verifyAfterSign = %v, strict = %v, want true, true", opts.verifyAfterSign, opts.strict)
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--verify-after-sign", "--dump-tbs", "tbs.bin"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --verify-after-sign with --dump-tbs")
+	}
+}
+
+func TestSignCommand_TagAs(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--tag-as", "debug-signature"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.tagAs != "debug-signature" {
+		t.Fatalf("tagAs = %s, want debug-signature", opts.tagAs)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--tag-as", "debug/signature"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--tag-as") {
+		t.Fatalf("Execute() error = %v, want error of invalid --tag-as", err)
+	}
+}
+
+func TestSignCommand_PEMHeadersPreserve(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--pem-headers-preserve", "friendlyName,localKeyID"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if want := []string{"friendlyName", "localKeyID"}; !reflect.DeepEqual(opts.pemHeaders, want) {
+		t.Fatalf("pemHeaders = %v, want %v", opts.pemHeaders, want)
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--pem-headers-preserve", "friendlyName", "--dump-tbs", "tbs.bin"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --pem-headers-preserve with --dump-tbs")
+	}
+}
+
+func TestSignCommand_OutputJSON(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--output", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.outputFormat != cmd.OutputJSON {
+		t.Fatalf("outputFormat = %s, want %s", opts.outputFormat, cmd.OutputJSON)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--output", "yaml"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Fatalf("Execute() error = %v, want error of invalid --output", err)
+	}
+}
+
+func TestSignCommand_MultipleReferences(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref1", "ref2", "--continue-on-error", "ref3"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if opts.reference != "ref1" || !reflect.DeepEqual(opts.moreReferences, []string{"ref2", "ref3"}) || !opts.continueOnError {
+		t.Fatalf("sign opts = (%q, %v, %v), want (%q, %v, %v)", opts.reference, opts.moreReferences, opts.continueOnError, "ref1", []string{"ref2", "ref3"}, true)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"localhost:5000/net-monitor:v1", "localhost:5000/net-monitor:v2", "--tag-as", "sig"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "single reference") {
+		t.Fatalf("Execute() error = %v, want error of flags supporting a single reference", err)
+	}
+}
+
+func TestSignCommand_MetadataAndPluginConfigFiles(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--user-metadata-file", "metadata.txt", "--plugin-config-file", "plugin_config.json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.userMetadataFile != "metadata.txt" || opts.pluginConfigFile != "plugin_config.json" {
+		t.Fatalf("files = (%q, %q), want (%q, %q)", opts.userMetadataFile, opts.pluginConfigFile, "metadata.txt", "plugin_config.json")
+	}
+}
+
+func TestSignCommand_Timestamp(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--timestamp-url", "https://timestamp.example.com", "--timestamp-root-cert", "tsa_root.pem"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.tsaURL != "https://timestamp.example.com" || opts.tsaRootCert != "tsa_root.pem" {
+		t.Fatalf("timestamp flags = (%q, %q), want (%q, %q)", opts.tsaURL, opts.tsaRootCert, "https://timestamp.example.com", "tsa_root.pem")
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--timestamp-url", "https://timestamp.example.com"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --timestamp-url without --timestamp-root-cert")
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--timestamp-url", "http://timestamp.example.com", "--timestamp-root-cert", "tsa_root.pem"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "HTTPS") {
+		t.Fatalf("Execute() error = %v, want error of non-HTTPS --timestamp-url", err)
+	}
+}
+
+func TestSignCommand_SigningAgent(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--signing-agent", "acme-pipeline/1.2"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.signingAgent != "acme-pipeline/1.2" {
+		t.Fatalf("signingAgent = %q, want %q", opts.signingAgent, "acme-pipeline/1.2")
+	}
+
+	t.Setenv(signingAgentEnv, "acme-env/1.0")
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{name: "environment variable", args: []string{"ref"}, want: "acme-env/1.0"},
+		{name: "flag", args: []string{"ref", "--signing-agent", "acme-pipeline/1.2"}, want: "acme-pipeline/1.2"},
+		{name: "strip unsigned attributes", args: []string{"ref", "--strip-unsigned-attributes"}},
+		{name: "dump tbs", args: []string{"ref", "--dump-tbs", filepath.Join(t.TempDir(), "tbs.bin")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := &signOpts{}
+			command := signCommand(opts)
+			command.SetArgs(tt.args)
+			command.SilenceUsage = true
+			command.SilenceErrors = true
+			// signing fails without a signing key, after the signing agent
+			// is resolved
+			command.Execute()
+			if opts.signingAgent != tt.want {
+				t.Fatalf("signingAgent = %q, want %q", opts.signingAgent, tt.want)
+			}
+		})
+	}
+
+	command = signCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--signing-agent", "acme", "--strip-unsigned-attributes"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --signing-agent with --strip-unsigned-attributes")
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--signing-agent", "acme\tpipeline"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--signing-agent") {
+		t.Fatalf("Execute() error = %v, want error of invalid --signing-agent", err)
+	}
+}
+
+func TestSignCommand_RegistryScope(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--registry-scope", "pull,push", "--registry-scope", "repository:base/image:pull"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if want := []string{"pull,push", "repository:base/image:pull"}; !reflect.DeepEqual(opts.SecureFlagOpts.RegistryScopes, want) {
+		t.Fatalf("registry scopes = %v, want %v", opts.SecureFlagOpts.RegistryScopes, want)
+	}
+
+	command = signCommand(nil)
+	command.SetArgs([]string{"ref", "--registry-scope", "repository:pull"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--registry-scope") {
+		t.Fatalf("Execute() error = %v, want error of invalid --registry-scope", err)
+	}
+}
+
+func TestNeedsRecorder(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *signOpts
+		want bool
+	}{
+		{name: "plain signature", opts: &signOpts{outputFormat: cmd.OutputPlaintext}, want: false},
+		{name: "JSON output", opts: &signOpts{outputFormat: cmd.OutputJSON}, want: true},
+		{name: "summary file", opts: &signOpts{summaryFile: "summary.json"}, want: true},
+		{name: "verify after sign", opts: &signOpts{verifyAfterSign: true}, want: true},
+		{name: "overwrite expiry", opts: &signOpts{overwriteExpiry: time.Hour}, want: true},
+		{name: "dry run", opts: &signOpts{dryRun: true}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsRecorder(tt.opts); got != tt.want {
+				t.Fatalf("needsRecorder() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignCommand_LocalContent(t *testing.T) {
+	opts := &signOpts{}
+	command := signCommand(opts)
+	if err := command.ParseFlags([]string{"hello-world@" + zeroDigest, "--local-content", "--key", "key"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.ociLayout {
+		t.Fatal("ociLayout = false, want true for --local-content")
+	}
+}
+
+type annotatingSigner struct {
+	batchSigner
+	annotations map[string]string
+}
+
+func (s *annotatingSigner) PluginAnnotations() map[string]string {
+	return s.annotations
+}
+
+func TestSignerWrapper_PluginAnnotations(t *testing.T) {
+	annotations := map[string]string{"io.example.plugin": "kms"}
+	inner := &annotatingSigner{annotations: annotations}
+	for _, signer := range []interface{ PluginAnnotations() map[string]string }{
+		newCRLEmbeddingSigner(inner),
+		&signingAgentSigner{signerWrapper: signerWrapper{Signer: inner}, agent: "acme"},
+		&timestampingSigner{signerWrapper: signerWrapper{Signer: inner}},
+		&unsignedAttributeStrippingSigner{signerWrapper: signerWrapper{Signer: &unsignedAttributeStrippingSigner{signerWrapper: signerWrapper{Signer: inner}}}},
+	} {
+		if got := signer.PluginAnnotations(); !reflect.DeepEqual(got, annotations) {
+			t.Fatalf("%T.PluginAnnotations() = %v, want %v", signer, got, annotations)
+		}
+	}
+	if got := (signerWrapper{Signer: &batchSigner{}}).PluginAnnotations(); got != nil {
+		t.Fatalf("PluginAnnotations() = %v, want nil for signers without plugin annotations", got)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// parseSignatureRepository parses the --signature-repository value, a
+// repository in the format {registry}/{repository} storing the signatures of
+// the artifact identified by reference.
+func parseSignatureRepository(value, reference string) (registry.Reference, error) {
+	repo, err := registry.ParseReference(value)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("invalid --signature-repository %q: %w", value, err)
+	}
+	if repo.Reference != "" {
+		return registry.Reference{}, fmt.Errorf("invalid --signature-repository %q, it must be a repository in the format {registry}/{repository} without tag or digest", value)
+	}
+	ref, err := registry.ParseReference(reference)
+	if err != nil {
+		return registry.Reference{}, err
+	}
+	if repo.Registry == ref.Registry && repo.Repository == ref.Repository {
+		return registry.Reference{}, fmt.Errorf("--signature-repository %q is the repository of %s, omit the flag for signatures stored alongside the artifact", value, reference)
+	}
+	if repo.Registry != ref.Registry {
+		fmt.Fprintf(os.Stderr, "Warning: signatures are stored in registry %s, but the artifact is stored in registry %s. Credentials are required for both registries.\n", repo.Registry, ref.Registry)
+	}
+	return repo, nil
+}
+
+// signatureRepositoryOverride is a signature repository pushing and listing
+// signatures in a repository other than the repository of the signed
+// artifact. Artifacts are resolved in the repository of the signed artifact.
+type signatureRepositoryOverride struct {
+	notationregistry.Repository
+	subject notationregistry.Repository
+}
+
+// Resolve resolves reference in the repository of the signed artifact.
+func (r *signatureRepositoryOverride) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return r.subject.Resolve(ctx, reference)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParseSignatureRepository(t *testing.T) {
+	reference := "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "repository of the same registry", value: "localhost:5000/signatures"},
+		{name: "repository of another registry", value: "registry.example.com/signatures"},
+		{name: "repository with a tag", value: "localhost:5000/signatures:v1", wantErr: true},
+		{name: "repository with a digest", value: "localhost:5000/signatures@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", wantErr: true},
+		{name: "repository of the artifact", value: "localhost:5000/net-monitor", wantErr: true},
+		{name: "invalid repository", value: "localhost:5000/Signatures", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, err := parseSignatureRepository(tt.value, reference)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSignatureRepository() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && repo.String() != tt.value {
+				t.Fatalf("parseSignatureRepository() = %s, want %s", repo, tt.value)
+			}
+		})
+	}
+}
+
+func TestSignatureRepositoryOverride_Resolve(t *testing.T) {
+	pushRepo := &resolveRecorder{}
+	subjectRepo := &resolveRecorder{}
+	repo := &signatureRepositoryOverride{Repository: pushRepo, subject: subjectRepo}
+	digest := "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	if _, err := repo.Resolve(context.Background(), digest); err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if subjectRepo.reference != digest || pushRepo.reference != "" {
+		t.Fatalf("resolved %q in the artifact repository and %q in the signature repository, want %q in the artifact repository only", subjectRepo.reference, pushRepo.reference, digest)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+
+	"oras.land/oras-go/v2/registry"
+)
+
+// referrersTagSchemaRegexp matches the tags of the Referrers tag schema, in
+// the format {algorithm}-{encoded digest}, which --tag-as must not overwrite.
+var referrersTagSchemaRegexp = regexp.MustCompile(`^[a-z0-9]+-[a-f0-9]{32,}$`)
+
+// validateSignatureTag checks that tag is a valid tag that does not collide
+// with the tags of the Referrers tag schema.
+func validateSignatureTag(tag string) error {
+	ref := registry.Reference{Registry: "localhost", Repository: "repository", Reference: tag}
+	if err := ref.ValidateReferenceAsTag(); err != nil {
+		return fmt.Errorf("%q is not a valid tag: %w", tag, err)
+	}
+	if referrersTagSchemaRegexp.MatchString(tag) {
+		return fmt.Errorf("tag %q is in the format of the Referrers tag schema {algorithm}-{digest}, and could overwrite a referrers index", tag)
+	}
+	return nil
+}
+
+// signatureRepositoryReference returns the reference of the repository the
+// signature of ref is pushed to, which is the repository of
+// `--signature-repository` if set.
+func signatureRepositoryReference(opts *signOpts, ref registry.Reference) (registry.Reference, error) {
+	if opts.sigRepository == "" {
+		return ref, nil
+	}
+	return registry.ParseReference(opts.sigRepository)
+}
+
+// tagSignature tags the signature manifest recorded by recorder with the tag
+// of `--tag-as`, in the repository it was pushed to.
+func tagSignature(ctx context.Context, w io.Writer, opts *signOpts, ref registry.Reference, recorder *signatureRecorder) error {
+	repoRef, err := signatureRepositoryReference(opts, ref)
+	if err != nil {
+		return err
+	}
+	remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, repoRef)
+	if err != nil {
+		return err
+	}
+	if err := remoteRepo.Tag(ctx, recorder.manifestDesc, opts.tagAs); err != nil {
+		return fmt.Errorf("signature %s was pushed but failed to be tagged as %q: %w", recorder.manifestDesc.Digest, opts.tagAs, err)
+	}
+	fmt.Fprintln(os.Stderr, "Warning: --tag-as is non-standard and for debugging only. Verifiers discover signatures with the Referrers API or the Referrers tag schema, not by tag, and the tag is not removed with the signature.")
+	fmt.Fprintf(w, "Signature %s tagged as %s/%s:%s\n", recorder.manifestDesc.Digest, repoRef.Registry, repoRef.Repository, opts.tagAs)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestValidateSignatureTag(t *testing.T) {
+	tests := []struct {
+		name    string
+		tag     string
+		wantErr bool
+	}{
+		{name: "tag", tag: "debug-signature"},
+		{name: "tag with dots", tag: "v1.0.0_signature"},
+		{name: "empty", tag: "", wantErr: true},
+		{name: "invalid character", tag: "debug/signature", wantErr: true},
+		{name: "leading period", tag: ".signature", wantErr: true},
+		{name: "Referrers tag schema", tag: "sha256-" + zeroDigest[len("sha256:"):], wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSignatureTag(tt.tag); (err != nil) != tt.wantErr {
+				t.Fatalf("validateSignatureTag(%q) error = %v, wantErr %v", tt.tag, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTagSignature(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	manifestDesc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    digest.FromBytes(manifest),
+		Size:      int64(len(manifest)),
+	}
+	var tagged []byte
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/test/manifests/"+manifestDesc.Digest.String():
+			w.Header().Set("Content-Type", manifestDesc.MediaType)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.Write(manifest)
+		case r.Method == http.MethodPut && r.URL.Path == "/v2/test/manifests/debug-signature":
+			tagged, _ = io.ReadAll(r.Body)
+			w.Header().Set("Docker-Content-Digest", manifestDesc.Digest.String())
+			w.WriteHeader(http.StatusCreated)
+		default:
+			t.Errorf("unexpected access: %s %q", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	uri, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatalf("invalid test http server: %v", err)
+	}
+
+	opts := &signOpts{SecureFlagOpts: SecureFlagOpts{PlainHTTP: true}, tagAs: "debug-signature"}
+	ref := registry.Reference{Registry: uri.Host, Repository: "test", Reference: zeroDigest}
+	recorder := &signatureRecorder{manifestDesc: manifestDesc}
+	if err := tagSignature(context.Background(), io.Discard, opts, ref, recorder); err != nil {
+		t.Fatalf("tagSignature() error = %v", err)
+	}
+	if string(tagged) != string(manifest) {
+		t.Fatalf("tagged manifest = %s, want %s", tagged, manifest)
+	}
+}
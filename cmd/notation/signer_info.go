@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/pkg/configutil"
+)
+
+// signerInfoVersion is the version of the signer information record schema.
+const signerInfoVersion = "1.0"
+
+// runnerEnvs are environment variables identifying the CI runner or job which
+// a signature is generated on, in order of precedence. Only identifiers are
+// read, never credentials.
+var runnerEnvs = []string{
+	"RUNNER_NAME",           // GitHub Actions
+	"CI_RUNNER_DESCRIPTION", // GitLab CI
+	"BUILD_TAG",             // Jenkins
+	"AGENT_NAME",            // Azure Pipelines
+}
+
+// signerInfo is the provenance record of a signature written by
+// `notation sign --signer-info-file`. It must never contain secrets.
+type signerInfo struct {
+	// Version is the version of the record schema.
+	Version string `json:"version"`
+
+	// Artifact is the digest reference of the signed artifact.
+	Artifact string `json:"artifact"`
+
+	// SignatureDigest is the digest of the signature manifest.
+	SignatureDigest string `json:"signatureDigest"`
+
+	// SigningTime is the signing time of the signature.
+	SigningTime time.Time `json:"signingTime"`
+
+	// Signer describes who signed the artifact and where.
+	Signer signerIdentity `json:"signer"`
+
+	// Key describes the signing key.
+	Key signingKeyInfo `json:"key"`
+
+	// Label is the label of the signing operation set by `--label`.
+	Label string `json:"label,omitempty"`
+}
+
+// signerIdentity describes the user and machine that generated a signature.
+type signerIdentity struct {
+	User   string `json:"user,omitempty"`
+	Host   string `json:"host,omitempty"`
+	Runner string `json:"runner,omitempty"`
+}
+
+// signingKeyInfo describes the key that generated a signature.
+type signingKeyInfo struct {
+	Name       string `json:"name,omitempty"`
+	PluginName string `json:"pluginName,omitempty"`
+	KeyID      string `json:"keyId,omitempty"`
+
+	// CertificateFingerprint is the SHA-256 fingerprint of the signing
+	// certificate.
+	CertificateFingerprint string `json:"certificateFingerprint"`
+}
+
+// newSignerInfo returns the signer information record of the signature
+// recorded by recorder, signed for the artifact ref.
+func newSignerInfo(opts *cmd.SignerFlagOpts, ref string, recorder *signatureRecorder) (*signerInfo, error) {
+	sigInfo, err := parseSignature(recorder.mediaType, recorder.blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the signature %s: %w", recorder.manifestDesc.Digest, err)
+	}
+	fingerprint := sha256.Sum256(sigInfo.CertificateChain[0].Raw)
+	info := &signerInfo{
+		Version:         signerInfoVersion,
+		Artifact:        ref,
+		SignatureDigest: recorder.manifestDesc.Digest.String(),
+		SigningTime:     sigInfo.SignedAttributes.SigningTime.UTC(),
+		Signer:          currentSignerIdentity(),
+		Key: signingKeyInfo{
+			CertificateFingerprint: hex.EncodeToString(fingerprint[:]),
+		},
+	}
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		// on-demand key
+		info.Key.PluginName = opts.PluginName
+		info.Key.KeyID = opts.KeyID
+		return info, nil
+	}
+	key, err := configutil.ResolveKey(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	info.Key.Name = key.Name
+	if key.ExternalKey != nil {
+		info.Key.PluginName = key.PluginName
+		info.Key.KeyID = key.ExternalKey.ID
+	}
+	return info, nil
+}
+
+// currentSignerIdentity returns the identity of the current user, host and
+// CI runner. Unavailable fields are left empty.
+func currentSignerIdentity() signerIdentity {
+	var identity signerIdentity
+	if u, err := user.Current(); err == nil {
+		identity.User = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		identity.Host = host
+	}
+	for _, env := range runnerEnvs {
+		if runner := os.Getenv(env); runner != "" {
+			identity.Runner = runner
+			break
+		}
+	}
+	return identity
+}
+
+// writeSignerInfo writes the signer information record to path as JSON.
+func writeSignerInfo(path string, info *signerInfo) error {
+	infoJSON, err := json.MarshalIndent(info, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(path, append(infoJSON, '\n'))
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func newTestSignature(t *testing.T, signingTime time.Time) ([]byte, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "signer", Organization: []string{"notation"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	localSigner, err := signature.NewLocalSigner([]*x509.Certificate{cert}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := signature.NewEnvelope(jws.MediaTypeEnvelope)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig, err := env.Sign(&signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: "application/vnd.cncf.notary.payload.v1+json",
+			Content:     []byte(`{"targetArtifact":{}}`),
+		},
+		Signer:        localSigner,
+		SigningTime:   signingTime,
+		SigningScheme: signature.SigningSchemeX509,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig, cert
+}
+
+func TestNewSignerInfo_OnDemandKey(t *testing.T) {
+	signingTime := time.Now().UTC().Truncate(time.Second)
+	sig, cert := newTestSignature(t, signingTime)
+	manifestDigest := digest.FromString("signature")
+	recorder := &signatureRecorder{
+		mediaType:    jws.MediaTypeEnvelope,
+		blob:         sig,
+		manifestDesc: ocispec.Descriptor{Digest: manifestDigest},
+	}
+	opts := &cmd.SignerFlagOpts{KeyID: "key-id", PluginName: "plugin"}
+
+	info, err := newSignerInfo(opts, "localhost:5000/net-monitor@sha256:abc", recorder)
+	if err != nil {
+		t.Fatalf("newSignerInfo() error = %v", err)
+	}
+	if info.SignatureDigest != manifestDigest.String() || !info.SigningTime.Equal(signingTime) {
+		t.Fatalf("newSignerInfo() = %+v, want signature %s signed at %s", info, manifestDigest, signingTime)
+	}
+	wantKey := signingKeyInfo{
+		PluginName:             "plugin",
+		KeyID:                  "key-id",
+		CertificateFingerprint: digest.FromBytes(cert.Raw).Encoded(),
+	}
+	if !reflect.DeepEqual(info.Key, wantKey) {
+		t.Fatalf("newSignerInfo() key = %+v, want %+v", info.Key, wantKey)
+	}
+}
+
+func TestWriteSignerInfo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signer_info.json")
+	t.Setenv("RUNNER_NAME", "runner-1")
+	info := &signerInfo{
+		Version:         signerInfoVersion,
+		Artifact:        "localhost:5000/net-monitor@sha256:abc",
+		SignatureDigest: digest.FromString("signature").String(),
+		Signer:          currentSignerIdentity(),
+		Key:             signingKeyInfo{Name: "wabbit-networks"},
+	}
+	if err := writeSignerInfo(path, info); err != nil {
+		t.Fatalf("writeSignerInfo() error = %v", err)
+	}
+	infoJSON, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got signerInfo
+	if err := json.Unmarshal(infoJSON, &got); err != nil {
+		t.Fatalf("failed to parse signer information: %v", err)
+	}
+	if !reflect.DeepEqual(got, *info) {
+		t.Fatalf("signer information = %+v, want %+v", got, *info)
+	}
+	if got.Signer.Runner != "runner-1" {
+		t.Fatalf("runner = %q, want %q", got.Signer.Runner, "runner-1")
+	}
+}
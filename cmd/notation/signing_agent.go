@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// signingAgentEnv is the environment variable setting the signing agent if
+// --signing-agent is not set.
+const signingAgentEnv = "NOTATION_SIGNING_AGENT"
+
+// maxSigningAgentLength is the maximum length of a signing agent.
+const maxSigningAgentLength = 256
+
+// unsignedAttributeSigningAgent is the unsigned attribute of a signature
+// envelope storing the signing agent.
+const unsignedAttributeSigningAgent = "io.cncf.notary.signingAgent"
+
+// validateSigningAgent checks that agent can be used both as the signing
+// agent of signature envelopes and as the User-Agent header of registry
+// requests.
+func validateSigningAgent(agent string) error {
+	if agent == "" {
+		return errors.New("signing agent cannot be empty")
+	}
+	if len(agent) > maxSigningAgentLength {
+		return fmt.Errorf("signing agent is longer than %d characters", maxSigningAgentLength)
+	}
+	for _, c := range agent {
+		if c < 0x20 || c > 0x7e {
+			return fmt.Errorf("signing agent %q contains characters other than printable ASCII characters", agent)
+		}
+	}
+	return nil
+}
+
+// signingAgentConflict returns the flag of opts that cannot be used with a
+// signing agent, or an empty string if there is none.
+func signingAgentConflict(opts *signOpts) string {
+	switch {
+	case opts.stripUnsigned:
+		return "--strip-unsigned-attributes"
+	case opts.dumpTBS != "":
+		return "--dump-tbs"
+	}
+	return ""
+}
+
+// signingAgentSigner wraps a notation.Signer and sets the signing agent of
+// the generated signature envelope.
+type signingAgentSigner struct {
+	signerWrapper
+	agent string
+}
+
+// Sign signs the artifact with the wrapped signer, requesting the signing
+// agent. Signers overriding the requested signing agent, such as plugins,
+// have the signing agent of the envelope replaced.
+func (s *signingAgentSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	opts.SigningAgent = s.agent
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if signerInfo.UnsignedAttributes.SigningAgent != s.agent {
+		sig, err = embedUnsignedAttribute(opts.SignatureMediaType, sig, unsignedAttributeSigningAgent, s.agent)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to set the signing agent of the signature: %w", err)
+		}
+		signerInfo.UnsignedAttributes.SigningAgent = s.agent
+	}
+	return sig, signerInfo, nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+// overridingAgentSigner is a notation.Signer ignoring the requested signing
+// agent, as plugins do.
+type overridingAgentSigner struct {
+	notation.Signer
+}
+
+func (s *overridingAgentSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	opts.SigningAgent = "Notation/1.0.0 test-plugin/1.0.0"
+	return s.Signer.Sign(ctx, desc, opts)
+}
+
+func TestValidateSigningAgent(t *testing.T) {
+	tests := []struct {
+		agent   string
+		wantErr bool
+	}{
+		{agent: "acme-pipeline/1.2"},
+		{agent: "acme-pipeline/1.2 (build 42)"},
+		{agent: "", wantErr: true},
+		{agent: strings.Repeat("a", maxSigningAgentLength+1), wantErr: true},
+		{agent: "acme\npipeline", wantErr: true},
+		{agent: "acmé", wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := validateSigningAgent(tt.agent); (err != nil) != tt.wantErr {
+			t.Errorf("validateSigningAgent(%q) error = %v, wantErr %v", tt.agent, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSigningAgentSigner(t *testing.T) {
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	const agent = "acme-pipeline/1.2"
+
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		for name, inner := range map[string]notation.Signer{
+			"local key":         localSigner,
+			"overriding signer": &overridingAgentSigner{Signer: localSigner},
+		} {
+			t.Run(mediaType+"/"+name, func(t *testing.T) {
+				s := &signingAgentSigner{signerWrapper: signerWrapper{Signer: inner}, agent: agent}
+				sig, signerInfo, err := s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+				if err != nil {
+					t.Fatalf("Sign() error = %v", err)
+				}
+				if signerInfo.UnsignedAttributes.SigningAgent != agent {
+					t.Fatalf("Sign() signing agent = %q, want %q", signerInfo.UnsignedAttributes.SigningAgent, agent)
+				}
+
+				// the signature stays valid and carries the signing agent
+				sigEnv, err := signature.ParseEnvelope(mediaType, sig)
+				if err != nil {
+					t.Fatalf("ParseEnvelope() error = %v", err)
+				}
+				if _, err := sigEnv.Verify(); err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if got := envelopeSigningAgent(t, mediaType, sig); got != agent {
+					t.Fatalf("signing agent of the envelope = %q, want %q", got, agent)
+				}
+			})
+		}
+	}
+}
+
+// envelopeSigningAgent returns the signing agent of the signature envelope
+// sig of mediaType.
+func envelopeSigningAgent(t *testing.T, mediaType string, sig []byte) string {
+	t.Helper()
+	if mediaType == cose.MediaTypeEnvelope {
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			t.Fatal(err)
+		}
+		agent, _ := msg.Headers.Unprotected[unsignedAttributeSigningAgent].(string)
+		return agent
+	}
+	sigEnv, err := signature.ParseEnvelope(mediaType, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := sigEnv.Content()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return content.SignerInfo.UnsignedAttributes.SigningAgent
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notaryproject/notation/internal/cmd"
+)
+
+// sourceDateEpochEnv is the environment variable defined by the reproducible
+// builds specification for the time of the last modification of the source,
+// in seconds since the Unix epoch.
+const sourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// resolveSourceDate returns the signing time derived from the source date
+// epoch value of --source-date-epoch, or of the SOURCE_DATE_EPOCH environment
+// variable if value is empty. The signing time cannot be later than now, and
+// a signature expiring after expiry, if not zero, cannot be expired at now.
+func resolveSourceDate(value string, now time.Time, expiry time.Duration) (time.Time, error) {
+	source := "--source-date-epoch"
+	if value == "" {
+		source = sourceDateEpochEnv
+		value = os.Getenv(sourceDateEpochEnv)
+		if value == "" {
+			return time.Time{}, fmt.Errorf("--deterministic-signing-time-from-source requires the %s environment variable or --source-date-epoch to be set", sourceDateEpochEnv)
+		}
+	}
+	signingTime, err := parseSourceDateEpoch(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", source, err)
+	}
+	if signingTime.After(now) {
+		return time.Time{}, fmt.Errorf("invalid %s: source date %s is in the future", source, signingTime.Format(time.RFC3339))
+	}
+	if expiry > 0 && !signingTime.Add(expiry).After(now) {
+		return time.Time{}, fmt.Errorf("signature signed at the source date %s from %s would already be expired with --expiry %s", signingTime.Format(time.RFC3339), source, expiry)
+	}
+	return signingTime, nil
+}
+
+// parseSourceDateEpoch parses value as a source date epoch, a non-negative
+// decimal integer of seconds since the Unix epoch, and returns the time in
+// UTC.
+func parseSourceDateEpoch(value string) (time.Time, error) {
+	if strings.TrimLeft(value, "0123456789") != "" {
+		return time.Time{}, fmt.Errorf("%q is not a non-negative number of seconds since the Unix epoch", value)
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a valid number of seconds since the Unix epoch: %w", value, err)
+	}
+	return time.Unix(seconds, 0).UTC(), nil
+}
+
+// newSourceDateSigner returns a backdatedSigner for the key in opts, signing
+// at the fixed signingTime. Only local keys are supported, as plugins set the
+// signing time themselves.
+func newSourceDateSigner(opts *cmd.SignerFlagOpts, signingTime time.Time) (*backdatedSigner, error) {
+	privateKey, certs, err := loadLocalSigningKey(opts, "--deterministic-signing-time-from-source")
+	if err != nil {
+		return nil, err
+	}
+	signer, err := newBackdatedSignerFromKey(privateKey, certs, 0)
+	if err != nil {
+		return nil, err
+	}
+	signer.signingTime = signingTime
+	return signer, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestResolveSourceDate(t *testing.T) {
+	now := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	sourceDate := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		env     string
+		value   string
+		expiry  time.Duration
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "flag", value: "1672531200", want: sourceDate},
+		{name: "environment variable", env: "1672531200", want: sourceDate},
+		{name: "flag overrides environment variable", env: "0", value: "1672531200", want: sourceDate},
+		{name: "epoch", value: "0", want: time.Unix(0, 0).UTC()},
+		{name: "unset", wantErr: true},
+		{name: "negative", value: "-1", wantErr: true},
+		{name: "not a number", value: "2023-01-01T00:00:00Z", wantErr: true},
+		{name: "fraction", value: "1672531200.5", wantErr: true},
+		{name: "overflow", value: "99999999999999999999", wantErr: true},
+		{name: "future", value: "1704067200", wantErr: true},
+		{name: "not expired", value: "1672531200", expiry: 365 * 24 * time.Hour, want: sourceDate},
+		{name: "expired", value: "1672531200", expiry: 24 * time.Hour, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(sourceDateEpochEnv, tt.env)
+			got, err := resolveSourceDate(tt.value, now, tt.expiry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSourceDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Fatalf("resolveSourceDate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSourceDateSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signingTime := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "source date test"},
+		NotBefore:             signingTime.Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := newBackdatedSignerFromKey(key, []*x509.Certificate{cert}, 0)
+	if err != nil {
+		t.Fatalf("newBackdatedSignerFromKey() error = %v", err)
+	}
+	signer.signingTime = signingTime
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	for i := 0; i < 2; i++ {
+		sig, _, err := signer.Sign(context.Background(), desc, notation.SignOptions{
+			SignatureMediaType: jws.MediaTypeEnvelope,
+			ExpiryDuration:     365 * 24 * time.Hour,
+		})
+		if err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+		sigEnv, err := signature.ParseEnvelope(jws.MediaTypeEnvelope, sig)
+		if err != nil {
+			t.Fatalf("ParseEnvelope() error = %v", err)
+		}
+		content, err := sigEnv.Verify()
+		if err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+		signedAttrs := content.SignerInfo.SignedAttributes
+		if !signedAttrs.SigningTime.Equal(signingTime) {
+			t.Fatalf("signing time = %s, want %s", signedAttrs.SigningTime, signingTime)
+		}
+		if want := signingTime.Add(365 * 24 * time.Hour); !signedAttrs.Expiry.Equal(want) {
+			t.Fatalf("expiry = %s, want %s", signedAttrs.Expiry, want)
+		}
+	}
+}
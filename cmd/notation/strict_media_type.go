@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// maxSignatureManifestSize is the maximum size of a signature manifest
+// checked by --strict-media-type.
+const maxSignatureManifestSize = 4 * 1024 * 1024 // 4 MiB
+
+// signatureEnvelopeMediaTypes are the media types of signature envelopes
+// defined by the Notary Project specification.
+var signatureEnvelopeMediaTypes = []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope}
+
+// strictMediaTypeRepository is a repository that rejects signatures whose
+// manifest, config or envelope media types do not exactly match the values
+// of the Notary Project specification.
+type strictMediaTypeRepository struct {
+	notationregistry.Repository
+	manifests content.Fetcher
+}
+
+// FetchSignatureBlob checks the media types of the signature manifest and the
+// signature envelope before returning the signature envelope.
+func (r *strictMediaTypeRepository) FetchSignatureBlob(ctx context.Context, desc ocispec.Descriptor) ([]byte, ocispec.Descriptor, error) {
+	if desc.Size > maxSignatureManifestSize {
+		return nil, ocispec.Descriptor{}, fmt.Errorf("signature manifest %s is too large, exceeding %d bytes", desc.Digest, maxSignatureManifestSize)
+	}
+	manifestJSON, err := content.FetchAll(ctx, r.manifests, desc)
+	if err != nil {
+		return nil, ocispec.Descriptor{}, err
+	}
+	if err := checkSignatureManifestMediaTypes(desc, manifestJSON); err != nil {
+		return nil, ocispec.Descriptor{}, fmt.Errorf("signature %s does not conform to the Notary Project specification: %w", desc.Digest, err)
+	}
+	return r.Repository.FetchSignatureBlob(ctx, desc)
+}
+
+// checkSignatureManifestMediaTypes returns an error if the media types of a
+// signature manifest do not exactly match the Notary Project specification.
+func checkSignatureManifestMediaTypes(desc ocispec.Descriptor, manifestJSON []byte) error {
+	var manifest struct {
+		MediaType    string               `json:"mediaType"`
+		ArtifactType string               `json:"artifactType"`
+		Config       ocispec.Descriptor   `json:"config"`
+		Layers       []ocispec.Descriptor `json:"layers"`
+		Blobs        []ocispec.Descriptor `json:"blobs"`
+	}
+	if err := json.Unmarshal(manifestJSON, &manifest); err != nil {
+		return fmt.Errorf("failed to parse signature manifest: %w", err)
+	}
+	if manifest.MediaType != desc.MediaType {
+		return fmt.Errorf("manifest media type %q does not match the descriptor media type %q", manifest.MediaType, desc.MediaType)
+	}
+
+	var blobs []ocispec.Descriptor
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest:
+		if manifest.Config.MediaType != notationregistry.ArtifactTypeNotation {
+			return fmt.Errorf("config media type must be %q, got %q", notationregistry.ArtifactTypeNotation, manifest.Config.MediaType)
+		}
+		blobs = manifest.Layers
+	case ocispec.MediaTypeArtifactManifest:
+		if manifest.ArtifactType != notationregistry.ArtifactTypeNotation {
+			return fmt.Errorf("artifact type must be %q, got %q", notationregistry.ArtifactTypeNotation, manifest.ArtifactType)
+		}
+		blobs = manifest.Blobs
+	default:
+		return fmt.Errorf("manifest media type must be %q or %q, got %q", ocispec.MediaTypeImageManifest, ocispec.MediaTypeArtifactManifest, desc.MediaType)
+	}
+	if len(blobs) != 1 {
+		return fmt.Errorf("signature manifest requires exactly one signature envelope, got %d", len(blobs))
+	}
+	if !slices.Contains(signatureEnvelopeMediaTypes, blobs[0].MediaType) {
+		return fmt.Errorf("signature envelope media type must be one of %v, got %q", signatureEnvelopeMediaTypes, blobs[0].MediaType)
+	}
+	return nil
+}
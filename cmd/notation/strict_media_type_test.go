@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestCheckSignatureManifestMediaTypes(t *testing.T) {
+	imageDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest}
+	artifactDesc := ocispec.Descriptor{MediaType: ocispec.MediaTypeArtifactManifest}
+	tests := []struct {
+		name     string
+		desc     ocispec.Descriptor
+		manifest string
+		wantErr  bool
+	}{
+		{
+			name:     "image manifest",
+			desc:     imageDesc,
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.cncf.notary.signature"},"layers":[{"mediaType":"application/jose+json"}]}`,
+		},
+		{
+			name:     "artifact manifest",
+			desc:     artifactDesc,
+			manifest: `{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","artifactType":"application/vnd.cncf.notary.signature","blobs":[{"mediaType":"application/cose"}]}`,
+		},
+		{
+			name:     "mismatched manifest media type",
+			desc:     imageDesc,
+			manifest: `{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","config":{"mediaType":"application/vnd.cncf.notary.signature"},"layers":[{"mediaType":"application/jose+json"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "off config media type",
+			desc:     imageDesc,
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.cncf.notary.signature+json"},"layers":[{"mediaType":"application/jose+json"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "off envelope media type",
+			desc:     imageDesc,
+			manifest: `{"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.cncf.notary.signature"},"layers":[{"mediaType":"application/jose+json; charset=utf-8"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "multiple envelopes",
+			desc:     artifactDesc,
+			manifest: `{"mediaType":"application/vnd.oci.artifact.manifest.v1+json","artifactType":"application/vnd.cncf.notary.signature","blobs":[{"mediaType":"application/cose"},{"mediaType":"application/cose"}]}`,
+			wantErr:  true,
+		},
+		{
+			name:     "docker manifest",
+			desc:     ocispec.Descriptor{MediaType: "application/vnd.docker.distribution.manifest.v2+json"},
+			manifest: `{"mediaType":"application/vnd.docker.distribution.manifest.v2+json"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkSignatureManifestMediaTypes(tt.desc, []byte(tt.manifest)); (err != nil) != tt.wantErr {
+				t.Fatalf("checkSignatureManifestMediaTypes() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
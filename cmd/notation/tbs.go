@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/osutil"
+	"github.com/notaryproject/notation/pkg/configutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// tbsSigningAgent is the signing agent set in the sign request, as set by the
+// builtin signer of notation-go. It is an unprotected header and therefore
+// not part of the to-be-signed bytes.
+const tbsSigningAgent = "Notation/1.0.0"
+
+// errTBSCaptured is returned by tbsSigner once the to-be-signed bytes are
+// captured, which aborts signing before any signature is pushed.
+var errTBSCaptured = errors.New("to-be-signed bytes captured, signing aborted")
+
+// tbsCapturingSigner is a remote signature.Signer recording the bytes it is
+// asked to sign instead of signing them.
+type tbsCapturingSigner struct {
+	keySpec signature.KeySpec
+	tbs     []byte
+}
+
+// Sign records payload and fails, as no signature is generated.
+func (s *tbsCapturingSigner) Sign(payload []byte) ([]byte, []*x509.Certificate, error) {
+	s.tbs = payload
+	return nil, nil, errTBSCaptured
+}
+
+// KeySpec returns the key spec of the signing certificate.
+func (s *tbsCapturingSigner) KeySpec() (signature.KeySpec, error) {
+	return s.keySpec, nil
+}
+
+// tbsSigner implements notation.Signer. Instead of signing, it builds the
+// sign request the builtin signer would build and records the bytes the
+// signature envelope signs over.
+type tbsSigner struct {
+	keySpec signature.KeySpec
+	tbs     []byte
+}
+
+// newTBSSigner returns a tbsSigner for the signing certificate chain certs.
+func newTBSSigner(certs []*x509.Certificate) (*tbsSigner, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("certificate chain is empty")
+	}
+	keySpec, err := signature.ExtractKeySpec(certs[0])
+	if err != nil {
+		return nil, err
+	}
+	return &tbsSigner{keySpec: keySpec}, nil
+}
+
+// Sign records the to-be-signed bytes of the signature of desc and returns
+// errTBSCaptured on success.
+func (s *tbsSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	capturer := &tbsCapturingSigner{keySpec: s.keySpec}
+	signReq, err := newSignRequest(desc, opts, capturer)
+	if err != nil {
+		return nil, nil, err
+	}
+	sigEnv, err := signature.NewEnvelope(opts.SignatureMediaType)
+	if err != nil {
+		return nil, nil, err
+	}
+	// the envelope wraps the error of the signer, so check for the captured
+	// bytes instead
+	_, err = sigEnv.Sign(signReq)
+	if capturer.tbs == nil {
+		if err == nil {
+			err = errors.New("signature envelope did not request signing")
+		}
+		return nil, nil, err
+	}
+	s.tbs = capturer.tbs
+	return nil, nil, errTBSCaptured
+}
+
+// newSignRequest returns the sign request the builtin signer of notation-go
+// builds for the signature of desc with signer, signed at the current time.
+func newSignRequest(desc ocispec.Descriptor, opts notation.SignOptions, signer signature.Signer) (*signature.SignRequest, error) {
+	payload := envelope.Payload{
+		TargetArtifact: ocispec.Descriptor{
+			MediaType:   desc.MediaType,
+			Digest:      desc.Digest,
+			Size:        desc.Size,
+			Annotations: desc.Annotations,
+		},
+	}
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("envelope payload can't be marshalled: %w", err)
+	}
+	signingAgent := tbsSigningAgent
+	if opts.SigningAgent != "" {
+		signingAgent = opts.SigningAgent
+	}
+	signReq := &signature.SignRequest{
+		Payload: signature.Payload{
+			ContentType: envelope.MediaTypePayloadV1,
+			Content:     payloadBytes,
+		},
+		Signer:        signer,
+		SigningTime:   time.Now(),
+		SigningScheme: signature.SigningSchemeX509,
+		SigningAgent:  signingAgent,
+	}
+	if opts.ExpiryDuration != 0 {
+		signReq.Expiry = signReq.SigningTime.Add(opts.ExpiryDuration)
+	}
+	return signReq, nil
+}
+
+// loadSigningCertificates returns the certificate chain of the signing key.
+// Only local keys are supported, as plugin keys may not expose their
+// certificate chain before signing. flag is the flag requiring the chain,
+// reported as unsupported otherwise.
+func loadSigningCertificates(opts *cmd.SignerFlagOpts, flag string) ([]*x509.Certificate, error) {
+	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		return nil, fmt.Errorf("%s is not supported with on-demand plugin keys", flag)
+	}
+	key, err := configutil.ResolveKey(opts.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key.X509KeyPair == nil {
+		return nil, fmt.Errorf("%s is not supported with plugin key %q, use a local key", flag, key.Name)
+	}
+	certs, err := corex509.ReadCertificateFile(key.X509KeyPair.CertificatePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%q does not contain certificate", key.X509KeyPair.CertificatePath)
+	}
+	return certs, nil
+}
+
+// dumpTBS writes the to-be-signed bytes of the signature of the artifact
+// referenced in opts to path, without signing the artifact.
+func dumpTBS(ctx context.Context, cmdOpts *signOpts, path string) error {
+	certs, err := loadSigningCertificates(&cmdOpts.SignerFlagOpts, "--dump-tbs")
+	if err != nil {
+		return err
+	}
+	signer, err := newTBSSigner(certs)
+	if err != nil {
+		return err
+	}
+	sigRepo, err := getSignatureRepositoryForSign(ctx, &cmdOpts.SecureFlagOpts, cmdOpts.reference, true)
+	if err != nil {
+		return err
+	}
+	opts, ref, err := prepareSigningContent(ctx, cmdOpts, sigRepo)
+	if err != nil {
+		return err
+	}
+	_, err = notation.Sign(ctx, signer, sigRepo, opts)
+	if !errors.Is(err, errTBSCaptured) {
+		if err == nil {
+			err = errors.New("signing was not aborted")
+		}
+		return err
+	}
+	if err := osutil.WriteFile(path, signer.tbs); err != nil {
+		return fmt.Errorf("failed to write to-be-signed bytes: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "To-be-signed bytes of the signature for %s written to %s, the artifact is not signed\n", ref, path)
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestTBSSigner(t *testing.T) {
+	_, cert := newTestSignature(t, time.Now())
+	desc := ocispec.Descriptor{
+		MediaType:   ocispec.MediaTypeImageManifest,
+		Digest:      digest.FromString("artifact"),
+		Size:        8,
+		Annotations: map[string]string{"buildId": "42"},
+	}
+
+	t.Run("jws", func(t *testing.T) {
+		signer, err := newTBSSigner([]*x509.Certificate{cert})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = signer.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: jws.MediaTypeEnvelope, ExpiryDuration: time.Hour})
+		if !errors.Is(err, errTBSCaptured) {
+			t.Fatalf("Sign() error = %v, want %v", err, errTBSCaptured)
+		}
+		header, payload, found := strings.Cut(string(signer.tbs), ".")
+		if !found {
+			t.Fatalf("to-be-signed bytes %q are not in the format <header>.<payload>", signer.tbs)
+		}
+		headerJSON, err := base64.RawURLEncoding.DecodeString(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var protected map[string]interface{}
+		if err := json.Unmarshal(headerJSON, &protected); err != nil {
+			t.Fatal(err)
+		}
+		if protected["alg"] != "ES256" {
+			t.Errorf("protected header alg = %v, want ES256", protected["alg"])
+		}
+		if _, ok := protected["io.cncf.notary.expiry"]; !ok {
+			t.Errorf("protected header %v has no expiry", protected)
+		}
+		payloadJSON, err := base64.RawURLEncoding.DecodeString(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got envelope.Payload
+		if err := json.Unmarshal(payloadJSON, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.TargetArtifact.Digest != desc.Digest || got.TargetArtifact.Annotations["buildId"] != "42" {
+			t.Errorf("payload target artifact = %v, want %v", got.TargetArtifact, desc)
+		}
+	})
+
+	t.Run("cose", func(t *testing.T) {
+		signer, err := newTBSSigner([]*x509.Certificate{cert})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _, err = signer.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: cose.MediaTypeEnvelope})
+		if !errors.Is(err, errTBSCaptured) {
+			t.Fatalf("Sign() error = %v, want %v", err, errTBSCaptured)
+		}
+		var sigStructure []cbor.RawMessage
+		if err := cbor.Unmarshal(signer.tbs, &sigStructure); err != nil {
+			t.Fatalf("to-be-signed bytes are not a CBOR array: %v", err)
+		}
+		if len(sigStructure) != 4 {
+			t.Fatalf("Sig_structure has %d items, want 4", len(sigStructure))
+		}
+		var context string
+		if err := cbor.Unmarshal(sigStructure[0], &context); err != nil || context != "Signature1" {
+			t.Errorf("Sig_structure context = %q, %v, want Signature1", context, err)
+		}
+		var payload []byte
+		if err := cbor.Unmarshal(sigStructure[3], &payload); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(payload), desc.Digest.String()) {
+			t.Errorf("Sig_structure payload %s does not contain the artifact digest %s", payload, desc.Digest)
+		}
+	})
+
+	t.Run("empty certificate chain", func(t *testing.T) {
+		if _, err := newTBSSigner(nil); err == nil {
+			t.Fatal("newTBSSigner() expects error but got nil")
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/osutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// errNoTimestampToken is returned if a signature carries no timestamp token.
+var errNoTimestampToken = errors.New("the signature has no timestamp token, sign with --timestamp-url to timestamp the signature with a TSA, or use an envelope generating plugin adding a timestamp token")
+
+// timestampingSigner wraps a notation.Signer and timestamps the generated
+// signature with an RFC 3161 TSA, embedding the timestamp token into the
+// unsigned attributes of the signature envelope.
+type timestampingSigner struct {
+	signerWrapper
+	url    string
+	roots  *x509.CertPool
+	client *http.Client
+}
+
+// newTimestampingSigner returns a timestampingSigner wrapping s, requesting
+// timestamps from the TSA at tsaURL whose certificates chain to the root
+// certificates of the file rootCertPath.
+func newTimestampingSigner(s notation.Signer, tsaURL, rootCertPath string) (*timestampingSigner, error) {
+	roots, err := loadTimestampRootCerts(rootCertPath)
+	if err != nil {
+		return nil, err
+	}
+	return &timestampingSigner{
+		signerWrapper: signerWrapper{Signer: s},
+		url:           tsaURL,
+		roots:         roots,
+		client:        &http.Client{Timeout: tsaRequestTimeout},
+	}, nil
+}
+
+// Sign signs the artifact with the wrapped signer, then timestamps the
+// signature value and embeds the timestamp token into the signature
+// envelope.
+func (s *timestampingSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	digest := sha256.Sum256(signerInfo.Signature)
+	token, err := requestTimestamp(ctx, s.client, s.url, digest[:], s.roots)
+	if err != nil {
+		return nil, nil, &timestampError{url: s.url, err: err}
+	}
+	sig, err = embedUnsignedAttribute(opts.SignatureMediaType, sig, unsignedAttributeTimestamp, token)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to embed the timestamp token into the signature: %w", err)
+	}
+	signerInfo.UnsignedAttributes.TimestampSignature = token
+	log.GetLogger(ctx).Infof("Timestamped the signature with the TSA %s", s.url)
+	return sig, signerInfo, nil
+}
+
+// writeTimestampToken writes the RFC 3161 timestamp token of the signature
+// recorded by recorder to path. The token is written as is, which is a DER
+// encoded TimeStampToken.
+func writeTimestampToken(path string, recorder *signatureRecorder) error {
+	sigInfo, err := parseSignature(recorder.mediaType, recorder.blob)
+	if err != nil {
+		return fmt.Errorf("failed to parse the signature %s: %w", recorder.manifestDesc.Digest, err)
+	}
+	token := sigInfo.UnsignedAttributes.TimestampSignature
+	if len(token) == 0 {
+		// the timestamp token of COSE envelopes is not parsed into the
+		// signer information
+		token, err = extractUnsignedAttribute(recorder.mediaType, recorder.blob, unsignedAttributeTimestamp)
+		if err != nil {
+			return fmt.Errorf("failed to parse the signature %s: %w", recorder.manifestDesc.Digest, err)
+		}
+	}
+	if len(token) == 0 {
+		return errNoTimestampToken
+	}
+	return osutil.WriteFile(path, token)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/signer"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestWriteTimestampToken_NotTimestamped(t *testing.T) {
+	sig, _ := newTestSignature(t, time.Now())
+	recorder := &signatureRecorder{mediaType: jws.MediaTypeEnvelope, blob: sig}
+	err := writeTimestampToken(filepath.Join(t.TempDir(), "token.tst"), recorder)
+	if !errors.Is(err, errNoTimestampToken) {
+		t.Fatalf("writeTimestampToken() error = %v, want %v", err, errNoTimestampToken)
+	}
+}
+
+func TestWriteTimestampToken_InvalidSignature(t *testing.T) {
+	recorder := &signatureRecorder{mediaType: jws.MediaTypeEnvelope, blob: []byte("invalid")}
+	if err := writeTimestampToken(filepath.Join(t.TempDir(), "token.tst"), recorder); err == nil {
+		t.Fatal("writeTimestampToken() expected error for invalid signature, but got nil")
+	}
+}
+
+func TestTimestampingSigner(t *testing.T) {
+	tsa := newTestTSA(t)
+	server := httptest.NewTLSServer(tsa)
+	defer server.Close()
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			s, err := newTimestampingSigner(localSigner, server.URL, tsa.writeRoot(t))
+			if err != nil {
+				t.Fatalf("newTimestampingSigner() error = %v", err)
+			}
+			s.client = server.Client()
+			sig, signerInfo, err := s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: mediaType})
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+
+			// the signature stays valid and carries the timestamp token
+			sigEnv, err := signature.ParseEnvelope(mediaType, sig)
+			if err != nil {
+				t.Fatalf("ParseEnvelope() error = %v", err)
+			}
+			content, err := sigEnv.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			token, err := extractUnsignedAttribute(mediaType, sig, unsignedAttributeTimestamp)
+			if err != nil {
+				t.Fatalf("extractUnsignedAttribute() error = %v", err)
+			}
+			if !bytes.Equal(token, signerInfo.UnsignedAttributes.TimestampSignature) {
+				t.Fatal("Sign() timestamp token of the envelope differs from the returned signer info")
+			}
+			digest := sha256.Sum256(content.SignerInfo.Signature)
+			if _, err := verifyTimestampToken(token, digest[:], nil, tsa.roots()); err != nil {
+				t.Fatalf("verifyTimestampToken() error = %v", err)
+			}
+
+			path := filepath.Join(t.TempDir(), "token.tst")
+			recorder := &signatureRecorder{mediaType: mediaType, blob: sig}
+			if err := writeTimestampToken(path, recorder); err != nil {
+				t.Fatalf("writeTimestampToken() error = %v", err)
+			}
+			written, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(written, token) {
+				t.Fatal("writeTimestampToken() wrote a different timestamp token")
+			}
+		})
+	}
+}
+
+func TestTimestampingSigner_Unavailable(t *testing.T) {
+	tsa := newTestTSA(t)
+	server := httptest.NewTLSServer(tsa)
+	serverURL := server.URL
+	server.Close()
+	pki := newCRLTestPKI(t, "http://localhost/ca.crl")
+	localSigner, err := signer.New(pki.leafKey, []*x509.Certificate{pki.leaf, pki.ca})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := newTimestampingSigner(localSigner, serverURL, tsa.writeRoot(t))
+	if err != nil {
+		t.Fatalf("newTimestampingSigner() error = %v", err)
+	}
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	_, _, err = s.Sign(context.Background(), desc, notation.SignOptions{SignatureMediaType: jws.MediaTypeEnvelope})
+	var tsErr *timestampError
+	if !errors.As(err, &tsErr) {
+		t.Fatalf("Sign() error = %v, want timestampError", err)
+	}
+	var pushErr notation.ErrorPushSignatureFailed
+	if errors.As(err, &pushErr) {
+		t.Fatalf("Sign() error = %v, must not be ErrorPushSignatureFailed", err)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	corex509 "github.com/notaryproject/notation-core-go/x509"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/cmd/notation/internal/cmdutil"
+	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/osutil"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// tofuFileName is the name of the file under the notation configuration
+// directory recording the signing identities trusted on first use.
+const tofuFileName = "trust_on_first_use.json"
+
+// tofuIdentity is a signing identity trusted on first use for a repository.
+type tofuIdentity struct {
+	// Subject is the subject of the signing certificate.
+	Subject string `json:"subject"`
+
+	// RootCertificateSHA256 is the SHA-256 fingerprint of the root
+	// certificate of the certificate chain.
+	RootCertificateSHA256 string `json:"rootCertificateSHA256"`
+
+	// RecordedAt is the time the identity was recorded.
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// matches reports whether other is the same signing identity as i.
+func (i tofuIdentity) matches(other tofuIdentity) bool {
+	return i.Subject == other.Subject && i.RootCertificateSHA256 == other.RootCertificateSHA256
+}
+
+func (i tofuIdentity) String() string {
+	return fmt.Sprintf("subject %q with root certificate SHA-256 fingerprint %s", i.Subject, i.RootCertificateSHA256)
+}
+
+// tofuStore records the signing identities trusted on first use, keyed by
+// repository in the format {registry}/{repository}.
+type tofuStore struct {
+	Identities map[string]tofuIdentity `json:"identities"`
+}
+
+// loadTOFUStore loads the signing identities trusted on first use from path.
+// A missing file is an empty store.
+func loadTOFUStore(path string) (*tofuStore, error) {
+	store := &tofuStore{Identities: map[string]tofuIdentity{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return store, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("malformed %s: %w", path, err)
+	}
+	if store.Identities == nil {
+		store.Identities = map[string]tofuIdentity{}
+	}
+	return store, nil
+}
+
+// save writes the store to path.
+func (s *tofuStore) save(path string) error {
+	data, err := json.MarshalIndent(s, "", "    ")
+	if err != nil {
+		return err
+	}
+	return osutil.WriteFile(path, data)
+}
+
+// signingIdentity returns the signing identity of a signature with the
+// certificate chain certs.
+func signingIdentity(certs []*x509.Certificate) tofuIdentity {
+	root := sha256.Sum256(certs[len(certs)-1].Raw)
+	return tofuIdentity{
+		Subject:               certs[0].Subject.String(),
+		RootCertificateSHA256: hex.EncodeToString(root[:]),
+	}
+}
+
+// hasApplicableTrustPolicy reports whether a statement of the local trust
+// policy applies to ref. A missing trust policy has no applicable statement.
+func hasApplicableTrustPolicy(ref registry.Reference) (bool, error) {
+	if err := checkLocalTrustPolicyVersion(); err != nil {
+		return false, err
+	}
+	doc, err := trustpolicy.LoadDocument()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load trust policy: %w", err)
+	}
+	for _, policy := range findApplicablePolicies(doc, ref.Registry+"/"+ref.Repository) {
+		if policy.applied {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyTrustOnFirstUse verifies the artifact identified by reference, which
+// has no applicable trust policy, against the signing identity trusted on
+// first use for its repository. Without a recorded identity, the identity of
+// the first valid signature is recorded once confirmed by the user, or
+// without prompting if opts.yes is set.
+//
+// Signatures are checked for integrity, signed target, expiry and validity of
+// the certificate chain at the current time. The certificate chain is not
+// checked against any trust store, nor for revocation.
+func verifyTrustOnFirstUse(ctx context.Context, opts *verifyOpts, reference string) (*notation.VerificationOutcome, registry.Reference, error) {
+	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return nil, registry.Reference{}, err
+	}
+	manifestDesc, ref, err := resolveReference(ctx, &opts.SecureFlagOpts, reference, sigRepo, func(ref registry.Reference, manifestDesc ocispec.Descriptor) {
+		fmt.Fprintf(os.Stderr, "Warning: Always verify the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", ref.Reference)
+	})
+	if err != nil {
+		return nil, registry.Reference{}, err
+	}
+	parseUserMetadata := cmd.ParseFlagMap
+	if opts.emptyMetadataOK {
+		parseUserMetadata = cmd.ParseFlagMapAllowEmptyValues
+	}
+	userMetadata, err := parseUserMetadata(opts.userMetadata, cmd.PflagUserMetadata.Name)
+	if err != nil {
+		return nil, ref, err
+	}
+	storePath, err := dir.ConfigFS().SysPath(tofuFileName)
+	if err != nil {
+		return nil, ref, fmt.Errorf("failed to obtain path of %s: %w", tofuFileName, err)
+	}
+	store, err := loadTOFUStore(storePath)
+	if err != nil {
+		return nil, ref, err
+	}
+	repository := ref.Registry + "/" + ref.Repository
+	recorded, known := store.Identities[repository]
+
+	sigManifests, err := listSignatureManifests(ctx, sigRepo, manifestDesc, nil)
+	if err != nil {
+		return nil, ref, notation.ErrorSignatureRetrievalFailed{Msg: err.Error()}
+	}
+	var mismatched []tofuIdentity
+	for _, sigManifestDesc := range sigManifests {
+		content, identity, err := verifyTOFUSignature(ctx, sigRepo, sigManifestDesc, manifestDesc, userMetadata, opts.maxCertChainDepth)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: signature %s: %v\n", sigManifestDesc.Digest, err)
+			continue
+		}
+		if known {
+			if !recorded.matches(identity) {
+				mismatched = append(mismatched, identity)
+				continue
+			}
+		} else {
+			confirmed, err := cmdutil.AskForConfirmation(os.Stdin, fmt.Sprintf("The artifact %s is signed by %s. Trust this signing identity for future verifications of %s?", ref, identity, repository), opts.yes)
+			if err != nil {
+				return nil, ref, err
+			}
+			if !confirmed {
+				return nil, ref, fmt.Errorf("signing identity of %s is not trusted", ref)
+			}
+			identity.RecordedAt = time.Now().UTC()
+			store.Identities[repository] = identity
+			if err := store.save(storePath); err != nil {
+				return nil, ref, fmt.Errorf("failed to record signing identity: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Recorded the signing identity of %s in %s\n", repository, storePath)
+		}
+		return &notation.VerificationOutcome{EnvelopeContent: content}, ref, nil
+	}
+	for _, identity := range mismatched {
+		fmt.Fprintf(os.Stderr, "Error: SIGNING IDENTITY OF %s HAS CHANGED. Recorded %s, but the artifact is signed by %s. Remove the entry of %s from %s if the change is expected.\n", repository, recorded, identity, repository, storePath)
+	}
+	return nil, ref, allSignaturesFailedError{artifact: ref.String()}
+}
+
+// verifyTOFUSignature verifies the signature of sigManifestDesc without a
+// trust store and returns its content and signing identity. Signatures with
+// more than maxCertChainDepth certificates are rejected before parsing.
+func verifyTOFUSignature(ctx context.Context, sigRepo notationregistry.Repository, sigManifestDesc, manifestDesc ocispec.Descriptor, userMetadata map[string]string, maxCertChainDepth int) (*signature.EnvelopeContent, tofuIdentity, error) {
+	sigBlob, sigDesc, err := sigRepo.FetchSignatureBlob(ctx, sigManifestDesc)
+	if err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	if err := checkCertChainDepth(sigDesc.MediaType, sigBlob, maxCertChainDepth); err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	sigEnv, err := signature.ParseEnvelope(sigDesc.MediaType, sigBlob)
+	if err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	content, err := sigEnv.Verify()
+	if err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	if err := verifySignedTarget(&content.Payload, manifestDesc, userMetadata); err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	now := time.Now()
+	if expiry := content.SignerInfo.SignedAttributes.Expiry; !expiry.IsZero() && now.After(expiry) {
+		return nil, tofuIdentity{}, fmt.Errorf("signature expired at %s", expiry.Format(time.RFC3339))
+	}
+	certs := content.SignerInfo.CertificateChain
+	if err := corex509.ValidateCodeSigningCertChain(certs, &now); err != nil {
+		return nil, tofuIdentity{}, err
+	}
+	return content, signingIdentity(certs), nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-go/dir"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestTOFUStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), tofuFileName)
+	store, err := loadTOFUStore(path)
+	if err != nil {
+		t.Fatalf("loadTOFUStore() error = %v", err)
+	}
+	if len(store.Identities) != 0 {
+		t.Fatalf("loadTOFUStore() of a missing file = %v, want empty store", store.Identities)
+	}
+
+	identity := signingIdentity([]*x509.Certificate{newTestCertificate(t, "wabbit-networks.io")})
+	identity.RecordedAt = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.Identities["localhost:5000/net-monitor"] = identity
+	if err := store.save(path); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	store, err = loadTOFUStore(path)
+	if err != nil {
+		t.Fatalf("loadTOFUStore() error = %v", err)
+	}
+	if got := store.Identities["localhost:5000/net-monitor"]; got != identity {
+		t.Fatalf("recorded identity = %v, want %v", got, identity)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTOFUStore(path); err == nil {
+		t.Fatal("loadTOFUStore() expects error for a malformed file but got nil")
+	}
+}
+
+func TestSigningIdentity_Matches(t *testing.T) {
+	cert := newTestCertificate(t, "wabbit-networks.io")
+	identity := signingIdentity([]*x509.Certificate{cert})
+	if identity.Subject != "CN=wabbit-networks.io" {
+		t.Fatalf("signing identity subject = %q, want %q", identity.Subject, "CN=wabbit-networks.io")
+	}
+	recorded := identity
+	recorded.RecordedAt = time.Now()
+	if !recorded.matches(identity) {
+		t.Fatal("matches() = false for the same signing identity, want true")
+	}
+	other := signingIdentity([]*x509.Certificate{newTestCertificate(t, "wabbit-networks.io")})
+	if recorded.matches(other) {
+		t.Fatal("matches() = true for a signing identity with another root certificate, want false")
+	}
+}
+
+func TestHasApplicableTrustPolicy(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	ref := registry.Reference{Registry: "localhost:5000", Repository: "net-monitor"}
+
+	applicable, err := hasApplicableTrustPolicy(ref)
+	if err != nil || applicable {
+		t.Fatalf("hasApplicableTrustPolicy() without trust policy = %v, %v, want false, nil", applicable, err)
+	}
+
+	policy := `{"version":"1.0","trustPolicies":[{"name":"net-monitor","registryScopes":["localhost:5000/net-monitor"],"signatureVerification":{"level":"skip"}}]}`
+	if err := os.WriteFile(filepath.Join(dir.UserConfigDir, dir.PathTrustPolicy), []byte(policy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	applicable, err = hasApplicableTrustPolicy(ref)
+	if err != nil || !applicable {
+		t.Fatalf("hasApplicableTrustPolicy() = %v, %v, want true, nil", applicable, err)
+	}
+	applicable, err = hasApplicableTrustPolicy(registry.Reference{Registry: "localhost:5000", Repository: "net-logger"})
+	if err != nil || applicable {
+		t.Fatalf("hasApplicableTrustPolicy() for another repository = %v, %v, want false, nil", applicable, err)
+	}
+}
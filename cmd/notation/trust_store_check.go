@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	notationtruststore "github.com/notaryproject/notation/cmd/notation/internal/truststore"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// selfCheckPolicyName is the name of the trust policy statement used to
+// verify a new signature against a trust store.
+const selfCheckPolicyName = "sign-self-check"
+
+// validateTrustStoreName checks that a trust store name is in the format
+// "{type}:{name}", as used by trust policies.
+func validateTrustStoreName(value string) error {
+	storeType, name, found := strings.Cut(value, ":")
+	if !found || name == "" {
+		return fmt.Errorf("trust store %q must be in the format {type}:{name}", value)
+	}
+	if !slices.Contains(truststore.Types, truststore.Type(storeType)) {
+		return fmt.Errorf("trust store %q has an unsupported type %q, supported types are %v", value, storeType, truststore.Types)
+	}
+	if !notationtruststore.IsValidFileName(name) {
+		return fmt.Errorf("trust store %q has an invalid name, it must only contain alphanumeric characters, hyphens, underscores and periods", value)
+	}
+	return nil
+}
+
+// selfCheckPolicy returns a trust policy document trusting any identity
+// issued by the given trust store with the strict verification level.
+func selfCheckPolicy(trustStore string) *trustpolicy.Document {
+	return &trustpolicy.Document{
+		Version: "1.0",
+		TrustPolicies: []trustpolicy.TrustPolicy{
+			{
+				Name:                  selfCheckPolicyName,
+				RegistryScopes:        []string{"*"},
+				SignatureVerification: trustpolicy.SignatureVerification{VerificationLevel: trustpolicy.LevelStrict.Name},
+				TrustStores:           []string{trustStore},
+				TrustedIdentities:     []string{"*"},
+			},
+		},
+	}
+}
+
+// checkTrustStore verifies the signature recorded by recorder against the
+// given trust store, as a trust policy using only that trust store would.
+func checkTrustStore(ctx context.Context, x509TrustStore truststore.X509TrustStore, trustStore string, subject ocispec.Descriptor, artifactRef string, recorder *signatureRecorder) error {
+	if recorder.blob == nil {
+		return errors.New("no signature was pushed")
+	}
+	v, err := verifier.New(selfCheckPolicy(trustStore), x509TrustStore, nil)
+	if err != nil {
+		return err
+	}
+	outcome, err := v.Verify(ctx, subject, recorder.blob, notation.VerifyOptions{
+		ArtifactReference:  artifactRef,
+		SignatureMediaType: recorder.mediaType,
+	})
+	if err != nil {
+		return err
+	}
+	if outcome.Error != nil {
+		return outcome.Error
+	}
+	return nil
+}
+
+// selfCheckSignature verifies the signature just pushed for ref against the
+// trust store of `--require-trust-store`. If it does not verify, signing fails
+// unless `--require-trust-store-warn` is set.
+func selfCheckSignature(ctx context.Context, opts *signOpts, sigRepo notationregistry.Repository, ref registry.Reference, recorder *signatureRecorder) error {
+	subject, err := sigRepo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return err
+	}
+	x509TrustStore := truststore.NewX509TrustStore(dir.ConfigFS())
+	err = checkTrustStore(ctx, x509TrustStore, opts.trustStore, subject, ref.String(), recorder)
+	if err == nil {
+		fmt.Fprintf(os.Stderr, "Signature %s verified against trust store %s\n", recorder.manifestDesc.Digest, opts.trustStore)
+		return nil
+	}
+	if opts.trustStoreWarn {
+		fmt.Fprintf(os.Stderr, "Warning: signature %s does not verify against trust store %s: %v\n", recorder.manifestDesc.Digest, opts.trustStore, err)
+		return nil
+	}
+	return fmt.Errorf("signature %s was pushed but does not verify against trust store %s: %w", recorder.manifestDesc.Digest, opts.trustStore, err)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+type mockTrustStore struct {
+	certs map[string][]*x509.Certificate
+}
+
+func (s *mockTrustStore) GetCertificates(ctx context.Context, storeType truststore.Type, namedStore string) ([]*x509.Certificate, error) {
+	return s.certs[string(storeType)+":"+namedStore], nil
+}
+
+func TestValidateTrustStoreName(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		wantErr bool
+	}{
+		{name: "ca", value: "ca:acme-rockets"},
+		{name: "signing authority", value: "signingAuthority:acme_rockets.v1"},
+		{name: "missing type", value: "acme-rockets", wantErr: true},
+		{name: "missing name", value: "ca:", wantErr: true},
+		{name: "unsupported type", value: "tsa:acme-rockets", wantErr: true},
+		{name: "invalid name", value: "ca:../acme-rockets", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateTrustStoreName(tt.value); (err != nil) != tt.wantErr {
+				t.Fatalf("validateTrustStoreName(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckTrustStore(t *testing.T) {
+	ctx := context.Background()
+	sig, cert := newTestSignature(t, time.Now().UTC().Truncate(time.Second))
+	recorder := &signatureRecorder{mediaType: jws.MediaTypeEnvelope, blob: sig}
+	store := &mockTrustStore{certs: map[string][]*x509.Certificate{
+		"ca:trusted": {cert},
+	}}
+
+	t.Run("trusted", func(t *testing.T) {
+		if err := checkTrustStore(ctx, store, "ca:trusted", ocispec.Descriptor{}, "localhost:5000/net-monitor@sha256:abc", recorder); err != nil {
+			t.Fatalf("checkTrustStore() error = %v", err)
+		}
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		if err := checkTrustStore(ctx, store, "ca:untrusted", ocispec.Descriptor{}, "localhost:5000/net-monitor@sha256:abc", recorder); err == nil {
+			t.Fatal("checkTrustStore() expected error, but got nil")
+		}
+	})
+
+	t.Run("no signature", func(t *testing.T) {
+		if err := checkTrustStore(ctx, store, "ca:trusted", ocispec.Descriptor{}, "localhost:5000/net-monitor@sha256:abc", &signatureRecorder{}); err == nil {
+			t.Fatal("checkTrustStore() expected error, but got nil")
+		}
+	})
+}
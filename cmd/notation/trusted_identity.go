@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	ldapv3 "github.com/go-ldap/ldap/v3"
+)
+
+// trustedIdentity is an X.509 distinguished name a signing certificate
+// subject must match, parsed into its RDN attributes.
+type trustedIdentity struct {
+	name       string
+	attributes map[string]string
+}
+
+// parseTrustedIdentity parses the distinguished name of a trusted identity,
+// following the rules of x509.subject identities of trust policies. The name
+// must contain the C, ST and O attributes, and must not contain multi-valued
+// or duplicate attributes.
+func parseTrustedIdentity(name string) (trustedIdentity, error) {
+	if strings.Contains(name, "=#") {
+		return trustedIdentity{}, fmt.Errorf("distinguished name %q must not contain \"=#\"", name)
+	}
+	dn, err := ldapv3.ParseDN(name)
+	if err != nil {
+		return trustedIdentity{}, fmt.Errorf("distinguished name %q is not valid: %v", name, err)
+	}
+	attributes := make(map[string]string)
+	for _, rdn := range dn.RDNs {
+		if len(rdn.Attributes) > 1 {
+			return trustedIdentity{}, fmt.Errorf("distinguished name %q has multi-valued RDN attributes, which are not supported", name)
+		}
+		for _, attribute := range rdn.Attributes {
+			if _, ok := attributes[attribute.Type]; ok {
+				return trustedIdentity{}, fmt.Errorf("distinguished name %q has duplicate RDN attribute %q", name, attribute.Type)
+			}
+			attributes[attribute.Type] = attribute.Value
+		}
+	}
+	for _, mandatory := range []string{"C", "ST", "O"} {
+		if attributes[mandatory] == "" {
+			return trustedIdentity{}, fmt.Errorf("distinguished name %q has no RDN attribute %q, it must contain 'C', 'ST' and 'O' RDN attributes at a minimum", name, mandatory)
+		}
+	}
+	return trustedIdentity{name: name, attributes: attributes}, nil
+}
+
+// loadTrustedIdentities returns the trusted identities given inline by
+// names, followed by the trusted identities of the file at path, if path is
+// not empty. The file lists one distinguished name per line. Empty lines and
+// lines starting with "#" are ignored.
+func loadTrustedIdentities(names []string, path string) ([]trustedIdentity, error) {
+	var identities []trustedIdentity
+	for _, name := range names {
+		identity, err := parseTrustedIdentity(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --trusted-identity: %w", err)
+		}
+		identities = append(identities, identity)
+	}
+	if path == "" {
+		return identities, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read --trusted-identity-file: %w", err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	var fromFile int
+	for line := 1; scanner.Scan(); line++ {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		identity, err := parseTrustedIdentity(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --trusted-identity-file %s at line %d: %w", path, line, err)
+		}
+		identities = append(identities, identity)
+		fromFile++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read --trusted-identity-file: %w", err)
+	}
+	if fromFile == 0 {
+		return nil, fmt.Errorf("--trusted-identity-file %s does not list any distinguished name", path)
+	}
+	return identities, nil
+}
+
+// checkTrustedIdentities returns an error if the subject of the signing
+// certificate cert matches none of identities. As for x509.subject identities
+// of trust policies, the subject matches an identity if it has all the RDN
+// attributes of the identity.
+func checkTrustedIdentities(cert *x509.Certificate, identities []trustedIdentity) error {
+	subject, err := parseSubjectAttributes(cert)
+	if err != nil {
+		return err
+	}
+	for _, identity := range identities {
+		if matchSubjectAttributes(identity.attributes, subject) {
+			return nil
+		}
+	}
+	return fmt.Errorf("signing certificate with subject %q matches none of the %d identities of --trusted-identity and --trusted-identity-file", cert.Subject, len(identities))
+}
+
+// parseSubjectAttributes returns the RDN attributes of the subject of cert.
+func parseSubjectAttributes(cert *x509.Certificate) (map[string]string, error) {
+	dn, err := ldapv3.ParseDN(cert.Subject.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the subject %q of the signing certificate: %v", cert.Subject, err)
+	}
+	attributes := make(map[string]string)
+	for _, rdn := range dn.RDNs {
+		for _, attribute := range rdn.Attributes {
+			if _, ok := attributes[attribute.Type]; ok {
+				return nil, errors.New("subject of the signing certificate has duplicate RDN attribute " + attribute.Type)
+			}
+			attributes[attribute.Type] = attribute.Value
+		}
+	}
+	return attributes, nil
+}
+
+// matchSubjectAttributes reports whether subject has all the attributes of
+// identity.
+func matchSubjectAttributes(identity, subject map[string]string) bool {
+	for key, value := range identity {
+		if subject[key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseTrustedIdentity(t *testing.T) {
+	identity, err := parseTrustedIdentity("C=US, ST=WA, O=wabbit-networks.io, CN=release")
+	if err != nil {
+		t.Fatalf("parseTrustedIdentity() error = %v", err)
+	}
+	if len(identity.attributes) != 4 || identity.attributes["O"] != "wabbit-networks.io" {
+		t.Fatalf("parseTrustedIdentity() attributes = %v", identity.attributes)
+	}
+
+	for _, name := range []string{
+		"C=US,ST=WA",
+		"C=US,ST=WA,O=wabbit-networks.io,O=acme-rockets.io",
+		"C=US+ST=WA,O=wabbit-networks.io",
+		"C=US,ST=WA,O=#0403",
+		"not a distinguished name",
+	} {
+		if _, err := parseTrustedIdentity(name); err == nil {
+			t.Errorf("parseTrustedIdentity(%q) expects error", name)
+		}
+	}
+}
+
+func TestLoadTrustedIdentities(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trusted_identities.txt")
+	content := "# release signers\nC=US,ST=WA,O=wabbit-networks.io\n\n  C=US,ST=WA,O=acme-rockets.io  \n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	identities, err := loadTrustedIdentities([]string{"C=US,ST=NY,O=example.com"}, path)
+	if err != nil {
+		t.Fatalf("loadTrustedIdentities() error = %v", err)
+	}
+	var names []string
+	for _, identity := range identities {
+		names = append(names, identity.name)
+	}
+	want := "C=US,ST=NY,O=example.com;C=US,ST=WA,O=wabbit-networks.io;C=US,ST=WA,O=acme-rockets.io"
+	if got := strings.Join(names, ";"); got != want {
+		t.Fatalf("loadTrustedIdentities() = %s, want %s", got, want)
+	}
+
+	t.Run("malformed line", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "trusted_identities.txt")
+		if err := os.WriteFile(path, []byte("C=US,ST=WA,O=wabbit-networks.io\n# comment\nC=US,ST=WA\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		_, err := loadTrustedIdentities(nil, path)
+		if err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Fatalf("loadTrustedIdentities() error = %v, want error at line 3", err)
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "trusted_identities.txt")
+		if err := os.WriteFile(path, []byte("# no identity\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadTrustedIdentities(nil, path); err == nil {
+			t.Fatal("loadTrustedIdentities() expects error for a file without identity")
+		}
+	})
+
+	t.Run("invalid inline identity", func(t *testing.T) {
+		_, err := loadTrustedIdentities([]string{"C=US"}, "")
+		if err == nil || !strings.Contains(err.Error(), "--trusted-identity") {
+			t.Fatalf("loadTrustedIdentities() error = %v, want error of --trusted-identity", err)
+		}
+	})
+}
+
+func TestCheckTrustedIdentities(t *testing.T) {
+	identities, err := loadTrustedIdentities([]string{"C=US,ST=WA,O=wabbit-networks.io", "C=US,ST=WA,O=acme-rockets.io,CN=release"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		subject pkix.Name
+		wantErr bool
+	}{
+		{
+			name:    "subset of subject",
+			subject: pkix.Name{CommonName: "anything", Organization: []string{"wabbit-networks.io"}, Province: []string{"WA"}, Country: []string{"US"}},
+		},
+		{
+			name:    "exact subject",
+			subject: pkix.Name{CommonName: "release", Organization: []string{"acme-rockets.io"}, Province: []string{"WA"}, Country: []string{"US"}},
+		},
+		{
+			name:    "missing attribute",
+			subject: pkix.Name{CommonName: "nightly", Organization: []string{"acme-rockets.io"}, Province: []string{"WA"}, Country: []string{"US"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTrustedIdentities(&x509.Certificate{Subject: tt.subject}, identities)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkTrustedIdentities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// tsaRequestTimeout is the timeout of requesting a timestamp from a TSA.
+const tsaRequestTimeout = 30 * time.Second
+
+// maxTimestampResponseSize is the largest timestamp response read from a
+// TSA, in bytes.
+const maxTimestampResponseSize = 1 << 20
+
+// media types of RFC 3161 timestamp requests and responses
+const (
+	mediaTypeTimestampQuery = "application/timestamp-query"
+	mediaTypeTimestampReply = "application/timestamp-reply"
+)
+
+// object identifiers of RFC 3161 timestamp tokens
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSHA256        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512        = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// messageImprint is the hash of the timestamped data.
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timestampRequest is an RFC 3161 TimeStampReq.
+type timestampRequest struct {
+	Version        int
+	MessageImprint messageImprint
+	Nonce          *big.Int `asn1:"optional"`
+	CertReq        bool     `asn1:"optional"`
+}
+
+// timestampResponse is an RFC 3161 TimeStampResp.
+type timestampResponse struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// pkiStatusInfo is the status of an RFC 3161 TimeStampResp.
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// contentInfo is a CMS ContentInfo.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// signedData is a CMS SignedData.
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+// encapsulatedContentInfo is the signed content of a CMS SignedData.
+type encapsulatedContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// cmsSignerInfo is a CMS SignerInfo.
+type cmsSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// issuerAndSerialNumber identifies the certificate of a CMS signer.
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// cmsAttribute is a signed attribute of a CMS SignerInfo.
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue `asn1:"set"`
+}
+
+// tstInfo is the signed content of an RFC 3161 timestamp token.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time     `asn1:"generalized"`
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// accuracy is the accuracy of the time of an RFC 3161 timestamp token.
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// timestampError is returned if a signature cannot be timestamped by the TSA
+// of --timestamp-url, so that it is not confused with failures to push the
+// signature.
+type timestampError struct {
+	url string
+	err error
+}
+
+func (e *timestampError) Error() string {
+	return fmt.Sprintf("failed to timestamp the signature with the TSA %s: %v", e.url, e.err)
+}
+
+func (e *timestampError) Unwrap() error {
+	return e.err
+}
+
+// validateTimestampFlags checks that the TSA URL of --timestamp-url is an
+// HTTPS URL and that the file of --timestamp-root-cert exists, before any
+// TSA is contacted.
+func validateTimestampFlags(tsaURL, rootCertPath string) error {
+	u, err := url.Parse(tsaURL)
+	if err != nil {
+		return fmt.Errorf("invalid --timestamp-url: %w", err)
+	}
+	if u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("invalid --timestamp-url: %q is not an HTTPS URL", tsaURL)
+	}
+	fi, err := os.Stat(rootCertPath)
+	if err != nil {
+		return fmt.Errorf("invalid --timestamp-root-cert: %w", err)
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("invalid --timestamp-root-cert: %q is not a regular file", rootCertPath)
+	}
+	return nil
+}
+
+// loadTimestampRootCerts reads the PEM or DER encoded root certificates of
+// --timestamp-root-cert, which the certificate chain of timestamp tokens
+// must chain to.
+func loadTimestampRootCerts(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --timestamp-root-cert: %w", err)
+	}
+	var certs []*x509.Certificate
+	if block, _ := pem.Decode(data); block != nil {
+		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid --timestamp-root-cert %q: %w", path, err)
+			}
+			certs = append(certs, cert)
+		}
+	} else {
+		certs, err = x509.ParseCertificates(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --timestamp-root-cert %q: %w", path, err)
+		}
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("invalid --timestamp-root-cert %q: no certificate found", path)
+	}
+	roots := x509.NewCertPool()
+	for _, cert := range certs {
+		roots.AddCert(cert)
+	}
+	return roots, nil
+}
+
+// requestTimestamp requests an RFC 3161 timestamp token of the SHA-256
+// digest from the TSA at tsaURL, and returns it DER encoded once it is
+// verified against roots.
+func requestTimestamp(ctx context.Context, client *http.Client, tsaURL string, digest []byte, roots *x509.CertPool) ([]byte, error) {
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	reqBody, err := asn1.Marshal(timestampRequest{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			HashedMessage: digest,
+		},
+		Nonce:   nonce,
+		CertReq: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tsaURL, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mediaTypeTimestampQuery)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxTimestampResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(respBody) > maxTimestampResponseSize {
+		return nil, fmt.Errorf("timestamp response is larger than %d bytes", maxTimestampResponseSize)
+	}
+	var tsResp timestampResponse
+	if rest, err := asn1.Unmarshal(respBody, &tsResp); err != nil || len(rest) != 0 {
+		return nil, errors.New("malformed timestamp response")
+	}
+	// granted or grantedWithMods
+	if tsResp.Status.Status != 0 && tsResp.Status.Status != 1 {
+		return nil, fmt.Errorf("timestamp request rejected with status %d %q", tsResp.Status.Status, tsResp.Status.StatusString)
+	}
+	token := tsResp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, errors.New("timestamp response has no timestamp token")
+	}
+	if _, err := verifyTimestampToken(token, digest, nonce, roots); err != nil {
+		return nil, fmt.Errorf("invalid timestamp token: %w", err)
+	}
+	return token, nil
+}
+
+// verifyTimestampToken verifies that the DER encoded RFC 3161 timestamp token
+// timestamps the SHA-256 digest with the nonce, if not nil, and is signed by
+// a timestamping certificate chaining to roots at the time of the token. It
+// returns the time of the token.
+func verifyTimestampToken(token, digest []byte, nonce *big.Int, roots *x509.CertPool) (time.Time, error) {
+	var ci contentInfo
+	if rest, err := asn1.Unmarshal(token, &ci); err != nil || len(rest) != 0 {
+		return time.Time{}, errors.New("malformed content info")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return time.Time{}, fmt.Errorf("unexpected content type %v", ci.ContentType)
+	}
+	var sd signedData
+	if rest, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil || len(rest) != 0 {
+		return time.Time{}, errors.New("malformed signed data")
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return time.Time{}, fmt.Errorf("unexpected signed content type %v", sd.EncapContentInfo.EContentType)
+	}
+	var info tstInfo
+	if rest, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil || len(rest) != 0 {
+		return time.Time{}, errors.New("malformed TSTInfo")
+	}
+	if !info.MessageImprint.HashAlgorithm.Algorithm.Equal(oidSHA256) || !bytes.Equal(info.MessageImprint.HashedMessage, digest) {
+		return time.Time{}, errors.New("timestamp token does not timestamp the signature")
+	}
+	if nonce != nil && (info.Nonce == nil || info.Nonce.Cmp(nonce) != 0) {
+		return time.Time{}, errors.New("nonce of the timestamp token does not match the request")
+	}
+	if len(sd.SignerInfos) != 1 {
+		return time.Time{}, fmt.Errorf("timestamp token has %d signers, expected 1", len(sd.SignerInfos))
+	}
+	signer := sd.SignerInfos[0]
+	certs, err := x509.ParseCertificates(sd.Certificates.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("malformed certificates: %w", err)
+	}
+	signerCert, err := findCMSSignerCert(signer.SID, certs)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := verifyCMSSignerInfo(signer, sd.EncapContentInfo.EContent, signerCert); err != nil {
+		return time.Time{}, err
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs {
+		intermediates.AddCert(cert)
+	}
+	if _, err := signerCert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   info.GenTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return time.Time{}, fmt.Errorf("certificate of the TSA is not trusted: %w", err)
+	}
+	return info.GenTime, nil
+}
+
+// findCMSSignerCert returns the certificate of certs identified by the
+// signer identifier sid, either an issuer and serial number or a subject key
+// identifier.
+func findCMSSignerCert(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		for _, cert := range certs {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, errors.New("certificate of the signer not found in the timestamp token")
+	}
+	var ias issuerAndSerialNumber
+	if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+		return nil, errors.New("malformed signer identifier")
+	}
+	for _, cert := range certs {
+		if bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("certificate of the signer not found in the timestamp token")
+}
+
+// verifyCMSSignerInfo verifies that signer signs content with the key of
+// cert. The signed attributes must include the digest of content.
+func verifyCMSSignerInfo(signer cmsSignerInfo, content []byte, cert *x509.Certificate) error {
+	hash, ok := cmsHashes[signer.DigestAlgorithm.Algorithm.String()]
+	if !ok {
+		return fmt.Errorf("unsupported digest algorithm %v", signer.DigestAlgorithm.Algorithm)
+	}
+	if len(signer.SignedAttrs.FullBytes) == 0 {
+		return errors.New("timestamp token has no signed attributes")
+	}
+	// signed attributes are signed with their SET OF tag, instead of their
+	// implicit tag in the SignerInfo
+	signedAttrs := append([]byte{0x31}, signer.SignedAttrs.FullBytes[1:]...)
+	var attrs []cmsAttribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrs, &attrs, "set"); err != nil {
+		return errors.New("malformed signed attributes")
+	}
+	var contentType asn1.ObjectIdentifier
+	var messageDigest []byte
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidContentType):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &contentType); err != nil {
+				return errors.New("malformed content type attribute")
+			}
+		case attr.Type.Equal(oidMessageDigest):
+			if _, err := asn1.Unmarshal(attr.Values.Bytes, &messageDigest); err != nil {
+				return errors.New("malformed message digest attribute")
+			}
+		}
+	}
+	if !contentType.Equal(oidTSTInfo) {
+		return errors.New("content type attribute does not match the signed content")
+	}
+	h := hash.New()
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), messageDigest) {
+		return errors.New("message digest attribute does not match the signed content")
+	}
+	algorithm, err := cmsSignatureAlgorithm(cert, hash)
+	if err != nil {
+		return err
+	}
+	if err := cert.CheckSignature(algorithm, signedAttrs, signer.Signature); err != nil {
+		return fmt.Errorf("invalid signature of the timestamp token: %w", err)
+	}
+	return nil
+}
+
+// cmsHashes are the digest algorithms of timestamp tokens supported, by
+// their object identifier.
+var cmsHashes = map[string]crypto.Hash{
+	oidSHA256.String(): crypto.SHA256,
+	oidSHA384.String(): crypto.SHA384,
+	oidSHA512.String(): crypto.SHA512,
+}
+
+// cmsSignatureAlgorithm returns the signature algorithm of a CMS signer with
+// the key of cert and the digest algorithm hash.
+func cmsSignatureAlgorithm(cert *x509.Certificate, hash crypto.Hash) (x509.SignatureAlgorithm, error) {
+	algorithms := map[x509.PublicKeyAlgorithm]map[crypto.Hash]x509.SignatureAlgorithm{
+		x509.RSA: {
+			crypto.SHA256: x509.SHA256WithRSA,
+			crypto.SHA384: x509.SHA384WithRSA,
+			crypto.SHA512: x509.SHA512WithRSA,
+		},
+		x509.ECDSA: {
+			crypto.SHA256: x509.ECDSAWithSHA256,
+			crypto.SHA384: x509.ECDSAWithSHA384,
+			crypto.SHA512: x509.ECDSAWithSHA512,
+		},
+	}
+	algorithm, ok := algorithms[cert.PublicKeyAlgorithm][hash]
+	if !ok {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported key algorithm %v of the TSA", cert.PublicKeyAlgorithm)
+	}
+	return algorithm, nil
+}
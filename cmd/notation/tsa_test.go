@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// oidECDSAWithSHA256 is the signature algorithm of the test TSA.
+var oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+
+// testTSA is an RFC 3161 timestamping authority issuing timestamp tokens
+// signed by a certificate chaining to root.
+type testTSA struct {
+	rootKey *ecdsa.PrivateKey
+	root    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	cert    *x509.Certificate
+	// status is the status of the timestamp responses.
+	status int
+	// imprint, if not nil, replaces the message imprint of the tokens.
+	imprint []byte
+}
+
+func newTestTSA(t *testing.T) *testTSA {
+	t.Helper()
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "tsa test root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "tsa test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+		BasicConstraintsValid: true,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, root, &key.PublicKey, rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testTSA{rootKey: rootKey, root: root, key: key, cert: cert}
+}
+
+// writeRoot writes the PEM encoded root certificate of the TSA to a file
+// and returns its path.
+func (tsa *testTSA) writeRoot(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tsa_root.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tsa.root.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// roots returns a pool of the root certificate of the TSA.
+func (tsa *testTSA) roots() *x509.CertPool {
+	roots := x509.NewCertPool()
+	roots.AddCert(tsa.root)
+	return roots
+}
+
+// ServeHTTP answers RFC 3161 timestamp requests.
+func (tsa *testTSA) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Content-Type") != mediaTypeTimestampQuery {
+		http.Error(w, "unexpected content type", http.StatusBadRequest)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var req timestampRequest
+	if _, err := asn1.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	resp := timestampResponse{Status: pkiStatusInfo{Status: tsa.status}}
+	if tsa.status == 0 {
+		if tsa.imprint != nil {
+			req.MessageImprint.HashedMessage = tsa.imprint
+		}
+		token, err := tsa.createToken(req.MessageImprint, req.Nonce, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.TimeStampToken = asn1.RawValue{FullBytes: token}
+	}
+	respBody, err := asn1.Marshal(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypeTimestampReply)
+	w.Write(respBody)
+}
+
+// createToken returns a DER encoded timestamp token of imprint.
+func (tsa *testTSA) createToken(imprint messageImprint, nonce *big.Int, genTime time.Time) ([]byte, error) {
+	content, err := asn1.Marshal(tstInfo{
+		Version:        1,
+		Policy:         asn1.ObjectIdentifier{1, 2, 3, 4},
+		MessageImprint: imprint,
+		SerialNumber:   big.NewInt(time.Now().UnixNano()),
+		GenTime:        genTime.UTC().Truncate(time.Second),
+		Nonce:          nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+	contentType, err := asn1.Marshal(oidTSTInfo)
+	if err != nil {
+		return nil, err
+	}
+	contentDigest := sha256.Sum256(content)
+	messageDigest, err := asn1.Marshal(contentDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	signedAttrs, err := asn1.MarshalWithParams([]cmsAttribute{
+		{Type: oidContentType, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: contentType}},
+		{Type: oidMessageDigest, Values: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: messageDigest}},
+	}, "set")
+	if err != nil {
+		return nil, err
+	}
+	signedAttrsDigest := sha256.Sum256(signedAttrs)
+	sig, err := ecdsa.SignASN1(rand.Reader, tsa.key, signedAttrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	sid, err := asn1.Marshal(issuerAndSerialNumber{
+		Issuer:       asn1.RawValue{FullBytes: tsa.cert.RawIssuer},
+		SerialNumber: tsa.cert.SerialNumber,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sd, err := asn1.Marshal(signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapsulatedContentInfo{EContentType: oidTSTInfo, EContent: content},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: tsa.cert.Raw},
+		SignerInfos: []cmsSignerInfo{{
+			Version:            1,
+			SID:                asn1.RawValue{FullBytes: sid},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: append([]byte{0xa0}, signedAttrs[1:]...)},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+			Signature:          sig,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: sd},
+	})
+}
+
+func TestRequestTimestamp(t *testing.T) {
+	tsa := newTestTSA(t)
+	server := httptest.NewTLSServer(tsa)
+	defer server.Close()
+	digest := sha256.Sum256([]byte("signature"))
+
+	token, err := requestTimestamp(context.Background(), server.Client(), server.URL, digest[:], tsa.roots())
+	if err != nil {
+		t.Fatalf("requestTimestamp() error = %v", err)
+	}
+	genTime, err := verifyTimestampToken(token, digest[:], nil, tsa.roots())
+	if err != nil {
+		t.Fatalf("verifyTimestampToken() error = %v", err)
+	}
+	if time.Since(genTime) > time.Minute {
+		t.Fatalf("verifyTimestampToken() time = %v, want about now", genTime)
+	}
+
+	// token of another digest
+	otherDigest := sha256.Sum256([]byte("other"))
+	if _, err := verifyTimestampToken(token, otherDigest[:], nil, tsa.roots()); err == nil || !strings.Contains(err.Error(), "does not timestamp the signature") {
+		t.Fatalf("verifyTimestampToken() error = %v, want error of mismatched digest", err)
+	}
+
+	// untrusted TSA
+	other := newTestTSA(t)
+	if _, err := verifyTimestampToken(token, digest[:], nil, other.roots()); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Fatalf("verifyTimestampToken() error = %v, want error of untrusted TSA", err)
+	}
+}
+
+func TestRequestTimestamp_Errors(t *testing.T) {
+	digest := sha256.Sum256([]byte("signature"))
+	tests := []struct {
+		name    string
+		modify  func(tsa *testTSA)
+		handler http.Handler
+		wantErr string
+	}{
+		{
+			name:    "rejected",
+			modify:  func(tsa *testTSA) { tsa.status = 2 },
+			wantErr: "rejected with status 2",
+		},
+		{
+			name:    "mismatched imprint",
+			modify:  func(tsa *testTSA) { tsa.imprint = make([]byte, sha256.Size) },
+			wantErr: "does not timestamp the signature",
+		},
+		{
+			name:    "server error",
+			handler: http.NotFoundHandler(),
+			wantErr: "unexpected status",
+		},
+		{
+			name: "malformed response",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("malformed"))
+			}),
+			wantErr: "malformed timestamp response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tsa := newTestTSA(t)
+			var handler http.Handler = tsa
+			if tt.handler != nil {
+				handler = tt.handler
+			}
+			if tt.modify != nil {
+				tt.modify(tsa)
+			}
+			server := httptest.NewTLSServer(handler)
+			defer server.Close()
+			_, err := requestTimestamp(context.Background(), server.Client(), server.URL, digest[:], tsa.roots())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("requestTimestamp() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTimestampFlags(t *testing.T) {
+	rootCert := newTestTSA(t).writeRoot(t)
+	tests := []struct {
+		name     string
+		url      string
+		rootCert string
+		wantErr  string
+	}{
+		{name: "valid", url: "https://timestamp.example.com", rootCert: rootCert},
+		{name: "plain HTTP", url: "http://timestamp.example.com", rootCert: rootCert, wantErr: "is not an HTTPS URL"},
+		{name: "no host", url: "https://", rootCert: rootCert, wantErr: "is not an HTTPS URL"},
+		{name: "missing root cert", url: "https://timestamp.example.com", rootCert: filepath.Join(t.TempDir(), "missing.pem"), wantErr: "invalid --timestamp-root-cert"},
+		{name: "root cert directory", url: "https://timestamp.example.com", rootCert: t.TempDir(), wantErr: "is not a regular file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTimestampFlags(tt.url, tt.rootCert)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validateTimestampFlags() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("validateTimestampFlags() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadTimestampRootCerts(t *testing.T) {
+	tsa := newTestTSA(t)
+	dir := t.TempDir()
+	derPath := filepath.Join(dir, "root.der")
+	if err := os.WriteFile(derPath, tsa.root.Raw, 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{tsa.writeRoot(t), derPath} {
+		roots, err := loadTimestampRootCerts(path)
+		if err != nil {
+			t.Fatalf("loadTimestampRootCerts(%q) error = %v", path, err)
+		}
+		if _, err := tsa.cert.Verify(x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping}}); err != nil {
+			t.Fatalf("loadTimestampRootCerts(%q) roots do not verify the TSA certificate: %v", path, err)
+		}
+	}
+
+	invalidPath := filepath.Join(dir, "invalid.pem")
+	if err := os.WriteFile(invalidPath, []byte("invalid"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTimestampRootCerts(invalidPath); err == nil {
+		t.Fatal("loadTimestampRootCerts() expected error for invalid file, but got nil")
+	}
+}
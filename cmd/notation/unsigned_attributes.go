@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+// unsignedAttributeTimestamp is the unsigned attribute of a signature
+// envelope storing the RFC 3161 timestamp token of the signature.
+const unsignedAttributeTimestamp = "io.cncf.notary.timestampSignature"
+
+// essentialJWSUnprotectedHeaders are the unprotected headers of a JWS
+// envelope required for verification, kept by --strip-unsigned-attributes.
+var essentialJWSUnprotectedHeaders = []string{"x5c", unsignedAttributeTimestamp}
+
+// essentialCOSEUnprotectedHeaders are the unprotected headers of a COSE
+// envelope required for verification, kept by --strip-unsigned-attributes.
+var essentialCOSEUnprotectedHeaders = []any{gocose.HeaderLabelX5Chain, unsignedAttributeTimestamp}
+
+// unsignedAttributeStrippingSigner wraps a notation.Signer and strips the
+// unsigned attributes not required for verification from the generated
+// signature envelope.
+type unsignedAttributeStrippingSigner struct {
+	signerWrapper
+}
+
+// Sign signs the artifact with the wrapped signer, then strips the
+// non-essential unsigned attributes of the signature envelope.
+func (s *unsignedAttributeStrippingSigner) Sign(ctx context.Context, desc ocispec.Descriptor, opts notation.SignOptions) ([]byte, *signature.SignerInfo, error) {
+	sig, signerInfo, err := s.Signer.Sign(ctx, desc, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, stripped, err := stripUnsignedAttributes(opts.SignatureMediaType, sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to strip the unsigned attributes of the signature: %w", err)
+	}
+	signerInfo.UnsignedAttributes.SigningAgent = ""
+	log.GetLogger(ctx).Infof("Stripped unsigned attributes %q from the signature", stripped)
+	return sig, signerInfo, nil
+}
+
+// stripUnsignedAttributes returns the signature envelope sig of mediaType
+// without the unsigned attributes not required for verification, and the
+// names of the stripped attributes in order.
+func stripUnsignedAttributes(mediaType string, sig []byte) ([]byte, []string, error) {
+	var stripped []string
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		var envelope map[string]json.RawMessage
+		if err := json.Unmarshal(sig, &envelope); err != nil {
+			return nil, nil, err
+		}
+		raw, ok := envelope["header"]
+		if !ok {
+			return sig, nil, nil
+		}
+		var header map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &header); err != nil {
+			return nil, nil, err
+		}
+		kept := map[string]json.RawMessage{}
+		for name, value := range header {
+			if slices.Contains(essentialJWSUnprotectedHeaders, name) {
+				kept[name] = value
+			} else {
+				stripped = append(stripped, name)
+			}
+		}
+		headerJSON, err := json.Marshal(kept)
+		if err != nil {
+			return nil, nil, err
+		}
+		envelope["header"] = headerJSON
+		sig, err = json.Marshal(envelope)
+		if err != nil {
+			return nil, nil, err
+		}
+	case cose.MediaTypeEnvelope:
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			return nil, nil, err
+		}
+		for label := range msg.Headers.Unprotected {
+			if !slices.Contains(essentialCOSEUnprotectedHeaders, label) {
+				delete(msg.Headers.Unprotected, label)
+				stripped = append(stripped, fmt.Sprint(label))
+			}
+		}
+		// re-encode the unprotected header only
+		msg.Headers.RawUnprotected = nil
+		var err error
+		sig, err = msg.MarshalCBOR()
+		if err != nil {
+			return nil, nil, err
+		}
+	default:
+		return nil, nil, fmt.Errorf("unsupported signature envelope media type %q", mediaType)
+	}
+	sort.Strings(stripped)
+	return sig, stripped, nil
+}
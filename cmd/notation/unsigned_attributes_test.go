@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+func TestStripUnsignedAttributes(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	crl := []byte("crl")
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig := signTestEnvelope(t, mediaType, desc)
+			// add a non-essential unsigned attribute besides the signing agent
+			sig, err := embedCRL(mediaType, sig, crl)
+			if err != nil {
+				t.Fatal(err)
+			}
+			stripped, names, err := stripUnsignedAttributes(mediaType, sig)
+			if err != nil {
+				t.Fatalf("stripUnsignedAttributes() error = %v", err)
+			}
+			want := []string{unsignedAttributeCRL, "io.cncf.notary.signingAgent"}
+			if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+				t.Fatalf("stripUnsignedAttributes() stripped %q, want %q", names, want)
+			}
+			if len(stripped) >= len(sig) {
+				t.Fatalf("stripped signature has %d bytes, want less than %d", len(stripped), len(sig))
+			}
+
+			// the minimal envelope still verifies
+			sigEnv, err := signature.ParseEnvelope(mediaType, stripped)
+			if err != nil {
+				t.Fatalf("ParseEnvelope() error = %v", err)
+			}
+			content, err := sigEnv.Verify()
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if agent := content.SignerInfo.UnsignedAttributes.SigningAgent; agent != "" {
+				t.Fatalf("signing agent = %q, want stripped", agent)
+			}
+			if n := len(content.SignerInfo.CertificateChain); n != 2 {
+				t.Fatalf("certificate chain has %d certificates, want 2", n)
+			}
+			if embedded, err := extractEmbeddedCRL(mediaType, stripped); err != nil || embedded != nil {
+				t.Fatalf("extractEmbeddedCRL() = (%v, %v), want stripped", embedded, err)
+			}
+			if err := verifySignedTarget(&content.Payload, desc, nil); err != nil {
+				t.Fatalf("verifySignedTarget() error = %v", err)
+			}
+
+			// stripping is idempotent
+			again, names, err := stripUnsignedAttributes(mediaType, stripped)
+			if err != nil {
+				t.Fatalf("stripUnsignedAttributes() error = %v", err)
+			}
+			if len(names) != 0 || string(again) != string(stripped) {
+				t.Fatalf("stripUnsignedAttributes() stripped %q again, want no change", names)
+			}
+		})
+	}
+}
+
+func TestStripUnsignedAttributes_KeepsTimestamp(t *testing.T) {
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	token := []byte("timestamp token")
+
+	sig := signTestEnvelope(t, jws.MediaTypeEnvelope, desc)
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(sig, &envelope); err != nil {
+		t.Fatal(err)
+	}
+	var header map[string]any
+	if err := json.Unmarshal(envelope["header"], &header); err != nil {
+		t.Fatal(err)
+	}
+	header[unsignedAttributeTimestamp] = token
+	envelope["header"], _ = json.Marshal(header)
+	sig, _ = json.Marshal(envelope)
+	stripped, _, err := stripUnsignedAttributes(jws.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatalf("stripUnsignedAttributes() error = %v", err)
+	}
+	var got struct {
+		Header struct {
+			Timestamp []byte `json:"io.cncf.notary.timestampSignature"`
+		} `json:"header"`
+	}
+	if err := json.Unmarshal(stripped, &got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got.Header.Timestamp) != string(token) {
+		t.Fatalf("JWS timestamp token = %q, want %q", got.Header.Timestamp, token)
+	}
+
+	sig = signTestEnvelope(t, cose.MediaTypeEnvelope, desc)
+	var msg gocose.Sign1Message
+	if err := msg.UnmarshalCBOR(sig); err != nil {
+		t.Fatal(err)
+	}
+	msg.Headers.Unprotected[unsignedAttributeTimestamp] = token
+	msg.Headers.RawUnprotected = nil
+	if sig, err = msg.MarshalCBOR(); err != nil {
+		t.Fatal(err)
+	}
+	stripped, _, err = stripUnsignedAttributes(cose.MediaTypeEnvelope, sig)
+	if err != nil {
+		t.Fatalf("stripUnsignedAttributes() error = %v", err)
+	}
+	msg = gocose.Sign1Message{}
+	if err := msg.UnmarshalCBOR(stripped); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := msg.Headers.Unprotected[unsignedAttributeTimestamp].([]byte); string(got) != string(token) {
+		t.Fatalf("COSE timestamp token = %q, want %q", got, token)
+	}
+}
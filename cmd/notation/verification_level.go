@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+const (
+	checkResultPassed    = "passed"
+	checkResultTolerated = "failed (tolerated)"
+	checkResultSkipped   = "skipped"
+	checkResultNotRun    = "not run"
+)
+
+// printVerificationLevel prints the verification level applied to the
+// outcome and, for each validation type, whether it was enforced, logged or
+// skipped along with its result. Checks that failed but were tolerated by the
+// verification level are listed explicitly.
+func printVerificationLevel(w io.Writer, outcome *notation.VerificationOutcome) error {
+	level := outcome.VerificationLevel
+	if level == nil {
+		level = trustpolicy.LevelSkip
+	}
+	results := make(map[trustpolicy.ValidationType]*notation.ValidationResult, len(outcome.VerificationResults))
+	for _, result := range outcome.VerificationResults {
+		results[result.Type] = result
+	}
+
+	fmt.Fprintf(w, "Verification level: %s\n", level.Name)
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tACTION\tRESULT\t")
+	var tolerated []string
+	for _, validationType := range trustpolicy.ValidationTypes {
+		action := level.Enforcement[validationType]
+		if action == "" {
+			action = trustpolicy.ActionSkip
+		}
+		result := checkResult(action, results[validationType])
+		if result == checkResultTolerated {
+			tolerated = append(tolerated, string(validationType))
+			result = fmt.Sprintf("%s: %v", result, results[validationType].Error)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", validationType, action, result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(tolerated) > 0 {
+		fmt.Fprintf(w, "Checks failed but tolerated by verification level %s: %s\n", level.Name, strings.Join(tolerated, ", "))
+	}
+	return nil
+}
+
+// checkResult returns the result of a check with the given action.
+func checkResult(action trustpolicy.ValidationAction, result *notation.ValidationResult) string {
+	switch {
+	case action == trustpolicy.ActionSkip:
+		return checkResultSkipped
+	case result == nil:
+		return checkResultNotRun
+	case result.Error != nil:
+		return checkResultTolerated
+	default:
+		return checkResultPassed
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+func TestPrintVerificationLevel(t *testing.T) {
+	outcome := &notation.VerificationOutcome{
+		VerificationLevel: trustpolicy.LevelAudit,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeIntegrity, Action: trustpolicy.ActionEnforce},
+			{Type: trustpolicy.TypeAuthenticity, Action: trustpolicy.ActionLog, Error: errors.New("untrusted certificate")},
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionLog},
+		},
+	}
+	var buf bytes.Buffer
+	if err := printVerificationLevel(&buf, outcome); err != nil {
+		t.Fatalf("printVerificationLevel() error = %v", err)
+	}
+	got := buf.String()
+	for _, want := range []string{
+		"Verification level: audit\n",
+		"integrity            enforce   passed",
+		"authenticity         log       failed (tolerated): untrusted certificate",
+		"authenticTimestamp   log       not run",
+		"expiry               log       passed",
+		"revocation           log       not run",
+		"Checks failed but tolerated by verification level audit: authenticity\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("output does not contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+func TestPrintVerificationLevel_Skip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := printVerificationLevel(&buf, &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}); err != nil {
+		t.Fatalf("printVerificationLevel() error = %v", err)
+	}
+	got := buf.String()
+	if !strings.HasPrefix(got, "Verification level: skip\n") || strings.Count(got, checkResultSkipped) != len(trustpolicy.ValidationTypes) {
+		t.Fatalf("expected all checks skipped, got:\n%s", got)
+	}
+}
@@ -0,0 +1,649 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/slices"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+)
+
+// skipVerifier is implemented by verifiers that can decide whether signature
+// verification should be skipped for an artifact. Wrapping verifiers must
+// forward it so that notation.Verify keeps honoring the trust policy.
+type skipVerifier interface {
+	SkipVerify(ctx context.Context, artifactRef string) (bool, *trustpolicy.VerificationLevel, error)
+}
+
+// verifierWrapper is embedded by the verifiers wrapping a notation.Verifier,
+// and forwards SkipVerify to the wrapped verifier.
+type verifierWrapper struct {
+	notation.Verifier
+}
+
+// SkipVerify forwards to the wrapped verifier if it implements skipVerifier.
+func (v verifierWrapper) SkipVerify(ctx context.Context, artifactRef string) (bool, *trustpolicy.VerificationLevel, error) {
+	if skipChecker, ok := v.Verifier.(skipVerifier); ok {
+		return skipChecker.SkipVerify(ctx, artifactRef)
+	}
+	return false, nil, nil
+}
+
+// resumeAbortedVerification runs the checks notation-go skipped after a check
+// that aborted the verification passed when re-evaluated by a wrapping
+// verifier, and records the result in outcome. The checks of a verification
+// plugin cannot be run again, so signatures associated with one fail with
+// pluginErr.
+func resumeAbortedVerification(outcome *notation.VerificationOutcome, desc ocispec.Descriptor, opts notation.VerifyOptions, pluginErr error) (*notation.VerificationOutcome, error) {
+	err := pluginErr
+	if !hasVerificationPlugin(&outcome.EnvelopeContent.SignerInfo) {
+		err = verifySignedTarget(&outcome.EnvelopeContent.Payload, desc, opts.UserMetadata)
+	}
+	outcome.Error = err
+	return outcome, err
+}
+
+// extendedValidationVerifier wraps a notation.Verifier and only accepts
+// signatures that were validated by the required verification plugin.
+// Errors of rejected signatures are recorded so that they can be reported
+// if no signature passes verification.
+type extendedValidationVerifier struct {
+	verifierWrapper
+	pluginName string
+	errs       []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signature does not require the verification plugin.
+func (v *extendedValidationVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	for _, attr := range outcome.EnvelopeContent.SignerInfo.SignedAttributes.ExtendedAttributes {
+		if attr.Key == verifier.HeaderVerificationPlugin && attr.Value == v.pluginName {
+			return outcome, nil
+		}
+	}
+	err = fmt.Errorf("signature is not associated with the required verification plugin %q", v.pluginName)
+	outcome.Error = err
+	v.errs = append(v.errs, err)
+	return outcome, err
+}
+
+// metadataPatternVerifier wraps a notation.Verifier and only accepts
+// signatures whose signed user metadata values match the required patterns.
+// Errors of rejected signatures are recorded so that they can be reported
+// if no signature passes verification.
+type metadataPatternVerifier struct {
+	verifierWrapper
+	patterns map[string]*regexp.Regexp
+	errs     []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signed user metadata does not match the required patterns.
+func (v *metadataPatternVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	metadata, err := outcome.UserMetadata()
+	if err == nil {
+		err = matchMetadataPatterns(metadata, v.patterns)
+	}
+	if err != nil {
+		outcome.Error = err
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// keyUsageVerifier wraps a notation.Verifier and only accepts signatures
+// whose signing certificate has the required key usages and extended key
+// usages. Errors of rejected signatures are recorded so that they can be
+// reported if no signature passes verification.
+type keyUsageVerifier struct {
+	verifierWrapper
+	required keyUsageRequirement
+	errs     []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signing certificate lacks any of the required key usages.
+func (v *keyUsageVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	certs := outcome.EnvelopeContent.SignerInfo.CertificateChain
+	if len(certs) == 0 {
+		err = errors.New("signature has no signing certificate")
+	} else {
+		err = v.required.check(certs[0])
+	}
+	if err != nil {
+		outcome.Error = err
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// trustedIdentityVerifier wraps a notation.Verifier and only accepts
+// signatures whose signing certificate subject matches one of the trusted
+// identities, in addition to the trusted identities of the trust policy.
+// Errors of rejected signatures are recorded so that they can be reported if
+// no signature passes verification.
+type trustedIdentityVerifier struct {
+	verifierWrapper
+	identities []trustedIdentity
+	errs       []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signing certificate matches none of the trusted identities.
+func (v *trustedIdentityVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	certs := outcome.EnvelopeContent.SignerInfo.CertificateChain
+	if len(certs) == 0 {
+		err = errors.New("signature has no signing certificate")
+	} else {
+		err = checkTrustedIdentities(certs[0], v.identities)
+	}
+	if err != nil {
+		outcome.Error = err
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// embeddedCRLVerifier wraps a notation.Verifier and checks the signing
+// certificate of signatures for revocation against the CRL embedded in the
+// signature envelope, without network access. Signatures without embedded CRL
+// are rejected. Errors of rejected signatures are recorded so that they can
+// be reported if no signature passes verification.
+type embeddedCRLVerifier struct {
+	verifierWrapper
+	errs []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signing certificate is revoked by the embedded CRL.
+func (v *embeddedCRLVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	if err := checkEmbeddedCRL(opts.SignatureMediaType, signature, outcome.EnvelopeContent.SignerInfo.CertificateChain, time.Now()); err != nil {
+		outcome.Error = err
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// stapledRevocationVerifier wraps a notation.Verifier and evaluates the
+// revocation status of the signing certificate of signatures with stapled
+// revocation data, that is the CRL embedded in the signature envelope,
+// following the revocation action of the trust policy. Signatures without
+// stapled revocation data are left to the wrapped verifier. Errors of
+// rejected signatures are recorded so that they can be reported if no
+// signature passes verification.
+type stapledRevocationVerifier struct {
+	verifierWrapper
+	errs []error
+}
+
+// Verify verifies the signature with the wrapped verifier, then checks the
+// stapled revocation data if any. A revoked signing certificate fails the
+// verification if the revocation check is enforced by the trust policy, and
+// is recorded as a failed revocation result if it is logged.
+func (v *stapledRevocationVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	if outcome.EnvelopeContent == nil || outcome.VerificationLevel == nil {
+		// signature verification was skipped by the trust policy
+		return outcome, nil
+	}
+	action := outcome.VerificationLevel.Enforcement[trustpolicy.TypeRevocation]
+	if action == trustpolicy.ActionSkip {
+		return outcome, nil
+	}
+	logger := log.GetLogger(ctx)
+	crl, err := extractEmbeddedCRL(opts.SignatureMediaType, signature)
+	if err == nil && len(crl) == 0 {
+		logger.Info("Signature has no stapled revocation data, revocation is evaluated by the trust policy")
+		return outcome, nil
+	}
+	if err == nil {
+		logger.Info("Checking revocation with the stapled CRL")
+		err = checkEmbeddedCRL(opts.SignatureMediaType, signature, outcome.EnvelopeContent.SignerInfo.CertificateChain, time.Now())
+	}
+	if err == nil {
+		return outcome, nil
+	}
+	if err := recordRevocationFailure(outcome, action, fmt.Errorf("stapled revocation check failed: %w", err)); err != nil {
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// recordRevocationFailure records the failed revocation check err in outcome
+// with the revocation action of the trust policy. It returns err if the
+// revocation check is enforced, and nil if it is only logged.
+func recordRevocationFailure(outcome *notation.VerificationOutcome, action trustpolicy.ValidationAction, err error) error {
+	outcome.VerificationResults = append(outcome.VerificationResults, &notation.ValidationResult{
+		Type:   trustpolicy.TypeRevocation,
+		Action: action,
+		Error:  err,
+	})
+	if action != trustpolicy.ActionEnforce {
+		return nil
+	}
+	outcome.Error = err
+	return err
+}
+
+// checkEmbeddedCRL checks the signing certificate of the certificate chain
+// certs for revocation at now against the CRL embedded in the signature
+// envelope sig of mediaType.
+func checkEmbeddedCRL(mediaType string, sig []byte, certs []*x509.Certificate, now time.Time) error {
+	crl, err := extractEmbeddedCRL(mediaType, sig)
+	if err != nil {
+		return fmt.Errorf("failed to read the embedded CRL: %w", err)
+	}
+	if len(crl) == 0 {
+		return errors.New("signature has no embedded CRL")
+	}
+	if len(certs) < 2 {
+		return errors.New("certificate chain of the signature does not contain the issuer of the signing certificate")
+	}
+	if err := checkRevocationWithCRL(crl, certs[0], certs[1], now); err != nil {
+		return fmt.Errorf("revocation check with the embedded CRL failed: %w", err)
+	}
+	return nil
+}
+
+// defaultMaxCertChainDepth is the default of --max-cert-chain-depth.
+const defaultMaxCertChainDepth = 10
+
+// certChainDepthVerifier wraps a notation.Verifier and rejects signatures
+// whose certificate chain has more than maxDepth certificates, before the
+// wrapped verifier parses and validates the chain. Only rejections by this
+// verifier are recorded.
+type certChainDepthVerifier struct {
+	verifierWrapper
+	maxDepth int
+	errs     []error
+}
+
+// Verify counts the certificates of the signature envelope without parsing
+// them, and verifies the signature with the wrapped verifier if the chain is
+// not too long. Envelopes that cannot be read are left to the wrapped
+// verifier.
+func (v *certChainDepthVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	if err := checkCertChainDepth(opts.SignatureMediaType, signature, v.maxDepth); err != nil {
+		v.errs = append(v.errs, err)
+		return &notation.VerificationOutcome{Error: err}, err
+	}
+	return v.Verifier.Verify(ctx, desc, signature, opts)
+}
+
+// checkCertChainDepth returns an error if the certificate chain of the
+// signature envelope sig of mediaType has more than maxDepth certificates.
+// Envelopes that cannot be read are not rejected.
+func checkCertChainDepth(mediaType string, sig []byte, maxDepth int) error {
+	var headers *envelopeHeaders
+	var err error
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		headers, err = parseJWSHeaders(sig)
+	case cose.MediaTypeEnvelope:
+		headers, err = parseCOSEHeaders(sig)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	if headers.certChainLength > maxDepth {
+		return fmt.Errorf("signature has a certificate chain of %d certificates, exceeding the maximum depth of %d", headers.certChainLength, maxDepth)
+	}
+	return nil
+}
+
+// knownCriticalHeaders are the extended critical headers understood by
+// notation.
+var knownCriticalHeaders = []string{
+	verifier.HeaderVerificationPlugin,
+	verifier.HeaderVerificationPluginMinVersion,
+}
+
+// criticalHeaderVerifier wraps a notation.Verifier and rejects signatures
+// with extended critical headers that are not understood by notation, unless
+// they are explicitly accepted. Signatures associated with a verification
+// plugin are left to the plugin, which must process all extended critical
+// headers. Only rejections by this verifier are recorded.
+type criticalHeaderVerifier struct {
+	verifierWrapper
+	accepted []string
+	errs     []error
+}
+
+// Verify verifies the signature with the wrapped verifier and fails if the
+// signature has unknown extended critical headers that are not accepted.
+func (v *criticalHeaderVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if err != nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	unknown, accepted := unknownCriticalHeaders(&outcome.EnvelopeContent.SignerInfo, v.accepted)
+	for _, key := range accepted {
+		fmt.Fprintf(os.Stderr, "Warning: Accepted unknown critical header %q of signature. Accepting unknown critical headers weakens the guarantees of signature verification.\n", key)
+	}
+	if len(unknown) > 0 {
+		err = fmt.Errorf("signature has unknown critical headers %q, use --accept-unknown-critical-header to accept them for debugging", unknown)
+		outcome.Error = err
+		v.errs = append(v.errs, err)
+		return outcome, err
+	}
+	return outcome, nil
+}
+
+// unknownCriticalHeaders returns the keys of the extended critical headers
+// of signerInfo not understood by notation, split into the ones not accepted
+// and the ones accepted. No header is returned if the signature is associated
+// with a verification plugin.
+func unknownCriticalHeaders(signerInfo *signature.SignerInfo, acceptedKeys []string) (unknown, accepted []string) {
+	if hasVerificationPlugin(signerInfo) {
+		return nil, nil
+	}
+	for _, attr := range signerInfo.SignedAttributes.ExtendedAttributes {
+		// COSE header labels may be integers
+		key := fmt.Sprint(attr.Key)
+		if !attr.Critical || slices.Contains(knownCriticalHeaders, key) {
+			continue
+		}
+		if slices.Contains(acceptedKeys, key) {
+			accepted = append(accepted, key)
+		} else {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown, accepted
+}
+
+// chainValidationTimeVerifier wraps a notation.Verifier and evaluates the
+// validity period of the certificate chain of signatures at the given time
+// instead of the current time. The enforcement of the authenticTimestamp
+// check by the trust policy and all other checks, including the signature
+// expiry, are left unchanged. It must wrap the notation-go verifier directly,
+// so that other wrappers see the re-evaluated outcome.
+type chainValidationTimeVerifier struct {
+	verifierWrapper
+	validationTime time.Time
+}
+
+// Verify verifies the signature with the wrapped verifier and re-evaluates
+// the authenticTimestamp check at the chain validation time.
+func (v *chainValidationTimeVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if outcome == nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	result := findVerificationResult(outcome, trustpolicy.TypeAuthenticTimestamp)
+	if result == nil || result.Action == trustpolicy.ActionSkip || !isVerificationTimeDependent(&outcome.EnvelopeContent.SignerInfo) {
+		// the check was not run or does not depend on the current time
+		return outcome, err
+	}
+	// verification stops at the first enforced check that fails, so the
+	// remaining checks must be run if the check passes at the chain
+	// validation time
+	aborted := err != nil && result.Error != nil && errors.Is(err, result.Error)
+	result.Error = validateCertificateChainAt(outcome.EnvelopeContent.SignerInfo.CertificateChain, v.validationTime)
+	switch {
+	case result.Error != nil && result.Action == trustpolicy.ActionEnforce:
+		outcome.Error = result.Error
+		return outcome, result.Error
+	case !aborted:
+		return outcome, err
+	}
+	return resumeAbortedVerification(outcome, desc, opts, errors.New("--chain-validation-time is not supported for signatures associated with a verification plugin whose certificate chain is not valid at the current time"))
+}
+
+// clockSkewVerifier wraps a notation.Verifier and tolerates clock differences
+// between signers and verifiers of up to tolerance when checking the validity
+// boundaries of signatures. The signature expiry and the validity period of
+// the certificate chain of signatures checked at the current time are evaluated
+// with a grace window of tolerance on both ends. The enforcement of the checks
+// by the trust policy is unchanged. It must wrap the notation-go verifier
+// directly, so that other wrappers see the re-evaluated outcome.
+type clockSkewVerifier struct {
+	verifierWrapper
+	tolerance time.Duration
+}
+
+// Verify verifies the signature with the wrapped verifier and re-evaluates
+// the expiry and authenticTimestamp checks with the tolerance.
+func (v *clockSkewVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	outcome, err := v.Verifier.Verify(ctx, desc, signature, opts)
+	if outcome == nil || outcome.EnvelopeContent == nil {
+		return outcome, err
+	}
+	now := time.Now()
+	signerInfo := &outcome.EnvelopeContent.SignerInfo
+
+	// verification stops at the first enforced check that fails, so the
+	// checks following the expiry check must be run if it passes with the
+	// tolerance
+	var aborted bool
+	if result := findVerificationResult(outcome, trustpolicy.TypeExpiry); result != nil && result.Error != nil {
+		aborted = err != nil && errors.Is(err, result.Error)
+		if result.Error = verifyExpiryWithin(signerInfo, now, v.tolerance); result.Error != nil {
+			return outcome, err
+		}
+		if aborted {
+			outcome.VerificationResults = append(outcome.VerificationResults, &notation.ValidationResult{
+				Type:   trustpolicy.TypeAuthenticTimestamp,
+				Action: outcome.VerificationLevel.Enforcement[trustpolicy.TypeAuthenticTimestamp],
+			})
+		}
+	}
+	if result := findVerificationResult(outcome, trustpolicy.TypeAuthenticTimestamp); result != nil && (aborted || result.Error != nil) {
+		aborted = aborted || (err != nil && result.Error != nil && errors.Is(err, result.Error))
+		result.Error = verifyAuthenticTimestampWithin(signerInfo, now, v.tolerance)
+		if result.Error != nil && result.Action == trustpolicy.ActionEnforce {
+			outcome.Error = result.Error
+			return outcome, result.Error
+		}
+	}
+	if !aborted {
+		return outcome, err
+	}
+	return resumeAbortedVerification(outcome, desc, opts, errors.New("--clock-skew-tolerance is not supported for signatures associated with a verification plugin that fail verification without the tolerance"))
+}
+
+// verifyExpiryWithin returns an error if the signature expired more than
+// tolerance before now.
+func verifyExpiryWithin(signerInfo *signature.SignerInfo, now time.Time, tolerance time.Duration) error {
+	if expiry := signerInfo.SignedAttributes.Expiry; !expiry.IsZero() && !now.Add(-tolerance).Before(expiry) {
+		return fmt.Errorf("digital signature has expired on %q, beyond the clock skew tolerance of %s", expiry.Format(time.RFC1123Z), tolerance)
+	}
+	return nil
+}
+
+// verifyAuthenticTimestampWithin runs the authenticTimestamp check as
+// notation-go does, except that certificate chains checked at the current
+// time may be valid up to tolerance around now. Signatures of the
+// notary.x509.signingAuthority scheme are checked at their signing time,
+// without tolerance.
+func verifyAuthenticTimestampWithin(signerInfo *signature.SignerInfo, now time.Time, tolerance time.Duration) error {
+	switch signerInfo.SignedAttributes.SigningScheme {
+	case signature.SigningSchemeX509:
+		if len(signerInfo.UnsignedAttributes.TimestampSignature) != 0 {
+			return nil
+		}
+		for _, cert := range signerInfo.CertificateChain {
+			if now.Add(tolerance).Before(cert.NotBefore) {
+				return fmt.Errorf("certificate %q is not valid yet, it will be valid from %q, beyond the clock skew tolerance of %s", cert.Subject, cert.NotBefore.Format(time.RFC1123Z), tolerance)
+			}
+			if now.Add(-tolerance).After(cert.NotAfter) {
+				return fmt.Errorf("certificate %q is not valid anymore, it was expired at %q, beyond the clock skew tolerance of %s", cert.Subject, cert.NotAfter.Format(time.RFC1123Z), tolerance)
+			}
+		}
+	case signature.SigningSchemeX509SigningAuthority:
+		signingTime := signerInfo.SignedAttributes.SigningTime
+		for _, cert := range signerInfo.CertificateChain {
+			if signingTime.Before(cert.NotBefore) || signingTime.After(cert.NotAfter) {
+				return fmt.Errorf("certificate %q was not valid when the digital signature was produced at %q", cert.Subject, signingTime.Format(time.RFC1123Z))
+			}
+		}
+	}
+	return nil
+}
+
+// findVerificationResult returns the result of the check of the given type
+// in outcome, or nil if the check was not run.
+func findVerificationResult(outcome *notation.VerificationOutcome, checkType trustpolicy.ValidationType) *notation.ValidationResult {
+	for _, result := range outcome.VerificationResults {
+		if result.Type == checkType {
+			return result
+		}
+	}
+	return nil
+}
+
+// isVerificationTimeDependent reports whether the validity of the certificate
+// chain of a signature is checked at the time of verification, which is the
+// case for signatures of the notary.x509 signing scheme without a timestamp.
+func isVerificationTimeDependent(signerInfo *signature.SignerInfo) bool {
+	return signerInfo.SignedAttributes.SigningScheme == signature.SigningSchemeX509 && len(signerInfo.UnsignedAttributes.TimestampSignature) == 0
+}
+
+// hasVerificationPlugin reports whether the signature is associated with a
+// verification plugin.
+func hasVerificationPlugin(signerInfo *signature.SignerInfo) bool {
+	for _, attr := range signerInfo.SignedAttributes.ExtendedAttributes {
+		if attr.Key == verifier.HeaderVerificationPlugin {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCertificateChainAt returns an error if any certificate of certChain
+// is not valid at validationTime.
+func validateCertificateChainAt(certChain []*x509.Certificate, validationTime time.Time) error {
+	for _, cert := range certChain {
+		if validationTime.Before(cert.NotBefore) {
+			return fmt.Errorf("certificate %q is not valid at the chain validation time %q, it will be valid from %q", cert.Subject, validationTime.Format(time.RFC1123Z), cert.NotBefore.Format(time.RFC1123Z))
+		}
+		if validationTime.After(cert.NotAfter) {
+			return fmt.Errorf("certificate %q is not valid at the chain validation time %q, it was expired at %q", cert.Subject, validationTime.Format(time.RFC1123Z), cert.NotAfter.Format(time.RFC1123Z))
+		}
+	}
+	return nil
+}
+
+// verifySignedTarget checks that the signature payload describes desc and
+// carries the required user metadata, as notation-go does after the trust
+// policy checks.
+func verifySignedTarget(payload *signature.Payload, desc ocispec.Descriptor, userMetadata map[string]string) error {
+	target, err := envelope.DescriptorFromSignaturePayload(payload)
+	if err != nil {
+		return err
+	}
+	if !content.Equal(*target, desc) {
+		return errors.New("content descriptor mismatch")
+	}
+	for key, value := range userMetadata {
+		if got, ok := target.Annotations[key]; !ok || got != value {
+			return notation.ErrorUserMetadataVerificationFailed{}
+		}
+	}
+	return nil
+}
+
+// compileMetadataPatterns compiles the {key}={regex} pairs of required user
+// metadata patterns. Patterns must match the whole value.
+func compileMetadataPatterns(pairs map[string]string) (map[string]*regexp.Regexp, error) {
+	patterns := make(map[string]*regexp.Regexp, len(pairs))
+	for key, pattern := range pairs {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q of required metadata key %q: %w", pattern, key, err)
+		}
+		patterns[key] = re
+	}
+	return patterns, nil
+}
+
+// matchMetadataPatterns returns an error if any of the patterns is not
+// matched by the value of its key in metadata.
+func matchMetadataPatterns(metadata map[string]string, patterns map[string]*regexp.Regexp) error {
+	keys := make([]string, 0, len(patterns))
+	for key := range patterns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := metadata[key]
+		if !ok {
+			return fmt.Errorf("signature is missing required metadata key %q", key)
+		}
+		if !patterns[key].MatchString(value) {
+			return fmt.Errorf("value %q of signature metadata key %q does not match the required pattern %q", value, key, patterns[key])
+		}
+	}
+	return nil
+}
@@ -0,0 +1,660 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	b64 "encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-core-go/signature/cose"
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	gocose "github.com/veraison/go-cose"
+)
+
+type mockVerifier struct {
+	outcome *notation.VerificationOutcome
+	err     error
+	calls   int
+}
+
+func (v *mockVerifier) Verify(ctx context.Context, desc ocispec.Descriptor, signature []byte, opts notation.VerifyOptions) (*notation.VerificationOutcome, error) {
+	v.calls++
+	return v.outcome, v.err
+}
+
+func (v *mockVerifier) SkipVerify(ctx context.Context, artifactRef string) (bool, *trustpolicy.VerificationLevel, error) {
+	return true, trustpolicy.LevelSkip, nil
+}
+
+func outcomeWithPlugin(pluginName string) *notation.VerificationOutcome {
+	content := &signature.EnvelopeContent{}
+	if pluginName != "" {
+		content.SignerInfo.SignedAttributes.ExtendedAttributes = []signature.Attribute{
+			{Key: verifier.HeaderVerificationPlugin, Value: pluginName, Critical: true},
+		}
+	}
+	return &notation.VerificationOutcome{EnvelopeContent: content}
+}
+
+func TestExtendedValidationVerifier(t *testing.T) {
+	ctx := context.Background()
+	t.Run("signature validated by required plugin", func(t *testing.T) {
+		v := &extendedValidationVerifier{
+			verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcomeWithPlugin("policy-engine")}},
+			pluginName:      "policy-engine",
+		}
+		if _, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{}); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	})
+
+	t.Run("signature not associated with plugin", func(t *testing.T) {
+		v := &extendedValidationVerifier{
+			verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcomeWithPlugin("")}},
+			pluginName:      "policy-engine",
+		}
+		outcome, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{})
+		if err == nil || outcome.Error == nil {
+			t.Fatal("Verify() expected error, but got nil")
+		}
+		if len(v.errs) != 1 {
+			t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+		}
+	})
+
+	t.Run("signature associated with another plugin", func(t *testing.T) {
+		v := &extendedValidationVerifier{
+			verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcomeWithPlugin("other")}},
+			pluginName:      "policy-engine",
+		}
+		if _, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{}); err == nil {
+			t.Fatal("Verify() expected error, but got nil")
+		}
+	})
+
+	t.Run("plugin error is recorded", func(t *testing.T) {
+		pluginErr := errors.New(`revocation check by verification plugin "policy-engine" failed with reason "revoked"`)
+		v := &extendedValidationVerifier{
+			verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcomeWithPlugin("policy-engine"), err: pluginErr}},
+			pluginName:      "policy-engine",
+		}
+		if _, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{}); err != pluginErr {
+			t.Fatalf("Verify() error = %v, want %v", err, pluginErr)
+		}
+		if len(v.errs) != 1 || v.errs[0] != pluginErr {
+			t.Fatalf("expected recorded error %v, got %v", pluginErr, v.errs)
+		}
+	})
+
+	t.Run("skip verify is forwarded", func(t *testing.T) {
+		v := &extendedValidationVerifier{verifierWrapper: verifierWrapper{Verifier: &mockVerifier{}}}
+		skip, level, err := v.SkipVerify(ctx, "ref")
+		if err != nil || !skip || level != trustpolicy.LevelSkip {
+			t.Fatalf("SkipVerify() = %v, %v, %v", skip, level, err)
+		}
+	})
+}
+
+func TestVerifierWrapper_SkipVerify(t *testing.T) {
+	ctx := context.Background()
+	inner := verifierWrapper{Verifier: &mockVerifier{}}
+	for _, v := range []skipVerifier{
+		&allErrorsVerifier{verifierWrapper: inner},
+		&expiryIgnoringVerifier{verifierWrapper: inner},
+		&prefetchedRevocationVerifier{verifierWrapper: inner},
+		&extendedValidationVerifier{verifierWrapper: inner},
+		&metadataPatternVerifier{verifierWrapper: inner},
+		&keyUsageVerifier{verifierWrapper: inner},
+		&trustedIdentityVerifier{verifierWrapper: inner},
+		&embeddedCRLVerifier{verifierWrapper: inner},
+		&stapledRevocationVerifier{verifierWrapper: inner},
+		&certChainDepthVerifier{verifierWrapper: inner},
+		&criticalHeaderVerifier{verifierWrapper: inner},
+		&chainValidationTimeVerifier{verifierWrapper: inner},
+		&clockSkewVerifier{verifierWrapper: inner},
+	} {
+		skip, level, err := v.SkipVerify(ctx, "ref")
+		if err != nil || !skip || level != trustpolicy.LevelSkip {
+			t.Fatalf("%T.SkipVerify() = %v, %v, %v, want the result of the wrapped verifier", v, skip, level, err)
+		}
+	}
+
+	// the wrapped verifier does not implement skipVerifier
+	v := verifierWrapper{Verifier: struct{ notation.Verifier }{&mockVerifier{}}}
+	skip, level, err := v.SkipVerify(ctx, "ref")
+	if err != nil || skip || level != nil {
+		t.Fatalf("SkipVerify() = %v, %v, %v, want no skip", skip, level, err)
+	}
+}
+
+func outcomeWithMetadata(t *testing.T, metadata map[string]string) *notation.VerificationOutcome {
+	t.Helper()
+	payload, err := json.Marshal(map[string]any{
+		"targetArtifact": ocispec.Descriptor{Annotations: metadata},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := &signature.EnvelopeContent{Payload: signature.Payload{Content: payload}}
+	return &notation.VerificationOutcome{EnvelopeContent: content}
+}
+
+func TestMetadataPatternVerifier(t *testing.T) {
+	ctx := context.Background()
+	patterns, err := compileMetadataPatterns(map[string]string{"buildId": "[0-9]+"})
+	if err != nil {
+		t.Fatalf("compileMetadataPatterns() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		wantErr  bool
+	}{
+		{name: "matched", metadata: map[string]string{"buildId": "42"}},
+		{name: "partially matched", metadata: map[string]string{"buildId": "42a"}, wantErr: true},
+		{name: "missing key", metadata: map[string]string{"env": "production"}, wantErr: true},
+		{name: "no metadata", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &metadataPatternVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcomeWithMetadata(t, tt.metadata)}},
+				patterns:        patterns,
+			}
+			_, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(v.errs) != 1 {
+				t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+			}
+		})
+	}
+}
+
+func TestCompileMetadataPatterns_Invalid(t *testing.T) {
+	if _, err := compileMetadataPatterns(map[string]string{"buildId": "("}); err == nil {
+		t.Fatal("compileMetadataPatterns() expected error for invalid pattern, but got nil")
+	}
+}
+
+func outcomeWithCriticalHeaders(keys ...string) *notation.VerificationOutcome {
+	content := &signature.EnvelopeContent{}
+	for _, key := range keys {
+		content.SignerInfo.SignedAttributes.ExtendedAttributes = append(content.SignerInfo.SignedAttributes.ExtendedAttributes, signature.Attribute{
+			Key: key, Value: "value", Critical: true,
+		})
+	}
+	content.SignerInfo.SignedAttributes.ExtendedAttributes = append(content.SignerInfo.SignedAttributes.ExtendedAttributes, signature.Attribute{
+		Key: "io.example.informational", Value: "value",
+	})
+	return &notation.VerificationOutcome{EnvelopeContent: content}
+}
+
+func TestCriticalHeaderVerifier(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name     string
+		outcome  *notation.VerificationOutcome
+		accepted []string
+		wantErr  bool
+	}{
+		{name: "no critical headers", outcome: outcomeWithCriticalHeaders()},
+		{name: "known critical header", outcome: outcomeWithCriticalHeaders(verifier.HeaderVerificationPluginMinVersion)},
+		{name: "unknown critical header", outcome: outcomeWithCriticalHeaders("io.example.unknown"), wantErr: true},
+		{name: "accepted unknown critical header", outcome: outcomeWithCriticalHeaders("io.example.unknown"), accepted: []string{"io.example.unknown"}},
+		{name: "partially accepted", outcome: outcomeWithCriticalHeaders("io.example.unknown", "io.example.other"), accepted: []string{"io.example.unknown"}, wantErr: true},
+		{name: "left to verification plugin", outcome: outcomeWithCriticalHeaders(verifier.HeaderVerificationPlugin, "io.example.unknown")},
+		{name: "skipped verification", outcome: &notation.VerificationOutcome{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &criticalHeaderVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: tt.outcome}},
+				accepted:        tt.accepted,
+			}
+			_, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(v.errs) != 1 {
+				t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+			}
+		})
+	}
+
+	t.Run("errors of the wrapped verifier are not recorded", func(t *testing.T) {
+		v := &criticalHeaderVerifier{verifierWrapper: verifierWrapper{Verifier: &mockVerifier{err: errors.New("integrity check failed")}}}
+		if _, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{}); err == nil {
+			t.Fatal("Verify() expected error, but got nil")
+		}
+		if len(v.errs) != 0 {
+			t.Fatalf("expected no recorded error, got %v", v.errs)
+		}
+	})
+}
+
+// outcomeWithCertificateValidity returns an outcome of a signature of desc
+// with a certificate valid from notBefore to notAfter, with the
+// authenticTimestamp check enforced, which failed with timestampErr.
+func outcomeWithCertificateValidity(t *testing.T, desc ocispec.Descriptor, notBefore, notAfter time.Time, timestampErr error, plugin bool) *notation.VerificationOutcome {
+	t.Helper()
+	payload, err := json.Marshal(envelope.Payload{TargetArtifact: desc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := &signature.EnvelopeContent{
+		Payload: signature.Payload{ContentType: envelope.MediaTypePayloadV1, Content: payload},
+	}
+	content.SignerInfo.SignedAttributes.SigningScheme = signature.SigningSchemeX509
+	content.SignerInfo.CertificateChain = []*x509.Certificate{{NotBefore: notBefore, NotAfter: notAfter}}
+	if plugin {
+		content.SignerInfo.SignedAttributes.ExtendedAttributes = []signature.Attribute{
+			{Key: verifier.HeaderVerificationPlugin, Value: "policy-engine", Critical: true},
+		}
+	}
+	return &notation.VerificationOutcome{
+		EnvelopeContent:   content,
+		VerificationLevel: trustpolicy.LevelStrict,
+		VerificationResults: []*notation.ValidationResult{
+			{Type: trustpolicy.TypeAuthenticTimestamp, Action: trustpolicy.ActionEnforce, Error: timestampErr},
+		},
+	}
+}
+
+func TestChainValidationTimeVerifier(t *testing.T) {
+	ctx := context.Background()
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("artifact"), Size: 8}
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	expired := errors.New("certificate is not valid anymore")
+
+	tests := []struct {
+		name           string
+		outcome        *notation.VerificationOutcome
+		err            error
+		desc           ocispec.Descriptor
+		validationTime time.Time
+		wantErr        bool
+	}{
+		{
+			name:           "expired certificate valid at the chain validation time",
+			outcome:        outcomeWithCertificateValidity(t, desc, yesterday.Add(-time.Hour), yesterday.Add(time.Hour), expired, false),
+			err:            expired,
+			desc:           desc,
+			validationTime: yesterday,
+		},
+		{
+			name:           "valid certificate not valid at the chain validation time",
+			outcome:        outcomeWithCertificateValidity(t, desc, now.Add(-time.Hour), now.Add(time.Hour), nil, false),
+			desc:           desc,
+			validationTime: yesterday,
+			wantErr:        true,
+		},
+		{
+			name:           "resumed verification checks the target artifact",
+			outcome:        outcomeWithCertificateValidity(t, desc, yesterday.Add(-time.Hour), yesterday.Add(time.Hour), expired, false),
+			err:            expired,
+			desc:           ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("other"), Size: 5},
+			validationTime: yesterday,
+			wantErr:        true,
+		},
+		{
+			name:           "verification aborted for signature with verification plugin",
+			outcome:        outcomeWithCertificateValidity(t, desc, yesterday.Add(-time.Hour), yesterday.Add(time.Hour), expired, true),
+			err:            expired,
+			desc:           desc,
+			validationTime: yesterday,
+			wantErr:        true,
+		},
+		{
+			name:           "other failures are kept",
+			outcome:        outcomeWithCertificateValidity(t, desc, now.Add(-time.Hour), now.Add(time.Hour), nil, false),
+			err:            errors.New("content descriptor mismatch"),
+			desc:           desc,
+			validationTime: now,
+			wantErr:        true,
+		},
+		{
+			name:           "skipped verification",
+			outcome:        &notation.VerificationOutcome{},
+			validationTime: yesterday,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// notation-go sets the returned error as the outcome error
+			tt.outcome.Error = tt.err
+			v := &chainValidationTimeVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: tt.outcome, err: tt.err}},
+				validationTime:  tt.validationTime,
+			}
+			outcome, err := v.Verify(ctx, tt.desc, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if outcome.Error != err {
+				t.Fatalf("outcome error = %v, want %v", outcome.Error, err)
+			}
+		})
+	}
+}
+
+func TestClockSkewVerifier(t *testing.T) {
+	ctx := context.Background()
+	desc := ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("artifact"), Size: 8}
+	now := time.Now()
+	notYetValid := errors.New("certificate is not valid yet")
+	expired := errors.New("digital signature has expired")
+	expiredOutcome := func(expiry time.Time) *notation.VerificationOutcome {
+		outcome := outcomeWithCertificateValidity(t, desc, now.Add(-time.Hour), now.Add(time.Hour), nil, false)
+		outcome.EnvelopeContent.SignerInfo.SignedAttributes.Expiry = expiry
+		outcome.VerificationResults = []*notation.ValidationResult{
+			{Type: trustpolicy.TypeExpiry, Action: trustpolicy.ActionEnforce, Error: expired},
+		}
+		return outcome
+	}
+
+	tests := []struct {
+		name    string
+		outcome *notation.VerificationOutcome
+		err     error
+		desc    ocispec.Descriptor
+		wantErr bool
+	}{
+		{
+			name:    "certificate valid within the tolerance",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(10*time.Second), now.Add(time.Hour), notYetValid, false),
+			err:     notYetValid,
+			desc:    desc,
+		},
+		{
+			name:    "certificate not valid beyond the tolerance",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(time.Hour), now.Add(2*time.Hour), notYetValid, false),
+			err:     notYetValid,
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "signature expired within the tolerance",
+			outcome: expiredOutcome(now.Add(-10 * time.Second)),
+			err:     expired,
+			desc:    desc,
+		},
+		{
+			name:    "signature expired beyond the tolerance",
+			outcome: expiredOutcome(now.Add(-time.Hour)),
+			err:     expired,
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "resumed verification checks the target artifact",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(10*time.Second), now.Add(time.Hour), notYetValid, false),
+			err:     notYetValid,
+			desc:    ocispec.Descriptor{MediaType: ocispec.MediaTypeImageManifest, Digest: digest.FromString("other"), Size: 5},
+			wantErr: true,
+		},
+		{
+			name:    "verification aborted for signature with verification plugin",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(10*time.Second), now.Add(time.Hour), notYetValid, true),
+			err:     notYetValid,
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "other failures are kept",
+			outcome: outcomeWithCertificateValidity(t, desc, now.Add(-time.Hour), now.Add(time.Hour), nil, false),
+			err:     errors.New("content descriptor mismatch"),
+			desc:    desc,
+			wantErr: true,
+		},
+		{
+			name:    "skipped verification",
+			outcome: &notation.VerificationOutcome{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// notation-go sets the returned error as the outcome error
+			tt.outcome.Error = tt.err
+			v := &clockSkewVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: tt.outcome, err: tt.err}},
+				tolerance:       30 * time.Second,
+			}
+			outcome, err := v.Verify(ctx, tt.desc, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if outcome.Error != err {
+				t.Fatalf("outcome error = %v, want %v", outcome.Error, err)
+			}
+		})
+	}
+}
+
+func TestParseChainValidationTime(t *testing.T) {
+	got, err := parseChainValidationTime("2023-01-01T00:00:00Z")
+	if err != nil {
+		t.Fatalf("parseChainValidationTime() error = %v", err)
+	}
+	if want := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("parseChainValidationTime() = %v, want %v", got, want)
+	}
+	if _, err := parseChainValidationTime("2023-01-01"); err == nil {
+		t.Fatal("parseChainValidationTime() expects error but got nil")
+	}
+}
+
+func TestKeyUsageVerifier(t *testing.T) {
+	ctx := context.Background()
+	required, err := parseKeyUsageRequirement([]string{"CodeSigning"})
+	if err != nil {
+		t.Fatalf("parseKeyUsageRequirement() error = %v", err)
+	}
+	tests := []struct {
+		name        string
+		extKeyUsage []x509.ExtKeyUsage
+		wantErr     bool
+	}{
+		{name: "code signing certificate", extKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning}},
+		{name: "no extended key usage", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := &notation.VerificationOutcome{EnvelopeContent: &signature.EnvelopeContent{}}
+			outcome.EnvelopeContent.SignerInfo.CertificateChain = []*x509.Certificate{newKeyUsageTestCertificate(t, x509.KeyUsageDigitalSignature, tt.extKeyUsage)}
+			v := &keyUsageVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcome}},
+				required:        required,
+			}
+			_, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(v.errs) != 1 {
+				t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+			}
+		})
+	}
+}
+
+func TestTrustedIdentityVerifier(t *testing.T) {
+	ctx := context.Background()
+	identities, err := loadTrustedIdentities([]string{"C=US,ST=WA,O=wabbit-networks.io"}, "")
+	if err != nil {
+		t.Fatalf("loadTrustedIdentities() error = %v", err)
+	}
+	tests := []struct {
+		name    string
+		subject pkix.Name
+		wantErr bool
+	}{
+		{
+			name:    "trusted subject",
+			subject: pkix.Name{CommonName: "release", Organization: []string{"wabbit-networks.io"}, Province: []string{"WA"}, Country: []string{"US"}},
+		},
+		{
+			name:    "untrusted subject",
+			subject: pkix.Name{CommonName: "release", Organization: []string{"acme-rockets.io"}, Province: []string{"WA"}, Country: []string{"US"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome := &notation.VerificationOutcome{EnvelopeContent: &signature.EnvelopeContent{}}
+			outcome.EnvelopeContent.SignerInfo.CertificateChain = []*x509.Certificate{{Subject: tt.subject}}
+			v := &trustedIdentityVerifier{
+				verifierWrapper: verifierWrapper{Verifier: &mockVerifier{outcome: outcome}},
+				identities:      identities,
+			}
+			_, err := v.Verify(ctx, ocispec.Descriptor{}, nil, notation.VerifyOptions{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr && len(v.errs) != 1 {
+				t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+			}
+		})
+	}
+}
+
+// padCertChain returns the signature envelope sig of mediaType with the
+// certificates of its unprotected certificate chain header repeated until
+// the chain has n certificates.
+func padCertChain(t *testing.T, mediaType string, sig []byte, n int) []byte {
+	t.Helper()
+	pad := func(certs [][]byte) [][]byte {
+		padded := make([][]byte, n)
+		for i := range padded {
+			padded[i] = certs[i%len(certs)]
+		}
+		return padded
+	}
+	switch mediaType {
+	case jws.MediaTypeEnvelope:
+		var env map[string]any
+		if err := json.Unmarshal(sig, &env); err != nil {
+			t.Fatal(err)
+		}
+		header := env["header"].(map[string]any)
+		var certs [][]byte
+		for _, cert := range header["x5c"].([]any) {
+			der, err := b64.StdEncoding.DecodeString(cert.(string))
+			if err != nil {
+				t.Fatal(err)
+			}
+			certs = append(certs, der)
+		}
+		header["x5c"] = pad(certs)
+		padded, err := json.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return padded
+	case cose.MediaTypeEnvelope:
+		var msg gocose.Sign1Message
+		if err := msg.UnmarshalCBOR(sig); err != nil {
+			t.Fatal(err)
+		}
+		var certs [][]byte
+		for _, cert := range msg.Headers.Unprotected[gocose.HeaderLabelX5Chain].([]any) {
+			certs = append(certs, cert.([]byte))
+		}
+		var chain []any
+		for _, cert := range pad(certs) {
+			chain = append(chain, cert)
+		}
+		msg.Headers.Unprotected[gocose.HeaderLabelX5Chain] = chain
+		msg.Headers.RawUnprotected = nil
+		padded, err := msg.MarshalCBOR()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return padded
+	}
+	t.Fatalf("unsupported media type %q", mediaType)
+	return nil
+}
+
+func TestCertChainDepthVerifier(t *testing.T) {
+	ctx := context.Background()
+	desc := ocispec.Descriptor{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Digest:    "sha256:0000000000000000000000000000000000000000000000000000000000000000",
+		Size:      100,
+	}
+	for _, mediaType := range []string{jws.MediaTypeEnvelope, cose.MediaTypeEnvelope} {
+		t.Run(mediaType, func(t *testing.T) {
+			sig := signTestEnvelope(t, mediaType, desc)
+			opts := notation.VerifyOptions{SignatureMediaType: mediaType}
+
+			t.Run("chain within the maximum depth", func(t *testing.T) {
+				inner := &mockVerifier{outcome: &notation.VerificationOutcome{}}
+				v := &certChainDepthVerifier{verifierWrapper: verifierWrapper{Verifier: inner}, maxDepth: 2}
+				if _, err := v.Verify(ctx, desc, sig, opts); err != nil {
+					t.Fatalf("Verify() error = %v", err)
+				}
+				if inner.calls != 1 {
+					t.Fatalf("wrapped verifier called %d times, want 1", inner.calls)
+				}
+			})
+
+			t.Run("oversized chain rejected before validation", func(t *testing.T) {
+				oversized := padCertChain(t, mediaType, sig, defaultMaxCertChainDepth+1)
+				if err := checkCertChainDepth(mediaType, oversized, defaultMaxCertChainDepth+1); err != nil {
+					t.Fatalf("checkCertChainDepth() error = %v, want nil for the padded chain length", err)
+				}
+				inner := &mockVerifier{outcome: &notation.VerificationOutcome{}}
+				v := &certChainDepthVerifier{verifierWrapper: verifierWrapper{Verifier: inner}, maxDepth: defaultMaxCertChainDepth}
+				outcome, err := v.Verify(ctx, desc, oversized, opts)
+				if err == nil || !strings.Contains(err.Error(), "exceeding the maximum depth of 10") {
+					t.Fatalf("Verify() error = %v, want error of exceeded depth", err)
+				}
+				if outcome == nil || outcome.Error != err {
+					t.Fatalf("Verify() outcome = %+v, want outcome with the error", outcome)
+				}
+				if inner.calls != 0 {
+					t.Fatalf("wrapped verifier called %d times, want 0", inner.calls)
+				}
+				if len(v.errs) != 1 {
+					t.Fatalf("expected 1 recorded error, got %d", len(v.errs))
+				}
+			})
+
+			t.Run("tightened maximum depth", func(t *testing.T) {
+				inner := &mockVerifier{outcome: &notation.VerificationOutcome{}}
+				v := &certChainDepthVerifier{verifierWrapper: verifierWrapper{Verifier: inner}, maxDepth: 1}
+				if _, err := v.Verify(ctx, desc, sig, opts); err == nil {
+					t.Fatal("Verify() expects error for a chain of 2 certificates with maximum depth 1")
+				}
+				if inner.calls != 0 {
+					t.Fatalf("wrapped verifier called %d times, want 0", inner.calls)
+				}
+			})
+		})
+	}
+
+	t.Run("unreadable envelope left to the wrapped verifier", func(t *testing.T) {
+		inner := &mockVerifier{err: errors.New("invalid envelope")}
+		v := &certChainDepthVerifier{verifierWrapper: verifierWrapper{Verifier: inner}, maxDepth: 1}
+		if _, err := v.Verify(ctx, desc, []byte("{"), notation.VerifyOptions{SignatureMediaType: jws.MediaTypeEnvelope}); err != inner.err {
+			t.Fatalf("Verify() error = %v, want %v", err, inner.err)
+		}
+		if len(v.errs) != 0 {
+			t.Fatalf("expected no recorded error, got %d", len(v.errs))
+		}
+	})
+}
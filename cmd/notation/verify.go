@@ -4,28 +4,78 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"reflect"
+	"time"
 
 	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/log"
+	"github.com/notaryproject/notation-go/plugin"
 	notationregistry "github.com/notaryproject/notation-go/registry"
 	"github.com/notaryproject/notation-go/verifier"
 	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"github.com/notaryproject/notation-go/verifier/truststore"
+	"github.com/notaryproject/notation/cmd/notation/internal/policyutil"
 	"github.com/notaryproject/notation/internal/cmd"
+	"github.com/notaryproject/notation/internal/experimental"
 	"github.com/notaryproject/notation/internal/ioutil"
+	"github.com/notaryproject/notation/internal/junit"
+	"github.com/notaryproject/notation/internal/slices"
 	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
 	"github.com/spf13/cobra"
 	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote/auth"
 )
 
 type verifyOpts struct {
 	cmd.LoggingFlagOpts
 	SecureFlagOpts
-	reference    string
-	pluginConfig []string
-	userMetadata []string
+	reference             string
+	pluginConfig          []string
+	userMetadata          []string
+	requiredMetadata      []string
+	requiredMetadataRegex []string
+	outputFormat          string
+	requiredPlugin        string
+	policyRef             string
+	policyDigest          string
+	policyInline          string
+	listPolicies          bool
+	dryRun                bool
+	policyCacheTTL        time.Duration
+	reloadPolicy          bool
+	strictMediaType       bool
+	printLevel            bool
+	certFingerprints      string
+	acceptCritical        []string
+	chainValidationTime   string
+	clockSkew             time.Duration
+	summary               bool
+	verifiedDigestLog     string
+	rewrites              []string
+	emptyMetadataOK       bool
+	requiredKeyUsages     []string
+	trustedIdentities     []string
+	trustedIdentityFile   string
+	checkEmbeddedCRL      bool
+	stapledRevocation     bool
+	sigRepository         string
+	maxCertChainDepth     int
+	referrersRequired     bool
+	trustOnFirstUse       bool
+	yes                   bool
+	moreReferences        []string
+	collectAllErrors      bool
+	ignoreExpiry          bool
+	prefetch              bool
+	prefetchTTL           time.Duration
+	ociLayout             bool
+	trustPolicyScope      string
+	jsonSchemaVersion     string
 }
 
 func verifyCommand(opts *verifyOpts) *cobra.Command {
@@ -33,7 +83,7 @@ func verifyCommand(opts *verifyOpts) *cobra.Command {
 		opts = &verifyOpts{}
 	}
 	command := &cobra.Command{
-		Use:   "verify [reference]",
+		Use:   "verify [flags] <reference>...",
 		Short: "Verify OCI artifacts",
 		Long: `Verify OCI artifacts
 
@@ -44,64 +94,565 @@ Example - Verify a signature on an OCI artifact identified by a digest:
 
 Example - Verify a signature on an OCI artifact identified by a tag  (Notation will resolve tag to digest):
   notation verify <registry>/<repository>:<tag>
+
+Example - Verify a signature on an OCI artifact and require the verification plugin "policy-engine" to validate it:
+  notation verify --require-extended-validation policy-engine <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and require the signed user metadata "env" to be "production":
+  notation verify --require-metadata env=production <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and require the signed user metadata "buildId" to be numeric:
+  notation verify --require-metadata-regex 'buildId=[0-9]+' <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact against a trust policy published in a registry, pinned by its digest:
+  notation verify --policy-from-registry <registry>/<repository>@<digest> --policy-digest <policy_digest> <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact against a trust policy given as a JSON string, without a trust policy file:
+  notation verify --trust-policy-inline '<trust_policy_json>' <registry>/<repository>@<digest>
+
+Example - List the trust policy statements applicable to an OCI artifact without verifying it:
+  notation verify --list-applicable-policies --dry-run <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact against a trust policy published in a registry, cached for 10 minutes:
+  notation verify --policy-from-registry <registry>/<repository>@<digest> --policy-digest <policy_digest> --cache-trust-policy 10m <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and print the verification level and checks applied:
+  notation verify --print-verification-level <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact with the validity of the certificate chain evaluated at a point in time:
+  notation verify --chain-validation-time 2023-01-01T00:00:00Z <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, tolerating a clock difference of up to 30 seconds with the signer:
+  notation verify --clock-skew-tolerance 30s <registry>/<repository>@<digest>
+
+Example - Verify signatures on multiple OCI artifacts and print an aggregate report of the results:
+  notation verify --summary <registry>/<repository>@<digest> <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and require the code signing EKU in the signing certificate:
+  notation verify --require-key-usage CodeSigning <registry>/<repository>@<digest>
+
+Example - [Development only] Verify an OCI artifact without applicable trust policy, trusting the signing identity on first use:
+  notation verify --trust-on-first-use <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact signed by one of the identities of a centrally managed allowlist:
+  notation verify --trusted-identity-file trusted_identities.txt <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact offline, checking revocation against the CRL embedded at signing:
+  notation verify --check-embedded-crl <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, evaluating revocation with the revocation data stapled at signing if any:
+  notation verify --use-stapled-revocation <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, rejecting signatures with more than 3 certificates in their certificate chain:
+  notation verify --max-cert-chain-depth 3 <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and print the SHA-256 fingerprints of its certificate chain in JSON:
+  notation verify --output-cert-fingerprints json <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, failing if the registry does not support the Referrers API:
+  notation verify --require-referrers-api <registry>/<repository>@<digest>
+
+Example - [Advanced] Verify a signature on an OCI artifact stored in the dedicated repository "<registry>/signatures"
+  notation verify --signature-repository <registry>/signatures <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact pulled from a mirror against the trust policy of its canonical name:
+  notation verify --rewrite mirror.example.com/library=docker.io/library mirror.example.com/library/nginx@<digest>
+
+Example - Verify a signature on an OCI artifact and append a record of the verification to an audit log:
+  notation verify --log-verified-digest verified.log <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and report every failing check of each signature, instead of the first one:
+  notation verify --collect-all-errors <registry>/<repository>@<digest>
+
+Example - [Forensics] Verify a signature on an expired OCI artifact, running all checks but treating the expiry of the signature and its certificates as non-fatal:
+  notation verify --ignore-expiry <registry>/<repository>@<digest>
+
+Example - Prefetch the signatures and revocation data of all the tagged artifacts of a repository, then verify an artifact with them within 1 hour:
+  notation verify --prefetch <registry>/<repository>
+  notation verify --use-prefetched 1h <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact, allowing only approved TLS 1.2 cipher suites for the connections to the registry:
+  notation verify --cipher-suites TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384 <registry>/<repository>@<digest>
+
+Example - [Experimental] Verify a signature on an OCI artifact stored in an OCI layout directory or tarball, against the trust policy of the scope "<registry>/<repository>":
+  notation verify --oci-layout --scope <registry>/<repository> <oci_layout_path>@<digest>
+  notation verify --local-content --scope <registry>/<repository> <oci_layout_path>@<digest>
+
+Example - Verify a signature on an OCI artifact, attributing the registry requests to the tenant "tenant-a":
+  notation verify --registry-user-agent tenant-a/1.0 <registry>/<repository>@<digest>
+
+Example - Verify a signature on an OCI artifact and write the result as a JUnit report:
+  notation verify --output junit <registry>/<repository>@<digest> > report.xml
+
+Example - Verify signatures on OCI artifacts and write the results as JSON in the output schema version 1:
+  notation verify --output json --json-schema-version 1 <registry>/<repository>@<digest> <registry>/<repository>@<other_digest>
 `,
 		Args: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return errors.New("missing reference")
 			}
 			opts.reference = args[0]
+			if len(args) > 1 {
+				opts.moreReferences = args[1:]
+			}
 			return nil
 		},
+		PreRunE: func(cmd *cobra.Command, args []string) error {
+			return experimental.CheckFlagsAndWarn(cmd, "oci-layout", "scope")
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.listPolicies && len(opts.moreReferences) > 0 {
+				return errors.New("--list-applicable-policies supports a single reference")
+			}
+			if opts.dryRun && !opts.listPolicies {
+				return errors.New("--dry-run requires --list-applicable-policies")
+			}
+			if (opts.policyCacheTTL != 0 || opts.reloadPolicy) && opts.policyRef == "" {
+				return errors.New("--cache-trust-policy and --reload-policy require --policy-from-registry")
+			}
+			if opts.policyInline != "" {
+				if _, err := parseInlineTrustPolicy(opts.policyInline); err != nil {
+					return err
+				}
+			}
+			if opts.prefetch && len(opts.moreReferences) > 0 {
+				return errors.New("--prefetch supports a single repository")
+			}
+			if opts.prefetchTTL < 0 {
+				return fmt.Errorf("--use-prefetched cannot be a negative value, got %s", opts.prefetchTTL)
+			}
+			if opts.policyCacheTTL < 0 {
+				return fmt.Errorf("--cache-trust-policy cannot be a negative value, got %s", opts.policyCacheTTL)
+			}
+			if opts.chainValidationTime != "" {
+				if _, err := parseChainValidationTime(opts.chainValidationTime); err != nil {
+					return err
+				}
+			}
+			if _, err := parseReferenceRewrites(opts.rewrites); err != nil {
+				return err
+			}
+			if opts.certFingerprints != "" && !slices.Contains(supportedCertFingerprintsFormats, opts.certFingerprints) {
+				return fmt.Errorf("--output-cert-fingerprints must be one of the following %v but got %s", supportedCertFingerprintsFormats, opts.certFingerprints)
+			}
+			if opts.maxCertChainDepth < 1 {
+				return fmt.Errorf("--max-cert-chain-depth must be at least 1, got %d", opts.maxCertChainDepth)
+			}
+			if err := validateClockSkewTolerance(opts.clockSkew); err != nil {
+				return err
+			}
+			if opts.yes && !opts.trustOnFirstUse {
+				return errors.New("--yes requires --trust-on-first-use")
+			}
+			if _, err := parseKeyUsageRequirement(opts.requiredKeyUsages); err != nil {
+				return fmt.Errorf("invalid --require-key-usage: %w", err)
+			}
+			if _, err := loadTrustedIdentities(opts.trustedIdentities, opts.trustedIdentityFile); err != nil {
+				return err
+			}
+			if err := validateRegistryUserAgent(opts.UserAgent); err != nil {
+				return err
+			}
+			jsonOutput := isJSONOutput(opts.outputFormat) || opts.certFingerprints == certFingerprintsFormatJSON
+			if err := validateJSONSchemaVersion(cmd.Flags(), opts.jsonSchemaVersion, jsonOutput); err != nil {
+				return err
+			}
 			return runVerify(cmd, opts)
 		},
 	}
 	opts.LoggingFlagOpts.ApplyFlags(command.Flags())
 	opts.SecureFlagOpts.ApplyFlags(command.Flags())
+	setFlagRegistryUserAgent(command.Flags(), &opts.UserAgent)
 	command.Flags().StringArrayVar(&opts.pluginConfig, "plugin-config", nil, "{key}={value} pairs that are passed as it is to a plugin, if the verification is associated with a verification plugin, refer plugin documentation to set appropriate values")
 	cmd.SetPflagUserMetadata(command.Flags(), &opts.userMetadata, cmd.PflagUserMetadataVerifyUsage)
+	cmd.SetPflagEmptyUserMetadataOK(command.Flags(), &opts.emptyMetadataOK)
+	cmd.SetPflagOutput(command.Flags(), &opts.outputFormat, cmd.PflagOutputVerifyUsage)
+	setFlagJSONSchemaVersion(command.Flags(), &opts.jsonSchemaVersion)
+	command.Flags().StringVar(&opts.policyRef, "policy-from-registry", "", "reference of an artifact in a registry storing the trust policy to verify against, instead of the local trust policy")
+	command.Flags().StringVar(&opts.policyDigest, "policy-digest", "", "expected digest of the trust policy fetched by --policy-from-registry")
+	command.MarkFlagsRequiredTogether("policy-from-registry", "policy-digest")
+	command.Flags().StringVar(&opts.policyInline, "trust-policy-inline", "", "trust policy to verify against as a JSON string, instead of the local trust policy, validated as the trust policy file. Prefer the trust policy file for complex policies")
+	command.MarkFlagsMutuallyExclusive("trust-policy-inline", "policy-from-registry")
+	command.Flags().DurationVar(&opts.policyCacheTTL, "cache-trust-policy", 0, "cache the trust policy fetched by --policy-from-registry for the given duration, so that repeated verifications do not fetch it again")
+	command.Flags().BoolVar(&opts.reloadPolicy, "reload-policy", false, "ignore the cached trust policy and fetch it from the registry again")
+	command.Flags().StringArrayVar(&opts.requiredMetadata, "require-metadata", nil, "{key}={value} pairs that must be present in the signed user metadata of the verified signature, can be used multiple times")
+	command.Flags().StringArrayVar(&opts.requiredMetadataRegex, "require-metadata-regex", nil, "{key}={regex} pairs where the regex must match the whole value of the key in the signed user metadata of the verified signature, can be used multiple times")
+	command.Flags().BoolVar(&opts.listPolicies, "list-applicable-policies", false, "list the trust policy statements with a registry scope matching the artifact before verification")
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "exit after listing the applicable trust policy statements without verifying signatures, requires --list-applicable-policies")
+	command.Flags().BoolVar(&opts.strictMediaType, "strict-media-type", false, "fail if the media types of any signature manifest, config or envelope do not exactly match the Notary Project specification")
+	command.Flags().StringVar(&opts.certFingerprints, "output-cert-fingerprints", "", "print the SHA-256 fingerprints of the certificate chain of the verified signature, from the signing certificate to the root, options: \"text\", \"json\"")
+	command.Flags().BoolVar(&opts.printLevel, "print-verification-level", false, "print the verification level applied and whether each check was enforced, logged or skipped, including checks that failed but were tolerated")
+	command.Flags().StringArrayVar(&opts.acceptCritical, "accept-unknown-critical-header", nil, "[Debugging] key of an unknown critical header to accept in signatures, weakening the guarantees of signature verification, can be used multiple times")
+	command.Flags().StringVar(&opts.chainValidationTime, "chain-validation-time", "", "time in RFC 3339 format to evaluate the validity of the certificate chain at instead of the current time, for example \"2023-01-01T00:00:00Z\". The trust policy enforcement of the check is unchanged")
+	command.Flags().DurationVar(&opts.clockSkew, "clock-skew-tolerance", 0, "grace window around the current time when checking the signature expiry and the validity period of the certificate chain, tolerating clock differences between signers and verifiers. The trust policy enforcement of the checks is unchanged")
+	command.MarkFlagsMutuallyExclusive("clock-skew-tolerance", "chain-validation-time")
+	command.Flags().StringArrayVar(&opts.rewrites, "rewrite", nil, "{from}={to} pair rewriting the registry and repository prefix of the artifact reference before matching the trust policy, so that a mirrored artifact verifies under the trust policy of its canonical name, for example \"mirror.example.com/library=docker.io/library\", can be used multiple times")
+	command.Flags().StringVar(&opts.verifiedDigestLog, "log-verified-digest", "", "path to an audit log file to append a JSON record of each successful verification to, with the artifact digest, signing identity, applied trust policy statement and verification time")
+	command.Flags().BoolVar(&opts.summary, "summary", false, "after verifying all references, print the number of references passed and failed by reason, and the list of failed references")
+	command.Flags().StringArrayVar(&opts.requiredKeyUsages, "require-key-usage", nil, "key usage or extended key usage the signing certificate must have for successful verification, for example \"DigitalSignature\" or \"CodeSigning\", can be used multiple times")
+	command.Flags().StringArrayVar(&opts.trustedIdentities, "trusted-identity", nil, "X.509 distinguished name the subject of the signing certificate must match for successful verification, in addition to the trusted identities of the trust policy, for example \"C=US,ST=WA,O=wabbit-networks.io\", can be used multiple times")
+	command.Flags().StringVar(&opts.trustedIdentityFile, "trusted-identity-file", "", "path to a file listing one X.509 distinguished name per line, merged with --trusted-identity, the subject of the signing certificate must match one of for successful verification")
+	command.Flags().BoolVar(&opts.checkEmbeddedCRL, "check-embedded-crl", false, "check the signing certificate for revocation against the CRL embedded in the signature by \"notation sign --embed-crl\", without network access, and fail if the signature has no embedded CRL")
+	command.Flags().BoolVar(&opts.stapledRevocation, "use-stapled-revocation", false, "evaluate the revocation status of the signing certificate with the revocation data stapled in the signature by \"notation sign --embed-crl\", if any, following the revocation action of the trust policy")
+	command.MarkFlagsMutuallyExclusive("use-stapled-revocation", "check-embedded-crl")
+	command.Flags().StringVar(&opts.sigRepository, "signature-repository", "", "[Advanced] repository to look up the signatures of the artifact in, in the format {registry}/{repository}, for signatures pushed by \"notation sign --signature-repository\"")
+	command.Flags().BoolVar(&opts.referrersRequired, "require-referrers-api", false, "fail if the registry does not support the Referrers API, so that the signatures are only discoverable with the Referrers tag schema")
+	command.Flags().IntVar(&opts.maxCertChainDepth, "max-cert-chain-depth", defaultMaxCertChainDepth, "maximum number of certificates in the certificate chain of a signature, signatures with longer chains are rejected before their certificates are parsed")
+	command.Flags().StringVar(&opts.requiredPlugin, "require-extended-validation", "", "name of a verification plugin that must validate the signature for successful verification")
+	command.Flags().BoolVar(&opts.trustOnFirstUse, "trust-on-first-use", false, "[Development only] for artifacts without applicable trust policy, trust the signing identity seen on first verification of the repository and fail if it changes later. Never use it in production")
+	command.Flags().BoolVarP(&opts.yes, "yes", "y", false, "record the signing identity of --trust-on-first-use without prompting")
+	command.Flags().BoolVar(&opts.collectAllErrors, "collect-all-errors", false, "if verification fails, report every failing check of each signature, such as integrity, authenticity, trusted identity, expiry and revocation, instead of the first failing check only")
+	command.MarkFlagsMutuallyExclusive("collect-all-errors", "clock-skew-tolerance")
+	command.MarkFlagsMutuallyExclusive("collect-all-errors", "chain-validation-time")
+	command.Flags().BoolVar(&opts.ignoreExpiry, "ignore-expiry", false, "[Forensics] treat the expiry of signatures and of their certificates as non-fatal, running all other checks, to investigate historical artifacts. Never use it to admit artifacts")
+	command.MarkFlagsMutuallyExclusive("ignore-expiry", "clock-skew-tolerance")
+	command.MarkFlagsMutuallyExclusive("ignore-expiry", "chain-validation-time")
+	command.Flags().BoolVar(&opts.prefetch, "prefetch", false, "list the tagged artifacts of the repository in the format {registry}/{repository}, and cache their signatures and the CRLs of their signing certificates for later verifications with --use-prefetched, without verifying them")
+	command.Flags().DurationVar(&opts.prefetchTTL, "use-prefetched", 0, "verify with the signatures and CRLs cached by --prefetch if they were prefetched within the given duration, falling back to the registry for artifacts not prefetched")
+	for _, name := range []string{"use-prefetched", "list-applicable-policies", "signature-repository", "summary", "log-verified-digest"} {
+		command.MarkFlagsMutuallyExclusive("prefetch", name)
+	}
+	command.MarkFlagsMutuallyExclusive("use-prefetched", "signature-repository")
+	command.Flags().BoolVar(&opts.ociLayout, "oci-layout", false, "[Experimental] verify the artifact stored as OCI image layout, also accepted as --local-content")
+	setFlagOCILayoutAlias(command.Flags())
+	command.Flags().StringVar(&opts.trustPolicyScope, "scope", "", "[Experimental] set trust policy scope for artifact verification, only required if flag \"--oci-layout\" is set")
+	command.MarkFlagsRequiredTogether("oci-layout", "scope")
+	for _, name := range []string{"signature-repository", "require-referrers-api", "strict-media-type", "rewrite", "list-applicable-policies", "prefetch", "use-prefetched"} {
+		command.MarkFlagsMutuallyExclusive("oci-layout", name)
+	}
+	for _, name := range []string{"policy-from-registry", "require-metadata", "require-metadata-regex", "require-extended-validation", "require-key-usage", "chain-validation-time", "clock-skew-tolerance", "accept-unknown-critical-header", "strict-media-type", "log-verified-digest", "rewrite", "trusted-identity", "trusted-identity-file", "check-embedded-crl", "use-stapled-revocation", "signature-repository", "require-referrers-api", "collect-all-errors", "ignore-expiry", "trust-policy-inline", "prefetch", "use-prefetched", "oci-layout"} {
+		command.MarkFlagsMutuallyExclusive("trust-on-first-use", name)
+	}
 	return command
 }
 
 func runVerify(command *cobra.Command, opts *verifyOpts) error {
+	if opts.outputFormat != cmd.OutputPlaintext && opts.outputFormat != cmd.OutputJUnit && opts.outputFormat != cmd.OutputJSON {
+		return fmt.Errorf("unrecognized output format %s", opts.outputFormat)
+	}
+	// the JUnit and JSON reports are written to stdout after verifying all
+	// references, and the other messages to stderr
+	report := opts.outputFormat != cmd.OutputPlaintext
+
 	// set log level
 	ctx := opts.LoggingFlagOpts.SetLoggerLevel(command.Context())
+	opts.SecureFlagOpts.AuthCache = auth.NewCache()
+	if opts.ignoreExpiry {
+		fmt.Fprintln(os.Stderr, "Warning: --ignore-expiry is set. Expired signatures and certificates are not rejected, so a successful verification does not mean the artifact is trustworthy today. Use it for forensic analysis only, never to admit artifacts.")
+	}
+	if opts.trustOnFirstUse {
+		fmt.Fprintln(os.Stderr, "Warning: --trust-on-first-use is a development-only convenience. Signing identities of artifacts without applicable trust policy are trusted on first use without a trust store. Never use it in production.")
+	}
+
+	// prefetch the repository instead of verifying
+	if opts.prefetch {
+		return runPrefetch(ctx, opts)
+	}
+
+	// list applicable trust policy statements, keeping stdout for the report
+	if opts.listPolicies {
+		w := io.Writer(os.Stdout)
+		if report {
+			w = os.Stderr
+		}
+		if err := printApplicablePolicies(ctx, w, opts); err != nil {
+			return err
+		}
+		if opts.dryRun {
+			return nil
+		}
+	}
+
+	// core verify process
+	references := append([]string{opts.reference}, opts.moreReferences...)
+	batch := len(references) > 1
+	suite := junit.NewSuite("notation verify")
+	output := verifyOutput{SchemaVersion: opts.jsonSchemaVersion, Results: []verifyResultOutput{}}
+	var summary verifySummary
+	var outcomes []*notation.VerificationOutcome
+	var verifiedRefs []registry.Reference
+	var verifyErr error
+	for _, reference := range references {
+		start := time.Now()
+		outcome, ref, err := verifyReference(ctx, opts, reference)
+		name := reference
+		if ref.Reference != "" {
+			name = ref.String()
+		}
+		if err == nil && opts.verifiedDigestLog != "" {
+			if logErr := logVerifiedDigest(ctx, opts, outcome, ref); logErr != nil {
+				return logErr
+			}
+		}
+		summary.add(name, err)
+		verifyErr = err
+		if report {
+			if opts.outputFormat == cmd.OutputJUnit {
+				suite.AddCase(name, time.Since(start), err)
+			} else {
+				output.Results = append(output.Results, newVerifyResultOutput(name, outcome, err))
+			}
+			if err == nil {
+				outcomes = append(outcomes, outcome)
+				verifiedRefs = append(verifiedRefs, ref)
+			} else if batch {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+			}
+			continue
+		}
+		if err != nil {
+			if batch {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", name, err)
+			}
+			continue
+		}
+		if err := printVerifiedReference(os.Stdout, opts, outcome, ref); err != nil {
+			return err
+		}
+	}
+
+	// write out
+	summaryWriter := io.Writer(os.Stdout)
+	if report {
+		if opts.outputFormat == cmd.OutputJUnit {
+			if printErr := junit.Write(os.Stdout, suite); printErr != nil {
+				return printErr
+			}
+		} else if printErr := ioutil.PrintObjectAsJSON(output); printErr != nil {
+			return printErr
+		}
+		for i, outcome := range outcomes {
+			if opts.certFingerprints != "" {
+				if err := printCertFingerprints(os.Stderr, opts.certFingerprints, opts.jsonSchemaVersion, outcome, verifiedRefs[i]); err != nil {
+					return err
+				}
+			}
+			if opts.printLevel {
+				if err := printVerificationLevel(os.Stderr, outcome); err != nil {
+					return err
+				}
+			}
+		}
+		summaryWriter = os.Stderr
+	}
+	if opts.summary {
+		summary.print(summaryWriter)
+	}
+	if batch && len(summary.failed) > 0 {
+		return fmt.Errorf("signature verification failed for %d of %d references", len(summary.failed), summary.total)
+	}
+	return verifyErr
+}
+
+// printVerifiedReference prints the successful verification outcome of ref.
+func printVerifiedReference(w io.Writer, opts *verifyOpts, outcome *notation.VerificationOutcome, ref registry.Reference) error {
+	if reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		fmt.Fprintln(w, "Trust policy is configured to skip signature verification for", ref.String())
+	} else if ignored := ignoredExpiryErrors(outcome); len(ignored) > 0 {
+		fmt.Fprintln(w, "Verified signature for", ref.String(), "except expiry, the signature or its certificates expired and the expiry was ignored by --ignore-expiry")
+		printMetadataIfPresent(outcome)
+	} else {
+		fmt.Fprintln(w, "Successfully verified signature for", ref.String())
+		printMetadataIfPresent(outcome)
+	}
+	if opts.certFingerprints != "" {
+		if err := printCertFingerprints(w, opts.certFingerprints, opts.jsonSchemaVersion, outcome, ref); err != nil {
+			return err
+		}
+	}
+	if opts.printLevel {
+		return printVerificationLevel(w, outcome)
+	}
+	return nil
+}
+
+// verifyReference verifies the artifact identified by reference and returns
+// the successful verification outcome along with the resolved digest
+// reference.
+func verifyReference(ctx context.Context, opts *verifyOpts, reference string) (*notation.VerificationOutcome, registry.Reference, error) {
+	if opts.trustOnFirstUse {
+		parsedRef, err := registry.ParseReference(reference)
+		if err != nil {
+			return nil, registry.Reference{}, err
+		}
+		applicable, err := hasApplicableTrustPolicy(parsedRef)
+		if err != nil {
+			return nil, registry.Reference{}, err
+		}
+		if !applicable {
+			return verifyTrustOnFirstUse(ctx, opts, reference)
+		}
+	}
 
 	// initialize
-	reference := opts.reference
-	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	var sigRepo notationregistry.Repository
+	var ref registry.Reference
+	var prefetched *prefetchCache
+	var err error
+	if opts.ociLayout {
+		sigRepo, ref, err = ociLayoutRepositoryForVerify(ctx, reference, opts.trustPolicyScope)
+	} else {
+		sigRepo, ref, prefetched, err = remoteRepositoryForVerify(ctx, opts, reference)
+	}
 	if err != nil {
-		return err
+		return nil, registry.Reference{}, err
 	}
-
-	// resolve the given reference and set the digest
-	ref, err := resolveReference(command.Context(), &opts.SecureFlagOpts, reference, sigRepo, func(ref registry.Reference, manifestDesc ocispec.Descriptor) {
-		fmt.Fprintf(os.Stderr, "Warning: Always verify the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", ref.Reference)
-	})
+	policyRef, err := policyReference(opts, ref)
 	if err != nil {
-		return err
+		return nil, ref, err
+	}
+	if policyRef != ref {
+		log.GetLogger(ctx).Infof("Matching the trust policy against %s rewritten from %s", policyRef, ref)
+		sigRepo = &rewrittenRepository{Repository: sigRepo}
 	}
 
 	// initialize verifier
-	verifier, err := verifier.NewFromConfig()
+	sigVerifier, err := getVerifier(ctx, opts)
 	if err != nil {
-		return err
+		return nil, ref, err
+	}
+	if opts.ignoreExpiry {
+		sigVerifier = &expiryIgnoringVerifier{verifierWrapper: verifierWrapper{Verifier: sigVerifier}}
+	}
+	if opts.clockSkew > 0 {
+		sigVerifier = &clockSkewVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			tolerance:       opts.clockSkew,
+		}
+	}
+	if opts.chainValidationTime != "" {
+		validationTime, err := parseChainValidationTime(opts.chainValidationTime)
+		if err != nil {
+			return nil, ref, err
+		}
+		fmt.Fprintf(os.Stderr, "Warning: Evaluating the validity of certificate chains at %s instead of the current time.\n", validationTime.Format(time.RFC3339))
+		sigVerifier = &chainValidationTimeVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			validationTime:  validationTime,
+		}
+	}
+	if len(opts.acceptCritical) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Accepting unknown critical headers %q for debugging. Signatures with these headers may not be interpreted as their signers intended.\n", opts.acceptCritical)
+	}
+	criticalVerifier := &criticalHeaderVerifier{
+		verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+		accepted:        opts.acceptCritical,
+	}
+	sigVerifier = criticalVerifier
+	var patternVerifier *metadataPatternVerifier
+	if len(opts.requiredMetadataRegex) > 0 {
+		pairs, err := cmd.ParseFlagMap(opts.requiredMetadataRegex, "require-metadata-regex")
+		if err != nil {
+			return nil, ref, err
+		}
+		patterns, err := compileMetadataPatterns(pairs)
+		if err != nil {
+			return nil, ref, err
+		}
+		patternVerifier = &metadataPatternVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			patterns:        patterns,
+		}
+		sigVerifier = patternVerifier
+	}
+	var usageVerifier *keyUsageVerifier
+	if len(opts.requiredKeyUsages) > 0 {
+		required, err := parseKeyUsageRequirement(opts.requiredKeyUsages)
+		if err != nil {
+			return nil, ref, fmt.Errorf("invalid --require-key-usage: %w", err)
+		}
+		usageVerifier = &keyUsageVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			required:        required,
+		}
+		sigVerifier = usageVerifier
+	}
+	var identityVerifier *trustedIdentityVerifier
+	if len(opts.trustedIdentities) > 0 || opts.trustedIdentityFile != "" {
+		identities, err := loadTrustedIdentities(opts.trustedIdentities, opts.trustedIdentityFile)
+		if err != nil {
+			return nil, ref, err
+		}
+		identityVerifier = &trustedIdentityVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			identities:      identities,
+		}
+		sigVerifier = identityVerifier
+	}
+	var crlVerifier *embeddedCRLVerifier
+	if opts.checkEmbeddedCRL {
+		crlVerifier = &embeddedCRLVerifier{verifierWrapper: verifierWrapper{Verifier: sigVerifier}}
+		sigVerifier = crlVerifier
+	}
+	var stapledVerifier *stapledRevocationVerifier
+	if opts.stapledRevocation {
+		stapledVerifier = &stapledRevocationVerifier{verifierWrapper: verifierWrapper{Verifier: sigVerifier}}
+		sigVerifier = stapledVerifier
 	}
+	var prefetchedVerifier *prefetchedRevocationVerifier
+	if prefetched != nil {
+		prefetchedVerifier = &prefetchedRevocationVerifier{verifierWrapper: verifierWrapper{Verifier: sigVerifier}, cache: prefetched}
+		sigVerifier = prefetchedVerifier
+	}
+	var pluginVerifier *extendedValidationVerifier
+	if opts.requiredPlugin != "" {
+		mgr := plugin.NewCLIManager(dir.PluginFS())
+		if _, err := mgr.Get(ctx, opts.requiredPlugin); err != nil {
+			return nil, ref, fmt.Errorf("required verification plugin %q is not available: %w", opts.requiredPlugin, err)
+		}
+		pluginVerifier = &extendedValidationVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			pluginName:      opts.requiredPlugin,
+		}
+		sigVerifier = pluginVerifier
+	}
+	var errorsVerifier *allErrorsVerifier
+	if opts.collectAllErrors {
+		diagnostic, err := getDiagnosticVerifier(ctx, opts)
+		if err != nil {
+			return nil, ref, err
+		}
+		errorsVerifier = &allErrorsVerifier{
+			verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+			diagnostic:      diagnostic,
+		}
+		sigVerifier = errorsVerifier
+	}
+	chainDepthVerifier := &certChainDepthVerifier{
+		verifierWrapper: verifierWrapper{Verifier: sigVerifier},
+		maxDepth:        opts.maxCertChainDepth,
+	}
+	sigVerifier = chainDepthVerifier
 
 	// set up verification plugin config.
 	configs, err := cmd.ParseFlagMap(opts.pluginConfig, cmd.PflagPluginConfig.Name)
 	if err != nil {
-		return err
+		return nil, ref, err
 	}
 
 	// set up user metadata
-	userMetadata, err := cmd.ParseFlagMap(opts.userMetadata, cmd.PflagUserMetadata.Name)
+	parseUserMetadata := cmd.ParseFlagMap
+	if opts.emptyMetadataOK {
+		parseUserMetadata = cmd.ParseFlagMapAllowEmptyValues
+	}
+	userMetadata, err := parseUserMetadata(opts.userMetadata, cmd.PflagUserMetadata.Name)
 	if err != nil {
-		return err
+		return nil, ref, err
+	}
+
+	requiredMetadata, err := parseUserMetadata(opts.requiredMetadata, "require-metadata")
+	if err != nil {
+		return nil, ref, err
+	}
+	for key, value := range requiredMetadata {
+		if v, ok := userMetadata[key]; ok && v != value {
+			return nil, ref, fmt.Errorf("conflicting values %q and %q required for metadata key %q", v, value, key)
+		}
+		userMetadata[key] = value
 	}
 
 	verifyOpts := notation.RemoteVerifyOptions{
-		ArtifactReference: ref.String(),
+		ArtifactReference: policyRef.String(),
 		PluginConfig:      configs,
 		// TODO: need to change MaxSignatureAttempts as a user input flag or
 		// a field in config.json
@@ -110,46 +661,227 @@ func runVerify(command *cobra.Command, opts *verifyOpts) error {
 	}
 
 	// core verify process
-	_, outcomes, err := notation.Verify(ctx, verifier, sigRepo, verifyOpts)
-	// write out on failure
+	_, outcomes, err := notation.Verify(ctx, sigVerifier, sigRepo, verifyOpts)
+	if err != nil || len(outcomes) == 0 {
+		// errors of the inner verifier are recorded by both wrappers, print
+		// the outermost ones only
+		var verifyErrs []error
+		switch {
+		case errorsVerifier != nil:
+			// printed with all the failed checks below
+		case pluginVerifier != nil:
+			verifyErrs = pluginVerifier.errs
+		case prefetchedVerifier != nil:
+			verifyErrs = prefetchedVerifier.errs
+		case stapledVerifier != nil:
+			verifyErrs = stapledVerifier.errs
+		case crlVerifier != nil:
+			verifyErrs = crlVerifier.errs
+		case identityVerifier != nil:
+			verifyErrs = identityVerifier.errs
+		case usageVerifier != nil:
+			verifyErrs = usageVerifier.errs
+		case patternVerifier != nil:
+			verifyErrs = patternVerifier.errs
+		default:
+			verifyErrs = criticalVerifier.errs
+		}
+		// signatures rejected for their certificate chain depth never reach
+		// the wrapped verifiers
+		verifyErrs = append(chainDepthVerifier.errs, verifyErrs...)
+		for _, verifyErr := range verifyErrs {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", verifyErr)
+		}
+		if errorsVerifier != nil {
+			printSignatureReports(os.Stderr, errorsVerifier.reports)
+		}
+	}
 	if err != nil || len(outcomes) == 0 {
 		if err != nil {
 			var errorVerificationFailed notation.ErrorVerificationFailed
 			if !errors.As(err, &errorVerificationFailed) {
-				return fmt.Errorf("signature verification failed: %w", err)
+				return nil, ref, fmt.Errorf("signature verification failed: %w", err)
 			}
 		}
-		return fmt.Errorf("signature verification failed for all the signatures associated with %s", ref.String())
+		return nil, ref, allSignaturesFailedError{artifact: ref.String()}
 	}
 
-	// write out on success
 	outcome := outcomes[0]
+	if opts.requiredPlugin != "" && reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return nil, ref, fmt.Errorf("trust policy is configured to skip signature verification for %s, required verification plugin %q was not run", ref.String(), opts.requiredPlugin)
+	}
+	if crlVerifier != nil && reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return nil, ref, fmt.Errorf("trust policy is configured to skip signature verification for %s, the embedded CRL was not checked", ref.String())
+	}
+	if identityVerifier != nil && reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return nil, ref, fmt.Errorf("trust policy is configured to skip signature verification for %s, trusted identities were not verified", ref.String())
+	}
+	if (len(requiredMetadata) > 0 || patternVerifier != nil) && reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return nil, ref, fmt.Errorf("trust policy is configured to skip signature verification for %s, required metadata was not verified", ref.String())
+	}
+	if usageVerifier != nil && reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return nil, ref, fmt.Errorf("trust policy is configured to skip signature verification for %s, required key usages were not verified", ref.String())
+	}
 	// print out warning for any failed result with logged verification action
 	for _, result := range outcome.VerificationResults {
-		if result.Error != nil {
+		var ignored ignoredExpiryError
+		if result.Error != nil && errors.As(result.Error, &ignored) {
+			fmt.Fprintf(os.Stderr, "Warning: %v check failed and was %v\n", result.Type, result.Error)
+		} else if result.Error != nil {
 			// at this point, the verification action has to be logged and
 			// it's failed
 			fmt.Fprintf(os.Stderr, "Warning: %v was set to %q and failed with error: %v\n", result.Type, result.Action, result.Error)
 		}
 	}
-	if reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
-		fmt.Println("Trust policy is configured to skip signature verification for", ref.String())
-	} else {
-		fmt.Println("Successfully verified signature for", ref.String())
-		printMetadataIfPresent(outcome)
+	return outcome, ref, nil
+}
+
+// getVerifier returns a verifier with the trust policy selected by opts.
+func getVerifier(ctx context.Context, opts *verifyOpts) (notation.Verifier, error) {
+	if opts.policyRef == "" && opts.policyInline == "" {
+		if err := checkLocalTrustPolicyVersion(); err != nil {
+			return nil, err
+		}
+		return verifier.NewFromConfig()
 	}
-	return nil
+	policyDocument, err := loadTrustPolicyDocument(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	x509TrustStore := truststore.NewX509TrustStore(dir.ConfigFS())
+	return verifier.New(policyDocument, x509TrustStore, plugin.NewCLIManager(dir.PluginFS()))
+}
+
+// parseChainValidationTime parses the value of `--chain-validation-time` in
+// RFC 3339 format.
+func parseChainValidationTime(value string) (time.Time, error) {
+	validationTime, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --chain-validation-time %q, it must be in RFC 3339 format, for example \"2023-01-01T00:00:00Z\": %w", value, err)
+	}
+	return validationTime, nil
+}
+
+// checkLocalTrustPolicyVersion checks the version of the local trust policy
+// so that a policy newer than this binary supports fails with a clear error.
+// Other errors are left to be reported when loading the trust policy.
+func checkLocalTrustPolicyVersion() error {
+	policyPath, err := dir.ConfigFS().SysPath(dir.PathTrustPolicy)
+	if err != nil {
+		return nil
+	}
+	policyJSON, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil
+	}
+	return policyutil.CheckDocumentVersion(policyJSON)
+}
+
+// remoteRepositoryForVerify returns the signature repository of the artifact
+// identified by reference in a registry, along with the resolved digest
+// reference and the prefetched data used, if any.
+func remoteRepositoryForVerify(ctx context.Context, opts *verifyOpts, reference string) (notationregistry.Repository, registry.Reference, *prefetchCache, error) {
+	sigRepo, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, reference)
+	if err != nil {
+		return nil, registry.Reference{}, nil, err
+	}
+	var prefetched *prefetchCache
+	if opts.prefetchTTL > 0 {
+		prefetched, err = getPrefetchCache(reference, opts.prefetchTTL)
+		if err != nil {
+			log.GetLogger(ctx).Infof("Prefetched data of %s is not used: %v", reference, err)
+		} else {
+			sigRepo = &prefetchedRepository{Repository: sigRepo, cache: prefetched}
+		}
+	}
+	signatureReference := reference
+	if opts.sigRepository != "" {
+		if _, err := parseSignatureRepository(opts.sigRepository, reference); err != nil {
+			return nil, registry.Reference{}, nil, err
+		}
+		signatureReference = opts.sigRepository
+		signatureStore, err := getSignatureRepository(ctx, &opts.SecureFlagOpts, opts.sigRepository)
+		if err != nil {
+			return nil, registry.Reference{}, nil, err
+		}
+		sigRepo = &signatureRepositoryOverride{Repository: signatureStore, subject: sigRepo}
+	}
+	if opts.referrersRequired {
+		if err := checkReferrersDiscovery(ctx, &opts.SecureFlagOpts, signatureReference); err != nil {
+			return nil, registry.Reference{}, nil, err
+		}
+	}
+	if opts.strictMediaType {
+		parsedRef, err := registry.ParseReference(signatureReference)
+		if err != nil {
+			return nil, registry.Reference{}, nil, err
+		}
+		remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, parsedRef)
+		if err != nil {
+			return nil, registry.Reference{}, nil, err
+		}
+		sigRepo = &strictMediaTypeRepository{Repository: sigRepo, manifests: remoteRepo.Manifests()}
+	}
+
+	// resolve the given reference and set the digest
+	_, ref, err := resolveReference(ctx, &opts.SecureFlagOpts, reference, sigRepo, func(ref registry.Reference, manifestDesc ocispec.Descriptor) {
+		warnTagReference(ref.Reference)
+	})
+	if err != nil {
+		return nil, registry.Reference{}, nil, err
+	}
+	return sigRepo, ref, prefetched, nil
+}
+
+// ociLayoutRepositoryForVerify returns the read-only signature repository of
+// the artifact identified by reference, in the format <path>@<digest> or
+// <path>:<tag>, in an OCI image layout directory or tarball. The returned
+// digest reference is in the repository scope, against which the trust policy
+// is matched.
+func ociLayoutRepositoryForVerify(ctx context.Context, reference, scope string) (notationregistry.Repository, registry.Reference, error) {
+	ref, err := registry.ParseReference(scope)
+	if err != nil || ref.Reference != "" {
+		return nil, registry.Reference{}, fmt.Errorf("invalid --scope %q: must be in the format {registry}/{repository}", scope)
+	}
+	layoutPath, layoutReference, err := parseOCILayoutReference(reference)
+	if err != nil {
+		return nil, registry.Reference{}, err
+	}
+	layout, err := newReadOnlyOCILayoutRepository(ctx, layoutPath)
+	if err != nil {
+		return nil, registry.Reference{}, err
+	}
+	sigRepo := notationregistry.NewRepository(layout)
+	manifestDesc, err := sigRepo.Resolve(ctx, layoutReference)
+	if err != nil {
+		return nil, registry.Reference{}, err
+	}
+	if layoutReference != manifestDesc.Digest.String() {
+		warnTagReference(layoutReference)
+	}
+	ref.Reference = manifestDesc.Digest.String()
+	log.GetLogger(ctx).Infof("Verifying %s@%s in the OCI layout against the trust policy of scope %s", layoutPath, manifestDesc.Digest, scope)
+	return sigRepo, ref, nil
+}
+
+// warnTagReference warns that the artifact is identified by a mutable tag
+// instead of a digest.
+func warnTagReference(tag string) {
+	fmt.Fprintf(os.Stderr, "Warning: Always verify the artifact using digest(@sha256:...) rather than a tag(:%s) because resolved digest may not point to the same signed artifact, as tags are mutable.\n", tag)
 }
 
-func resolveReference(ctx context.Context, opts *SecureFlagOpts, reference string, sigRepo notationregistry.Repository, fn func(registry.Reference, ocispec.Descriptor)) (registry.Reference, error) {
+// resolveReference resolves the given reference to a digest reference and
+// returns it along with the resolved manifest descriptor. fn is called when
+// the reference is a tag reference.
+func resolveReference(ctx context.Context, opts *SecureFlagOpts, reference string, sigRepo notationregistry.Repository, fn func(registry.Reference, ocispec.Descriptor)) (ocispec.Descriptor, registry.Reference, error) {
 	manifestDesc, ref, err := getManifestDescriptor(ctx, opts, reference, sigRepo)
 	if err != nil {
-		return registry.Reference{}, err
+		return ocispec.Descriptor{}, registry.Reference{}, err
 	}
 
 	// reference is a digest reference
 	if err := ref.ValidateReferenceAsDigest(); err == nil {
-		return ref, nil
+		return manifestDesc, ref, nil
 	}
 
 	// reference is a tag reference
@@ -157,7 +889,7 @@ func resolveReference(ctx context.Context, opts *SecureFlagOpts, reference strin
 	// resolve tag to digest reference
 	ref.Reference = manifestDesc.Digest.String()
 
-	return ref, nil
+	return manifestDesc, ref, nil
 }
 
 func printMetadataIfPresent(outcome *notation.VerificationOutcome) {
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/notaryproject/notation-go"
+	notationregistry "github.com/notaryproject/notation-go/registry"
+	"github.com/notaryproject/notation-go/verifier"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/registry"
+)
+
+// errVerificationSkipped is returned by verifyNewSignature if the trust
+// policy applicable to the artifact skips signature verification.
+var errVerificationSkipped = errors.New("signature verification is skipped by the trust policy")
+
+// verifyNewSignature verifies the signature recorded by recorder with v, as
+// `notation verify` would verify it for the artifact artifactRef.
+func verifyNewSignature(ctx context.Context, v notation.Verifier, subject ocispec.Descriptor, artifactRef string, recorder *signatureRecorder) error {
+	if recorder.blob == nil {
+		return errors.New("no signature was pushed")
+	}
+	outcome, err := v.Verify(ctx, subject, recorder.blob, notation.VerifyOptions{
+		ArtifactReference:  artifactRef,
+		SignatureMediaType: recorder.mediaType,
+	})
+	if err != nil {
+		return err
+	}
+	if outcome.Error != nil {
+		return outcome.Error
+	}
+	if reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return errVerificationSkipped
+	}
+	return nil
+}
+
+// verifyAfterSign verifies the signature just pushed for ref against the
+// trust policy configured for `notation verify`. If it does not verify, a
+// warning is printed, unless `--strict` is set, in which case the signature
+// is deleted and signing fails.
+func verifyAfterSign(ctx context.Context, opts *signOpts, sigRepo notationregistry.Repository, ref registry.Reference, recorder *signatureRecorder) error {
+	if err := checkLocalTrustPolicyVersion(); err != nil {
+		return err
+	}
+	v, err := verifier.NewFromConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load the trust policy to verify the new signature: %w", err)
+	}
+	subject, err := sigRepo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return err
+	}
+	err = verifyNewSignature(ctx, v, subject, ref.String(), recorder)
+	switch {
+	case err == nil:
+		fmt.Fprintf(os.Stderr, "Signature %s verified against the trust policy\n", recorder.manifestDesc.Digest)
+		return nil
+	case errors.Is(err, errVerificationSkipped):
+		fmt.Fprintf(os.Stderr, "Warning: signature %s was not verified: %v\n", recorder.manifestDesc.Digest, err)
+		return nil
+	case !opts.strict:
+		fmt.Fprintf(os.Stderr, "Warning: signature %s does not pass the trust policy: %v\n", recorder.manifestDesc.Digest, err)
+		return nil
+	}
+	if deleteErr := deleteNewSignature(ctx, opts, ref, recorder); deleteErr != nil {
+		return fmt.Errorf("signature %s does not pass the trust policy: %v, and failed to delete it: %w", recorder.manifestDesc.Digest, err, deleteErr)
+	}
+	return fmt.Errorf("signature %s does not pass the trust policy and was deleted: %w", recorder.manifestDesc.Digest, err)
+}
+
+// deleteNewSignature deletes the signature manifest recorded by recorder from
+// the repository it was pushed to.
+func deleteNewSignature(ctx context.Context, opts *signOpts, ref registry.Reference, recorder *signatureRecorder) error {
+	repoRef, err := signatureRepositoryReference(opts, ref)
+	if err != nil {
+		return err
+	}
+	remoteRepo, err := getRepositoryClient(ctx, &opts.SecureFlagOpts, repoRef)
+	if err != nil {
+		return err
+	}
+	return remoteRepo.Manifests().Delete(ctx, recorder.manifestDesc)
+}
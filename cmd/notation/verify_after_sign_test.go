@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature/jws"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+func TestVerifyNewSignature(t *testing.T) {
+	ctx := context.Background()
+	recorder := &signatureRecorder{mediaType: jws.MediaTypeEnvelope, blob: []byte("signature")}
+	artifactRef := "localhost:5000/net-monitor@sha256:abc"
+	policyErr := errors.New("identity not trusted")
+	tests := []struct {
+		name     string
+		verifier *mockVerifier
+		recorder *signatureRecorder
+		wantErr  error
+	}{
+		{
+			name:     "passed",
+			verifier: &mockVerifier{outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelStrict}},
+			recorder: recorder,
+		},
+		{
+			name:     "failed outcome",
+			verifier: &mockVerifier{outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelStrict, Error: policyErr}},
+			recorder: recorder,
+			wantErr:  policyErr,
+		},
+		{
+			name:     "failed",
+			verifier: &mockVerifier{err: policyErr},
+			recorder: recorder,
+			wantErr:  policyErr,
+		},
+		{
+			name:     "skipped",
+			verifier: &mockVerifier{outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}},
+			recorder: recorder,
+			wantErr:  errVerificationSkipped,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyNewSignature(ctx, tt.verifier, ocispec.Descriptor{}, artifactRef, tt.recorder)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verifyNewSignature() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.verifier.calls != 1 {
+				t.Fatalf("verifier called %d times, want 1", tt.verifier.calls)
+			}
+		})
+	}
+
+	t.Run("not pushed", func(t *testing.T) {
+		v := &mockVerifier{}
+		if err := verifyNewSignature(ctx, v, ocispec.Descriptor{}, artifactRef, &signatureRecorder{}); err == nil {
+			t.Fatal("verifyNewSignature() expected error, but got nil")
+		}
+		if v.calls != 0 {
+			t.Fatalf("verifier called %d times, want 0", v.calls)
+		}
+	})
+}
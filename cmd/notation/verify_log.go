@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/notaryproject/notation-go"
+	"oras.land/oras-go/v2/registry"
+)
+
+// verifiedDigestRecordVersion is the version of the verification record
+// schema written by `notation verify --log-verified-digest`.
+const verifiedDigestRecordVersion = "1.0"
+
+// verifiedDigestRecord is a record of a successful verification appended to
+// the audit log of `notation verify --log-verified-digest`, one JSON object
+// per line.
+type verifiedDigestRecord struct {
+	// Version is the version of the record schema.
+	Version string `json:"version"`
+
+	// Artifact is the digest reference of the verified artifact.
+	Artifact string `json:"artifact"`
+
+	// Digest is the digest of the verified artifact.
+	Digest string `json:"digest"`
+
+	// Identity is the signing identity of the verified signature. It is
+	// omitted if the trust policy skips signature verification.
+	Identity *verifiedIdentity `json:"identity,omitempty"`
+
+	// Policy is the trust policy statement applied to the artifact.
+	Policy verifiedPolicy `json:"policy"`
+
+	// VerifiedAt is the time the artifact was verified.
+	VerifiedAt time.Time `json:"verifiedAt"`
+}
+
+// verifiedIdentity describes the signing certificate of a verified signature.
+type verifiedIdentity struct {
+	Subject string `json:"subject"`
+
+	// CertificateFingerprint is the SHA-256 fingerprint of the signing
+	// certificate.
+	CertificateFingerprint string `json:"certificateFingerprint"`
+
+	// SigningTime is the signing time of the signature.
+	SigningTime time.Time `json:"signingTime"`
+}
+
+// verifiedPolicy describes the trust policy statement applied to a verified
+// artifact.
+type verifiedPolicy struct {
+	Name              string `json:"name,omitempty"`
+	VerificationLevel string `json:"verificationLevel"`
+}
+
+// newVerifiedDigestRecord returns the record of the successful verification
+// outcome of ref, verified at verifiedAt under the trust policy statement
+// named policyName.
+func newVerifiedDigestRecord(outcome *notation.VerificationOutcome, ref registry.Reference, policyName string, verifiedAt time.Time) *verifiedDigestRecord {
+	record := &verifiedDigestRecord{
+		Version:  verifiedDigestRecordVersion,
+		Artifact: ref.String(),
+		Digest:   ref.Reference,
+		Policy: verifiedPolicy{
+			Name: policyName,
+		},
+		VerifiedAt: verifiedAt.UTC(),
+	}
+	if outcome.VerificationLevel != nil {
+		record.Policy.VerificationLevel = outcome.VerificationLevel.Name
+	}
+	if outcome.EnvelopeContent != nil && len(outcome.EnvelopeContent.SignerInfo.CertificateChain) > 0 {
+		cert := outcome.EnvelopeContent.SignerInfo.CertificateChain[0]
+		fingerprint := sha256.Sum256(cert.Raw)
+		record.Identity = &verifiedIdentity{
+			Subject:                cert.Subject.String(),
+			CertificateFingerprint: hex.EncodeToString(fingerprint[:]),
+			SigningTime:            outcome.EnvelopeContent.SignerInfo.SignedAttributes.SigningTime.UTC(),
+		}
+	}
+	return record
+}
+
+// appliedPolicyName returns the name of the trust policy statement applied to
+// ref, after rewriting by opts, or an empty string if no statement applies.
+func appliedPolicyName(ctx context.Context, opts *verifyOpts, ref registry.Reference) (string, error) {
+	ref, err := policyReference(opts, ref)
+	if err != nil {
+		return "", err
+	}
+	doc, err := loadTrustPolicyDocument(ctx, opts)
+	if err != nil {
+		return "", fmt.Errorf("failed to load trust policy: %w", err)
+	}
+	for _, policy := range findApplicablePolicies(doc, ref.Registry+"/"+ref.Repository) {
+		if policy.applied {
+			return policy.statement.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// appendVerifiedDigestRecord appends record to the audit log at path as a
+// single line of JSON. The line is written with a single write to the file
+// opened in append mode, so that records of concurrent verifications are not
+// interleaved.
+func appendVerifiedDigestRecord(path string, record *verifiedDigestRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(line); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// logVerifiedDigest appends the record of the successful verification outcome
+// of ref to the audit log of opts.
+func logVerifiedDigest(ctx context.Context, opts *verifyOpts, outcome *notation.VerificationOutcome, ref registry.Reference) error {
+	policyName, err := appliedPolicyName(ctx, opts, ref)
+	if err != nil {
+		return err
+	}
+	record := newVerifiedDigestRecord(outcome, ref, policyName, time.Now())
+	if err := appendVerifiedDigestRecord(opts.verifiedDigestLog, record); err != nil {
+		return fmt.Errorf("failed to append to the verification audit log %s: %w", opts.verifiedDigestLog, err)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+	"oras.land/oras-go/v2/registry"
+)
+
+func TestNewVerifiedDigestRecord(t *testing.T) {
+	ref := registry.Reference{Registry: "localhost:5000", Repository: "net-monitor", Reference: "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"}
+	verifiedAt := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	signingTime := verifiedAt.Add(-time.Hour)
+
+	content := &signature.EnvelopeContent{}
+	content.SignerInfo.CertificateChain = []*x509.Certificate{{Raw: []byte("certificate"), Subject: pkix.Name{CommonName: "wabbit-networks.io"}}}
+	content.SignerInfo.SignedAttributes.SigningTime = signingTime
+	outcome := &notation.VerificationOutcome{EnvelopeContent: content, VerificationLevel: trustpolicy.LevelStrict}
+	record := newVerifiedDigestRecord(outcome, ref, "wabbit-networks-images", verifiedAt)
+	if record.Artifact != ref.String() || record.Digest != ref.Reference {
+		t.Fatalf("record artifact = %q, digest = %q, want %q, %q", record.Artifact, record.Digest, ref.String(), ref.Reference)
+	}
+	if record.Policy != (verifiedPolicy{Name: "wabbit-networks-images", VerificationLevel: "strict"}) {
+		t.Fatalf("record policy = %+v", record.Policy)
+	}
+	if record.Identity == nil || record.Identity.Subject != "CN=wabbit-networks.io" || !record.Identity.SigningTime.Equal(signingTime) {
+		t.Fatalf("record identity = %+v", record.Identity)
+	}
+	if len(record.Identity.CertificateFingerprint) != 64 {
+		t.Fatalf("certificate fingerprint %q is not a SHA-256 hex digest", record.Identity.CertificateFingerprint)
+	}
+
+	skipped := newVerifiedDigestRecord(&notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip}, ref, "unsigned-images", verifiedAt)
+	if skipped.Identity != nil || skipped.Policy.VerificationLevel != "skip" {
+		t.Fatalf("record of skipped verification = %+v", skipped)
+	}
+}
+
+func TestAppendVerifiedDigestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "verified.log")
+	for _, digest := range []string{"sha256:a", "sha256:b"} {
+		record := &verifiedDigestRecord{Version: verifiedDigestRecordVersion, Digest: digest}
+		if err := appendVerifiedDigestRecord(path, record); err != nil {
+			t.Fatalf("appendVerifiedDigestRecord() error = %v", err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 records, got %d: %s", len(lines), data)
+	}
+	for i, want := range []string{"sha256:a", "sha256:b"} {
+		var record verifiedDigestRecord
+		if err := json.Unmarshal(lines[i], &record); err != nil {
+			t.Fatalf("record %d is not valid JSON: %v", i, err)
+		}
+		if record.Digest != want {
+			t.Fatalf("record %d digest = %q, want %q", i, record.Digest, want)
+		}
+	}
+}
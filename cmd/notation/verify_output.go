@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+// verifyOutput is the result of `notation verify --output json`.
+type verifyOutput struct {
+	// SchemaVersion is the version of the schema of the output.
+	SchemaVersion string `json:"schemaVersion"`
+
+	// Results are the verification results of the references, in the order
+	// they are given.
+	Results []verifyResultOutput `json:"results"`
+}
+
+// verifyResultOutput is the verification result of a reference in the
+// result of `notation verify --output json`.
+type verifyResultOutput struct {
+	// Reference is the digest reference of the artifact, or the reference as
+	// given if it cannot be resolved.
+	Reference string `json:"reference"`
+
+	// Verified is true if the verification succeeded.
+	Verified bool `json:"verified"`
+
+	// VerificationLevel is the name of the verification level applied to the
+	// verified signature.
+	VerificationLevel string `json:"verificationLevel,omitempty"`
+
+	// ExpiryIgnored is true if the verified signature or its certificates
+	// expired and the expiry was ignored by --ignore-expiry.
+	ExpiryIgnored bool `json:"expiryIgnored,omitempty"`
+
+	// UserMetadata is the user metadata signed in the verified signature.
+	UserMetadata map[string]string `json:"userMetadata,omitempty"`
+
+	// Error is the reason of the verification failure.
+	Error string `json:"error,omitempty"`
+}
+
+// newVerifyResultOutput returns the verification result of the reference
+// name, given the outcome of a successful verification or the error of a
+// failed one.
+func newVerifyResultOutput(name string, outcome *notation.VerificationOutcome, err error) verifyResultOutput {
+	result := verifyResultOutput{Reference: name}
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Verified = true
+	if outcome.VerificationLevel != nil {
+		result.VerificationLevel = outcome.VerificationLevel.Name
+	}
+	if reflect.DeepEqual(outcome.VerificationLevel, trustpolicy.LevelSkip) {
+		return result
+	}
+	result.ExpiryIgnored = len(ignoredExpiryErrors(outcome)) > 0
+	// the signature envelope is parsed as part of verification, so this
+	// error can be ignored as in printMetadataIfPresent
+	result.UserMetadata, _ = outcome.UserMetadata()
+	return result
+}
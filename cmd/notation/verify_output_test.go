@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-core-go/signature"
+	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/verifier/trustpolicy"
+)
+
+func TestNewVerifyResultOutput(t *testing.T) {
+	const name = "localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"
+	verified := &notation.VerificationOutcome{
+		VerificationLevel: trustpolicy.LevelStrict,
+		EnvelopeContent: &signature.EnvelopeContent{
+			Payload: signature.Payload{
+				Content: []byte(`{"targetArtifact":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9","size":16724,"annotations":{"buildId":"101"}}}`),
+			},
+		},
+	}
+	tests := []struct {
+		name    string
+		outcome *notation.VerificationOutcome
+		err     error
+		want    verifyResultOutput
+	}{
+		{
+			name:    "verified",
+			outcome: verified,
+			want:    verifyResultOutput{Reference: name, Verified: true, VerificationLevel: "strict", UserMetadata: map[string]string{"buildId": "101"}},
+		},
+		{
+			name:    "skipped",
+			outcome: &notation.VerificationOutcome{VerificationLevel: trustpolicy.LevelSkip},
+			want:    verifyResultOutput{Reference: name, Verified: true, VerificationLevel: "skip"},
+		},
+		{
+			name: "failed",
+			err:  errors.New("signature verification failed"),
+			want: verifyResultOutput{Reference: name, Error: "signature verification failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newVerifyResultOutput(name, tt.outcome, tt.err); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("newVerifyResultOutput() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyCommand_JSONSchemaVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "unsupported version", args: []string{"ref", "--output", "json", "--json-schema-version", "0"}, wantErr: "unsupported --json-schema-version"},
+		{name: "text output", args: []string{"ref", "--json-schema-version", "1"}, wantErr: "--json-schema-version requires a JSON output"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			command := verifyCommand(nil)
+			command.SetArgs(tt.args)
+			command.SilenceUsage = true
+			command.SilenceErrors = true
+			if err := command.Execute(); err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Fatalf("Execute() error = %v, want error %q", err, tt.wantErr)
+			}
+		})
+	}
+}
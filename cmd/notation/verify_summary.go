@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/notaryproject/notation-go"
+)
+
+// allSignaturesFailedError is returned for references with no signature
+// passing verification.
+type allSignaturesFailedError struct {
+	artifact string
+}
+
+func (e allSignaturesFailedError) Error() string {
+	return fmt.Sprintf("signature verification failed for all the signatures associated with %s", e.artifact)
+}
+
+// verifySummary aggregates the results of verifying multiple references.
+type verifySummary struct {
+	total   int
+	passed  int
+	reasons map[string]int
+	failed  []failedReference
+}
+
+// failedReference is a reference that failed verification.
+type failedReference struct {
+	reference string
+	reason    string
+}
+
+// add records the verification result of reference.
+func (s *verifySummary) add(reference string, err error) {
+	s.total++
+	if err == nil {
+		s.passed++
+		return
+	}
+	reason := verifyFailureReason(err)
+	if s.reasons == nil {
+		s.reasons = make(map[string]int)
+	}
+	s.reasons[reason]++
+	s.failed = append(s.failed, failedReference{reference: reference, reason: reason})
+}
+
+// print writes the summary to w, with failure reasons sorted by name.
+func (s *verifySummary) print(w io.Writer) {
+	fmt.Fprintln(w, "Verification summary:")
+	fmt.Fprintf(w, "  Total:  %d\n", s.total)
+	fmt.Fprintf(w, "  Passed: %d\n", s.passed)
+	fmt.Fprintf(w, "  Failed: %d\n", len(s.failed))
+	reasons := make([]string, 0, len(s.reasons))
+	for reason := range s.reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "    %s: %d\n", reason, s.reasons[reason])
+	}
+	if len(s.failed) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Failed references:")
+	for _, failed := range s.failed {
+		fmt.Fprintf(w, "  %s (%s)\n", failed.reference, failed.reason)
+	}
+}
+
+// verifyFailureReason returns a short reason for a verification failure,
+// used to group failures in the summary.
+func verifyFailureReason(err error) string {
+	var allFailed allSignaturesFailedError
+	var retrievalFailed notation.ErrorSignatureRetrievalFailed
+	var noPolicy notation.ErrorNoApplicableTrustPolicy
+	var inconclusive notation.ErrorVerificationInconclusive
+	var metadataFailed notation.ErrorUserMetadataVerificationFailed
+	switch {
+	case errors.As(err, &allFailed):
+		return "signature verification failed"
+	case errors.As(err, &retrievalFailed):
+		return "signature retrieval failed"
+	case errors.As(err, &noPolicy):
+		return "no applicable trust policy"
+	case errors.As(err, &inconclusive):
+		return "verification inconclusive"
+	case errors.As(err, &metadataFailed):
+		return "user metadata verification failed"
+	default:
+		return "other error"
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/notaryproject/notation-go"
+)
+
+func TestVerifySummary(t *testing.T) {
+	var summary verifySummary
+	summary.add("localhost:5000/a@sha256:aaa", nil)
+	summary.add("localhost:5000/b@sha256:bbb", allSignaturesFailedError{artifact: "localhost:5000/b@sha256:bbb"})
+	summary.add("localhost:5000/c@sha256:ccc", fmt.Errorf("signature verification failed: %w", notation.ErrorSignatureRetrievalFailed{Msg: "no signature is associated"}))
+	summary.add("localhost:5000/d@sha256:ddd", allSignaturesFailedError{artifact: "localhost:5000/d@sha256:ddd"})
+
+	var buf bytes.Buffer
+	summary.print(&buf)
+	want := `Verification summary:
+  Total:  4
+  Passed: 1
+  Failed: 3
+    signature retrieval failed: 1
+    signature verification failed: 2
+Failed references:
+  localhost:5000/b@sha256:bbb (signature verification failed)
+  localhost:5000/c@sha256:ccc (signature retrieval failed)
+  localhost:5000/d@sha256:ddd (signature verification failed)
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("print() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestVerifyFailureReason(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{err: allSignaturesFailedError{artifact: "ref"}, want: "signature verification failed"},
+		{err: fmt.Errorf("signature verification failed: %w", notation.ErrorNoApplicableTrustPolicy{}), want: "no applicable trust policy"},
+		{err: fmt.Errorf("signature verification failed: %w", notation.ErrorVerificationInconclusive{}), want: "verification inconclusive"},
+		{err: fmt.Errorf("signature verification failed: %w", notation.ErrorUserMetadataVerificationFailed{}), want: "user metadata verification failed"},
+		{err: errors.New("failed to resolve"), want: "other error"},
+	}
+	for _, tt := range tests {
+		if got := verifyFailureReason(tt.err); got != tt.want {
+			t.Errorf("verifyFailureReason(%v) = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/notaryproject/notation/internal/cmd"
 )
 
 func TestVerifyCommand_BasicArgs(t *testing.T) {
@@ -14,7 +18,10 @@ func TestVerifyCommand_BasicArgs(t *testing.T) {
 			Username: "user",
 			Password: "password",
 		},
-		pluginConfig: []string{"key1=val1"},
+		pluginConfig:      []string{"key1=val1"},
+		outputFormat:      cmd.OutputPlaintext,
+		jsonSchemaVersion: jsonSchemaVersionLatest,
+		maxCertChainDepth: defaultMaxCertChainDepth,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
@@ -39,13 +46,21 @@ func TestVerifyCommand_MoreArgs(t *testing.T) {
 		SecureFlagOpts: SecureFlagOpts{
 			PlainHTTP: true,
 		},
-		pluginConfig: []string{"key1=val1", "key2=val2"},
+		pluginConfig:          []string{"key1=val1", "key2=val2"},
+		requiredMetadata:      []string{"env=production"},
+		requiredMetadataRegex: []string{"buildId=[0-9]+"},
+		outputFormat:          cmd.OutputJUnit,
+		jsonSchemaVersion:     jsonSchemaVersionLatest,
+		maxCertChainDepth:     defaultMaxCertChainDepth,
 	}
 	if err := command.ParseFlags([]string{
 		expected.reference,
 		"--plain-http",
 		"--plugin-config", "key1=val1",
-		"--plugin-config", "key2=val2"}); err != nil {
+		"--plugin-config", "key2=val2",
+		"--require-metadata", "env=production",
+		"--require-metadata-regex", "buildId=[0-9]+",
+		"--output", "junit"}); err != nil {
 		t.Fatalf("Parse Flag failed: %v", err)
 	}
 	if err := command.Args(command, command.Flags().Args()); err != nil {
@@ -65,3 +80,331 @@ func TestVerifyCommand_MissingArgs(t *testing.T) {
 		t.Fatal("Parse Args expected error, but ok")
 	}
 }
+
+func TestVerifyCommand_MultipleReferences(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref1", "ref2", "--summary", "ref3"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.Args(command, command.Flags().Args()); err != nil {
+		t.Fatalf("Parse args failed: %v", err)
+	}
+	if opts.reference != "ref1" || !reflect.DeepEqual(opts.moreReferences, []string{"ref2", "ref3"}) || !opts.summary {
+		t.Fatalf("verify opts = (%q, %v, %v), want (%q, %v, %v)", opts.reference, opts.moreReferences, opts.summary, "ref1", []string{"ref2", "ref3"}, true)
+	}
+}
+
+func TestVerifyCommand_TrustOnFirstUse(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--trust-on-first-use", "--yes"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.trustOnFirstUse || !opts.yes {
+		t.Fatalf("trust on first use = (%v, %v), want (true, true)", opts.trustOnFirstUse, opts.yes)
+	}
+
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"ref", "--trust-on-first-use", "--require-key-usage", "CodeSigning"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "trust-on-first-use") {
+		t.Fatalf("Execute() error = %v, want error of mutually exclusive flags", err)
+	}
+}
+
+func TestVerifyCommand_Rewrite(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--rewrite", "mirror.example.com/library=docker.io/library", "--rewrite", "mirror.example.com=registry.example.com"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if len(opts.rewrites) != 2 {
+		t.Fatalf("rewrites = %v, want 2 rewrites", opts.rewrites)
+	}
+
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"ref", "--rewrite", "mirror.example.com"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--rewrite") {
+		t.Fatalf("Execute() error = %v, want error of invalid --rewrite", err)
+	}
+}
+
+func TestVerifyCommand_TrustedIdentity(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--trusted-identity", "C=US,ST=WA,O=wabbit-networks.io", "--trusted-identity-file", "trusted_identities.txt"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if len(opts.trustedIdentities) != 1 || opts.trustedIdentityFile != "trusted_identities.txt" {
+		t.Fatalf("trusted identities = (%v, %s), want 1 identity and trusted_identities.txt", opts.trustedIdentities, opts.trustedIdentityFile)
+	}
+
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"ref", "--trusted-identity", "C=US"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--trusted-identity") {
+		t.Fatalf("Execute() error = %v, want error of invalid --trusted-identity", err)
+	}
+}
+
+func TestVerifyCommand_CheckEmbeddedCRL(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--check-embedded-crl", "--trust-on-first-use"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.checkEmbeddedCRL {
+		t.Fatal("checkEmbeddedCRL = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --check-embedded-crl with --trust-on-first-use")
+	}
+}
+
+func TestVerifyCommand_UseStapledRevocation(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--use-stapled-revocation", "--check-embedded-crl"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.stapledRevocation {
+		t.Fatal("stapledRevocation = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --use-stapled-revocation with --check-embedded-crl")
+	}
+}
+
+func TestVerifyCommand_SignatureRepository(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--signature-repository", "localhost:5000/signatures", "--trust-on-first-use"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.sigRepository != "localhost:5000/signatures" {
+		t.Fatalf("sigRepository = %s, want localhost:5000/signatures", opts.sigRepository)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --signature-repository with --trust-on-first-use")
+	}
+
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"localhost:5000/net-monitor@sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9", "--signature-repository", "localhost:5000/signatures:v1"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--signature-repository") {
+		t.Fatalf("Execute() error = %v, want error of invalid --signature-repository", err)
+	}
+}
+
+func TestVerifyCommand_MaxCertChainDepth(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.maxCertChainDepth != defaultMaxCertChainDepth {
+		t.Fatalf("maxCertChainDepth = %d, want %d", opts.maxCertChainDepth, defaultMaxCertChainDepth)
+	}
+
+	opts = &verifyOpts{}
+	command = verifyCommand(opts)
+	command.SetArgs([]string{"ref", "--max-cert-chain-depth", "0"})
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--max-cert-chain-depth") {
+		t.Fatalf("Execute() error = %v, want error of invalid --max-cert-chain-depth", err)
+	}
+}
+
+func TestVerifyCommand_RequireReferrersAPI(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--require-referrers-api", "--trust-on-first-use"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.referrersRequired {
+		t.Fatal("referrersRequired = false, want true")
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --require-referrers-api with --trust-on-first-use")
+	}
+}
+
+func TestVerifyCommand_OutputCertFingerprints(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--output-cert-fingerprints", "json"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.certFingerprints != certFingerprintsFormatJSON {
+		t.Fatalf("certFingerprints = %q, want %q", opts.certFingerprints, certFingerprintsFormatJSON)
+	}
+
+	opts = &verifyOpts{}
+	command = verifyCommand(opts)
+	command.SetArgs([]string{"ref", "--output-cert-fingerprints", "yaml"})
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "--output-cert-fingerprints") {
+		t.Fatalf("Execute() error = %v, want error of invalid --output-cert-fingerprints", err)
+	}
+}
+
+func TestVerifyCommand_CollectAllErrors(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--collect-all-errors"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.collectAllErrors {
+		t.Fatal("collectAllErrors = false, want true")
+	}
+
+	command = verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--collect-all-errors", "--clock-skew-tolerance", "30s"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --collect-all-errors with --clock-skew-tolerance")
+	}
+}
+
+func TestVerifyCommand_IgnoreExpiry(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--ignore-expiry"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.ignoreExpiry {
+		t.Fatal("ignoreExpiry = false, want true")
+	}
+
+	command = verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--ignore-expiry", "--clock-skew-tolerance", "30s"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --ignore-expiry with --clock-skew-tolerance")
+	}
+}
+
+func TestVerifyCommand_TrustPolicyInline(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--trust-policy-inline", `{"version": "1.0"}`}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.policyInline != `{"version": "1.0"}` {
+		t.Fatalf("policyInline = %s, want {\"version\": \"1.0\"}", opts.policyInline)
+	}
+
+	command = verifyCommand(nil)
+	if err := command.ParseFlags([]string{"ref", "--trust-policy-inline", `{"version": "1.0"}`, "--policy-from-registry", "localhost:5000/policy@sha256:abc", "--policy-digest", "sha256:abc"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if err := command.ValidateFlagGroups(); err == nil {
+		t.Fatal("ValidateFlagGroups() expects error for --trust-policy-inline with --policy-from-registry")
+	}
+}
+
+func TestVerifyCommand_Prefetch(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"ref", "--use-prefetched", "1h"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if opts.prefetchTTL != time.Hour {
+		t.Fatalf("prefetchTTL = %s, want 1h", opts.prefetchTTL)
+	}
+
+	for _, args := range [][]string{
+		{"ref", "--prefetch", "--use-prefetched", "1h"},
+		{"ref", "--prefetch", "--signature-repository", "localhost:5000/signatures"},
+		{"ref", "--use-prefetched", "1h", "--signature-repository", "localhost:5000/signatures"},
+		{"ref", "--prefetch", "--trust-on-first-use"},
+	} {
+		command = verifyCommand(nil)
+		if err := command.ParseFlags(args); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := command.ValidateFlagGroups(); err == nil {
+			t.Fatalf("ValidateFlagGroups() expects error for %v", args)
+		}
+	}
+
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"--prefetch", "localhost:5000/net-monitor", "localhost:5000/other"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil {
+		t.Fatal("Execute() expects error for --prefetch with multiple repositories")
+	}
+}
+
+func TestVerifyCommand_OCILayout(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"hello-world:v1", "--oci-layout", "--scope", "local/hello-world"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.ociLayout || opts.trustPolicyScope != "local/hello-world" {
+		t.Fatalf("ociLayout = %v, trustPolicyScope = %q, want true and local/hello-world", opts.ociLayout, opts.trustPolicyScope)
+	}
+	if err := command.ValidateFlagGroups(); err != nil {
+		t.Fatalf("ValidateFlagGroups() error = %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"hello-world:v1", "--oci-layout"},
+		{"hello-world:v1", "--scope", "local/hello-world"},
+		{"hello-world:v1", "--oci-layout", "--scope", "local/hello-world", "--signature-repository", "localhost:5000/signatures"},
+		{"hello-world:v1", "--oci-layout", "--scope", "local/hello-world", "--use-prefetched", "1h"},
+		{"hello-world:v1", "--oci-layout", "--scope", "local/hello-world", "--trust-on-first-use"},
+	} {
+		command = verifyCommand(nil)
+		if err := command.ParseFlags(args); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := command.ValidateFlagGroups(); err == nil {
+			t.Fatalf("ValidateFlagGroups() expects error for %v", args)
+		}
+	}
+
+	t.Setenv("NOTATION_EXPERIMENTAL", "")
+	command = verifyCommand(nil)
+	command.SetArgs([]string{"hello-world:v1", "--oci-layout", "--scope", "local/hello-world"})
+	command.SilenceUsage = true
+	command.SilenceErrors = true
+	if err := command.Execute(); err == nil || !strings.Contains(err.Error(), "NOTATION_EXPERIMENTAL") {
+		t.Fatalf("Execute() error = %v, want error of experimental feature not enabled", err)
+	}
+}
+
+func TestVerifyCommand_LocalContent(t *testing.T) {
+	opts := &verifyOpts{}
+	command := verifyCommand(opts)
+	if err := command.ParseFlags([]string{"hello-world:v1", "--local-content", "--scope", "local/hello-world"}); err != nil {
+		t.Fatalf("Parse Flag failed: %v", err)
+	}
+	if !opts.ociLayout {
+		t.Fatal("ociLayout = false, want true for --local-content")
+	}
+	if err := command.ValidateFlagGroups(); err != nil {
+		t.Fatalf("ValidateFlagGroups() error = %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"hello-world:v1", "--local-content"},
+		{"hello-world:v1", "--local-content", "--scope", "local/hello-world", "--signature-repository", "localhost:5000/signatures"},
+	} {
+		command = verifyCommand(nil)
+		if err := command.ParseFlags(args); err != nil {
+			t.Fatalf("Parse Flag failed: %v", err)
+		}
+		if err := command.ValidateFlagGroups(); err == nil {
+			t.Fatalf("ValidateFlagGroups() expects error for %v", args)
+		}
+	}
+}
@@ -2,9 +2,13 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/notaryproject/notation/internal/envelope"
 	"github.com/notaryproject/notation/pkg/configutil"
@@ -14,6 +18,9 @@ import (
 const (
 	OutputPlaintext = "text"
 	OutputJSON      = "json"
+	OutputJUnit     = "junit"
+
+	OutputOrasCopyScript = "oras-copy-script"
 )
 
 var (
@@ -57,6 +64,14 @@ var (
 		fs.StringVar(p, PflagPlugin.Name, "", PflagPlugin.Usage)
 	}
 
+	PflagReproducibleCertOrder = &pflag.Flag{
+		Name:  "reproducible-cert-order",
+		Usage: "embed the certificate chain in canonical order (leaf first, then issuers in chain order). Only supported for keys with local key and certificate files",
+	}
+	SetPflagReproducibleCertOrder = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, PflagReproducibleCertOrder.Name, false, PflagReproducibleCertOrder.Usage)
+	}
+
 	PflagExpiry = &pflag.Flag{
 		Name:      "expiry",
 		Shorthand: "e",
@@ -83,6 +98,30 @@ var (
 		fs.StringArrayVar(p, PflagPluginConfig.Name, nil, PflagPluginConfig.Usage)
 	}
 
+	PflagNoDefaultPluginConfig = &pflag.Flag{
+		Name:  "no-default-plugin-config",
+		Usage: "only pass the --plugin-config values to the plugin, ignoring the plugin config stored with the signing key. Only supported for keys of signing plugins",
+	}
+	SetPflagNoDefaultPluginConfig = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, PflagNoDefaultPluginConfig.Name, false, PflagNoDefaultPluginConfig.Usage)
+	}
+
+	PflagPluginCapabilityCheck = &pflag.Flag{
+		Name:  "plugin-capability-check",
+		Usage: "check that the signing plugin advertises a signature generation capability before signing, failing early with the capabilities of the plugin otherwise",
+	}
+	SetPflagPluginCapabilityCheck = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, PflagPluginCapabilityCheck.Name, false, PflagPluginCapabilityCheck.Usage)
+	}
+
+	PflagEmptyUserMetadataOK = &pflag.Flag{
+		Name:  "empty-user-metadata-ok",
+		Usage: "accept {key}={value} pairs of user metadata with an empty value, which are rejected by default",
+	}
+	SetPflagEmptyUserMetadataOK = func(fs *pflag.FlagSet, p *bool) {
+		fs.BoolVar(p, PflagEmptyUserMetadataOK.Name, false, PflagEmptyUserMetadataOK.Usage)
+	}
+
 	PflagUserMetadata = &pflag.Flag{
 		Name:      "user-metadata",
 		Shorthand: "m",
@@ -93,12 +132,30 @@ var (
 		fs.StringArrayVarP(p, PflagUserMetadata.Name, PflagUserMetadata.Shorthand, nil, usage)
 	}
 
+	PflagUserMetadataFile = &pflag.Flag{
+		Name:  "user-metadata-file",
+		Usage: "path to a file of {key}={value} lines, or of a JSON object of string values, that are added to the signature payload, merged with --user-metadata taking precedence",
+	}
+	SetPflagUserMetadataFile = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, PflagUserMetadataFile.Name, "", PflagUserMetadataFile.Usage)
+	}
+
+	PflagPluginConfigFile = &pflag.Flag{
+		Name:  "plugin-config-file",
+		Usage: "path to a file of {key}={value} lines, or of a JSON object of string values, that are passed as it is to a plugin, merged with --plugin-config taking precedence",
+	}
+	SetPflagPluginConfigFile = func(fs *pflag.FlagSet, p *string) {
+		fs.StringVar(p, PflagPluginConfigFile.Name, "", PflagPluginConfigFile.Usage)
+	}
+
 	PflagOutput = &pflag.Flag{
 		Name:      "output",
 		Shorthand: "o",
 	}
-	PflagOutputUsage = fmt.Sprintf("output format, options: '%s', '%s'", OutputJSON, OutputPlaintext)
-	SetPflagOutput   = func(fs *pflag.FlagSet, p *string, usage string) {
+	PflagOutputUsage       = fmt.Sprintf("output format, options: '%s', '%s'", OutputJSON, OutputPlaintext)
+	PflagOutputVerifyUsage = fmt.Sprintf("output format, options: '%s', '%s', '%s'", OutputJSON, OutputJUnit, OutputPlaintext)
+	PflagOutputListUsage   = fmt.Sprintf("output format, options: '%s', '%s'", OutputOrasCopyScript, OutputPlaintext)
+	SetPflagOutput         = func(fs *pflag.FlagSet, p *string, usage string) {
 		fs.StringVarP(p, PflagOutput.Name, PflagOutput.Shorthand, OutputPlaintext, usage)
 	}
 )
@@ -109,14 +166,119 @@ type KeyValueSlice interface {
 	String() string
 }
 
+// ParseFlagMap parses the {key}={value} pairs of the flag flagName into a
+// map. Keys and values must be non-empty, and the last value of a key wins.
 func ParseFlagMap(c []string, flagName string) (map[string]string, error) {
+	return parseFlagMap(c, flagName, false)
+}
+
+// ParseFlagMapAllowEmptyValues is like ParseFlagMap, but accepts empty
+// values.
+func ParseFlagMapAllowEmptyValues(c []string, flagName string) (map[string]string, error) {
+	return parseFlagMap(c, flagName, true)
+}
+
+func parseFlagMap(c []string, flagName string, allowEmptyValues bool) (map[string]string, error) {
 	m := make(map[string]string, len(c))
 	for _, pair := range c {
 		key, val, found := strings.Cut(pair, "=")
-		if !found || key == "" || val == "" {
+		if !found || key == "" || (val == "" && !allowEmptyValues) {
 			return nil, fmt.Errorf("could not parse flag %s: key-value pair requires \"=\" as separator", flagName)
 		}
 		m[key] = val
 	}
 	return m, nil
 }
+
+// ParseUserMetadata parses the {key}={value} pairs of the signature user
+// metadata of the flag flagName into a map. Keys must be non-empty, unique
+// and must not contain whitespace or control characters. Values must be
+// non-empty.
+func ParseUserMetadata(c []string, flagName string) (map[string]string, error) {
+	return parseUserMetadata(c, flagName, false)
+}
+
+// ParseUserMetadataAllowEmptyValues is like ParseUserMetadata, but accepts
+// empty values.
+func ParseUserMetadataAllowEmptyValues(c []string, flagName string) (map[string]string, error) {
+	return parseUserMetadata(c, flagName, true)
+}
+
+func parseUserMetadata(c []string, flagName string, allowEmptyValues bool) (map[string]string, error) {
+	m := make(map[string]string, len(c))
+	for _, pair := range c {
+		key, val, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("could not parse flag %s: key-value pair %q requires \"=\" as separator", flagName, pair)
+		}
+		if key == "" {
+			return nil, fmt.Errorf("could not parse flag %s: key-value pair %q has an empty key", flagName, pair)
+		}
+		if strings.IndexFunc(key, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) != -1 {
+			return nil, fmt.Errorf("could not parse flag %s: key %q must not contain whitespace or control characters", flagName, key)
+		}
+		if val == "" && !allowEmptyValues {
+			return nil, fmt.Errorf("could not parse flag %s: key %q has an empty value", flagName, key)
+		}
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("could not parse flag %s: key %q is specified more than once", flagName, key)
+		}
+		m[key] = val
+	}
+	return m, nil
+}
+
+// ParseFlagMapFile parses the {key}={value} pairs of the file at path given
+// by the flag flagName into a map with parse, such as ParseFlagMap. The file
+// has either one pair per line, ignoring empty lines and lines starting with
+// "#", or a JSON object of string values.
+func ParseFlagMapFile(path, flagName string, parse func(c []string, flagName string) (map[string]string, error)) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read flag %s: %w", flagName, err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("could not parse flag %s: file %q is empty", flagName, path)
+	}
+	var pairs []string
+	if data[0] == '{' {
+		var values map[string]string
+		if err := json.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("could not parse flag %s: file %q is not a JSON object of string values: %w", flagName, path, err)
+		}
+		for key, val := range values {
+			if strings.Contains(key, "=") {
+				return nil, fmt.Errorf("could not parse flag %s: file %q has key %q containing \"=\"", flagName, path, key)
+			}
+			pairs = append(pairs, key+"="+val)
+		}
+	} else {
+		for _, line := range strings.Split(string(data), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			pairs = append(pairs, line)
+		}
+	}
+	m, err := parse(pairs, flagName)
+	if err != nil {
+		return nil, fmt.Errorf("%w, in file %q", err, path)
+	}
+	return m, nil
+}
+
+// MergeFlagMaps returns the pairs of fromFile overridden by the pairs of
+// inline, so that values given inline take precedence over values read from
+// a file.
+func MergeFlagMaps(fromFile, inline map[string]string) map[string]string {
+	m := make(map[string]string, len(fromFile)+len(inline))
+	for key, val := range fromFile {
+		m[key] = val
+	}
+	for key, val := range inline {
+		m[key] = val
+	}
+	return m
+}
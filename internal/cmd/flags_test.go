@@ -0,0 +1,246 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlagMap(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "valid pairs",
+			pairs: []string{"buildId=42", "url=https://example.com/?a=b"},
+			want:  map[string]string{"buildId": "42", "url": "https://example.com/?a=b"},
+		},
+		{
+			name:  "duplicate key",
+			pairs: []string{"buildId=42", "buildId=43"},
+			want:  map[string]string{"buildId": "43"},
+		},
+		{
+			name:  "key with whitespace",
+			pairs: []string{"build id=42"},
+			want:  map[string]string{"build id": "42"},
+		},
+		{
+			name:    "missing separator",
+			pairs:   []string{"buildId"},
+			wantErr: true,
+		},
+		{
+			name:    "empty key",
+			pairs:   []string{"=42"},
+			wantErr: true,
+		},
+		{
+			name:    "empty value",
+			pairs:   []string{"buildId="},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFlagMap(tt.pairs, PflagPluginConfig.Name)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("ParseFlagMap() expects error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFlagMap() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseFlagMap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFlagMapAllowEmptyValues(t *testing.T) {
+	got, err := ParseFlagMapAllowEmptyValues([]string{"note=", "note=x", "buildId="}, PflagUserMetadata.Name)
+	if err != nil {
+		t.Fatalf("ParseFlagMapAllowEmptyValues() error = %v", err)
+	}
+	if want := map[string]string{"note": "x", "buildId": ""}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseFlagMapAllowEmptyValues() = %v, want %v", got, want)
+	}
+	if _, err := ParseFlagMapAllowEmptyValues([]string{"=x"}, PflagUserMetadata.Name); err == nil {
+		t.Fatal("ParseFlagMapAllowEmptyValues() expects error for an empty key but got nil")
+	}
+}
+
+func TestParseUserMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:  "valid pairs",
+			pairs: []string{"buildId=42", "url=https://example.com/?a=b"},
+			want:  map[string]string{"buildId": "42", "url": "https://example.com/?a=b"},
+		},
+		{
+			name:    "missing separator",
+			pairs:   []string{"buildId"},
+			wantErr: `requires "=" as separator`,
+		},
+		{
+			name:    "empty key",
+			pairs:   []string{"=42"},
+			wantErr: "has an empty key",
+		},
+		{
+			name:    "key with whitespace",
+			pairs:   []string{"build id=42"},
+			wantErr: "must not contain whitespace or control characters",
+		},
+		{
+			name:    "key with control character",
+			pairs:   []string{"build\tid=42"},
+			wantErr: "must not contain whitespace or control characters",
+		},
+		{
+			name:    "empty value",
+			pairs:   []string{"buildId="},
+			wantErr: "has an empty value",
+		},
+		{
+			name:    "duplicate key",
+			pairs:   []string{"buildId=42", "buildId=43"},
+			wantErr: "is specified more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseUserMetadata(tt.pairs, PflagUserMetadata.Name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ParseUserMetadata() error = %v, want error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseUserMetadata() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseUserMetadata() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseUserMetadataAllowEmptyValues(t *testing.T) {
+	got, err := ParseUserMetadataAllowEmptyValues([]string{"note="}, PflagUserMetadata.Name)
+	if err != nil {
+		t.Fatalf("ParseUserMetadataAllowEmptyValues() error = %v", err)
+	}
+	if want := map[string]string{"note": ""}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseUserMetadataAllowEmptyValues() = %v, want %v", got, want)
+	}
+	if _, err := ParseUserMetadataAllowEmptyValues([]string{"note=", "note=x"}, PflagUserMetadata.Name); err == nil {
+		t.Fatal("ParseUserMetadataAllowEmptyValues() expects error for a duplicate key but got nil")
+	}
+}
+
+func TestParseFlagMapFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		parse   func(c []string, flagName string) (map[string]string, error)
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name:    "key-value lines",
+			content: "# build metadata\nbuildId=42\n\nurl=https://example.com/?a=b\n",
+			want:    map[string]string{"buildId": "42", "url": "https://example.com/?a=b"},
+		},
+		{
+			name:    "JSON object",
+			content: `{"buildId": "42", "commit": "abc"}`,
+			want:    map[string]string{"buildId": "42", "commit": "abc"},
+		},
+		{
+			name:    "empty value allowed",
+			content: "note=\n",
+			parse:   ParseUserMetadataAllowEmptyValues,
+			want:    map[string]string{"note": ""},
+		},
+		{
+			name:    "empty file",
+			content: "\n  \n",
+			wantErr: "is empty",
+		},
+		{
+			name:    "malformed line",
+			content: "buildId\n",
+			wantErr: `requires "=" as separator`,
+		},
+		{
+			name:    "duplicate key",
+			content: "buildId=42\nbuildId=43\n",
+			wantErr: "is specified more than once",
+		},
+		{
+			name:    "duplicate key with last value winning",
+			content: "buildId=42\nbuildId=43\n",
+			parse:   ParseFlagMap,
+			want:    map[string]string{"buildId": "43"},
+		},
+		{
+			name:    "JSON with non-string value",
+			content: `{"buildId": 42}`,
+			wantErr: "is not a JSON object of string values",
+		},
+		{
+			name:    "JSON key with separator",
+			content: `{"build=id": "42"}`,
+			wantErr: `containing "="`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metadata.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+			parse := tt.parse
+			if parse == nil {
+				parse = ParseUserMetadata
+			}
+			got, err := ParseFlagMapFile(path, PflagUserMetadataFile.Name, parse)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), path) {
+					t.Fatalf("ParseFlagMapFile() error = %v, want error containing %q and the file path", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFlagMapFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseFlagMapFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeFlagMaps(t *testing.T) {
+	fromFile := map[string]string{"buildId": "41", "commit": "abc"}
+	inline := map[string]string{"buildId": "42"}
+	got := MergeFlagMaps(fromFile, inline)
+	if want := map[string]string{"buildId": "42", "commit": "abc"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("MergeFlagMaps() = %v, want %v", got, want)
+	}
+}
@@ -11,10 +11,13 @@ import (
 
 // SignerFlagOpts cmd opts for using cmd.GetSigner
 type SignerFlagOpts struct {
-	Key             string
-	SignatureFormat string
-	KeyID           string
-	PluginName      string
+	Key                   string
+	SignatureFormat       string
+	KeyID                 string
+	PluginName            string
+	ReproducibleCertOrder bool
+	NoDefaultPluginConfig bool
+	PluginCapabilityCheck bool
 }
 
 // ApplyFlags set flags and their default values for the FlagSet
@@ -24,6 +27,9 @@ func (opts *SignerFlagOpts) ApplyFlagsToCommand(command *cobra.Command) {
 	SetPflagSignatureFormat(fs, &opts.SignatureFormat)
 	SetPflagID(fs, &opts.KeyID)
 	SetPflagPlugin(fs, &opts.PluginName)
+	SetPflagReproducibleCertOrder(fs, &opts.ReproducibleCertOrder)
+	SetPflagNoDefaultPluginConfig(fs, &opts.NoDefaultPluginConfig)
+	SetPflagPluginCapabilityCheck(fs, &opts.PluginCapabilityCheck)
 	command.MarkFlagsRequiredTogether("id", "plugin")
 	command.MarkFlagsMutuallyExclusive("key", "id")
 	command.MarkFlagsMutuallyExclusive("key", "plugin")
@@ -3,24 +3,43 @@ package cmd
 import (
 	"context"
 	"errors"
+	"fmt"
+
+	corex509 "github.com/notaryproject/notation-core-go/x509"
 
 	"github.com/notaryproject/notation-go"
+	"github.com/notaryproject/notation-go/config"
 	"github.com/notaryproject/notation-go/dir"
 	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
 	"github.com/notaryproject/notation-go/signer"
+	"github.com/notaryproject/notation/internal/envelope"
+	"github.com/notaryproject/notation/internal/x509util"
 	"github.com/notaryproject/notation/pkg/configutil"
 )
 
+var errReproducibleCertOrderNotSupported = errors.New("--reproducible-cert-order is only supported for keys with local key and certificate files")
+
+var errNoDefaultPluginConfigNotSupported = errors.New("--no-default-plugin-config is only supported for keys of signing plugins")
+
 // GetSigner returns a signer according to the CLI context.
 func GetSigner(ctx context.Context, opts *SignerFlagOpts) (notation.Signer, error) {
 	// Check if using on-demand key
 	if opts.KeyID != "" && opts.PluginName != "" && opts.Key == "" {
+		if opts.ReproducibleCertOrder {
+			return nil, errReproducibleCertOrderNotSupported
+		}
 		// Construct a signer from on-demand key
 		mgr := plugin.NewCLIManager(dir.PluginFS())
 		plugin, err := mgr.Get(ctx, opts.PluginName)
 		if err != nil {
 			return nil, err
 		}
+		if opts.PluginCapabilityCheck {
+			if err := checkSigningCapability(ctx, plugin, opts.SignatureFormat, nil); err != nil {
+				return nil, err
+			}
+		}
 		return signer.NewFromPlugin(plugin, opts.KeyID, map[string]string{})
 	}
 
@@ -31,17 +50,84 @@ func GetSigner(ctx context.Context, opts *SignerFlagOpts) (notation.Signer, erro
 		return nil, err
 	}
 	if key.X509KeyPair != nil {
+		if opts.NoDefaultPluginConfig {
+			return nil, errNoDefaultPluginConfigNotSupported
+		}
+		if opts.ReproducibleCertOrder {
+			return newSignerWithSortedChain(key.X509KeyPair.KeyPath, key.X509KeyPair.CertificatePath)
+		}
 		return signer.NewFromFiles(key.X509KeyPair.KeyPath, key.X509KeyPair.CertificatePath)
 	}
 	// Construct a plugin signer if key name provided as the CLI argument
 	// corresponds to an external key
 	if key.ExternalKey != nil {
+		if opts.ReproducibleCertOrder {
+			return nil, errReproducibleCertOrderNotSupported
+		}
 		mgr := plugin.NewCLIManager(dir.PluginFS())
 		plugin, err := mgr.Get(ctx, key.PluginName)
 		if err != nil {
 			return nil, err
 		}
-		return signer.NewFromPlugin(plugin, key.ExternalKey.ID, key.PluginConfig)
+		pluginConfig := keyPluginConfig(key, opts)
+		if opts.PluginCapabilityCheck {
+			if err := checkSigningCapability(ctx, plugin, opts.SignatureFormat, pluginConfig); err != nil {
+				return nil, err
+			}
+		}
+		return signer.NewFromPlugin(plugin, key.ExternalKey.ID, pluginConfig)
 	}
 	return nil, errors.New("unsupported key, either provide a local key and certificate file paths, or a key name in config.json, check [DOC_PLACEHOLDER] for details")
 }
+
+// keyPluginConfig returns the plugin config stored with the external key,
+// or nil if opts.NoDefaultPluginConfig is set.
+func keyPluginConfig(key config.KeySuite, opts *SignerFlagOpts) map[string]string {
+	if opts.NoDefaultPluginConfig {
+		return nil
+	}
+	return key.PluginConfig
+}
+
+// checkSigningCapability returns an error if the signature format is not
+// supported or if the plugin does not advertise a capability to generate
+// signatures, listing the capabilities the plugin advertises. Plugins
+// generating raw signatures support all signature formats, while plugins
+// generating signature envelopes can only reject the signature format when
+// signing.
+func checkSigningCapability(ctx context.Context, p plugin.Plugin, signatureFormat string, pluginConfig map[string]string) error {
+	if _, err := envelope.GetEnvelopeMediaType(signatureFormat); err != nil {
+		return err
+	}
+	metadata, err := p.GetMetadata(ctx, &proto.GetMetadataRequest{PluginConfig: pluginConfig})
+	if err != nil {
+		return fmt.Errorf("failed to get the capabilities of plugin: %w", err)
+	}
+	for _, capability := range metadata.Capabilities {
+		if capability == proto.CapabilitySignatureGenerator || capability == proto.CapabilityEnvelopeGenerator {
+			return nil
+		}
+	}
+	return fmt.Errorf("plugin %q cannot sign %s signatures: it advertises the capabilities %v, but signing requires %q or %q", metadata.Name, signatureFormat, metadata.Capabilities, proto.CapabilitySignatureGenerator, proto.CapabilityEnvelopeGenerator)
+}
+
+// newSignerWithSortedChain returns a signer given key and certificate chain
+// paths, with the certificate chain in canonical order.
+func newSignerWithSortedChain(keyPath, certChainPath string) (notation.Signer, error) {
+	key, err := corex509.ReadPrivateKeyFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	certs, err := corex509.ReadCertificateFile(certChainPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%q does not contain certificate", certChainPath)
+	}
+	certs, err = x509util.SortCertificateChain(certs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sort certificate chain %q: %w", certChainPath, err)
+	}
+	return signer.New(key, certs)
+}
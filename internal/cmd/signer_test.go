@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/notaryproject/notation-go/config"
+	"github.com/notaryproject/notation-go/dir"
+	"github.com/notaryproject/notation-go/plugin"
+	"github.com/notaryproject/notation-go/plugin/proto"
+)
+
+type mockPlugin struct {
+	plugin.Plugin
+	capabilities []proto.Capability
+}
+
+func (p *mockPlugin) GetMetadata(ctx context.Context, req *proto.GetMetadataRequest) (*proto.GetMetadataResponse, error) {
+	return &proto.GetMetadataResponse{Name: "mock", Capabilities: p.capabilities}, nil
+}
+
+func TestCheckSigningCapability(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name            string
+		capabilities    []proto.Capability
+		signatureFormat string
+		wantErr         string
+	}{
+		{name: "raw signature generator", capabilities: []proto.Capability{proto.CapabilitySignatureGenerator}, signatureFormat: "jws"},
+		{name: "envelope generator", capabilities: []proto.Capability{proto.CapabilityEnvelopeGenerator}, signatureFormat: "cose"},
+		{name: "verification plugin", capabilities: []proto.Capability{proto.CapabilityTrustedIdentityVerifier}, signatureFormat: "jws", wantErr: string(proto.CapabilityTrustedIdentityVerifier)},
+		{name: "unsupported signature format", capabilities: []proto.Capability{proto.CapabilitySignatureGenerator}, signatureFormat: "pgp", wantErr: "pgp"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSigningCapability(ctx, &mockPlugin{capabilities: tt.capabilities}, tt.signatureFormat, nil)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkSigningCapability() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkSigningCapability() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyPluginConfig(t *testing.T) {
+	key := config.KeySuite{
+		Name:        "plugin-key",
+		ExternalKey: &config.ExternalKey{ID: "key-id", PluginName: "plugin", PluginConfig: map[string]string{"region": "us-west-2"}},
+	}
+	if got := keyPluginConfig(key, &SignerFlagOpts{}); !reflect.DeepEqual(got, key.PluginConfig) {
+		t.Fatalf("keyPluginConfig() = %v, want the stored plugin config %v", got, key.PluginConfig)
+	}
+	if got := keyPluginConfig(key, &SignerFlagOpts{NoDefaultPluginConfig: true}); got != nil {
+		t.Fatalf("keyPluginConfig() = %v, want the stored plugin config dropped", got)
+	}
+}
+
+func TestGetSigner_NoDefaultPluginConfigLocalKey(t *testing.T) {
+	defer func(oldDir string) { dir.UserConfigDir = oldDir }(dir.UserConfigDir)
+	dir.UserConfigDir = t.TempDir()
+	signingKeys := &config.SigningKeys{Keys: []config.KeySuite{{
+		Name:        "local-key",
+		X509KeyPair: &config.X509KeyPair{KeyPath: "local-key.key", CertificatePath: "local-key.crt"},
+	}}}
+	if err := signingKeys.Save(); err != nil {
+		t.Fatal(err)
+	}
+	_, err := GetSigner(context.Background(), &SignerFlagOpts{Key: "local-key", NoDefaultPluginConfig: true})
+	if !errors.Is(err, errNoDefaultPluginConfigNotSupported) {
+		t.Fatalf("GetSigner() error = %v, want %v", err, errNoDefaultPluginConfigNotSupported)
+	}
+}
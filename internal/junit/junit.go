@@ -0,0 +1,100 @@
+// Package junit writes minimal JUnit XML reports that can be consumed by
+// common CI systems.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// TestSuites is the root element of a JUnit report.
+type TestSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []*TestSuite `xml:"testsuite"`
+}
+
+// TestSuite is a named group of test cases.
+type TestSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []*TestCase `xml:"testcase"`
+
+	duration time.Duration
+}
+
+// TestCase is a single test case. A nil Failure means the case passed.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	Failure   *Failure `xml:"failure,omitempty"`
+}
+
+// Failure describes why a test case failed.
+type Failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// NewSuite creates an empty test suite with the given name.
+func NewSuite(name string) *TestSuite {
+	return &TestSuite{Name: name, Time: formatDuration(0)}
+}
+
+// AddCase adds a test case to the suite. The case is marked as failed if err
+// is not nil.
+func (s *TestSuite) AddCase(name string, duration time.Duration, err error) {
+	c := &TestCase{
+		Name:      name,
+		ClassName: s.Name,
+		Time:      formatDuration(duration),
+	}
+	if err != nil {
+		c.Failure = &Failure{
+			Message: err.Error(),
+			Type:    "failure",
+			Text:    err.Error(),
+		}
+		s.Failures++
+	}
+	s.Cases = append(s.Cases, c)
+	s.Tests++
+	s.duration += duration
+	s.Time = formatDuration(s.duration)
+}
+
+// Write writes the suites to w as an indented JUnit XML document.
+func Write(w io.Writer, suites ...*TestSuite) error {
+	report := TestSuites{Suites: suites}
+	var total time.Duration
+	for _, s := range suites {
+		report.Tests += s.Tests
+		report.Failures += s.Failures
+		total += s.duration
+	}
+	report.Time = formatDuration(total)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "    ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// formatDuration formats d in seconds as expected by JUnit parsers.
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
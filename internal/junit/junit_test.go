@@ -0,0 +1,47 @@
+package junit
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	suite := NewSuite("notation verify")
+	suite.AddCase("localhost:5000/net-monitor@sha256:abc", 1500*time.Millisecond, nil)
+	suite.AddCase("localhost:5000/net-monitor@sha256:def", 500*time.Millisecond, errors.New("signature verification failed"))
+
+	var buf bytes.Buffer
+	if err := Write(&buf, suite); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="2" failures="1" time="2.000">
+    <testsuite name="notation verify" tests="2" failures="1" time="2.000">
+        <testcase name="localhost:5000/net-monitor@sha256:abc" classname="notation verify" time="1.500"></testcase>
+        <testcase name="localhost:5000/net-monitor@sha256:def" classname="notation verify" time="0.500">
+            <failure message="signature verification failed" type="failure">signature verification failed</failure>
+        </testcase>
+    </testsuite>
+</testsuites>
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("Write() got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWrite_EmptySuite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, NewSuite("empty")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="0" failures="0" time="0.000">
+    <testsuite name="empty" tests="0" failures="0" time="0.000"></testsuite>
+</testsuites>
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("Write() got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -108,6 +108,52 @@ func TestWriteFileWithPermission(t *testing.T) {
 	})
 }
 
+func TestWriteFileAtomic(t *testing.T) {
+	t.Run("write file", func(t *testing.T) {
+		tempDir := t.TempDir()
+		data := []byte("data")
+		filename := filepath.Join(tempDir, "a", "file.txt")
+		if err := WriteFileAtomic(filename, data); err != nil {
+			t.Fatal(err)
+		}
+		validFileContent(t, filename, data)
+		info, err := os.Stat(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Fatalf("file permission = %v, want 0600", info.Mode().Perm())
+		}
+	})
+
+	t.Run("replace file", func(t *testing.T) {
+		tempDir := t.TempDir()
+		filename := filepath.Join(tempDir, "file.txt")
+		if err := WriteFileAtomic(filename, []byte("old data")); err != nil {
+			t.Fatal(err)
+		}
+		data := []byte("data")
+		if err := WriteFileAtomic(filename, data); err != nil {
+			t.Fatal(err)
+		}
+		validFileContent(t, filename, data)
+		entries, err := os.ReadDir(tempDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("directory has %d entries, want no temporary file left", len(entries))
+		}
+	})
+
+	t.Run("write file to a directory", func(t *testing.T) {
+		tempDir := t.TempDir()
+		if err := WriteFileAtomic(tempDir, []byte("data")); err == nil {
+			t.Fatal("should write failed")
+		}
+	})
+}
+
 func TestCopyToDir(t *testing.T) {
 	t.Run("copy file", func(t *testing.T) {
 		tempDir := t.TempDir()
@@ -0,0 +1,73 @@
+// Package x509util provides helpers for handling X.509 certificate chains.
+package x509util
+
+import (
+	"crypto/x509"
+	"errors"
+)
+
+// SortCertificateChain returns the certificates of a chain in canonical order:
+// the leaf certificate first, followed by each issuer in chain order up to
+// the root. An error is returned if the certificates do not form a single
+// chain.
+func SortCertificateChain(certs []*x509.Certificate) ([]*x509.Certificate, error) {
+	if len(certs) <= 1 {
+		return certs, nil
+	}
+
+	// the leaf is the only certificate that has not issued another
+	// certificate in the chain
+	var leaf *x509.Certificate
+	for _, candidate := range certs {
+		isIssuer := false
+		for _, cert := range certs {
+			if cert != candidate && isIssuedBy(cert, candidate) {
+				isIssuer = true
+				break
+			}
+		}
+		if !isIssuer {
+			if leaf != nil {
+				return nil, errors.New("certificate chain has more than one leaf certificate")
+			}
+			leaf = candidate
+		}
+	}
+	if leaf == nil {
+		return nil, errors.New("certificate chain has no leaf certificate")
+	}
+
+	// walk up from the leaf, appending the issuer of each certificate
+	sorted := []*x509.Certificate{leaf}
+	remaining := make([]*x509.Certificate, 0, len(certs)-1)
+	for _, cert := range certs {
+		if cert != leaf {
+			remaining = append(remaining, cert)
+		}
+	}
+	current := leaf
+	for len(remaining) > 0 {
+		next := -1
+		for i, cert := range remaining {
+			if isIssuedBy(current, cert) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return nil, errors.New("certificate chain contains certificates that are not part of the leaf certificate's chain")
+		}
+		current = remaining[next]
+		sorted = append(sorted, current)
+		remaining = append(remaining[:next], remaining[next+1:]...)
+	}
+	return sorted, nil
+}
+
+// isIssuedBy reports whether subject is signed by issuer.
+func isIssuedBy(subject, issuer *x509.Certificate) bool {
+	if !subject.Equal(issuer) && subject.CheckSignatureFrom(issuer) == nil {
+		return true
+	}
+	return false
+}
@@ -0,0 +1,94 @@
+package x509util
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestSortCertificateChain(t *testing.T) {
+	chain := newTestChain(t, 4)
+	permutations := [][]int{
+		{0, 1, 2, 3},
+		{3, 2, 1, 0},
+		{2, 0, 3, 1},
+		{1, 3, 0, 2},
+	}
+	for _, p := range permutations {
+		certs := make([]*x509.Certificate, len(p))
+		for i, idx := range p {
+			certs[i] = chain[idx]
+		}
+		sorted, err := SortCertificateChain(certs)
+		if err != nil {
+			t.Fatalf("SortCertificateChain(%v) error = %v", p, err)
+		}
+		for i := range chain {
+			if !sorted[i].Equal(chain[i]) {
+				t.Fatalf("SortCertificateChain(%v) position %d got %s, want %s", p, i, sorted[i].Subject, chain[i].Subject)
+			}
+		}
+	}
+}
+
+func TestSortCertificateChain_Single(t *testing.T) {
+	chain := newTestChain(t, 1)
+	sorted, err := SortCertificateChain(chain)
+	if err != nil {
+		t.Fatalf("SortCertificateChain() error = %v", err)
+	}
+	if len(sorted) != 1 || !sorted[0].Equal(chain[0]) {
+		t.Fatalf("SortCertificateChain() got %v, want %v", sorted, chain)
+	}
+}
+
+func TestSortCertificateChain_Unrelated(t *testing.T) {
+	chain := newTestChain(t, 3)
+	other := newTestChain(t, 1)
+	if _, err := SortCertificateChain(append(chain, other...)); err == nil {
+		t.Fatal("SortCertificateChain() expected error for unrelated certificate, but got nil")
+	}
+}
+
+// newTestChain returns a chain of n certificates ordered from leaf to root.
+func newTestChain(t *testing.T, n int) []*x509.Certificate {
+	t.Helper()
+	var chain []*x509.Certificate
+	var parent *x509.Certificate
+	var parentKey *ecdsa.PrivateKey
+	for i := 0; i < n; i++ {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(int64(i + 1)),
+			Subject:               pkix.Name{CommonName: "cert" + string(rune('A'+i))},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  i != n-1,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		}
+		issuer, issuerKey := template, key
+		if parent != nil {
+			issuer, issuerKey = parent, parentKey
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, issuer, &key.PublicKey, issuerKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chain = append([]*x509.Certificate{cert}, chain...)
+		parent, parentKey = cert, key
+	}
+	return chain
+}